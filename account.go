@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/config"
+	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-go/keystore"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var accountCommand = cli.Command{
+	Name:  "account",
+	Usage: "Manage the node key and keystore accounts, the node doesn't have to be running",
+	Subcommands: []cli.Command{
+		{
+			Name:   "new",
+			Usage:  "Create a new keystore account",
+			Flags:  []cli.Flag{config.CfgFileFlag, config.DataDirFlag, config.TestnetFlag, config.DevnetFlag, config.PasswordFileFlag},
+			Action: accountNew,
+		},
+		{
+			Name:   "list",
+			Usage:  "Print the node key address and keystore accounts",
+			Flags:  []cli.Flag{config.CfgFileFlag, config.DataDirFlag, config.TestnetFlag, config.DevnetFlag},
+			Action: accountList,
+		},
+		{
+			Name:      "import",
+			Usage:     "Import a key file, use --nodekey to replace the node key with the key exported by dna_exportKey",
+			ArgsUsage: "<keyfile>",
+			Flags:     []cli.Flag{config.CfgFileFlag, config.DataDirFlag, config.TestnetFlag, config.DevnetFlag, config.PasswordFileFlag, config.NodeKeyFlag},
+			Action:    accountImport,
+		},
+		{
+			Name:      "export",
+			Usage:     "Print the encrypted key of the account or the node key with --nodekey",
+			ArgsUsage: "[<address>]",
+			Flags:     []cli.Flag{config.CfgFileFlag, config.DataDirFlag, config.TestnetFlag, config.DevnetFlag, config.PasswordFileFlag, config.NodeKeyFlag},
+			Action:    accountExport,
+		},
+		{
+			Name:      "update",
+			Usage:     "Change the password of the keystore account",
+			ArgsUsage: "<address>",
+			Flags:     []cli.Flag{config.CfgFileFlag, config.DataDirFlag, config.TestnetFlag, config.DevnetFlag, config.PasswordFileFlag},
+			Action:    accountUpdate,
+		},
+	},
+}
+
+// passwordReader reads passwords from the password file line by line or asks them from stdin
+type passwordReader struct {
+	lines []string
+	stdin *bufio.Reader
+}
+
+func newPasswordReader(ctx *cli.Context) (*passwordReader, error) {
+	reader := &passwordReader{}
+	if path := ctx.String(config.PasswordFileFlag.Name); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read password file")
+		}
+		reader.lines = strings.Split(strings.TrimRight(string(data), "\r\n"), "\n")
+		return reader, nil
+	}
+	reader.stdin = bufio.NewReader(os.Stdin)
+	return reader, nil
+}
+
+func (r *passwordReader) read(prompt string) (string, error) {
+	var password string
+	if r.stdin == nil {
+		if len(r.lines) == 0 {
+			return "", errors.New("not enough passwords in the password file")
+		}
+		password, r.lines = r.lines[0], r.lines[1:]
+	} else {
+		fmt.Print(prompt + ": ")
+		line, err := r.stdin.ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		password = line
+	}
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		return "", errors.New("password should not be empty")
+	}
+	return password, nil
+}
+
+func openKeyStore(ctx *cli.Context) (*config.Config, *keystore.KeyStore, error) {
+	cfg, err := config.MakeConfig(ctx, func(cfg *config.Config) {})
+	if err != nil {
+		return nil, nil, err
+	}
+	keyStoreDir, err := cfg.KeyStoreDataDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, keystore.NewKeyStore(keyStoreDir, keystore.StandardScryptN, keystore.StandardScryptP), nil
+}
+
+func accountArg(ctx *cli.Context) (keystore.Account, error) {
+	addr := ctx.Args().First()
+	if !common.IsHexAddress(addr) {
+		return keystore.Account{}, errors.Errorf("invalid account address %q", addr)
+	}
+	return keystore.Account{Address: common.HexToAddress(addr)}, nil
+}
+
+func accountNew(ctx *cli.Context) error {
+	_, ks, err := openKeyStore(ctx)
+	if err != nil {
+		return err
+	}
+	passwords, err := newPasswordReader(ctx)
+	if err != nil {
+		return err
+	}
+	password, err := passwords.read("Password")
+	if err != nil {
+		return err
+	}
+	account, err := ks.NewAccount(password)
+	if err != nil {
+		return errors.Wrap(err, "failed to create account")
+	}
+	fmt.Printf("Address: %v\n", account.Address.Hex())
+	return nil
+}
+
+func accountList(ctx *cli.Context) error {
+	cfg, ks, err := openKeyStore(ctx)
+	if err != nil {
+		return err
+	}
+	if key, err := cfg.ReadNodeKey(); err == nil {
+		fmt.Printf("Node key: %v\n", crypto.PubkeyToAddress(key.PublicKey).Hex())
+	}
+	for i, account := range ks.Accounts() {
+		fmt.Printf("Account #%d: %v %v\n", i, account.Address.Hex(), account.URL.Path)
+	}
+	return nil
+}
+
+func accountImport(ctx *cli.Context) error {
+	cfg, ks, err := openKeyStore(ctx)
+	if err != nil {
+		return err
+	}
+	path := ctx.Args().First()
+	if path == "" {
+		return errors.New("key file is not specified")
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to read key file")
+	}
+	passwords, err := newPasswordReader(ctx)
+	if err != nil {
+		return err
+	}
+	password, err := passwords.read("Password")
+	if err != nil {
+		return err
+	}
+	if ctx.Bool(config.NodeKeyFlag.Name) {
+		if err := cfg.ProvideNodeKey(strings.TrimSpace(string(data)), password, true); err != nil {
+			return err
+		}
+		key, err := cfg.ReadNodeKey()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Node key: %v\n", crypto.PubkeyToAddress(key.PublicKey).Hex())
+		return nil
+	}
+	account, err := ks.Import(data, password, password)
+	if err != nil {
+		return errors.Wrap(err, "failed to import account")
+	}
+	fmt.Printf("Address: %v\n", account.Address.Hex())
+	return nil
+}
+
+func accountExport(ctx *cli.Context) error {
+	cfg, ks, err := openKeyStore(ctx)
+	if err != nil {
+		return err
+	}
+	nodeKey := ctx.Bool(config.NodeKeyFlag.Name)
+	var account keystore.Account
+	if !nodeKey {
+		if account, err = accountArg(ctx); err != nil {
+			return err
+		}
+	}
+	passwords, err := newPasswordReader(ctx)
+	if err != nil {
+		return err
+	}
+	password, err := passwords.read("Password")
+	if err != nil {
+		return err
+	}
+	if nodeKey {
+		key, err := cfg.ReadNodeKey()
+		if err != nil {
+			return err
+		}
+		encrypted, err := crypto.Encrypt(crypto.FromECDSA(key), password)
+		if err != nil {
+			return err
+		}
+		fmt.Println(hex.EncodeToString(encrypted))
+		return nil
+	}
+	keyJSON, err := ks.Export(account, password, password)
+	if err != nil {
+		return errors.Wrap(err, "failed to export account")
+	}
+	fmt.Println(string(keyJSON))
+	return nil
+}
+
+func accountUpdate(ctx *cli.Context) error {
+	_, ks, err := openKeyStore(ctx)
+	if err != nil {
+		return err
+	}
+	account, err := accountArg(ctx)
+	if err != nil {
+		return err
+	}
+	passwords, err := newPasswordReader(ctx)
+	if err != nil {
+		return err
+	}
+	password, err := passwords.read("Current password")
+	if err != nil {
+		return err
+	}
+	newPassword, err := passwords.read("New password")
+	if err != nil {
+		return err
+	}
+	if err := ks.Update(account, password, newPassword); err != nil {
+		return errors.Wrap(err, "failed to update account")
+	}
+	fmt.Printf("Address: %v\n", account.Address.Hex())
+	return nil
+}
@@ -25,6 +25,32 @@ func (api *AccountApi) List() []common.Address {
 	return list
 }
 
+type AccountInfo struct {
+	Address  common.Address `json:"address"`
+	Created  time.Time      `json:"created"`
+	Path     string         `json:"path"`
+	Unlocked bool           `json:"unlocked"`
+	Coinbase bool           `json:"coinbase"`
+}
+
+// ListInfo returns the keystore accounts with their key files, creation time and lock status,
+// coinbase marks the account with the key of the node identity
+func (api *AccountApi) ListInfo() []AccountInfo {
+	coinbase := api.baseApi.getCurrentCoinbase()
+	list := make([]AccountInfo, 0)
+	for _, item := range api.baseApi.ks.Accounts() {
+		created, _ := keystore.KeyFileTime(item.URL.Path)
+		list = append(list, AccountInfo{
+			Address:  item.Address,
+			Created:  created,
+			Path:     item.URL.Path,
+			Unlocked: api.baseApi.ks.IsUnlocked(item.Address),
+			Coinbase: item.Address == coinbase,
+		})
+	}
+	return list
+}
+
 func (api *AccountApi) Create(passPhrase string) (common.Address, error) {
 	account, err := api.baseApi.ks.NewAccount(passPhrase)
 	return account.Address, err
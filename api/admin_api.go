@@ -0,0 +1,179 @@
+package api
+
+import (
+	"github.com/idena-network/idena-go/blockchain"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/config"
+	"github.com/idena-network/idena-go/log"
+	"github.com/idena-network/idena-go/protocol"
+	"github.com/pkg/errors"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// AdminApi offers node management methods
+type AdminApi struct {
+	pm               *protocol.IdenaGossipHandler
+	cfg              *config.Config
+	backup           func(path string) error
+	buildInfo        *config.BuildInfo
+	reloadConfig     func() ([]string, error)
+	shutdown         func(restart bool, afterCeremony bool)
+	exportIdentities func(path string, format string) (*blockchain.IdentitiesExport, error)
+	exportAddressTxs func(address common.Address, path string, format string) (*blockchain.TxsExport, error)
+	backupStatus     BackupStatus
+	backupMutex      sync.Mutex
+}
+
+type BackupStatus struct {
+	Running    bool       `json:"running"`
+	Path       string     `json:"path,omitempty"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// NewAdminApi creates a new AdminApi instance
+func NewAdminApi(pm *protocol.IdenaGossipHandler, cfg *config.Config, backup func(path string) error, buildInfo *config.BuildInfo, reloadConfig func() ([]string, error),
+	shutdown func(restart bool, afterCeremony bool), exportIdentities func(path string, format string) (*blockchain.IdentitiesExport, error),
+	exportAddressTxs func(address common.Address, path string, format string) (*blockchain.TxsExport, error)) *AdminApi {
+	return &AdminApi{pm: pm, cfg: cfg, backup: backup, buildInfo: buildInfo, reloadConfig: reloadConfig, shutdown: shutdown,
+		exportIdentities: exportIdentities, exportAddressTxs: exportAddressTxs}
+}
+
+// SetLogLevel changes the level of the subsystem (e.g. consensus, ipfs, p2p) until restart,
+// the empty module changes the default level
+func (api *AdminApi) SetLogLevel(module string, level string) error {
+	lvl, err := log.LvlFromString(level)
+	if err != nil {
+		return err
+	}
+	logCfg := *api.cfg.Log
+	if module == "" {
+		logCfg.Verbosity = int(lvl)
+	} else {
+		logCfg.Modules = make(map[string]string, len(api.cfg.Log.Modules)+1)
+		for m, l := range api.cfg.Log.Modules {
+			logCfg.Modules[m] = l
+		}
+		logCfg.Modules[module] = level
+	}
+	if err := logCfg.Apply(); err != nil {
+		return err
+	}
+	*api.cfg.Log = logCfg
+	return nil
+}
+
+// Stop gracefully stops the node, if afterCeremony is set the running validation ceremony is completed first
+func (api *AdminApi) Stop(afterCeremony *bool) {
+	api.shutdown(false, afterCeremony != nil && *afterCeremony)
+}
+
+// Restart gracefully stops the node and starts the process again with the same command line
+func (api *AdminApi) Restart(afterCeremony *bool) {
+	api.shutdown(true, afterCeremony != nil && *afterCeremony)
+}
+
+// ReloadConfig re-reads the config file and applies the log level, IPFS bootnodes, peer limits and RPC CORS origins
+// without restarting the node, the names of the applied settings are returned
+func (api *AdminApi) ReloadConfig() ([]string, error) {
+	return api.reloadConfig()
+}
+
+// Config returns the resolved node configuration, secrets are redacted
+func (api *AdminApi) Config() (*config.Config, error) {
+	return api.cfg.Redacted()
+}
+
+// Version returns the version, git commit, build date and Go version of the node binary
+func (api *AdminApi) Version() *config.BuildInfo {
+	return api.buildInfo
+}
+
+// AddTrustedPeer adds the peer to the trusted list until restart, use P2P.TrustedPeers config to make it persistent
+func (api *AdminApi) AddTrustedPeer(id string) error {
+	return api.pm.AddTrustedPeer(id)
+}
+
+func (api *AdminApi) RemoveTrustedPeer(id string) error {
+	return api.pm.RemoveTrustedPeer(id)
+}
+
+func (api *AdminApi) TrustedPeers() []string {
+	return api.pm.TrustedPeers()
+}
+
+// Ban bans the peer id or IP address, the configured ban duration is used if duration is omitted
+func (api *AdminApi) Ban(target string, durationSec *uint64) {
+	var duration time.Duration
+	if durationSec != nil {
+		duration = time.Duration(*durationSec) * time.Second
+	}
+	api.pm.BanManually(target, duration)
+}
+
+func (api *AdminApi) Unban(target string) bool {
+	return api.pm.Unban(target)
+}
+
+func (api *AdminApi) Bans() []*protocol.Ban {
+	return api.pm.Bans()
+}
+
+// Backup starts copying the databases and the keystore to the empty directory while the node keeps running,
+// use BackupStatus to get the result
+func (api *AdminApi) Backup(path string) error {
+	api.backupMutex.Lock()
+	defer api.backupMutex.Unlock()
+	if api.backupStatus.Running {
+		return errors.New("backup is already running")
+	}
+	startedAt := time.Now().UTC()
+	api.backupStatus = BackupStatus{
+		Running:   true,
+		Path:      path,
+		StartedAt: &startedAt,
+	}
+	go func() {
+		err := api.backup(path)
+		if err != nil {
+			log.Error("Backup failed", "path", path, "err", err)
+		}
+		api.backupMutex.Lock()
+		defer api.backupMutex.Unlock()
+		finishedAt := time.Now().UTC()
+		api.backupStatus.Running = false
+		api.backupStatus.FinishedAt = &finishedAt
+		if err != nil {
+			api.backupStatus.Error = err.Error()
+		}
+	}()
+	return nil
+}
+
+// BackupStatus returns the state of the running or the last backup
+func (api *AdminApi) BackupStatus() BackupStatus {
+	api.backupMutex.Lock()
+	defer api.backupMutex.Unlock()
+	return api.backupStatus
+}
+
+// ExportIdentities writes the identities with stakes and balances at the last epoch snapshot to the file
+// on the node host, the format is json or csv
+func (api *AdminApi) ExportIdentities(path string, format string) (*blockchain.IdentitiesExport, error) {
+	if !filepath.IsAbs(path) {
+		return nil, errors.New("export path should be absolute")
+	}
+	return api.exportIdentities(path, format)
+}
+
+// ExportAddressTxs writes the transaction history of the node account to the file on the node host,
+// the format is csv or jsonl (a JSON object per line)
+func (api *AdminApi) ExportAddressTxs(address common.Address, path string, format string) (*blockchain.TxsExport, error) {
+	if !filepath.IsAbs(path) {
+		return nil, errors.New("export path should be absolute")
+	}
+	return api.exportAddressTxs(address, path, format)
+}
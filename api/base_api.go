@@ -3,16 +3,19 @@ package api
 import (
 	"context"
 	"crypto/ecdsa"
+	"encoding/hex"
 	"github.com/idena-network/idena-go/blockchain"
 	"github.com/idena-network/idena-go/blockchain/types"
 	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-go/consensus"
 	"github.com/idena-network/idena-go/core/appstate"
 	"github.com/idena-network/idena-go/core/mempool"
+	"github.com/idena-network/idena-go/crypto"
 	"github.com/idena-network/idena-go/ipfs"
 	"github.com/idena-network/idena-go/keystore"
 	"github.com/idena-network/idena-go/log"
 	"github.com/idena-network/idena-go/secstore"
+	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 )
 
@@ -86,6 +89,10 @@ func (api *BaseApi) sendInternalTx(ctx context.Context, tx *types.Transaction) (
 	log.Info("Sending new tx", "ip", ctx.Value("remote"), "type", tx.Type, "hash", tx.Hash().Hex(), "nonce", tx.AccountNonce, "epoch", tx.Epoch)
 
 	if err := api.txpool.AddInternalTx(tx); err != nil {
+		// the state of a syncing node is outdated, so the tx may be rejected for the wrong reason
+		if !api.engine.Synced() {
+			return common.Hash{}, errors.Wrap(ErrNotSynced, err.Error())
+		}
 		return common.Hash{}, err
 	}
 
@@ -106,6 +113,17 @@ func (api *BaseApi) signTransaction(from common.Address, tx *types.Transaction,
 	return api.ks.SignTx(account, tx)
 }
 
+func (api *BaseApi) signHash(from common.Address, hash []byte) ([]byte, error) {
+	if from == api.getCurrentCoinbase() {
+		return api.secStore.Sign(hash), nil
+	}
+	account, err := api.ks.Find(keystore.Account{Address: from})
+	if err != nil {
+		return nil, err
+	}
+	return api.ks.SignHash(account, hash)
+}
+
 func (api *BaseApi) getCoinbaseShard() common.ShardId {
 	state := api.getReadonlyAppState()
 	return state.State.ShardId(api.secStore.GetAddress())
@@ -118,3 +136,30 @@ func (api *BaseApi) canSign(address common.Address) bool {
 	_, err := api.ks.Find(keystore.Account{Address: address})
 	return err == nil
 }
+
+func (api *BaseApi) sendInvite(ctx context.Context, args SendInviteArgs) (Invite, error) {
+	receiver := args.To
+	var key *ecdsa.PrivateKey
+
+	if receiver == (common.Address{}) {
+		key, _ = crypto.GenerateKey()
+		receiver = crypto.PubkeyToAddress(key.PublicKey)
+	}
+
+	hash, err := api.sendTx(ctx, api.getCurrentCoinbase(), &receiver, types.InviteTx, args.Amount, decimal.Zero, decimal.Zero, args.Nonce, args.Epoch, nil, nil)
+
+	if err != nil {
+		return Invite{}, err
+	}
+
+	var stringKey string
+	if key != nil {
+		stringKey = hex.EncodeToString(crypto.FromECDSA(key))
+	}
+
+	return Invite{
+		Receiver: receiver,
+		Hash:     hash,
+		Key:      stringKey,
+	}, nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/idena-network/idena-go/common/hexutil"
 	"github.com/idena-network/idena-go/core/mempool"
 	state2 "github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-go/core/upgrade"
 	"github.com/idena-network/idena-go/ipfs"
 	"github.com/idena-network/idena-go/keywords"
 	"github.com/idena-network/idena-go/protocol"
@@ -23,34 +24,6 @@ import (
 	"sync"
 )
 
-var (
-	txTypeMap = map[types.TxType]string{
-		types.SendTx:               "send",
-		types.ActivationTx:         "activation",
-		types.InviteTx:             "invite",
-		types.KillTx:               "kill",
-		types.KillInviteeTx:        "killInvitee",
-		types.SubmitFlipTx:         "submitFlip",
-		types.SubmitAnswersHashTx:  "submitAnswersHash",
-		types.SubmitShortAnswersTx: "submitShortAnswers",
-		types.SubmitLongAnswersTx:  "submitLongAnswers",
-		types.EvidenceTx:           "evidence",
-		types.OnlineStatusTx:       "online",
-		types.ChangeGodAddressTx:   "changeGodAddress",
-		types.BurnTx:               "burn",
-		types.ChangeProfileTx:      "changeProfile",
-		types.DeleteFlipTx:         "deleteFlip",
-		types.DeployContractTx:     "deployContract",
-		types.CallContractTx:       "callContract",
-		types.TerminateContractTx:  "terminateContract",
-		types.DelegateTx:           "delegate",
-		types.UndelegateTx:         "undelegate",
-		types.KillDelegatorTx:      "killDelegator",
-		types.StoreToIpfsTx:        "storeToIpfs",
-		types.ReplenishStakeTx:     "replenishStake",
-	}
-)
-
 type BlockchainApi struct {
 	bc              *blockchain.Blockchain
 	baseApi         *BaseApi
@@ -101,6 +74,8 @@ type Block struct {
 	Flags        []string        `json:"flags"`
 	IsEmpty      bool            `json:"isEmpty"`
 	OfflineAddr  *common.Address `json:"offlineAddress"`
+	// HasCertificate is false if the node has no certificate of the block, e.g. it is pruned
+	HasCertificate bool `json:"hasCertificate"`
 }
 
 type Transaction struct {
@@ -117,6 +92,9 @@ type Transaction struct {
 	BlockHash common.Hash     `json:"blockHash"`
 	UsedFee   decimal.Decimal `json:"usedFee"`
 	Timestamp int64           `json:"timestamp"`
+	// Confirmations is the number of blocks since the inclusion including the block of the transaction
+	Confirmations uint64 `json:"confirmations"`
+	Final         bool   `json:"final"`
 }
 
 type BurntCoins struct {
@@ -138,13 +116,21 @@ func (api *BlockchainApi) LastBlock() *Block {
 func (api *BlockchainApi) BlockAt(height uint64) *Block {
 	block := api.bc.GetBlockByHeight(height)
 
-	return convertToBlock(block)
+	return api.convertToBlock(block)
 }
 
 func (api *BlockchainApi) Block(hash common.Hash) *Block {
 	block := api.bc.GetBlock(hash)
 
-	return convertToBlock(block)
+	return api.convertToBlock(block)
+}
+
+func (api *BlockchainApi) convertToBlock(block *types.Block) *Block {
+	result := convertToBlock(block)
+	if result != nil {
+		result.HasCertificate = api.bc.HasCertificate(result.Hash)
+	}
+	return result
 }
 
 func (api *BlockchainApi) Transaction(hash common.Hash) *Transaction {
@@ -174,7 +160,39 @@ func (api *BlockchainApi) Transaction(hash common.Hash) *Transaction {
 			timestamp = block.Header.Time()
 		}
 	}
-	return convertToTransaction(tx, blockHash, feePerGas, timestamp)
+	res := convertToTransaction(tx, blockHash, feePerGas, timestamp)
+	if idx != nil {
+		res.Confirmations, res.Final = api.bc.Confirmations(blockHash)
+	}
+	return res
+}
+
+// GetRawTransaction returns the serialized transaction from the mempool or the chain, the transaction hash is the hash of these bytes
+func (api *BlockchainApi) GetRawTransaction(hash common.Hash) (hexutil.Bytes, error) {
+	tx := api.pool.GetTx(hash)
+	if tx == nil {
+		tx, _ = api.bc.GetTx(hash)
+	}
+	if tx == nil {
+		return nil, errTxNotFound
+	}
+	return tx.ToBytes()
+}
+
+// GetRawBlock returns the serialized block, the block hash is the hash of its serialized proposed or empty header
+func (api *BlockchainApi) GetRawBlock(hash common.Hash) (hexutil.Bytes, error) {
+	return rawBlock(api.bc.GetBlock(hash))
+}
+
+func (api *BlockchainApi) GetRawBlockAt(height uint64) (hexutil.Bytes, error) {
+	return rawBlock(api.bc.GetBlockByHeight(height))
+}
+
+func rawBlock(block *types.Block) (hexutil.Bytes, error) {
+	if block == nil {
+		return nil, errBlockNotFound
+	}
+	return block.ToBytes()
 }
 
 func (api *BlockchainApi) TxReceipt(hash common.Hash) *TxReceipt {
@@ -226,6 +244,9 @@ type Syncing struct {
 	WrongTime    bool   `json:"wrongTime"`
 	GenesisBlock uint64 `json:"genesisBlock"`
 	Message      string `json:"message"`
+	// Blocks per second and estimated seconds left, present while blocks are downloaded
+	BlocksPerSecond float64 `json:"blocksPerSecond,omitempty"`
+	Eta             uint64  `json:"eta,omitempty"`
 }
 
 func (api *BlockchainApi) Syncing() Syncing {
@@ -238,7 +259,7 @@ func (api *BlockchainApi) Syncing() Syncing {
 	if !isSyncing {
 		highest = current
 	}
-	return Syncing{
+	result := Syncing{
 		Syncing:      isSyncing,
 		GenesisBlock: api.bc.GenesisInfo().Genesis.Height(),
 		CurrentBlock: current,
@@ -246,6 +267,11 @@ func (api *BlockchainApi) Syncing() Syncing {
 		WrongTime:    api.pm.WrongTime(),
 		Message:      api.nodeState.Info(),
 	}
+	if progress := api.d.Progress(); isSyncing && progress != nil {
+		result.BlocksPerSecond = progress.BlocksPerSecond
+		result.Eta = uint64(progress.Eta.Seconds())
+	}
+	return result
 }
 
 type TransactionsArgs struct {
@@ -289,11 +315,20 @@ func (api *BlockchainApi) PendingTransactions(args TransactionsArgs) Transaction
 	}
 }
 
+// UpgradeStatus returns the voting state of the next consensus version, the parameter changes are activated
+// when enough online identities vote for the version in the activation period
+func (api *BlockchainApi) UpgradeStatus() *upgrade.Status {
+	return api.bc.Upgrader().Status()
+}
+
 func (api *BlockchainApi) FeePerGas() *big.Int {
 	return api.baseApi.getReadonlyAppState().State.FeePerGas()
 }
 
 func (api *BlockchainApi) SendRawTx(ctx context.Context, bytesTx hexutil.Bytes) (common.Hash, error) {
+	if err := checkSize("transaction", bytesTx, maxRawTxSize); err != nil {
+		return common.Hash{}, err
+	}
 	var tx types.Transaction
 	if err := tx.FromBytes(bytesTx); err != nil {
 		//TODO: remove later
@@ -331,8 +366,8 @@ func (api *BlockchainApi) GetRawTx(args SendTxArgs) (hexutil.Bytes, error) {
 }
 
 func (api *BlockchainApi) EstimateRawTx(bytesTx hexutil.Bytes, from *common.Address) (*EstimateRawTxResponse, error) {
-	tx := new(types.Transaction)
-	if err := tx.FromBytes(bytesTx); err != nil {
+	tx, err := decodeRawTx(bytesTx)
+	if err != nil {
 		return nil, err
 	}
 	if from == nil && !tx.Signed() {
@@ -519,7 +554,7 @@ func convertToTransaction(tx *types.Transaction, blockHash common.Hash, feePerGa
 		From:      sender,
 		Nonce:     tx.AccountNonce,
 		To:        tx.To,
-		Type:      txTypeMap[tx.Type],
+		Type:      types.TxTypeNames[tx.Type],
 		BlockHash: blockHash,
 		Timestamp: timestamp,
 		UsedFee:   blockchain.ConvertToFloat(fee.CalculateFee(1, feePerGas, tx)),
@@ -14,6 +14,7 @@ import (
 	"github.com/idena-network/idena-go/subscriptions"
 	"github.com/idena-network/idena-go/vm"
 	"github.com/idena-network/idena-go/vm/costs"
+	"github.com/idena-network/idena-go/vm/embedded"
 	"github.com/idena-network/idena-go/vm/env"
 	"github.com/idena-network/idena-go/vm/helpers"
 	models "github.com/idena-network/idena-wasm-binding/lib/protobuf"
@@ -86,6 +87,17 @@ type KeyWithFormat struct {
 	Format string `json:"format"`
 }
 
+type VestingInfo struct {
+	Owner       common.Address  `json:"owner"`
+	Beneficiary common.Address  `json:"beneficiary"`
+	StartHeight uint64          `json:"startHeight"`
+	EndHeight   uint64          `json:"endHeight"`
+	Balance     decimal.Decimal `json:"balance"`
+	Locked      decimal.Decimal `json:"locked"`
+	Releasable  decimal.Decimal `json:"releasable"`
+	Released    decimal.Decimal `json:"released"`
+}
+
 type ContractData struct {
 	Key   string      `json:"key"`
 	Value interface{} `json:"value,omitempty"`
@@ -514,6 +526,40 @@ func (api *ContractApi) ReadonlyCall(args ReadonlyCallArgs) (interface{}, error)
 	return conversion(args.Format, data)
 }
 
+// Vesting returns the schedule and the locked, releasable and released coins of the vesting contract
+func (api *ContractApi) Vesting(contract common.Address) (*VestingInfo, error) {
+	appState := api.baseApi.getReadonlyAppState()
+	if codeHash := appState.State.GetCodeHash(contract); codeHash == nil || *codeHash != embedded.VestingContract {
+		return nil, errors.New("contract is not a vesting contract")
+	}
+	vm := vm.NewVmImpl(appState, api.bc, api.bc.Head, nil, api.bc.Config())
+	read := func(method string) (decimal.Decimal, error) {
+		data, err := vm.Read(contract, method)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		return blockchain.ConvertToFloat(new(big.Int).SetBytes(data)), nil
+	}
+	info := &VestingInfo{
+		Owner:       common.BytesToAddress(appState.State.GetContractValue(contract, []byte("owner"))),
+		Beneficiary: common.BytesToAddress(appState.State.GetContractValue(contract, []byte("beneficiary"))),
+		Balance:     blockchain.ConvertToFloat(appState.State.GetBalance(contract)),
+	}
+	info.StartHeight, _ = helpers.ExtractUInt64(0, appState.State.GetContractValue(contract, []byte("start")))
+	info.EndHeight, _ = helpers.ExtractUInt64(0, appState.State.GetContractValue(contract, []byte("end")))
+	var err error
+	if info.Locked, err = read("locked"); err != nil {
+		return nil, err
+	}
+	if info.Releasable, err = read("releasable"); err != nil {
+		return nil, err
+	}
+	if info.Released, err = read("released"); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
 func (api *ContractApi) GetStake(contract common.Address) interface{} {
 	hash := api.baseApi.getReadonlyAppState().State.GetCodeHash(contract)
 	stake := api.baseApi.getReadonlyAppState().State.GetContractStake(contract)
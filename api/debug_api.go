@@ -0,0 +1,32 @@
+package api
+
+import (
+	"github.com/idena-network/idena-go/log"
+	"github.com/pkg/errors"
+	"sync/atomic"
+)
+
+// DebugApi offers node maintenance methods
+type DebugApi struct {
+	compactDb  func() error
+	compacting int32
+}
+
+// NewDebugApi creates a new DebugApi instance
+func NewDebugApi(compactDb func() error) *DebugApi {
+	return &DebugApi{compactDb: compactDb}
+}
+
+// CompactDatabase starts a full database compaction in background, the progress is written to the log
+func (api *DebugApi) CompactDatabase() error {
+	if !atomic.CompareAndSwapInt32(&api.compacting, 0, 1) {
+		return errors.New("compaction is already running")
+	}
+	go func() {
+		defer atomic.StoreInt32(&api.compacting, 0)
+		if err := api.compactDb(); err != nil {
+			log.Error("Database compaction failed", "err", err)
+		}
+	}()
+	return nil
+}
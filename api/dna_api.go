@@ -18,9 +18,12 @@ import (
 	"github.com/idena-network/idena-go/core/profile"
 	"github.com/idena-network/idena-go/core/state"
 	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-go/subscriptions"
 	"github.com/ipfs/go-cid"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
+	"math/big"
+	"net/url"
 	"time"
 )
 
@@ -30,11 +33,12 @@ type DnaApi struct {
 	ceremony       *ceremony.ValidationCeremony
 	appVersion     string
 	profileManager *profile.Manager
+	watcher        *subscriptions.Watcher
 }
 
 func NewDnaApi(baseApi *BaseApi, bc *blockchain.Blockchain, ceremony *ceremony.ValidationCeremony, appVersion string,
-	profileManager *profile.Manager) *DnaApi {
-	return &DnaApi{bc, baseApi, ceremony, appVersion, profileManager}
+	profileManager *profile.Manager, watcher *subscriptions.Watcher) *DnaApi {
+	return &DnaApi{bc, baseApi, ceremony, appVersion, profileManager, watcher}
 }
 
 type State struct {
@@ -58,10 +62,55 @@ type Balance struct {
 	Balance          decimal.Decimal `json:"balance"`
 	Nonce            uint32          `json:"nonce"`
 	MempoolNonce     uint32          `json:"mempoolNonce"`
+	ReservedNonce    uint32          `json:"reservedNonce"`
+}
+
+// maxBalancesAddresses limits the number of addresses requested by GetBalances at once
+const maxBalancesAddresses = 5000
+
+type AddressBalance struct {
+	Address common.Address `json:"address"`
+	Balance
 }
 
 func (api *DnaApi) GetBalance(address common.Address) Balance {
-	state := api.baseApi.getReadonlyAppState()
+	return getBalance(api.baseApi.getReadonlyAppState(), address)
+}
+
+// GetBalances returns the balances of the addresses read from the same state, so they are consistent with each other
+func (api *DnaApi) GetBalances(addresses []common.Address) ([]AddressBalance, error) {
+	if len(addresses) > maxBalancesAddresses {
+		return nil, errors.Errorf("too many addresses, max %v", maxBalancesAddresses)
+	}
+	appState := api.baseApi.getReadonlyAppState()
+	res := make([]AddressBalance, 0, len(addresses))
+	for _, address := range addresses {
+		res = append(res, AddressBalance{
+			Address: address,
+			Balance: getBalance(appState, address),
+		})
+	}
+	return res, nil
+}
+
+type ReservedNonce struct {
+	Nonce uint32 `json:"nonce"`
+	Epoch uint16 `json:"epoch"`
+}
+
+// ReserveNonce returns the next nonce of the address for the current epoch after the pending transactions and the previous
+// reservations, the services sending from one address use it to avoid nonce collisions.
+// The reserved nonce should be used, otherwise the next transactions wait for it until the reservation expires
+func (api *DnaApi) ReserveNonce(address common.Address) ReservedNonce {
+	appState := api.baseApi.getReadonlyAppState()
+	epoch := appState.State.Epoch()
+	return ReservedNonce{
+		Nonce: appState.NonceCache.ReserveNonce(address, epoch),
+		Epoch: epoch,
+	}
+}
+
+func getBalance(state *appstate.AppState, address common.Address) Balance {
 	currentEpoch := state.State.Epoch()
 	nonce, epoch := state.State.GetNonce(address), state.State.GetEpoch(address)
 	if epoch < currentEpoch {
@@ -75,6 +124,7 @@ func (api *DnaApi) GetBalance(address common.Address) Balance {
 		Balance:          blockchain.ConvertToFloat(state.State.GetBalance(address)),
 		Nonce:            nonce,
 		MempoolNonce:     state.NonceCache.GetNonce(address, currentEpoch),
+		ReservedNonce:    state.NonceCache.ReservedNonce(address, currentEpoch),
 	}
 }
 
@@ -126,30 +176,7 @@ type Invite struct {
 }
 
 func (api *DnaApi) SendInvite(ctx context.Context, args SendInviteArgs) (Invite, error) {
-	receiver := args.To
-	var key *ecdsa.PrivateKey
-
-	if receiver == (common.Address{}) {
-		key, _ = crypto.GenerateKey()
-		receiver = crypto.PubkeyToAddress(key.PublicKey)
-	}
-
-	hash, err := api.baseApi.sendTx(ctx, api.baseApi.getCurrentCoinbase(), &receiver, types.InviteTx, args.Amount, decimal.Zero, decimal.Zero, args.Nonce, args.Epoch, nil, nil)
-
-	if err != nil {
-		return Invite{}, err
-	}
-
-	var stringKey string
-	if key != nil {
-		stringKey = hex.EncodeToString(crypto.FromECDSA(key))
-	}
-
-	return Invite{
-		Receiver: receiver,
-		Hash:     hash,
-		Key:      stringKey,
-	}, nil
+	return api.baseApi.sendInvite(ctx, args)
 }
 
 func (api *DnaApi) ActivateInvite(ctx context.Context, args ActivateInviteArgs) (common.Hash, error) {
@@ -356,6 +383,110 @@ func (api *DnaApi) Identities() []Identity {
 	return identities
 }
 
+type IdentitiesFilter struct {
+	States   []string         `json:"states"`
+	MinStake *decimal.Decimal `json:"minStake"`
+	MaxStake *decimal.Decimal `json:"maxStake"`
+	MinAge   *uint16          `json:"minAge"`
+	MaxAge   *uint16          `json:"maxAge"`
+}
+
+type IterateIdentitiesResponse struct {
+	Items             []Identity      `json:"items"`
+	ContinuationToken *common.Address `json:"continuationToken"`
+}
+
+// IterateIdentities returns up to limit identities matching the filter in the address order.
+// The continuation token is the address to continue from, so it stays valid while the identity set changes between blocks
+func (api *DnaApi) IterateIdentities(filter *IdentitiesFilter, continuationToken *common.Address, limit int) (*IterateIdentitiesResponse, error) {
+	if limit <= 0 {
+		return nil, errors.New("limit must be positive")
+	}
+	match, err := filter.matcher()
+	if err != nil {
+		return nil, err
+	}
+	start := common.MinAddr
+	if continuationToken != nil {
+		start = *continuationToken
+	}
+
+	appState := api.baseApi.getReadonlyAppState()
+	epoch := appState.State.Epoch()
+	coinbase := api.GetCoinbaseAddr()
+	items := make([]Identity, 0)
+	var token *common.Address
+	appState.State.IterateIdentitiesFrom(start, func(key []byte, value []byte) bool {
+		if key == nil {
+			return true
+		}
+		addr := state.StateDbKeys.IdentityKeyToAddress(key)
+		var data state.Identity
+		if err = data.FromBytes(value); err != nil {
+			return true
+		}
+		if !match(epoch, data) {
+			return false
+		}
+		if len(items) >= limit {
+			token = &addr
+			return true
+		}
+		var flipKeyWordPairs []int
+		if addr == coinbase {
+			flipKeyWordPairs = api.ceremony.FlipKeyWordPairs()
+		}
+		items = append(items, convertIdentity(epoch, addr, data, flipKeyWordPairs, appState))
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &IterateIdentitiesResponse{
+		Items:             items,
+		ContinuationToken: token,
+	}, nil
+}
+
+func (f *IdentitiesFilter) matcher() (func(epoch uint16, data state.Identity) bool, error) {
+	if f == nil {
+		return func(uint16, state.Identity) bool { return true }, nil
+	}
+	states := make(map[string]struct{}, len(f.States))
+	for _, s := range f.States {
+		if _, ok := identityStates[s]; !ok {
+			return nil, errors.Errorf("unknown identity state %v", s)
+		}
+		states[s] = struct{}{}
+	}
+	var minStake, maxStake *big.Int
+	if f.MinStake != nil {
+		minStake = blockchain.ConvertToInt(*f.MinStake)
+	}
+	if f.MaxStake != nil {
+		maxStake = blockchain.ConvertToInt(*f.MaxStake)
+	}
+	return func(epoch uint16, data state.Identity) bool {
+		if len(states) > 0 {
+			if _, ok := states[data.State.String()]; !ok {
+				return false
+			}
+		}
+		stake := data.Stake
+		if stake == nil {
+			stake = common.Big0
+		}
+		if minStake != nil && stake.Cmp(minStake) < 0 || maxStake != nil && stake.Cmp(maxStake) > 0 {
+			return false
+		}
+		age := identityAge(epoch, data)
+		if f.MinAge != nil && age < *f.MinAge || f.MaxAge != nil && age > *f.MaxAge {
+			return false
+		}
+		return true
+	}, nil
+}
+
 func (api *DnaApi) Identity(address *common.Address) Identity {
 	var flipKeyWordPairs []int
 	coinbase := api.GetCoinbaseAddr()
@@ -368,28 +499,140 @@ func (api *DnaApi) Identity(address *common.Address) Identity {
 	return convertIdentity(appState.State.Epoch(), *address, appState.State.GetIdentity(*address), flipKeyWordPairs, appState)
 }
 
-func convertIdentity(currentEpoch uint16, address common.Address, data state.Identity, flipKeyWordPairs []int, appState *appstate.AppState) Identity {
-	var s string
-	switch data.State {
-	case state.Invite:
-		s = "Invite"
-	case state.Candidate:
-		s = "Candidate"
-	case state.Newbie:
-		s = "Newbie"
-	case state.Verified:
-		s = "Verified"
-	case state.Suspended:
-		s = "Suspended"
-	case state.Zombie:
-		s = "Zombie"
-	case state.Killed:
-		s = "Killed"
-	case state.Human:
-		s = "Human"
-	default:
-		s = "Undefined"
+// WatchAddress registers the address, the transactions touching it and the changes of its identity state
+// are reported by WatchedAddressEvents and the webhooks. The options set the webhook and the confirmations of the deposits
+func (api *DnaApi) WatchAddress(address common.Address, options *subscriptions.WatchOptions) error {
+	var opts subscriptions.WatchOptions
+	if options != nil {
+		opts = *options
+	}
+	if opts.Url != "" {
+		if u, err := url.Parse(opts.Url); err != nil || u.Scheme != "http" && u.Scheme != "https" {
+			return errors.New("url should be an http(s) url")
+		}
+	}
+	return api.watcher.Watch(address, opts)
+}
+
+func (api *DnaApi) UnwatchAddress(address common.Address) error {
+	return api.watcher.Unwatch(address)
+}
+
+func (api *DnaApi) WatchedAddresses() []common.Address {
+	return api.watcher.Addresses()
+}
+
+// WatchedAddressEvents returns the latest events of the watched addresses with ids greater than the passed one
+func (api *DnaApi) WatchedAddressEvents(after uint64) []*subscriptions.WatchEvent {
+	return api.watcher.Events(after)
+}
+
+type IdentityChange struct {
+	Address    common.Address  `json:"address"`
+	PrevState  string          `json:"prevState"`
+	State      string          `json:"state"`
+	PrevStake  decimal.Decimal `json:"prevStake"`
+	Stake      decimal.Decimal `json:"stake"`
+	StakeDelta decimal.Decimal `json:"stakeDelta"`
+}
+
+type EpochDiff struct {
+	From              uint16            `json:"from"`
+	To                uint16            `json:"to"`
+	Added             []*IdentityChange `json:"added"`
+	Killed            []*IdentityChange `json:"killed"`
+	Changed           []*IdentityChange `json:"changed"`
+	TotalSupplyDelta  decimal.Decimal   `json:"totalSupplyDelta"`
+	TotalBalanceDelta decimal.Decimal   `json:"totalBalanceDelta"`
+	TotalStakeDelta   decimal.Decimal   `json:"totalStakeDelta"`
+}
+
+// EpochDiff compares the states at the starts of the epochs. The summaries of the epoch states are saved by the node
+// when it applies the epoch blocks, so the epochs synced by a snapshot are not available
+func (api *DnaApi) EpochDiff(from, to uint16) (*EpochDiff, error) {
+	fromSummary := api.bc.ReadEpochSummary(from)
+	if fromSummary == nil {
+		return nil, errors.Errorf("epoch %v state is not available", from)
+	}
+	toSummary := api.bc.ReadEpochSummary(to)
+	if toSummary == nil {
+		return nil, errors.Errorf("epoch %v state is not available", to)
+	}
+	fromSupply := new(big.Int).Add(fromSummary.TotalBalance, fromSummary.TotalStake)
+	toSupply := new(big.Int).Add(toSummary.TotalBalance, toSummary.TotalStake)
+	diff := &EpochDiff{
+		From:              from,
+		To:                to,
+		Added:             make([]*IdentityChange, 0),
+		Killed:            make([]*IdentityChange, 0),
+		Changed:           make([]*IdentityChange, 0),
+		TotalSupplyDelta:  blockchain.ConvertToFloat(new(big.Int).Sub(toSupply, fromSupply)),
+		TotalBalanceDelta: blockchain.ConvertToFloat(new(big.Int).Sub(toSummary.TotalBalance, fromSummary.TotalBalance)),
+		TotalStakeDelta:   blockchain.ConvertToFloat(new(big.Int).Sub(toSummary.TotalStake, fromSummary.TotalStake)),
+	}
+	newIdentityChange := func(address common.Address, prev, current *types.EpochSummaryIdentity) *IdentityChange {
+		change := &IdentityChange{
+			Address:   address,
+			PrevState: state.Undefined.String(),
+			State:     state.Undefined.String(),
+		}
+		prevStake, stake := common.Big0, common.Big0
+		if prev != nil {
+			change.PrevState = state.IdentityState(prev.State).String()
+			prevStake = prev.Stake
+		}
+		if current != nil {
+			change.State = state.IdentityState(current.State).String()
+			stake = current.Stake
+		}
+		change.PrevStake = blockchain.ConvertToFloat(prevStake)
+		change.Stake = blockchain.ConvertToFloat(stake)
+		change.StakeDelta = blockchain.ConvertToFloat(new(big.Int).Sub(stake, prevStake))
+		return change
+	}
+	prevIdentities := fromSummary.IdentitiesByAddress()
+	for _, identity := range toSummary.Identities {
+		prev, ok := prevIdentities[identity.Address]
+		delete(prevIdentities, identity.Address)
+		switch {
+		case !ok || prev.State == uint8(state.Killed) && identity.State != uint8(state.Killed):
+			diff.Added = append(diff.Added, newIdentityChange(identity.Address, prev, identity))
+		case identity.State == uint8(state.Killed) && prev.State != uint8(state.Killed):
+			diff.Killed = append(diff.Killed, newIdentityChange(identity.Address, prev, identity))
+		case identity.State != prev.State || identity.Stake.Cmp(prev.Stake) != 0:
+			diff.Changed = append(diff.Changed, newIdentityChange(identity.Address, prev, identity))
+		}
+	}
+	// the killed identities are removed from the state
+	for _, identity := range fromSummary.Identities {
+		if _, ok := prevIdentities[identity.Address]; ok && identity.State != uint8(state.Killed) {
+			diff.Killed = append(diff.Killed, newIdentityChange(identity.Address, identity, nil))
+		}
 	}
+	return diff, nil
+}
+
+var identityStates = map[string]state.IdentityState{
+	"Undefined": state.Undefined,
+	"Invite":    state.Invite,
+	"Candidate": state.Candidate,
+	"Newbie":    state.Newbie,
+	"Verified":  state.Verified,
+	"Suspended": state.Suspended,
+	"Zombie":    state.Zombie,
+	"Killed":    state.Killed,
+	"Human":     state.Human,
+}
+
+func identityAge(currentEpoch uint16, data state.Identity) uint16 {
+	if data.State.NewbieOrBetter() || data.State == state.Suspended || data.State == state.Zombie {
+		return currentEpoch - data.Birthday
+	}
+	return 0
+}
+
+func convertIdentity(currentEpoch uint16, address common.Address, data state.Identity, flipKeyWordPairs []int, appState *appstate.AppState) Identity {
+	s := data.State.String()
 
 	var flags []string
 	if data.LastValidationStatus.HasFlag(state.AllFlipsNotQualified) {
@@ -437,10 +680,7 @@ func convertIdentity(currentEpoch uint16, address common.Address, data state.Ide
 		invitees = data.Invitees
 	}
 
-	age := uint16(0)
-	if data.State.NewbieOrBetter() || data.State == state.Suspended || data.State == state.Zombie {
-		age = currentEpoch - data.Birthday
-	}
+	age := identityAge(currentEpoch, data)
 
 	totalPoints, totalFlips := common.CalculateIdentityScores(data.Scores, data.GetShortFlipPoints(), data.QualifiedFlips)
 
@@ -577,10 +817,20 @@ func (api *DnaApi) CeremonyIntervals() CeremonyIntervals {
 	return CeremonyIntervals{
 		FlipLotteryDuration:  cfg.Validation.GetFlipLotteryDuration().Seconds(),
 		ShortSessionDuration: cfg.Validation.GetShortSessionDuration().Seconds(),
-		LongSessionDuration:  cfg.Validation.GetLongSessionDuration(networkSize).Seconds(),
+		LongSessionDuration:  cfg.Validation.GetLongSessionDuration(networkSize, cfg.Consensus.GetRequiredFlips(networkSize, cfg.Network)).Seconds(),
 	}
 }
 
+// CeremonyTelemetry returns counters of the current epoch ceremony split by validation periods
+func (api *DnaApi) CeremonyTelemetry() ceremony.CeremonyTelemetry {
+	return api.ceremony.Telemetry()
+}
+
+// ClockSkew returns the local clock skew, the flag is set if the skew may cause missing of the short session
+func (api *DnaApi) ClockSkew() ceremony.ClockSkew {
+	return api.ceremony.ClockSkew()
+}
+
 func (api *DnaApi) ExportKey(password string) (string, error) {
 	if password == "" {
 		return "", errors.New("password should not be empty")
@@ -745,12 +995,17 @@ const (
 	Prefix     SignedDataFormat = "prefix"
 )
 
-func (api *DnaApi) Sign(value string, format *SignedDataFormat) (hexutil.Bytes, error) {
+// Sign signs the value by the coinbase or by the unlocked keystore account, the prefix format adds the Idena message prefix,
+// so the signature can never be a valid transaction or consensus signature
+func (api *DnaApi) Sign(value string, format *SignedDataFormat, address *common.Address) (hexutil.Bytes, error) {
 	hash, err := signatureHash(value, signedDataFormatOrDefault(format))
 	if err != nil {
 		return hexutil.Bytes{}, err
 	}
-	return api.baseApi.secStore.Sign(hash[:]), nil
+	if address == nil {
+		return api.baseApi.secStore.Sign(hash[:]), nil
+	}
+	return api.baseApi.signHash(*address, hash[:])
 }
 
 type SignatureAddressArgs struct {
@@ -786,6 +1041,26 @@ func (api *DnaApi) SignatureAddress(args SignatureAddressArgs) (common.Address,
 	return addr, nil
 }
 
+type VerifyArgs struct {
+	Value     string
+	Signature hexutil.Bytes
+	Address   common.Address
+	Format    *SignedDataFormat
+}
+
+// Verify checks that the value is signed by the address, e.g. to prove the control of an identity off-chain
+func (api *DnaApi) Verify(args VerifyArgs) (bool, error) {
+	addr, err := api.SignatureAddress(SignatureAddressArgs{
+		Value:     args.Value,
+		Signature: args.Signature,
+		Format:    args.Format,
+	})
+	if err != nil {
+		return false, err
+	}
+	return addr == args.Address, nil
+}
+
 func signatureHash(value string, format SignedDataFormat) (common.Hash, error) {
 	switch format {
 	case DoubleHash:
@@ -865,8 +1140,8 @@ func (api *DnaApi) SendToIpfs(ctx context.Context, args SendToIpfsArgs) (common.
 	if args.Tx == nil || args.Data == nil || len(*args.Data) == 0 {
 		return common.Hash{}, errors.New("all fields are required")
 	}
-	tx := new(types.Transaction)
-	if err := tx.FromBytes(*args.Tx); err != nil {
+	tx, err := decodeRawTx(*args.Tx)
+	if err != nil {
 		return common.Hash{}, err
 	}
 	attachment := attachments.ParseStoreToIpfsAttachment(tx)
@@ -896,3 +1171,53 @@ func (api *DnaApi) SendToIpfs(ctx context.Context, args SendToIpfsArgs) (common.
 	}
 	return txHash, nil
 }
+
+// StateProof contains the protobuf encoded values of the account and identity with the paths to the state root,
+// a proof is null if the value doesn't exist
+type StateProof struct {
+	Address  common.Address `json:"address"`
+	Height   uint64         `json:"height"`
+	Root     common.Hash    `json:"root"`
+	Account  *hexutil.Bytes `json:"account"`
+	Identity *hexutil.Bytes `json:"identity"`
+}
+
+// GetProof returns the proofs of the account and identity against the state root of the block at the height or the
+// head if the height is not specified. Only recent states are kept, so proofs for old blocks are not available
+func (api *DnaApi) GetProof(address common.Address, height *uint64) (*StateProof, error) {
+	h := api.bc.Head.Height()
+	if height != nil {
+		h = *height
+	}
+	header := api.bc.GetBlockHeaderByHeight(h)
+	if header == nil {
+		return nil, errors.Errorf("block %v is not found", h)
+	}
+	stateDb, err := api.baseApi.engine.ReadonlyStateAt(h)
+	if err != nil {
+		return nil, errors.Wrapf(err, "state of block %v is not available", h)
+	}
+	if stateDb.Root() != header.Root() {
+		return nil, errors.Errorf("state root of block %v mismatches", h)
+	}
+	account, err := stateDb.GetAccountWithProof(address)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := stateDb.GetIdentityWithProof(address)
+	if err != nil {
+		return nil, err
+	}
+	result := &StateProof{
+		Address: address,
+		Height:  h,
+		Root:    header.Root(),
+	}
+	if account != nil {
+		result.Account = (*hexutil.Bytes)(&account)
+	}
+	if identity != nil {
+		result.Identity = (*hexutil.Bytes)(&identity)
+	}
+	return result, nil
+}
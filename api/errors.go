@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-go/blockchain/validation"
+	"github.com/idena-network/idena-go/core/mempool"
+	"github.com/idena-network/idena-go/keystore"
+	"github.com/idena-network/idena-go/rpc"
+	"github.com/pkg/errors"
+)
+
+// Codes of the errors returned by the api services, the values are stable, so clients can rely on them
+// instead of the messages. The code name is returned as the error data
+const (
+	InsufficientFundsErrorCode = -32010
+	InvalidNonceErrorCode      = -32011
+	InvalidEpochErrorCode      = -32012
+	InvalidFeeErrorCode        = -32013
+	WrongPeriodErrorCode       = -32014
+	DuplicatedTxErrorCode      = -32015
+	MempoolFullErrorCode       = -32016
+	InvalidSignatureErrorCode  = -32017
+	KeyLockedErrorCode         = -32018
+	InvalidPasswordErrorCode   = -32019
+	UnknownAccountErrorCode    = -32020
+	NotSyncedErrorCode         = -32021
+	NotCandidateErrorCode      = -32022
+	NotFoundErrorCode          = -32023
+)
+
+var errorCodeNames = map[int]string{
+	InsufficientFundsErrorCode: "INSUFFICIENT_FUNDS",
+	InvalidNonceErrorCode:      "INVALID_NONCE",
+	InvalidEpochErrorCode:      "INVALID_EPOCH",
+	InvalidFeeErrorCode:        "INVALID_FEE",
+	WrongPeriodErrorCode:       "WRONG_PERIOD",
+	DuplicatedTxErrorCode:      "DUPLICATED_TX",
+	MempoolFullErrorCode:       "MEMPOOL_FULL",
+	InvalidSignatureErrorCode:  "INVALID_SIGNATURE",
+	KeyLockedErrorCode:         "KEY_LOCKED",
+	InvalidPasswordErrorCode:   "INVALID_PASSWORD",
+	UnknownAccountErrorCode:    "UNKNOWN_ACCOUNT",
+	NotSyncedErrorCode:         "NOT_SYNCED",
+	NotCandidateErrorCode:      "NOT_CANDIDATE",
+	NotFoundErrorCode:          "NOT_FOUND",
+}
+
+var (
+	ErrNotSynced = errors.New("node is not synced")
+
+	errNotCandidate           = errors.New("coinbase address is not a ceremony candidate")
+	errShortSessionPeriodOnly = errors.New("this method is available during FlipLottery and ShortSession periods")
+	errLongSessionPeriodOnly  = errors.New("this method is available during FlipLottery, ShortSession and LongSession periods")
+	errTxNotFound             = errors.New("transaction not found")
+	errBlockNotFound          = errors.New("block not found")
+)
+
+func init() {
+	codes := []struct {
+		target error
+		code   int
+	}{
+		{validation.InsufficientFunds, InsufficientFundsErrorCode},
+		{validation.InvalidDeployAmount, InsufficientFundsErrorCode},
+		{validation.InvalidNonce, InvalidNonceErrorCode},
+		{validation.InvalidEpoch, InvalidEpochErrorCode},
+		{validation.WrongEpoch, InvalidEpochErrorCode},
+		{validation.BigFee, InvalidFeeErrorCode},
+		{validation.InvalidMaxFee, InvalidFeeErrorCode},
+		{validation.TooHighMaxFee, InvalidFeeErrorCode},
+		{validation.EarlyTx, WrongPeriodErrorCode},
+		{validation.LateTx, WrongPeriodErrorCode},
+		{errShortSessionPeriodOnly, WrongPeriodErrorCode},
+		{errLongSessionPeriodOnly, WrongPeriodErrorCode},
+		{validation.DuplicatedTx, DuplicatedTxErrorCode},
+		{mempool.DuplicateTxError, DuplicatedTxErrorCode},
+		{mempool.MempoolFullError, MempoolFullErrorCode},
+		{validation.InvalidSignature, InvalidSignatureErrorCode},
+		{validation.InvalidSender, InvalidSignatureErrorCode},
+		{keystore.ErrLocked, KeyLockedErrorCode},
+		{keystore.ErrDecrypt, InvalidPasswordErrorCode},
+		{keystore.ErrNoMatch, UnknownAccountErrorCode},
+		{ErrNotSynced, NotSyncedErrorCode},
+		{validation.NotCandidate, NotCandidateErrorCode},
+		{errTxNotFound, NotFoundErrorCode},
+		{errBlockNotFound, NotFoundErrorCode},
+		{errNotCandidate, NotCandidateErrorCode},
+	}
+	for _, c := range codes {
+		rpc.RegisterErrorCode(c.target, c.code, errorCodeNames[c.code])
+	}
+}
+
+// newApiError creates an error with the code for the cases which have no sentinel error
+func newApiError(code int, format string, args ...interface{}) error {
+	return &rpc.ApplicationError{
+		Code:    code,
+		Name:    errorCodeNames[code],
+		Message: fmt.Sprintf(format, args...),
+	}
+}
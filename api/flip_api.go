@@ -12,6 +12,7 @@ import (
 	"github.com/idena-network/idena-go/core/flip"
 	"github.com/idena-network/idena-go/core/state"
 	"github.com/idena-network/idena-go/ipfs"
+	"github.com/idena-network/idena-go/keywords"
 	"github.com/idena-network/idena-go/log"
 	"github.com/ipfs/go-cid"
 	"github.com/pkg/errors"
@@ -55,9 +56,11 @@ func (api *FlipApi) RawSubmit(args RawFlipSubmitArgs) (FlipSubmitResponse, error
 
 	encPublicPart := *args.EncryptedPublicHex
 	encPrivatePart := *args.EncryptedPrivateHex
+	if size := len(encPublicPart) + len(encPrivatePart); size > common.MaxFlipSize {
+		return FlipSubmitResponse{}, errors.Errorf("flip is too big, max expected size %v, actual %v", common.MaxFlipSize, size)
+	}
 
-	tx := new(types.Transaction)
-	err := tx.FromBytes(*args.Tx)
+	tx, err := decodeRawTx(*args.Tx)
 	if err != nil {
 		return FlipSubmitResponse{}, err
 	}
@@ -80,6 +83,39 @@ func (api *FlipApi) RawSubmit(args RawFlipSubmitArgs) (FlipSubmitResponse, error
 	}, nil
 }
 
+type FlipImportArgs struct {
+	// JSON encoded flip.FlipPackage
+	Package *hexutil.Bytes `json:"package"`
+}
+
+// Import publishes and submits the flip package prepared and encrypted by an offline tool
+func (api *FlipApi) Import(args FlipImportArgs) (FlipSubmitResponse, error) {
+	if args.Package == nil {
+		return FlipSubmitResponse{}, errors.New("flip package is empty")
+	}
+	if err := checkSize("flip package", *args.Package, maxFlipPackageSize); err != nil {
+		return FlipSubmitResponse{}, err
+	}
+	flipPackage := new(flip.FlipPackage)
+	if err := flipPackage.FromBytes(*args.Package); err != nil {
+		return FlipSubmitResponse{}, errors.Wrap(err, "flip package is invalid")
+	}
+
+	f, err := api.fp.ImportFlipPackage(flipPackage)
+	if err != nil {
+		return FlipSubmitResponse{}, err
+	}
+
+	attachment := attachments.ParseFlipSubmitAttachment(f.Tx)
+	c, _ := cid.Cast(attachment.Cid)
+	log.Info("Flip package imported", "hash", f.Tx.Hash().Hex(), "cid", c.String())
+
+	return FlipSubmitResponse{
+		TxHash: f.Tx.Hash(),
+		Hash:   c.String(),
+	}, nil
+}
+
 func (api *FlipApi) Submit(args FlipSubmitArgs) (FlipSubmitResponse, error) {
 	if args.Hex == nil && args.PublicHex == nil {
 		return FlipSubmitResponse{}, errors.New("flip is empty")
@@ -172,11 +208,11 @@ func (api *FlipApi) ShortHashes(addr *common.Address) ([]FlipHashesResponse, err
 	period := appState.State.ValidationPeriod()
 
 	if period != state.FlipLotteryPeriod && period != state.ShortSessionPeriod {
-		return nil, errors.New("this method is available during FlipLottery and ShortSession periods")
+		return nil, errShortSessionPeriodOnly
 	}
 
 	if !api.isCeremonyCandidate(address) {
-		return nil, errors.Errorf("0x%x address is not a ceremony candidate", address)
+		return nil, newApiError(NotCandidateErrorCode, "0x%x address is not a ceremony candidate", address)
 	}
 
 	flips := api.ceremony.GetShortFlipsToSolve(address, appState.State.ShardId(address))
@@ -200,11 +236,11 @@ func (api *FlipApi) LongHashes(addr *common.Address) ([]FlipHashesResponse, erro
 	period := appState.State.ValidationPeriod()
 
 	if period != state.FlipLotteryPeriod && period != state.ShortSessionPeriod && period != state.LongSessionPeriod {
-		return nil, errors.New("this method is available during FlipLottery, ShortSession and LongSession periods")
+		return nil, errLongSessionPeriodOnly
 	}
 
 	if !api.isCeremonyCandidate(address) {
-		return nil, errors.Errorf("0x%x address is not a ceremony candidate", address)
+		return nil, newApiError(NotCandidateErrorCode, "0x%x address is not a ceremony candidate", address)
 	}
 
 	flips := api.ceremony.GetLongFlipsToSolve(address, appState.State.ShardId(address))
@@ -385,7 +421,7 @@ func (api *FlipApi) SubmitShortAnswers(args SubmitAnswersArgs) (SubmitAnswersRes
 	defer log.Info("short answers submitting response")
 
 	if !api.isCeremonyCandidate(api.baseApi.getCurrentCoinbase()) {
-		return SubmitAnswersResponse{}, errors.New("coinbase address is not a ceremony candidate")
+		return SubmitAnswersResponse{}, errNotCandidate
 	}
 
 	flips := api.ceremony.GetShortFlipsToSolve(api.baseApi.getCurrentCoinbase(), api.baseApi.getCoinbaseShard())
@@ -408,7 +444,7 @@ func (api *FlipApi) SubmitLongAnswers(args SubmitAnswersArgs) (SubmitAnswersResp
 	defer log.Info("long answers submitting response")
 
 	if !api.isCeremonyCandidate(api.baseApi.getCurrentCoinbase()) {
-		return SubmitAnswersResponse{}, errors.New("coinbase address is not a ceremony candidate")
+		return SubmitAnswersResponse{}, errNotCandidate
 	}
 
 	flips := api.ceremony.GetLongFlipsToSolve(api.baseApi.getCurrentCoinbase(), api.baseApi.getCoinbaseShard())
@@ -426,6 +462,37 @@ func (api *FlipApi) SubmitLongAnswers(args SubmitAnswersArgs) (SubmitAnswersResp
 	}, nil
 }
 
+// Report marks a long session flip of the coinbase address as irrelevant or inappropriate, reports are submitted along with long answers
+func (api *FlipApi) Report(hash string) error {
+	c, err := cid.Parse(hash)
+	if err != nil {
+		return err
+	}
+	log.Info("flip report request", "hash", hash)
+	return api.ceremony.ReportFlip(c.Bytes())
+}
+
+func (api *FlipApi) CancelReport(hash string) error {
+	c, err := cid.Parse(hash)
+	if err != nil {
+		return err
+	}
+	log.Info("flip report cancellation request", "hash", hash)
+	return api.ceremony.CancelFlipReport(c.Bytes())
+}
+
+func (api *FlipApi) Reports() []string {
+	var result []string
+	for _, item := range api.ceremony.OwnFlipReports() {
+		c, err := cid.Cast(item)
+		if err != nil {
+			continue
+		}
+		result = append(result, c.String())
+	}
+	return result
+}
+
 type FlipWordsResponse struct {
 	Words [2]int `json:"words"`
 }
@@ -515,3 +582,73 @@ func (api *FlipApi) WordPairs(addr common.Address, vrfHash hexutil.Bytes) []Flip
 
 	return convertedFlipKeyWordPairs
 }
+
+type FlipKeyWord struct {
+	Index uint32 `json:"index"`
+	Name  string `json:"name"`
+	Desc  string `json:"desc"`
+}
+
+type FlipKeyWordPair struct {
+	Id    int            `json:"id"`
+	Words [2]FlipKeyWord `json:"words"`
+	Used  bool           `json:"used"`
+}
+
+type FlipKeyWordPairsResponse struct {
+	Address common.Address    `json:"address"`
+	Epoch   uint16            `json:"epoch"`
+	Pairs   []FlipKeyWordPair `json:"pairs"`
+}
+
+// KeyWordPairs returns key word pairs assigned to the coinbase identity for flips creation in the current epoch
+func (api *FlipApi) KeyWordPairs() (FlipKeyWordPairsResponse, error) {
+	coinbase := api.baseApi.getCurrentCoinbase()
+	appState := api.baseApi.getReadonlyAppState()
+	identity := appState.State.GetIdentity(coinbase)
+
+	wordPairs := api.ceremony.FlipKeyWordPairs()
+	if len(wordPairs) == 0 {
+		return FlipKeyWordPairsResponse{}, errors.New("key word pairs are not generated")
+	}
+
+	usedPairs := mapset.NewSet()
+	for _, v := range identity.Flips {
+		usedPairs.Add(v.Pair)
+	}
+
+	resolveWord := func(index int) (FlipKeyWord, error) {
+		keyword, err := keywords.Get(index)
+		if err != nil {
+			return FlipKeyWord{}, errors.Wrapf(err, "cannot resolve key word %v", index)
+		}
+		return FlipKeyWord{
+			Index: uint32(index),
+			Name:  keyword.Name,
+			Desc:  keyword.Desc,
+		}, nil
+	}
+
+	pairs := make([]FlipKeyWordPair, 0, len(wordPairs)/2)
+	for i := 0; i < len(wordPairs)/2; i++ {
+		word1, err := resolveWord(wordPairs[i*2])
+		if err != nil {
+			return FlipKeyWordPairsResponse{}, err
+		}
+		word2, err := resolveWord(wordPairs[i*2+1])
+		if err != nil {
+			return FlipKeyWordPairsResponse{}, err
+		}
+		pairs = append(pairs, FlipKeyWordPair{
+			Id:    i,
+			Words: [2]FlipKeyWord{word1, word2},
+			Used:  usedPairs.Contains(uint8(i)),
+		})
+	}
+
+	return FlipKeyWordPairsResponse{
+		Address: coinbase,
+		Epoch:   appState.State.Epoch(),
+		Pairs:   pairs,
+	}, nil
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"github.com/idena-network/idena-go/blockchain"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/shopspring/decimal"
+)
+
+// InviteApi manages the invites issued by the node identity
+type InviteApi struct {
+	baseApi *BaseApi
+}
+
+// NewInviteApi creates a new InviteApi instance
+func NewInviteApi(baseApi *BaseApi) *InviteApi {
+	return &InviteApi{baseApi}
+}
+
+type IssuedInvite struct {
+	Address   common.Address  `json:"address"`
+	State     string          `json:"state"`
+	TxHash    common.Hash     `json:"txHash"`
+	Activated bool            `json:"activated"`
+	Balance   decimal.Decimal `json:"balance"`
+}
+
+type RevokeInviteArgs struct {
+	To common.Address `json:"to"`
+	BaseTxArgs
+}
+
+// Issue sends an invite to the address or to a new random key, which is returned in the latter case
+func (api *InviteApi) Issue(ctx context.Context, args SendInviteArgs) (Invite, error) {
+	return api.baseApi.sendInvite(ctx, args)
+}
+
+// List returns the invites of the current epoch issued by the inviter (the node identity by default):
+// the pending invites and the activated ones, which are candidates now
+func (api *InviteApi) List(inviter *common.Address) []IssuedInvite {
+	if inviter == nil {
+		coinbase := api.baseApi.getCurrentCoinbase()
+		inviter = &coinbase
+	}
+	appState := api.baseApi.getReadonlyAppState()
+	res := make([]IssuedInvite, 0)
+	appState.State.IterateIdentities(func(key []byte, value []byte) bool {
+		if key == nil {
+			return true
+		}
+		var data state.Identity
+		if err := data.FromBytes(value); err != nil {
+			return false
+		}
+		if data.Inviter == nil || data.Inviter.Address != *inviter {
+			return false
+		}
+		addr := state.StateDbKeys.IdentityKeyToAddress(key)
+		res = append(res, IssuedInvite{
+			Address:   addr,
+			State:     data.State.String(),
+			TxHash:    data.Inviter.TxHash,
+			Activated: data.State != state.Invite,
+			Balance:   blockchain.ConvertToFloat(appState.State.GetBalance(addr)),
+		})
+		return false
+	})
+	return res
+}
+
+// Revoke kills the invited identity, which is not activated or not validated yet,
+// a verified inviter gets the invite back
+func (api *InviteApi) Revoke(ctx context.Context, args RevokeInviteArgs) (common.Hash, error) {
+	from := api.baseApi.getCurrentCoinbase()
+	return api.baseApi.sendTx(ctx, from, &args.To, types.KillInviteeTx, decimal.Zero, decimal.Zero, decimal.Zero, args.Nonce, args.Epoch, nil, nil)
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/blockchain/validation"
+	"github.com/idena-network/idena-go/common"
+	"github.com/pkg/errors"
+)
+
+// The RPC request size is limited by the HTTP and websocket servers only, the in-process calls of embedding apps
+// are not, so the raw objects are checked with the same limits as the ones received from peers before decoding
+const (
+	// maxSmallObjectSize is enough for the keys, signatures and encoding overhead of a message
+	maxSmallObjectSize = 64 * 1024
+	// maxRawTxSize is enough for a transaction with the largest allowed payload
+	maxRawTxSize = validation.MaxPayloadSizeUpgrade11 + maxSmallObjectSize
+	// maxFlipPackageSize is enough for the hex encoded flip parts and the submit flip tx of a flip package
+	maxFlipPackageSize = 2*common.MaxFlipSize + maxSmallObjectSize
+)
+
+func checkSize(name string, data []byte, maxSize int) error {
+	if len(data) > maxSize {
+		return errors.Errorf("%v is too large, max expected size %v, actual %v", name, maxSize, len(data))
+	}
+	return nil
+}
+
+func decodeRawTx(data []byte) (*types.Transaction, error) {
+	if err := checkSize("transaction", data, maxRawTxSize); err != nil {
+		return nil, err
+	}
+	tx := new(types.Transaction)
+	if err := tx.FromBytes(data); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
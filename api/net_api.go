@@ -3,6 +3,7 @@ package api
 import (
 	"github.com/idena-network/idena-go/ipfs"
 	"github.com/idena-network/idena-go/protocol"
+	"sort"
 )
 
 // NetApi offers helper utils
@@ -23,6 +24,8 @@ func (api *NetApi) PeersCount() int {
 type Peer struct {
 	ID         string `json:"id"`
 	RemoteAddr string `json:"addr"`
+	Score      int64  `json:"score"`
+	LatencyMs  int64  `json:"latencyMs"`
 }
 
 func (api *NetApi) Peers() []Peer {
@@ -31,11 +34,64 @@ func (api *NetApi) Peers() []Peer {
 		peers = append(peers, Peer{
 			ID:         p.ID(),
 			RemoteAddr: p.RemoteAddr(),
+			Score:      p.Score(),
+			LatencyMs:  p.Latency().Milliseconds(),
 		})
 	}
 	return peers
 }
 
+type Traffic struct {
+	Total map[string]protocol.MsgTraffic            `json:"total"`
+	Peers map[string]map[string]protocol.MsgTraffic `json:"peers"`
+}
+
+// Traffic returns messages and bytes sent and received by message type, in total and per connected peer
+func (api *NetApi) Traffic() Traffic {
+	result := Traffic{
+		Total: api.pm.Traffic(),
+		Peers: make(map[string]map[string]protocol.MsgTraffic),
+	}
+	for _, p := range api.pm.Peers() {
+		result.Peers[p.ID()] = p.Traffic()
+	}
+	return result
+}
+
+type PeerStats struct {
+	ID         string                         `json:"id"`
+	RemoteAddr string                         `json:"addr"`
+	Total      protocol.MsgTraffic            `json:"total"`
+	LastMinute protocol.MsgTraffic            `json:"lastMinute"`
+	ByType     map[string]protocol.MsgTraffic `json:"byType"`
+}
+
+// PeerStats returns the traffic of the connected peers since the connection and during the last minute,
+// the peers exchanging the most bytes go first
+func (api *NetApi) PeerStats() []PeerStats {
+	result := make([]PeerStats, 0)
+	for _, p := range api.pm.Peers() {
+		total, lastMinute := p.TrafficSummary()
+		result = append(result, PeerStats{
+			ID:         p.ID(),
+			RemoteAddr: p.RemoteAddr(),
+			Total:      total,
+			LastMinute: lastMinute,
+			ByType:     p.Traffic(),
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Total.BytesIn+result[i].Total.BytesOut > result[j].Total.BytesIn+result[j].Total.BytesOut
+	})
+	return result
+}
+
+// PeerEvents returns peer lifecycle events which happened after the event with the passed id,
+// clients subscribe by polling with the id of the last received event
+func (api *NetApi) PeerEvents(after uint64) []*protocol.PeerEvent {
+	return api.pm.PeerEvents(after)
+}
+
 func (api *NetApi) IpfsAddress() string {
 	return api.pm.Endpoint()
 }
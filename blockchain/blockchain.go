@@ -2,6 +2,7 @@ package blockchain
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"encoding/binary"
 	"fmt"
@@ -32,12 +33,14 @@ import (
 	"github.com/idena-network/idena-go/resources"
 	"github.com/idena-network/idena-go/secstore"
 	"github.com/idena-network/idena-go/stats/collector"
+	"github.com/idena-network/idena-go/stats/tracing"
 	"github.com/idena-network/idena-go/subscriptions"
 	"github.com/idena-network/idena-go/vm"
 	cid2 "github.com/ipfs/go-cid"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 	dbm "github.com/tendermint/tm-db"
+	"go.opentelemetry.io/otel/attribute"
 	math2 "math"
 	"math/big"
 	"math/rand"
@@ -56,6 +59,10 @@ const (
 	MaxFutureBlockOffset          = time.Minute * 2
 	MinBlockDelay                 = time.Second * 10
 	StoreToIpfsThreshold          = 1 - fee.StoreToIpfsFeeCoef
+	// max number of blocks the head is rolled back to reach consistent block indices
+	maxHeadRollbackDepth = 1000
+	// max number of blocks checked for a final certificate from the block of a transaction
+	maxFinalityScan = 100
 
 	SkipError = "transaction should be skipped"
 )
@@ -139,7 +146,7 @@ func NewBlockchain(config *config.Config, db dbm.DB, txpool *mempool.TxPool, app
 		txpool:          txpool,
 		appState:        appState,
 		ipfs:            ipfs,
-		timing:          NewTiming(config.Validation),
+		timing:          NewTiming(config.Validation, config.Consensus, config.Network),
 		bus:             bus,
 		secStore:        secStore,
 		offlineDetector: offlineDetector,
@@ -168,6 +175,10 @@ func (chain *Blockchain) Config() *config.Config {
 	return chain.config
 }
 
+func (chain *Blockchain) Upgrader() *upgrade.Upgrader {
+	return chain.upgrader
+}
+
 func (chain *Blockchain) Indexer() *indexer {
 	return chain.indexer
 }
@@ -194,25 +205,18 @@ func (chain *Blockchain) InitializeChain() error {
 	chain.coinBaseAddress = chain.secStore.GetAddress()
 	chain.pubKey = chain.secStore.GetPubKey()
 	head := chain.GetHead()
+	if head == nil && chain.repo.ReadCanonicalHash(predefinedGenesisHeight(chain.config.Network)) != (common.Hash{}) {
+		// the chain exists but the head record is unreadable after an unclean shutdown
+		var err error
+		if head, err = chain.recoverHead(); err != nil {
+			return err
+		}
+	}
 	if head != nil {
 		chain.setCurrentHead(head)
 		chain.tryUpgrade(head)
 
-		genesisHeight := uint64(1)
-
-		if chain.config.Network == Mainnet {
-			predefinedState, err := readPredefinedState()
-			if err == nil {
-				genesisHeight = predefinedState.Block
-			} else {
-				bindataGenesis, err := chain.readBindataGenesis()
-				if err == nil {
-					genesisHeight = bindataGenesis.Height()
-				}
-			}
-		}
-
-		predefinedGenesis := chain.GetBlockHeaderByHeight(genesisHeight)
+		predefinedGenesis := chain.GetBlockHeaderByHeight(predefinedGenesisHeight(chain.config.Network))
 		if predefinedGenesis == nil {
 			return errors.New("genesis block is not found")
 		}
@@ -251,7 +255,20 @@ func (chain *Blockchain) setHead(height uint64, batch dbm.Batch) {
 	chain.setCurrentHead(chain.GetHead())
 }
 
-func (chain *Blockchain) readBindataGenesis() (*types.Header, error) {
+func predefinedGenesisHeight(network types.Network) uint64 {
+	if network != Mainnet {
+		return 1
+	}
+	if predefinedState, err := readPredefinedState(); err == nil {
+		return predefinedState.Block
+	}
+	if bindataGenesis, err := readBindataGenesis(); err == nil {
+		return bindataGenesis.Height()
+	}
+	return 1
+}
+
+func readBindataGenesis() (*types.Header, error) {
 	data, err := resources.IntermediateGenesisHeader()
 	if err != nil {
 		return nil, err
@@ -269,7 +286,7 @@ func (chain *Blockchain) loadPredefinedGenesis(network types.Network) (*types.Bl
 		return nil, errors.New(fmt.Sprintf("predefined genesis for network=%v was not found", network))
 	}
 
-	header, err := chain.readBindataGenesis()
+	header, err := readBindataGenesis()
 	if err != nil {
 		return nil, err
 	}
@@ -426,16 +443,28 @@ func (chain *Blockchain) GenerateEmptyBlock() *types.Block {
 }
 
 func (chain *Blockchain) AddBlock(block *types.Block, checkState *appstate.AppState,
-	statsCollector collector.StatsCollector) error {
+	statsCollector collector.StatsCollector) (err error) {
+	ctx, span := tracing.StartSpan(context.Background(), "blockchain.AddBlock",
+		append(tracing.Block(block.Height(), block.Hash().Hex()), attribute.Int("block.txs", len(block.Body.Transactions)))...)
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
 
 	if err := validateBlockParentHash(block.Header, chain.Head); err != nil {
 		return err
 	}
 	statsCollector.EnableCollecting()
 	defer statsCollector.CompleteCollecting()
-	if blockInsertionResult, err := chain.ValidateBlock(block, checkState, statsCollector); err != nil {
+	_, validationSpan := tracing.StartSpan(ctx, "blockchain.ValidateBlock")
+	blockInsertionResult, err := chain.ValidateBlock(block, checkState, statsCollector)
+	tracing.EndSpan(validationSpan, err)
+	if err != nil {
 		return err
 	} else {
+		var balanceChanges []*state.BalanceChange
+		if chain.config.Blockchain.PublishBalanceEvents {
+			balanceChanges = chain.appState.State.BalanceChanges(blockInsertionResult.stateDiff)
+		}
 		chain.appState.State.AddDiff(blockInsertionResult.stateDiff)
 		chain.appState.IdentityState.AddDiff(block.Height(), blockInsertionResult.identityStateDiff)
 
@@ -449,12 +478,15 @@ func (chain *Blockchain) AddBlock(block *types.Block, checkState *appstate.AppSt
 			return errors.New("invalid block identity root")
 		}
 
-		if err := chain.appState.CommitTrees(block, blockInsertionResult.identityStateDiff); err != nil {
+		_, commitSpan := tracing.StartSpan(ctx, "appstate.CommitTrees")
+		err := chain.appState.CommitTrees(block, blockInsertionResult.identityStateDiff)
+		tracing.EndSpan(commitSpan, err)
+		if err != nil {
 			chain.appState.Reset()
 			return err
 		}
 
-		if err := chain.insertBlock(block, blockInsertionResult.identityStateDiff, blockInsertionResult.txReceipts); err != nil {
+		if err := chain.insertBlockCtx(ctx, block, blockInsertionResult.identityStateDiff, blockInsertionResult.txReceipts); err != nil {
 			return err
 		}
 
@@ -475,15 +507,96 @@ func (chain *Blockchain) AddBlock(block *types.Block, checkState *appstate.AppSt
 		chain.bus.Publish(&events.NewBlockEvent{
 			Block: block,
 		})
+		for _, change := range balanceChanges {
+			chain.bus.Publish(&events.BalanceChangedEvent{
+				Address:     change.Address,
+				Height:      block.Height(),
+				PrevBalance: change.PrevBalance,
+				Balance:     change.Balance,
+				PrevStake:   change.PrevStake,
+				Stake:       change.Stake,
+			})
+		}
+		chain.publishInviteActivations(block)
 		if block.Header.Flags().HasFlag(types.ValidationFinished) {
 			shardId, _ := chain.CoinbaseShard()
 			log.Info("Coinbase shard", "shardId", shardId)
+			chain.writeEpochSummary(block.Height())
 		}
 		chain.RemovePreliminaryHead(nil)
 		return nil
 	}
 }
 
+func (chain *Blockchain) publishInviteActivations(block *types.Block) {
+	for _, tx := range block.Body.Transactions {
+		if tx.Type != types.ActivationTx {
+			continue
+		}
+		inviter := chain.appState.State.GetInviter(*tx.To)
+		if inviter == nil {
+			continue
+		}
+		sender, _ := types.Sender(tx)
+		chain.bus.Publish(&events.InviteActivatedEvent{
+			Inviter:  inviter.Address,
+			Invite:   sender,
+			Identity: *tx.To,
+			TxHash:   tx.Hash(),
+			Height:   block.Height(),
+		})
+	}
+}
+
+// writeEpochSummary saves the identities and the coin totals of the committed state at the epoch start
+func (chain *Blockchain) writeEpochSummary(height uint64) {
+	stateDb := chain.appState.State
+	summary := &types.EpochSummary{
+		Epoch:        stateDb.Epoch(),
+		Height:       height,
+		TotalBalance: new(big.Int),
+		TotalStake:   new(big.Int),
+	}
+	stateDb.IterateIdentities(func(key []byte, value []byte) bool {
+		if key == nil {
+			return true
+		}
+		var data state.Identity
+		if err := data.FromBytes(value); err != nil {
+			return false
+		}
+		stake := data.Stake
+		if stake == nil {
+			stake = new(big.Int)
+		}
+		summary.TotalStake.Add(summary.TotalStake, stake)
+		summary.Identities = append(summary.Identities, &types.EpochSummaryIdentity{
+			Address: state.StateDbKeys.IdentityKeyToAddress(key),
+			State:   uint8(data.State),
+			Stake:   stake,
+		})
+		return false
+	})
+	stateDb.IterateAccounts(func(key []byte, value []byte) bool {
+		if key == nil {
+			return true
+		}
+		var data state.Account
+		if err := data.FromBytes(value); err != nil {
+			return false
+		}
+		if data.Balance != nil {
+			summary.TotalBalance.Add(summary.TotalBalance, data.Balance)
+		}
+		return false
+	})
+	chain.repo.WriteEpochSummary(summary)
+}
+
+func (chain *Blockchain) ReadEpochSummary(epoch uint16) *types.EpochSummary {
+	return chain.repo.ReadEpochSummary(epoch)
+}
+
 func (chain *Blockchain) applyBlockOnState(appState *appstate.AppState, block *types.Block, totalFee, totalTips *big.Int, usedGas uint64, blockRewardCtx *blockRewardCtx, statsCollector collector.StatsCollector) (root, identityRoot common.Hash, stateDiff []*state.StateTreeDiff, diff *state.IdentityStateDiff) {
 	chain.applyStatusSwitch(appState, block, statsCollector)
 	chain.applyDelayedOfflinePenalties(appState, block, statsCollector)
@@ -705,7 +818,7 @@ func (chain *Blockchain) applyNewEpoch(appState *appstate.AppState, block *types
 	validationResult := chain.applyNewEpochFn(block.Height(), appState, statsCollector)
 	networkSize, validationResults, pools, failed := validationResult.IdentitiesCount, validationResult.ShardResults, validationResult.Pools, validationResult.Failed
 	totalInvitesCount := float32(networkSize) * chain.config.Consensus.InvitesPercent
-	totalNewbies, totalVerified, totalSuspended, newbiesByShard, verifiedByShard, suspendedByShard := setNewIdentitiesAttributes(appState, chain.config.Consensus.UnlockStakeAge, totalInvitesCount, networkSize, pools, failed, validationResults, statsCollector)
+	totalNewbies, totalVerified, totalSuspended, newbiesByShard, verifiedByShard, suspendedByShard := setNewIdentitiesAttributes(appState, chain.config.Consensus.UnlockStakeAge, chain.config.Consensus.GetRequiredFlips(networkSize, chain.config.Network), totalInvitesCount, networkSize, pools, failed, validationResults, statsCollector)
 	epochBlock := appState.State.EpochBlock()
 	if !failed {
 		var epochDurations []uint32
@@ -821,8 +934,7 @@ func setInvites(appState *appstate.AppState, identitiesWithInvites []identityWit
 	collector.SetMinScoreForInvite(statsCollector, lastScore)
 }
 
-func setNewIdentitiesAttributes(appState *appstate.AppState, unlockStakeAge uint8, totalInvitesCount float32, networkSize int, pools map[common.Address]struct{}, validationFailed bool, validationResults map[common.ShardId]*types.ValidationResults, statsCollector collector.StatsCollector) (int, int, int, map[common.ShardId]int, map[common.ShardId]int, map[common.ShardId]int) {
-	_, flips := common.NetworkParams(networkSize)
+func setNewIdentitiesAttributes(appState *appstate.AppState, unlockStakeAge uint8, flips int, totalInvitesCount float32, networkSize int, pools map[common.Address]struct{}, validationFailed bool, validationResults map[common.ShardId]*types.ValidationResults, statsCollector collector.StatsCollector) (int, int, int, map[common.ShardId]int, map[common.ShardId]int, map[common.ShardId]int) {
 	identityFlags := calculateNewIdentityStatusFlags(validationResults)
 
 	identitiesWithInvites := make([]identityWithInvite, 0)
@@ -2216,16 +2328,19 @@ func (chain *Blockchain) insertHeader(header *types.Header) {
 }
 
 func (chain *Blockchain) insertBlock(block *types.Block, diff *state.IdentityStateDiff, receipts types.TxReceipts) error {
-	_, err := chain.ipfs.Add(block.Body.ToBytes(), chain.ipfs.ShouldPin(ipfs.Block))
+	return chain.insertBlockCtx(context.Background(), block, diff, receipts)
+}
+
+func (chain *Blockchain) insertBlockCtx(ctx context.Context, block *types.Block, diff *state.IdentityStateDiff, receipts types.TxReceipts) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.insertBlock")
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
+	_, ipfsSpan := tracing.StartSpan(ctx, "ipfs.Add", attribute.Bool("receipts", receipts != nil))
+	err = chain.storeBlockToIpfs(block, receipts)
+	tracing.EndSpan(ipfsSpan, err)
 	if err != nil {
-		return errors.Wrap(BlockInsertionErr, err.Error())
-	}
-	if receipts != nil {
-		data, _ := receipts.ToBytes()
-		_, err := chain.ipfs.Add(data, chain.ipfs.ShouldPin(ipfs.TxReceipt))
-		if err != nil {
-			return errors.Wrap(BlockInsertionErr, err.Error())
-		}
+		return err
 	}
 
 	chain.insertHeader(block.Header)
@@ -2239,6 +2354,21 @@ func (chain *Blockchain) insertBlock(block *types.Block, diff *state.IdentitySta
 	return nil
 }
 
+func (chain *Blockchain) storeBlockToIpfs(block *types.Block, receipts types.TxReceipts) error {
+	_, err := chain.ipfs.Add(block.Body.ToBytes(), chain.ipfs.ShouldPin(ipfs.Block))
+	if err != nil {
+		return errors.Wrap(BlockInsertionErr, err.Error())
+	}
+	if receipts != nil {
+		data, _ := receipts.ToBytes()
+		_, err := chain.ipfs.Add(data, chain.ipfs.ShouldPin(ipfs.TxReceipt))
+		if err != nil {
+			return errors.Wrap(BlockInsertionErr, err.Error())
+		}
+	}
+	return nil
+}
+
 func (chain *Blockchain) WriteTxIndex(hash common.Hash, txs types.Transactions) {
 	for i, tx := range txs {
 		idx := &types.TransactionIndex{
@@ -2737,7 +2867,61 @@ func (chain *Blockchain) ResetTo(height uint64) (revertedTxs []*types.Transactio
 	return revertedTxs, nil
 }
 
+// recoverHead restores the head record from the last block of the canonical chain, the search starts from
+// the genesis since fast synced and cloned chains don't have canonical hashes of the blocks before it
+func (chain *Blockchain) recoverHead() (*types.Header, error) {
+	from := predefinedGenesisHeight(chain.config.Network)
+	if intermediateGenesisHeight := chain.repo.ReadIntermediateGenesis(); intermediateGenesisHeight > from {
+		from = intermediateGenesisHeight
+	}
+	height := chain.repo.ReadLastCanonicalHeight(from)
+	chain.log.Warn("Head block is not readable, restoring it from the canonical chain", "height", height)
+	chain.repo.SetHead(nil, height)
+	head := chain.GetHead()
+	if head == nil {
+		return nil, errors.New("head block is not readable and cannot be restored, try to delete idenachain.db folder from your data directory and sync from scratch")
+	}
+	return head, nil
+}
+
+// isConsistentBlock checks that the canonical index, the header and the link to the parent match at the height
+func (chain *Blockchain) isConsistentBlock(height uint64) bool {
+	hash := chain.repo.ReadCanonicalHash(height)
+	if hash == (common.Hash{}) {
+		return false
+	}
+	header := chain.repo.ReadBlockHeader(hash)
+	if header == nil || header.Height() != height || header.Hash() != hash {
+		return false
+	}
+	if height == 1 {
+		return true
+	}
+	// the parent index may be absent below the fast sync point
+	parent := chain.repo.ReadCanonicalHash(height - 1)
+	return parent == (common.Hash{}) || parent == header.ParentHash()
+}
+
+// ensureHeadConsistency rolls the head back to the last height with consistent block indices
+func (chain *Blockchain) ensureHeadConsistency() error {
+	head := chain.Head.Height()
+	if chain.isConsistentBlock(head) && chain.repo.ReadCanonicalHash(head) == chain.Head.Hash() {
+		return nil
+	}
+	for h, tryCnt := head-1, 0; h >= 1 && tryCnt < maxHeadRollbackDepth; h, tryCnt = h-1, tryCnt+1 {
+		if chain.isConsistentBlock(h) {
+			chain.log.Warn("Block indices are inconsistent, rolling head back", "head", head, "new head", h)
+			chain.setHead(h, nil)
+			return nil
+		}
+	}
+	return errors.New("block indices are corrupted, try to delete idenachain.db folder from your data directory and sync from scratch")
+}
+
 func (chain *Blockchain) EnsureIntegrity() error {
+	if err := chain.ensureHeadConsistency(); err != nil {
+		return err
+	}
 	wasReset := false
 	for chain.Head.Root() != chain.appState.State.Root() ||
 		chain.Head.IdentityRoot() != chain.appState.IdentityState.Root() {
@@ -2845,6 +3029,10 @@ func (chain *Blockchain) GetCertificate(hash common.Hash) *types.BlockCert {
 	return chain.repo.ReadCertificate(hash)
 }
 
+func (chain *Blockchain) HasCertificate(hash common.Hash) bool {
+	return chain.repo.HasCertificate(hash)
+}
+
 func (chain *Blockchain) GetIdentityDiff(height uint64) *state.IdentityStateDiff {
 	data := chain.repo.ReadIdentityStateDiff(height)
 	if data == nil {
@@ -2886,8 +3074,44 @@ func (chain *Blockchain) RemovePreliminaryHead(batch dbm.Batch) {
 }
 
 func (chain *Blockchain) IsPermanentCert(header *types.Header) bool {
+	return isPermanentCert(header, chain.config.Blockchain)
+}
+
+func isPermanentCert(header *types.Header, cfg *config.BlockchainConfig) bool {
 	return header.Flags().HasFlag(types.IdentityUpdate|types.Snapshot|types.NewGenesis) ||
-		header.Height()%chain.config.Blockchain.StoreCertRange == 0 || header.ProposedHeader != nil && header.ProposedHeader.Upgrade > 0
+		header.Height()%cfg.StoreCertRange == 0 ||
+		header.ProposedHeader != nil && header.ProposedHeader.Upgrade > 0
+}
+
+// IsPermanentSyncedCert returns whether the certificate received during the sync is kept permanently,
+// without the pruning all of them are kept, otherwise only the last database.MaxWeakCertificatesCount ones
+// and the permanent ones, the StoreCertRange certificates are kept to serve the fast sync of other peers
+func (chain *Blockchain) IsPermanentSyncedCert(header *types.Header) bool {
+	return !chain.config.Blockchain.PruneCertificates || chain.IsPermanentCert(header)
+}
+
+// Confirmations returns the number of canonical blocks since the block including itself (0 if the block is not canonical)
+// and whether the block is final, i.e. the block or one of the next maxFinalityScan blocks has a final certificate
+func (chain *Blockchain) Confirmations(hash common.Hash) (confirmations uint64, final bool) {
+	header := chain.repo.ReadBlockHeader(hash)
+	if header == nil {
+		return 0, false
+	}
+	height := header.Height()
+	if chain.repo.ReadCanonicalHash(height) != hash {
+		return 0, false
+	}
+	head := chain.Head.Height()
+	if head < height {
+		return 0, false
+	}
+	confirmations = head - height + 1
+	for h := height; h <= head && h < height+maxFinalityScan; h++ {
+		if cert := chain.repo.ReadCertificate(chain.repo.ReadCanonicalHash(h)); cert != nil && cert.Step == types.Final {
+			return confirmations, true
+		}
+	}
+	return confirmations, false
 }
 
 func (chain *Blockchain) ReadTxs(address common.Address, count int, token []byte) ([]*types.SavedTransaction, []byte) {
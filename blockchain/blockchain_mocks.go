@@ -131,6 +131,47 @@ func NewCustomTestBlockchainWithConfig(blocksCount int, emptyBlocksCount int, ke
 	return result, appState
 }
 
+// NewTestBlockchainFromSnapshot loads the state exported by ExportSnapshot into a fresh in-memory chain,
+// so a test can reproduce a reported state without replaying the history. The key becomes the coinbase of the new blocks.
+func NewTestBlockchainFromSnapshot(dir string, network types.Network, key *ecdsa.PrivateKey) (*TestBlockchain, *appstate.AppState, error) {
+	db := db.NewMemDB()
+	if _, err := ImportSnapshot(db, network, dir); err != nil {
+		return nil, nil, err
+	}
+	bus := eventbus.New()
+	appState, _ := appstate.NewAppState(db, bus)
+	secStore := secstore.NewSecStore()
+	secStore.AddKey(crypto.FromECDSA(key))
+	consensusCfg := GetDefaultConsensusConfig()
+	consensusCfg.Automine = true
+	cfg := &config.Config{
+		Network:   network,
+		Consensus: consensusCfg,
+		GenesisConf: &config.GenesisConf{
+			GodAddress:        secStore.GetAddress(),
+			FirstCeremonyTime: 4070908800, //01.01.2099
+		},
+		Validation:       &config.ValidationConfig{},
+		Blockchain:       &config.BlockchainConfig{},
+		OfflineDetection: config.GetDefaultOfflineDetectionConfig(),
+		Mempool:          config.GetDefaultMempoolConfig(),
+	}
+	txPool := mempool.NewTxPool(appState, bus, cfg, collector.NewStatsCollector())
+	offline := NewOfflineDetector(cfg, db, appState, secStore, bus)
+	keyStore := keystore.NewKeyStore("./testdata", keystore.StandardScryptN, keystore.StandardScryptP)
+	subManager, _ := subscriptions.NewManager("./testdata2")
+	upgrader := upgrade.NewUpgrader(cfg, appState, db)
+	chain := NewBlockchain(cfg, db, txPool, appState, ipfs.NewMemoryIpfsProxy(), secStore, bus, offline, keyStore, subManager, upgrader)
+	if err := chain.InitializeChain(); err != nil {
+		return nil, nil, err
+	}
+	if err := appState.Initialize(chain.Head.Height()); err != nil {
+		return nil, nil, err
+	}
+	txPool.Initialize(chain.Head, secStore.GetAddress(), false)
+	return &TestBlockchain{db, chain, appState.State.GetNonce(secStore.GetAddress())}, appState, nil
+}
+
 type TestBlockchain struct {
 	db db.DB
 	*Blockchain
@@ -260,5 +301,6 @@ func GetDefaultConsensusConfig() *config.ConsensusConf {
 	config.ApplyConsensusVersion(config.ConsensusV10, &res)
 	config.ApplyConsensusVersion(config.ConsensusV11, &res)
 	config.ApplyConsensusVersion(config.ConsensusV12, &res)
+	config.ApplyConsensusVersion(config.ConsensusV13, &res)
 	return &res
 }
@@ -3,6 +3,8 @@ package blockchain
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"encoding/csv"
+	"encoding/json"
 	mapset "github.com/deckarep/golang-set"
 	"github.com/idena-network/idena-go/blockchain/attachments"
 	fee2 "github.com/idena-network/idena-go/blockchain/fee"
@@ -16,11 +18,16 @@ import (
 	"github.com/idena-network/idena-go/core/state"
 	"github.com/idena-network/idena-go/core/validators"
 	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-go/database"
 	"github.com/idena-network/idena-go/tests"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 	dbm "github.com/tendermint/tm-db"
+	"io"
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -1155,7 +1162,7 @@ func Test_setNewIdentitiesAttributes(t *testing.T) {
 	}
 	require.NoError(s.Commit(nil))
 
-	setNewIdentitiesAttributes(s, chain.config.Consensus.UnlockStakeAge, 12, 100, make(map[common.Address]struct{}), false, map[common.ShardId]*types.ValidationResults{}, nil)
+	setNewIdentitiesAttributes(s, chain.config.Consensus.UnlockStakeAge, 3, 12, 100, make(map[common.Address]struct{}), false, map[common.ShardId]*types.ValidationResults{}, nil)
 
 	for _, item := range identities {
 		var addr common.Address
@@ -1174,13 +1181,13 @@ func Test_setNewIdentitiesAttributes(t *testing.T) {
 	require.Equal(uint8(1), s.State.GetInvites(common.Address{0x9}))
 
 	s.Reset()
-	setNewIdentitiesAttributes(s, chain.config.Consensus.UnlockStakeAge, 1, 100, make(map[common.Address]struct{}), false, map[common.ShardId]*types.ValidationResults{}, nil)
+	setNewIdentitiesAttributes(s, chain.config.Consensus.UnlockStakeAge, 3, 1, 100, make(map[common.Address]struct{}), false, map[common.ShardId]*types.ValidationResults{}, nil)
 	require.Equal(uint8(1), s.State.GetInvites(common.Address{0x1}))
 	require.Equal(uint8(0), s.State.GetInvites(common.Address{0x7}))
 	require.Equal(uint8(0), s.State.GetInvites(common.Address{0x8}))
 
 	s.Reset()
-	setNewIdentitiesAttributes(s, chain.config.Consensus.UnlockStakeAge, 5, 100, make(map[common.Address]struct{}), false, map[common.ShardId]*types.ValidationResults{}, nil)
+	setNewIdentitiesAttributes(s, chain.config.Consensus.UnlockStakeAge, 3, 5, 100, make(map[common.Address]struct{}), false, map[common.ShardId]*types.ValidationResults{}, nil)
 	require.Equal(uint8(2), s.State.GetInvites(common.Address{0x1}))
 	require.Equal(uint8(1), s.State.GetInvites(common.Address{0x5}))
 	require.Equal(uint8(1), s.State.GetInvites(common.Address{0x7}))
@@ -1189,7 +1196,7 @@ func Test_setNewIdentitiesAttributes(t *testing.T) {
 	require.Equal(uint8(0), s.State.GetInvites(common.Address{0x4}))
 
 	s.Reset()
-	setNewIdentitiesAttributes(s, chain.config.Consensus.UnlockStakeAge, 15, 100, make(map[common.Address]struct{}), false, map[common.ShardId]*types.ValidationResults{}, nil)
+	setNewIdentitiesAttributes(s, chain.config.Consensus.UnlockStakeAge, 3, 15, 100, make(map[common.Address]struct{}), false, map[common.ShardId]*types.ValidationResults{}, nil)
 	require.Equal(uint8(2), s.State.GetInvites(common.Address{0x1}))
 	require.Equal(uint8(2), s.State.GetInvites(common.Address{0x5}))
 	require.Equal(uint8(1), s.State.GetInvites(common.Address{0x4}))
@@ -1200,7 +1207,7 @@ func Test_setNewIdentitiesAttributes(t *testing.T) {
 	require.Equal(uint8(1), s.State.GetInvites(common.Address{0xd}))
 
 	s.Reset()
-	setNewIdentitiesAttributes(s, chain.config.Consensus.UnlockStakeAge, 20, 100, make(map[common.Address]struct{}), false, map[common.ShardId]*types.ValidationResults{}, nil)
+	setNewIdentitiesAttributes(s, chain.config.Consensus.UnlockStakeAge, 3, 20, 100, make(map[common.Address]struct{}), false, map[common.ShardId]*types.ValidationResults{}, nil)
 	require.Equal(uint8(2), s.State.GetInvites(common.Address{0x1}))
 	require.Equal(uint8(2), s.State.GetInvites(common.Address{0x5}))
 	require.Equal(uint8(1), s.State.GetInvites(common.Address{0x4}))
@@ -1211,7 +1218,7 @@ func Test_setNewIdentitiesAttributes(t *testing.T) {
 	require.Equal(uint8(1), s.State.GetInvites(common.Address{0xd}))
 
 	s.Reset()
-	setNewIdentitiesAttributes(s, chain.config.Consensus.UnlockStakeAge, 2, 100, make(map[common.Address]struct{}), false, map[common.ShardId]*types.ValidationResults{}, nil)
+	setNewIdentitiesAttributes(s, chain.config.Consensus.UnlockStakeAge, 3, 2, 100, make(map[common.Address]struct{}), false, map[common.ShardId]*types.ValidationResults{}, nil)
 	require.Equal(uint8(1), s.State.GetInvites(common.Address{0x1}))
 	require.Equal(uint8(1), s.State.GetInvites(common.Address{0x7}))
 	require.Equal(uint8(1), s.State.GetInvites(common.Address{0x8}))
@@ -1258,7 +1265,7 @@ func Test_setNewIdentitiesAttributes(t *testing.T) {
 	s.State.SetBirthday(common.Address{0x2}, 2)
 	s.State.AddLockedStake(common.Address{0x2}, big.NewInt(1))
 
-	totalNewbies, totalVerified, totalSuspended, newbiesByShard, verifiedByShard, suspendedByShard := setNewIdentitiesAttributes(s, chain.config.Consensus.UnlockStakeAge, 6, 100, make(map[common.Address]struct{}), false, map[common.ShardId]*types.ValidationResults{}, nil)
+	totalNewbies, totalVerified, totalSuspended, newbiesByShard, verifiedByShard, suspendedByShard := setNewIdentitiesAttributes(s, chain.config.Consensus.UnlockStakeAge, 3, 6, 100, make(map[common.Address]struct{}), false, map[common.ShardId]*types.ValidationResults{}, nil)
 	discriminationStakeThreshold := balanceShards(s, totalNewbies, totalVerified, totalSuspended, newbiesByShard, verifiedByShard, suspendedByShard)
 	require.Zero(discriminationStakeThreshold.Cmp(big.NewInt(8)))
 	applyDiscriminationStakeThreshold(s, discriminationStakeThreshold)
@@ -2476,3 +2483,276 @@ func TestBlockchain_applyDiscriminationStatusSwitch(t *testing.T) {
 	require.True(t, appState.ValidatorsCache.IsDiscriminated(delegator2))
 	require.False(t, appState.ValidatorsCache.IsDiscriminated(delegator3))
 }
+
+func TestBlockchain_ensureHeadConsistency(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	chain, _ := NewCustomTestBlockchain(10, 0, key)
+	defer chain.SecStore().Destroy()
+
+	require.NoError(t, chain.ensureHeadConsistency())
+	require.Equal(t, uint64(11), chain.Head.Height())
+
+	chain.repo.RemoveHeader(chain.Head.Hash())
+	require.NoError(t, chain.ensureHeadConsistency())
+	require.Equal(t, uint64(10), chain.Head.Height())
+	require.Equal(t, chain.repo.ReadCanonicalHash(10), chain.Head.Hash())
+}
+
+func TestBlockchain_recoverHead(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	chain, _ := NewCustomTestBlockchain(10, 0, key)
+	defer chain.SecStore().Destroy()
+	head := chain.Head.Hash()
+
+	// a cloned chain keeps canonical hashes of the genesis, the intermediate genesis and the blocks since the import
+	for height := uint64(2); height < 10; height++ {
+		if height != 5 {
+			chain.repo.RemoveCanonicalHash(height)
+		}
+	}
+	chain.repo.WriteIntermediateGenesis(nil, 5)
+
+	recovered, err := chain.recoverHead()
+	require.NoError(t, err)
+	require.Equal(t, head, recovered.Hash())
+	require.Equal(t, head, chain.GetHead().Hash())
+}
+
+func TestExportImportSnapshot(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	chain, _ := NewCustomTestBlockchain(10, 0, key)
+	defer chain.SecStore().Destroy()
+	dir := t.TempDir()
+
+	head, err := ExportSnapshot(chain.db, 0x99, dir)
+	require.NoError(t, err)
+	require.Equal(t, chain.Head.Hash(), head.Hash())
+	_, err = ExportSnapshot(chain.db, 0x99, dir)
+	require.Error(t, err)
+
+	db := dbm.NewMemDB()
+	_, err = ImportSnapshot(db, 0x1, dir)
+	require.Error(t, err)
+	imported, err := ImportSnapshot(db, 0x99, dir)
+	require.NoError(t, err)
+	require.Equal(t, head.Hash(), imported.Hash())
+	_, err = ImportSnapshot(db, 0x99, dir)
+	require.Error(t, err)
+
+	appState, _ := appstate.NewAppState(db, eventbus.New())
+	require.NoError(t, appState.Initialize(imported.Height()))
+	require.Equal(t, head.Root(), appState.State.Root())
+	require.Equal(t, head.IdentityRoot(), appState.IdentityState.Root())
+	require.Equal(t, chain.GenesisInfo().Genesis.Hash(), readCanonicalHeader(database.NewRepo(db), 1).Hash())
+}
+
+func TestExportIdentities(t *testing.T) {
+	db := dbm.NewMemDB()
+	dir := t.TempDir()
+
+	_, err := ExportIdentities(db, filepath.Join(dir, "identities.json"), IdentitiesExportJson)
+	require.Error(t, err)
+
+	stateDb, _ := state.NewLazy(dbm.NewMemDB())
+	verified, killed := common.Address{0x1}, common.Address{0x2}
+	stateDb.SetState(verified, state.Verified)
+	stateDb.SetBirthday(verified, 2)
+	stateDb.AddStake(verified, big.NewInt(0).Mul(big.NewInt(5), common.DnaBase))
+	stateDb.SetBalance(verified, big.NewInt(0).Mul(big.NewInt(3), common.DnaBase))
+	stateDb.SetState(killed, state.Killed)
+	for i := 0; i < 10; i++ {
+		stateDb.IncEpoch()
+	}
+	_, root, version, err := stateDb.Commit(true)
+	require.NoError(t, err)
+
+	snapshotFile := filepath.Join(dir, "snapshot.tar")
+	require.NoError(t, writeSnapshotFile(snapshotFile, common.BytesToHash(root), func(to io.Writer) (common.Hash, error) {
+		return stateDb.WriteSnapshot2(uint64(version), to)
+	}))
+	require.NoError(t, database.NewRepo(db).WriteLastSnapshotManifest(nil, common.BytesToHash(root), uint64(version), snapshotFile))
+
+	_, err = ExportIdentities(db, filepath.Join(dir, "identities.xml"), "xml")
+	require.Error(t, err)
+
+	result, err := ExportIdentities(db, filepath.Join(dir, "identities.json"), IdentitiesExportJson)
+	require.NoError(t, err)
+	require.Equal(t, uint64(version), result.Height)
+	require.Equal(t, uint16(10), result.Epoch)
+	require.Equal(t, 2, result.Identities)
+	data, err := ioutil.ReadFile(result.Path)
+	require.NoError(t, err)
+	exported := struct {
+		Height     uint64              `json:"height"`
+		Identities []*exportedIdentity `json:"identities"`
+	}{}
+	require.NoError(t, json.Unmarshal(data, &exported))
+	require.Equal(t, uint64(version), exported.Height)
+	require.Len(t, exported.Identities, 2)
+	require.Equal(t, verified, exported.Identities[0].Address)
+	require.Equal(t, "Verified", exported.Identities[0].State)
+	require.Equal(t, uint16(8), exported.Identities[0].Age)
+	require.Equal(t, "5", exported.Identities[0].Stake)
+	require.Equal(t, "3", exported.Identities[0].Balance)
+	require.Equal(t, "Killed", exported.Identities[1].State)
+
+	result, err = ExportIdentities(db, filepath.Join(dir, "identities.csv"), IdentitiesExportCsv)
+	require.NoError(t, err)
+	data, err = ioutil.ReadFile(result.Path)
+	require.NoError(t, err)
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, []string{"address", "state", "age", "stake", "balance", "delegatee"}, records[0])
+	require.Equal(t, []string{verified.Hex(), "Verified", "8", "5", "3", ""}, records[1])
+}
+
+func TestBlockchain_Confirmations(t *testing.T) {
+	chain, _ := NewTestBlockchainWithBlocks(20, 0)
+	defer chain.SecStore().Destroy()
+	head := chain.Head.Height()
+	hash := chain.GetBlockHeaderByHeight(head - 4).Hash()
+
+	confirmations, final := chain.Confirmations(hash)
+	require.Equal(t, uint64(5), confirmations)
+	require.False(t, final)
+
+	finalHash := chain.GetBlockHeaderByHeight(head - 1).Hash()
+	chain.WriteCertificate(finalHash, &types.BlockCert{Step: types.Final, VotedHash: finalHash}, true)
+	confirmations, final = chain.Confirmations(hash)
+	require.Equal(t, uint64(5), confirmations)
+	require.True(t, final)
+
+	confirmations, final = chain.Confirmations(common.Hash{0x1})
+	require.Zero(t, confirmations)
+	require.False(t, final)
+}
+
+func TestExportAddressTxs(t *testing.T) {
+	db := dbm.NewMemDB()
+	dir := t.TempDir()
+	repo := database.NewRepo(db)
+	key, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	receiver := common.Address{0x1}
+
+	sent, _ := types.SignTx(&types.Transaction{Type: types.SendTx, To: &receiver, Amount: big.NewInt(0).Mul(big.NewInt(2), common.DnaBase), AccountNonce: 1}, key)
+	activation, _ := types.SignTx(&types.Transaction{Type: types.ActivationTx, To: &address, AccountNonce: 2}, key)
+	repo.SaveTx(address, common.Hash{0x2}, 200, big.NewInt(0), activation)
+	repo.SaveTx(address, common.Hash{0x1}, 100, big.NewInt(0), sent)
+	repo.SaveTx(receiver, common.Hash{0x1}, 100, big.NewInt(0), sent)
+
+	_, err := ExportAddressTxs(db, address, filepath.Join(dir, "txs.xml"), "xml")
+	require.Error(t, err)
+
+	result, err := ExportAddressTxs(db, address, filepath.Join(dir, "txs.jsonl"), TxsExportJsonLines)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Transactions)
+	data, err := ioutil.ReadFile(result.Path)
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(data), []byte{'\n'})
+	require.Len(t, lines, 2)
+	var first exportedTx
+	require.NoError(t, json.Unmarshal(lines[0], &first))
+	require.Equal(t, sent.Hash(), first.Hash)
+	require.Equal(t, "send", first.Type)
+	require.Equal(t, address, first.From)
+	require.Equal(t, "2", first.Amount)
+
+	result, err = ExportAddressTxs(db, address, filepath.Join(dir, "txs.csv"), TxsExportCsv)
+	require.NoError(t, err)
+	file, err := os.Open(result.Path)
+	require.NoError(t, err)
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, activation.Hash().Hex(), records[2][0])
+	require.Equal(t, "activation", records[2][3])
+}
+
+func TestNewTestBlockchainFromSnapshot(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	chain, _ := NewCustomTestBlockchain(10, 0, key)
+	defer chain.SecStore().Destroy()
+	dir := t.TempDir()
+	head, err := ExportSnapshot(chain.db, 0x99, dir)
+	require.NoError(t, err)
+
+	_, _, err = NewTestBlockchainFromSnapshot(dir, 0x1, key)
+	require.Error(t, err)
+
+	loaded, appState, err := NewTestBlockchainFromSnapshot(dir, 0x99, key)
+	require.NoError(t, err)
+	defer loaded.SecStore().Destroy()
+	require.Equal(t, head.Hash(), loaded.Head.Hash())
+	require.Equal(t, chain.appState.State.GetBalance(chain.coinBaseAddress), appState.State.GetBalance(loaded.coinBaseAddress))
+
+	loaded.GenerateBlocks(3, 1)
+	require.Equal(t, head.Height()+3, loaded.Head.Height())
+}
+
+func TestImportBlocks(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	chain, _ := NewCustomTestBlockchain(10, 0, key)
+	defer chain.SecStore().Destroy()
+	path := filepath.Join(t.TempDir(), "blocks.jsonl")
+	export, err := ExportBlocks(chain.db, 0, 0, path)
+	require.NoError(t, err)
+	require.Equal(t, chain.Head.Height(), export.To)
+
+	repo := database.NewRepo(chain.db)
+	lost := []*types.Header{chain.GetBlockByHeight(5).Header, chain.GetBlockByHeight(6).Header}
+	for _, header := range lost {
+		repo.RemoveHeader(header.Hash())
+		repo.RemoveCanonicalHash(header.Height())
+	}
+
+	result, err := ImportBlocks(chain.db, chain.config.Blockchain, &blocksFile{
+		headers: map[common.Hash]*types.Header{},
+		certs:   map[common.Hash]*types.BlockCert{},
+	}, 0)
+	require.NoError(t, err)
+	require.True(t, result.MissingHeader)
+	require.Equal(t, uint64(7), result.Lowest)
+	require.Zero(t, result.CanonicalHashes)
+
+	source, err := ReadBlocksFile(path)
+	require.NoError(t, err)
+	result, err = ImportBlocks(chain.db, chain.config.Blockchain, source, 0)
+	require.NoError(t, err)
+	require.False(t, result.MissingHeader)
+	require.Equal(t, 2, result.Headers)
+	require.Equal(t, 2, result.CanonicalHashes)
+	for _, header := range lost {
+		require.Equal(t, header.Hash(), repo.ReadCanonicalHash(header.Height()))
+		require.NotNil(t, repo.ReadBlockHeader(header.Hash()))
+	}
+
+	cert := repo.ReadCertificate(lost[0].Hash())
+	require.NoError(t, checkImportedCert(lost[0], cert))
+	require.Error(t, checkImportedCert(lost[1], cert))
+}
+
+func TestBlockchain_IsPermanentSyncedCert(t *testing.T) {
+	cfg := &config.Config{
+		Blockchain: &config.BlockchainConfig{
+			StoreCertRange: 10,
+		},
+	}
+	chain := &Blockchain{config: cfg}
+	header := func(height uint64, flags types.BlockFlag) *types.Header {
+		return &types.Header{
+			EmptyBlockHeader: &types.EmptyBlockHeader{Height: height, Flags: flags},
+		}
+	}
+
+	require.True(t, chain.IsPermanentSyncedCert(header(11, 0)))
+	require.True(t, chain.IsPermanentSyncedCert(header(20, 0)))
+
+	cfg.Blockchain.PruneCertificates = true
+	require.False(t, chain.IsPermanentSyncedCert(header(11, 0)))
+	require.True(t, chain.IsPermanentSyncedCert(header(20, 0)))
+	require.True(t, chain.IsPermanentSyncedCert(header(11, types.IdentityUpdate)))
+	require.True(t, chain.IsPermanentSyncedCert(header(11, types.Snapshot)))
+}
@@ -0,0 +1,239 @@
+package blockchain
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/hexutil"
+	"github.com/idena-network/idena-go/config"
+	"github.com/idena-network/idena-go/database"
+	"github.com/pkg/errors"
+	dbm "github.com/tendermint/tm-db"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// BlocksSource provides the headers and certificates to repair the chain database,
+// the repo of the chain database of another data directory is a source too
+type BlocksSource interface {
+	ReadBlockHeader(hash common.Hash) *types.Header
+	ReadCertificate(hash common.Hash) *types.BlockCert
+}
+
+type BlocksExport struct {
+	From   uint64 `json:"from"`
+	To     uint64 `json:"to"`
+	Blocks int    `json:"blocks"`
+	Path   string `json:"path"`
+}
+
+type BlocksImport struct {
+	Headers         int `json:"headers"`
+	CanonicalHashes int `json:"canonicalHashes"`
+	Certificates    int `json:"certificates"`
+	// Lowest is the lowest height checked, the headers below it are not reachable
+	// if MissingHeader is set
+	Lowest        uint64 `json:"lowest"`
+	MissingHeader bool   `json:"missingHeader"`
+}
+
+// exportedBlock is a line of the blocks file, the header and the certificate are proto encoded
+type exportedBlock struct {
+	Header hexutil.Bytes `json:"header"`
+	Cert   hexutil.Bytes `json:"cert,omitempty"`
+}
+
+type blocksFile struct {
+	headers map[common.Hash]*types.Header
+	certs   map[common.Hash]*types.BlockCert
+}
+
+func (f *blocksFile) ReadBlockHeader(hash common.Hash) *types.Header {
+	return f.headers[hash]
+}
+
+func (f *blocksFile) ReadCertificate(hash common.Hash) *types.BlockCert {
+	return f.certs[hash]
+}
+
+// ExportBlocks writes the headers and certificates of the canonical blocks from..to (the head if 0) to the file as JSON lines.
+// The file is written to a temporary path and renamed, so the readers never see a partial export
+func ExportBlocks(db dbm.DB, from uint64, to uint64, path string) (*BlocksExport, error) {
+	repo := database.NewRepo(db)
+	head := repo.ReadHead()
+	if head == nil {
+		return nil, errors.New("chain is empty")
+	}
+	if to == 0 || to > head.Height() {
+		to = head.Height()
+	}
+	if from > to {
+		return nil, errors.Errorf("invalid range %v-%v", from, to)
+	}
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	count, err := writeBlocks(repo, from, to, file)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	absPath, _ := filepath.Abs(path)
+	return &BlocksExport{
+		From:   from,
+		To:     to,
+		Blocks: count,
+		Path:   absPath,
+	}, nil
+}
+
+func writeBlocks(repo *database.Repo, from uint64, to uint64, file io.Writer) (int, error) {
+	w := bufio.NewWriter(file)
+	encoder := json.NewEncoder(w)
+	count := 0
+	for height := from; height <= to; height++ {
+		header := readCanonicalHeader(repo, height)
+		if header == nil {
+			continue
+		}
+		line := exportedBlock{}
+		var err error
+		if line.Header, err = header.ToBytes(); err != nil {
+			return 0, err
+		}
+		if cert := repo.ReadCertificate(header.Hash()); !cert.Empty() {
+			if line.Cert, err = cert.ToBytes(); err != nil {
+				return 0, err
+			}
+		}
+		if err := encoder.Encode(line); err != nil {
+			return 0, err
+		}
+		count++
+	}
+	return count, w.Flush()
+}
+
+// ReadBlocksFile loads the file written by ExportBlocks
+func ReadBlocksFile(path string) (BlocksSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	result := &blocksFile{
+		headers: make(map[common.Hash]*types.Header),
+		certs:   make(map[common.Hash]*types.BlockCert),
+	}
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for line := 1; decoder.More(); line++ {
+		block := new(exportedBlock)
+		if err := decoder.Decode(block); err != nil {
+			return nil, errors.Wrapf(err, "invalid block at line %v", line)
+		}
+		header := new(types.Header)
+		if err := header.FromBytes(block.Header); err != nil {
+			return nil, errors.Wrapf(err, "invalid header at line %v", line)
+		}
+		hash := header.Hash()
+		result.headers[hash] = header
+		if len(block.Cert) > 0 {
+			cert := new(types.BlockCert)
+			if err := cert.FromBytes(block.Cert); err != nil {
+				return nil, errors.Wrapf(err, "invalid certificate at line %v", line)
+			}
+			result.certs[hash] = cert
+		}
+	}
+	return result, nil
+}
+
+// ImportBlocks restores the headers, canonical hashes and certificates lost by the chain database from the source.
+// The head of the database is trusted: the chain is walked down to the height from by the parent hashes,
+// so every restored header is linked to the head, the walk stops at the first header missing in both the database and the source.
+// The blocks above the head are not imported, the node syncs them. The node must be stopped
+func ImportBlocks(db dbm.DB, cfg *config.BlockchainConfig, source BlocksSource, from uint64) (*BlocksImport, error) {
+	repo := database.NewRepo(db)
+	head := repo.ReadHead()
+	if head == nil {
+		return nil, errors.New("chain is empty, import a snapshot or sync the node")
+	}
+	result := &BlocksImport{}
+	header := head
+	for {
+		hash := header.Hash()
+		result.Lowest = header.Height()
+		if repo.ReadCanonicalHash(header.Height()) != hash {
+			repo.WriteCanonicalHash(header.Height(), hash)
+			result.CanonicalHashes++
+		}
+		if !repo.HasCertificate(hash) && (!cfg.PruneCertificates || isPermanentCert(header, cfg)) {
+			if cert := source.ReadCertificate(hash); !cert.Empty() {
+				if err := checkImportedCert(header, cert); err != nil {
+					return nil, errors.Wrapf(err, "invalid certificate of block %v", header.Height())
+				}
+				repo.WriteCertificate(hash, cert)
+				result.Certificates++
+			}
+		}
+		parentHash := header.ParentHash()
+		if header.Height() <= from || parentHash == (common.Hash{}) {
+			break
+		}
+		parent := repo.ReadBlockHeader(parentHash)
+		if parent == nil {
+			if parent = source.ReadBlockHeader(parentHash); parent == nil {
+				result.MissingHeader = true
+				break
+			}
+			if parent.Hash() != parentHash || parent.Height()+1 != header.Height() {
+				return nil, errors.Errorf("block %v of the source doesn't match the chain", header.Height()-1)
+			}
+			repo.WriteBlockHeader(parent)
+			result.Headers++
+		}
+		header = parent
+	}
+	return result, nil
+}
+
+// checkImportedCert checks the certificate without the committee of the block, which is unknown offline:
+// the certificate should be signed votes for the block
+func checkImportedCert(header *types.Header, cert *types.BlockCert) error {
+	if cert.VotedHash != header.Hash() {
+		return errors.New("invalid voted hash")
+	}
+	if cert.Round != header.Height() {
+		return errors.New("invalid round")
+	}
+	for _, signature := range cert.Signatures {
+		vote := types.Vote{
+			Header: &types.VoteHeader{
+				Step:        cert.Step,
+				Round:       cert.Round,
+				TurnOffline: signature.TurnOffline,
+				Upgrade:     signature.Upgrade,
+				VotedHash:   cert.VotedHash,
+				ParentHash:  header.ParentHash(),
+			},
+			Signature: signature.Signature,
+		}
+		if _, err := vote.PubKey(); err != nil {
+			return errors.Wrap(err, "invalid signature")
+		}
+	}
+	return nil
+}
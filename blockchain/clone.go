@@ -0,0 +1,212 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/hexutil"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-go/database"
+	"github.com/pkg/errors"
+	dbm "github.com/tendermint/tm-db"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	cloneManifestFile      = "snapshot.json"
+	cloneStateFile         = "state.tar"
+	cloneIdentityStateFile = "identity.tar"
+)
+
+// cloneManifest describes an exported node snapshot, headers are proto encoded
+type cloneManifest struct {
+	Network             types.Network `json:"network"`
+	Head                hexutil.Bytes `json:"head"`
+	Genesis             hexutil.Bytes `json:"genesis"`
+	IntermediateGenesis hexutil.Bytes `json:"intermediateGenesis,omitempty"`
+	ConsensusVersion    uint32        `json:"consensusVersion"`
+}
+
+// ExportSnapshot writes the appstate trees of the head block together with the head and genesis headers to the dir,
+// so the synced node can be cloned to a fresh data directory by ImportSnapshot. The node must be stopped.
+func ExportSnapshot(db dbm.DB, network types.Network, dir string) (*types.Header, error) {
+	repo := database.NewRepo(db)
+	head := repo.ReadHead()
+	if head == nil {
+		return nil, errors.New("chain is empty")
+	}
+	genesis := readCanonicalHeader(repo, predefinedGenesisHeight(network))
+	if genesis == nil {
+		return nil, errors.New("genesis block is not found")
+	}
+	var intermediateGenesis *types.Header
+	if height := repo.ReadIntermediateGenesis(); height > 0 && height != genesis.Height() {
+		if intermediateGenesis = readCanonicalHeader(repo, height); intermediateGenesis == nil {
+			return nil, errors.New("intermediate genesis block is not found")
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, cloneManifestFile)); err == nil {
+		return nil, errors.Errorf("snapshot already exists in %v", dir)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	stateDb, err := state.NewLazy(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeSnapshotFile(filepath.Join(dir, cloneStateFile), head.Root(), func(to io.Writer) (common.Hash, error) {
+		return stateDb.WriteSnapshot2(head.Height(), to)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to export state")
+	}
+	identityStateDb, err := state.NewLazyIdentityState(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeSnapshotFile(filepath.Join(dir, cloneIdentityStateFile), head.IdentityRoot(), func(to io.Writer) (common.Hash, error) {
+		return identityStateDb.WriteSnapshot2(head.Height(), to)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to export identity state")
+	}
+
+	manifest := &cloneManifest{
+		Network:          network,
+		ConsensusVersion: repo.ReadConsensusVersion(),
+	}
+	if manifest.Head, err = head.ToBytes(); err != nil {
+		return nil, err
+	}
+	if manifest.Genesis, err = genesis.ToBytes(); err != nil {
+		return nil, err
+	}
+	if intermediateGenesis != nil {
+		if manifest.IntermediateGenesis, err = intermediateGenesis.ToBytes(); err != nil {
+			return nil, err
+		}
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	// the manifest is written last, so an interrupted export is never imported
+	if err := ioutil.WriteFile(filepath.Join(dir, cloneManifestFile), data, 0600); err != nil {
+		return nil, err
+	}
+	return head, nil
+}
+
+// ImportSnapshot restores the snapshot written by ExportSnapshot into the empty chain database
+func ImportSnapshot(db dbm.DB, network types.Network, dir string) (*types.Header, error) {
+	repo := database.NewRepo(db)
+	if repo.ReadHead() != nil {
+		return nil, errors.New("chain database is not empty, use a fresh data directory to import the snapshot")
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, cloneManifestFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read snapshot manifest")
+	}
+	manifest := new(cloneManifest)
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, errors.Wrap(err, "invalid snapshot manifest")
+	}
+	if manifest.Network != network {
+		return nil, errors.Errorf("snapshot belongs to another network, expected %v, got %v", network, manifest.Network)
+	}
+	head, genesis := new(types.Header), new(types.Header)
+	if err := head.FromBytes(manifest.Head); err != nil {
+		return nil, errors.Wrap(err, "invalid head block")
+	}
+	if err := genesis.FromBytes(manifest.Genesis); err != nil {
+		return nil, errors.Wrap(err, "invalid genesis block")
+	}
+	headers := []*types.Header{genesis}
+	var intermediateGenesis *types.Header
+	if len(manifest.IntermediateGenesis) > 0 {
+		intermediateGenesis = new(types.Header)
+		if err := intermediateGenesis.FromBytes(manifest.IntermediateGenesis); err != nil {
+			return nil, errors.Wrap(err, "invalid intermediate genesis block")
+		}
+		headers = append(headers, intermediateGenesis)
+	}
+	headers = append(headers, head)
+
+	stateDb, err := state.NewLazy(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := readSnapshotFile(filepath.Join(dir, cloneStateFile), func(from io.Reader) error {
+		return stateDb.RecoverSnapshot2(head.Height(), head.Root(), from)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to import state")
+	}
+	stateDb.CommitSnapshot(head.Height(), nil)
+
+	identityStateDb, err := state.NewLazyIdentityState(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := readSnapshotFile(filepath.Join(dir, cloneIdentityStateFile), func(from io.Reader) error {
+		return identityStateDb.RecoverSnapshot2(head.Height(), head.IdentityRoot(), from)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to import identity state")
+	}
+	identityStateDb.CommitSnapshot(head.Height())
+
+	for _, header := range headers {
+		repo.WriteBlockHeader(header)
+		repo.WriteCanonicalHash(header.Height(), header.Hash())
+	}
+	if intermediateGenesis != nil {
+		repo.WriteIntermediateGenesis(nil, intermediateGenesis.Height())
+	}
+	if manifest.ConsensusVersion > 0 {
+		repo.WriteConsensusVersion(nil, manifest.ConsensusVersion)
+	}
+	// the head is written last, so an interrupted import leaves the database empty for the chain
+	batch := db.NewBatch()
+	defer batch.Close()
+	repo.WriteHead(batch, head)
+	if err := batch.WriteSync(); err != nil {
+		return nil, err
+	}
+	return head, nil
+}
+
+func readCanonicalHeader(repo *database.Repo, height uint64) *types.Header {
+	hash := repo.ReadCanonicalHash(height)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return repo.ReadBlockHeader(hash)
+}
+
+func writeSnapshotFile(path string, expectedRoot common.Hash, write func(to io.Writer) (common.Hash, error)) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	root, err := write(file)
+	if err != nil {
+		return err
+	}
+	if root != expectedRoot {
+		return errors.New("state is not consistent with the head block, start the node to repair it and try again")
+	}
+	return file.Sync()
+}
+
+func readSnapshotFile(path string, read func(from io.Reader) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return read(file)
+}
@@ -0,0 +1,173 @@
+package blockchain
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-go/database"
+	"github.com/pkg/errors"
+	dbm "github.com/tendermint/tm-db"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	IdentitiesExportJson = "json"
+	IdentitiesExportCsv  = "csv"
+)
+
+type IdentitiesExport struct {
+	Height     uint64 `json:"height"`
+	Epoch      uint16 `json:"epoch"`
+	Identities int    `json:"identities"`
+	Path       string `json:"path"`
+}
+
+type exportedIdentity struct {
+	Address   common.Address  `json:"address"`
+	State     string          `json:"state"`
+	Age       uint16          `json:"age"`
+	Stake     string          `json:"stake"`
+	Balance   string          `json:"balance"`
+	Delegatee *common.Address `json:"delegatee,omitempty"`
+}
+
+// ExportIdentities writes the identities with their stakes and balances from the last state snapshot to the file.
+// The snapshot is taken at the epoch boundary, it is loaded to memory, so the export does not depend on the running chain.
+// The file is written to a temporary path and renamed, so the readers never see a partial export
+func ExportIdentities(db dbm.DB, path string, format string) (*IdentitiesExport, error) {
+	if format != IdentitiesExportJson && format != IdentitiesExportCsv {
+		return nil, errors.Errorf("unknown export format %v, use %v or %v", format, IdentitiesExportJson, IdentitiesExportCsv)
+	}
+	_, root, height, snapshotFile := database.NewRepo(db).LastSnapshotManifest()
+	if snapshotFile == "" {
+		return nil, errors.New("state snapshot is not created yet")
+	}
+	stateDb, err := state.NewLazy(dbm.NewMemDB())
+	if err != nil {
+		return nil, err
+	}
+	if err := readSnapshotFile(snapshotFile, func(from io.Reader) error {
+		return stateDb.RecoverSnapshot2(height, root, from)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to load state snapshot")
+	}
+	stateDb.CommitSnapshot(height, nil)
+
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	count, err := writeIdentities(stateDb, height, file, format)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	absPath, _ := filepath.Abs(path)
+	return &IdentitiesExport{
+		Height:     height,
+		Epoch:      stateDb.Epoch(),
+		Identities: count,
+		Path:       absPath,
+	}, nil
+}
+
+func writeIdentities(stateDb *state.StateDB, height uint64, to io.Writer, format string) (int, error) {
+	w := bufio.NewWriter(to)
+	epoch := stateDb.Epoch()
+	var write func(identity *exportedIdentity) error
+	var finish func() error
+	switch format {
+	case IdentitiesExportCsv:
+		csvWriter := csv.NewWriter(w)
+		if err := csvWriter.Write([]string{"address", "state", "age", "stake", "balance", "delegatee"}); err != nil {
+			return 0, err
+		}
+		write = func(identity *exportedIdentity) error {
+			var delegatee string
+			if identity.Delegatee != nil {
+				delegatee = identity.Delegatee.Hex()
+			}
+			return csvWriter.Write([]string{identity.Address.Hex(), identity.State, strconv.Itoa(int(identity.Age)),
+				identity.Stake, identity.Balance, delegatee})
+		}
+		finish = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	default:
+		if _, err := fmt.Fprintf(w, `{"height":%d,"epoch":%d,"identities":[`, height, epoch); err != nil {
+			return 0, err
+		}
+		first := true
+		write = func(identity *exportedIdentity) error {
+			if !first {
+				if err := w.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			first = false
+			data, err := json.Marshal(identity)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		}
+		finish = func() error {
+			_, err := w.WriteString("]}\n")
+			return err
+		}
+	}
+
+	var count int
+	var err error
+	stateDb.IterateIdentities(func(key []byte, value []byte) bool {
+		if key == nil {
+			return true
+		}
+		var data state.Identity
+		if err = data.FromBytes(value); err != nil {
+			return true
+		}
+		addr := state.StateDbKeys.IdentityKeyToAddress(key)
+		var age uint16
+		if data.State.NewbieOrBetter() || data.State == state.Suspended || data.State == state.Zombie {
+			age = epoch - data.Birthday
+		}
+		if err = write(&exportedIdentity{
+			Address:   addr,
+			State:     data.State.String(),
+			Age:       age,
+			Stake:     ConvertToFloat(data.Stake).String(),
+			Balance:   ConvertToFloat(stateDb.GetBalance(addr)).String(),
+			Delegatee: data.Delegatee(),
+		}); err != nil {
+			return true
+		}
+		count++
+		return false
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := finish(); err != nil {
+		return 0, err
+	}
+	return count, w.Flush()
+}
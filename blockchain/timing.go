@@ -6,12 +6,16 @@ import (
 )
 
 type timing struct {
-	conf *config.ValidationConfig
+	conf      *config.ValidationConfig
+	consensus *config.ConsensusConf
+	network   uint32
 }
 
-func NewTiming(conf *config.ValidationConfig) *timing {
+func NewTiming(conf *config.ValidationConfig, consensus *config.ConsensusConf, network uint32) *timing {
 	return &timing{
-		conf: conf,
+		conf:      conf,
+		consensus: consensus,
+		network:   network,
 	}
 }
 
@@ -34,7 +38,7 @@ func (t *timing) isLongSessionStarted(nextValidation time.Time, timestamp int64)
 }
 
 func (t *timing) isAfterLongSessionStarted(nextValidation time.Time, timestamp int64, networkSize int) bool {
-	if current := time.Unix(timestamp, 0); current.Sub(nextValidation) > t.conf.GetShortSessionDuration()+t.conf.GetLongSessionDuration(networkSize) {
+	if current := time.Unix(timestamp, 0); current.Sub(nextValidation) > t.conf.GetShortSessionDuration()+t.conf.GetLongSessionDuration(networkSize, t.consensus.GetRequiredFlips(networkSize, t.network)) {
 		return true
 	}
 	return false
@@ -0,0 +1,153 @@
+package blockchain
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/idena-network/idena-go/blockchain/fee"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/hexutil"
+	"github.com/idena-network/idena-go/database"
+	"github.com/pkg/errors"
+	dbm "github.com/tendermint/tm-db"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	TxsExportCsv       = "csv"
+	TxsExportJsonLines = "jsonl"
+)
+
+type TxsExport struct {
+	Address      common.Address `json:"address"`
+	Transactions int            `json:"transactions"`
+	Path         string         `json:"path"`
+}
+
+type exportedTx struct {
+	Hash      common.Hash     `json:"hash"`
+	BlockHash common.Hash     `json:"blockHash"`
+	Timestamp int64           `json:"timestamp"`
+	Type      string          `json:"type"`
+	From      common.Address  `json:"from"`
+	To        *common.Address `json:"to"`
+	Amount    string          `json:"amount"`
+	Tips      string          `json:"tips"`
+	MaxFee    string          `json:"maxFee"`
+	UsedFee   string          `json:"usedFee"`
+	Nonce     uint32          `json:"nonce"`
+	Epoch     uint16          `json:"epoch"`
+	Payload   hexutil.Bytes   `json:"payload"`
+}
+
+// ExportAddressTxs writes the transactions sent from or to the address from the oldest one to the file.
+// Only the addresses of the node accounts are indexed, the history of other addresses is empty.
+// The file is written to a temporary path and renamed, so the readers never see a partial export
+func ExportAddressTxs(db dbm.DB, address common.Address, path string, format string) (*TxsExport, error) {
+	if format != TxsExportCsv && format != TxsExportJsonLines {
+		return nil, errors.Errorf("unknown export format %v, use %v or %v", format, TxsExportCsv, TxsExportJsonLines)
+	}
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	count, err := writeAddressTxs(database.NewRepo(db), address, file, format)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	absPath, _ := filepath.Abs(path)
+	return &TxsExport{
+		Address:      address,
+		Transactions: count,
+		Path:         absPath,
+	}, nil
+}
+
+func writeAddressTxs(repo *database.Repo, address common.Address, to io.Writer, format string) (int, error) {
+	w := bufio.NewWriter(to)
+	var write func(tx *exportedTx) error
+	var finish func() error
+	switch format {
+	case TxsExportCsv:
+		csvWriter := csv.NewWriter(w)
+		if err := csvWriter.Write([]string{"hash", "blockHash", "timestamp", "type", "from", "to", "amount", "tips", "maxFee",
+			"usedFee", "nonce", "epoch", "payload"}); err != nil {
+			return 0, err
+		}
+		write = func(tx *exportedTx) error {
+			var to string
+			if tx.To != nil {
+				to = tx.To.Hex()
+			}
+			return csvWriter.Write([]string{tx.Hash.Hex(), tx.BlockHash.Hex(), strconv.FormatInt(tx.Timestamp, 10), tx.Type,
+				tx.From.Hex(), to, tx.Amount, tx.Tips, tx.MaxFee, tx.UsedFee, strconv.Itoa(int(tx.Nonce)),
+				strconv.Itoa(int(tx.Epoch)), tx.Payload.String()})
+		}
+		finish = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	default:
+		write = func(tx *exportedTx) error {
+			data, err := json.Marshal(tx)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+			return w.WriteByte('\n')
+		}
+		finish = func() error {
+			return nil
+		}
+	}
+
+	var count int
+	var err error
+	repo.IterateSavedTxs(address, func(saved *types.SavedTransaction) bool {
+		tx := saved.Tx
+		sender, _ := types.Sender(tx)
+		if err = write(&exportedTx{
+			Hash:      tx.Hash(),
+			BlockHash: saved.BlockHash,
+			Timestamp: saved.Timestamp,
+			Type:      types.TxTypeNames[tx.Type],
+			From:      sender,
+			To:        tx.To,
+			Amount:    ConvertToFloat(tx.Amount).String(),
+			Tips:      ConvertToFloat(tx.Tips).String(),
+			MaxFee:    ConvertToFloat(tx.MaxFee).String(),
+			UsedFee:   ConvertToFloat(fee.CalculateFee(1, saved.FeePerGas, tx)).String(),
+			Nonce:     tx.AccountNonce,
+			Epoch:     tx.Epoch,
+			Payload:   tx.Payload,
+		}); err != nil {
+			return true
+		}
+		count++
+		return false
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := finish(); err != nil {
+		return 0, err
+	}
+	return count, w.Flush()
+}
@@ -0,0 +1,40 @@
+package types
+
+import (
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/rlp"
+	"math/big"
+)
+
+// EpochSummary is the compact identity set and the coin totals of the state at the epoch start,
+// the full states are pruned, so the summaries are kept to compare the epochs
+type EpochSummary struct {
+	Epoch        uint16
+	Height       uint64
+	TotalBalance *big.Int
+	TotalStake   *big.Int
+	Identities   []*EpochSummaryIdentity
+}
+
+type EpochSummaryIdentity struct {
+	Address common.Address
+	State   uint8
+	Stake   *big.Int
+}
+
+func (s *EpochSummary) ToBytes() ([]byte, error) {
+	return rlp.EncodeToBytes(s)
+}
+
+func (s *EpochSummary) FromBytes(data []byte) error {
+	return rlp.DecodeBytes(data, s)
+}
+
+// IdentitiesByAddress returns the identities of the summary indexed by the address
+func (s *EpochSummary) IdentitiesByAddress() map[common.Address]*EpochSummaryIdentity {
+	res := make(map[common.Address]*EpochSummaryIdentity, len(s.Identities))
+	for _, identity := range s.Identities {
+		res[identity.Address] = identity
+	}
+	return res
+}
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-go/common"
+	models "github.com/idena-network/idena-go/protobuf"
+)
+
+// The fixed size fields of the decoded messages are either absent or have the exact size,
+// otherwise they are silently cut or padded by the conversion to the arrays and the decoded object differs from the sent one
+
+func checkFixedSize(name string, value []byte, size int) error {
+	if len(value) != 0 && len(value) != size {
+		return fmt.Errorf("invalid %v length: %v, expected %v", name, len(value), size)
+	}
+	return nil
+}
+
+func checkProtoHeader(header *models.ProtoBlockHeader) error {
+	if header == nil {
+		return nil
+	}
+	if empty := header.EmptyHeader; empty != nil {
+		for name, value := range map[string][]byte{"parent hash": empty.ParentHash, "root": empty.Root, "identity root": empty.IdentityRoot, "seed": empty.BlockSeed} {
+			if err := checkFixedSize(name, value, common.HashLength); err != nil {
+				return err
+			}
+		}
+	}
+	if proposed := header.ProposedHeader; proposed != nil {
+		for name, value := range map[string][]byte{"parent hash": proposed.ParentHash, "tx hash": proposed.TxHash, "root": proposed.Root,
+			"identity root": proposed.IdentityRoot, "seed": proposed.BlockSeed} {
+			if err := checkFixedSize(name, value, common.HashLength); err != nil {
+				return err
+			}
+		}
+		if err := checkFixedSize("offline address", proposed.OfflineAddr, common.AddressLength); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkProtoTx(tx *models.ProtoTransaction) error {
+	if tx == nil || tx.Data == nil {
+		return nil
+	}
+	return checkFixedSize("recipient", tx.Data.To, common.AddressLength)
+}
+
+func checkProtoBody(body *models.ProtoBlockBody) error {
+	if body == nil {
+		return nil
+	}
+	for _, tx := range body.Transactions {
+		if err := checkProtoTx(tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkProtoVote(vote *models.ProtoVote) error {
+	if vote.Data == nil {
+		return nil
+	}
+	if err := checkFixedSize("parent hash", vote.Data.ParentHash, common.HashLength); err != nil {
+		return err
+	}
+	return checkFixedSize("voted hash", vote.Data.VotedHash, common.HashLength)
+}
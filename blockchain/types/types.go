@@ -64,6 +64,45 @@ const (
 	NewGenesis
 )
 
+// CeremonyPhases are the flags of the blocks starting the validation ceremony phases
+var CeremonyPhases = []struct {
+	Flag BlockFlag
+	Name string
+}{
+	{FlipLotteryStarted, "FlipLottery"},
+	{ShortSessionStarted, "ShortSession"},
+	{LongSessionStarted, "LongSession"},
+	{AfterLongSessionStarted, "AfterLongSession"},
+	{ValidationFinished, "ValidationFinished"},
+}
+
+// TxTypeNames are the names of the transaction types used by RPC and exports
+var TxTypeNames = map[TxType]string{
+	SendTx:               "send",
+	ActivationTx:         "activation",
+	InviteTx:             "invite",
+	KillTx:               "kill",
+	KillInviteeTx:        "killInvitee",
+	SubmitFlipTx:         "submitFlip",
+	SubmitAnswersHashTx:  "submitAnswersHash",
+	SubmitShortAnswersTx: "submitShortAnswers",
+	SubmitLongAnswersTx:  "submitLongAnswers",
+	EvidenceTx:           "evidence",
+	OnlineStatusTx:       "online",
+	ChangeGodAddressTx:   "changeGodAddress",
+	BurnTx:               "burn",
+	ChangeProfileTx:      "changeProfile",
+	DeleteFlipTx:         "deleteFlip",
+	DeployContractTx:     "deployContract",
+	CallContractTx:       "callContract",
+	TerminateContractTx:  "terminateContract",
+	DelegateTx:           "delegate",
+	UndelegateTx:         "undelegate",
+	KillDelegatorTx:      "killDelegator",
+	StoreToIpfsTx:        "storeToIpfs",
+	ReplenishStakeTx:     "replenishStake",
+}
+
 var CeremonialTxs map[uint16]struct{}
 
 func init() {
@@ -291,6 +330,9 @@ func (f *Flip) FromBytes(data []byte) error {
 	if err := proto.Unmarshal(data, protoObj); err != nil {
 		return err
 	}
+	if err := checkProtoTx(protoObj.Transaction); err != nil {
+		return err
+	}
 	f.PublicPart = protoObj.PublicPart
 	f.PrivatePart = protoObj.PrivatePart
 	if protoObj.Transaction != nil {
@@ -481,6 +523,12 @@ func (b *Block) FromBytes(data []byte) error {
 	if err := proto.Unmarshal(data, protoObj); err != nil {
 		return err
 	}
+	if err := checkProtoHeader(protoObj.Header); err != nil {
+		return err
+	}
+	if err := checkProtoBody(protoObj.Body); err != nil {
+		return err
+	}
 	if protoObj.Header != nil {
 		b.Header = new(Header).FromProto(protoObj.Header)
 	}
@@ -737,6 +785,9 @@ func (v *Vote) FromBytes(data []byte) error {
 	if err := proto.Unmarshal(data, protoObj); err != nil {
 		return err
 	}
+	if err := checkProtoVote(protoObj); err != nil {
+		return err
+	}
 	v.Signature = protoObj.Signature
 	if protoObj.Data != nil {
 		v.Header = &VoteHeader{
@@ -917,6 +968,9 @@ func (tx *Transaction) FromBytes(data []byte) error {
 	if err := proto.Unmarshal(data, protoTx); err != nil {
 		return err
 	}
+	if err := checkProtoTx(protoTx); err != nil {
+		return err
+	}
 	tx.FromProto(protoTx)
 	return nil
 }
@@ -1152,6 +1206,12 @@ func (p *BlockProposal) FromBytes(data []byte) error {
 	}
 	p.Signature = protoObj.Signature
 	if protoObj.Data != nil {
+		if err := checkProtoHeader(protoObj.Data.Header); err != nil {
+			return err
+		}
+		if err := checkProtoBody(protoObj.Data.Body); err != nil {
+			return err
+		}
 		p.Proof = protoObj.Data.Proof
 		p.Block = &Block{}
 		if protoObj.Data.Header != nil {
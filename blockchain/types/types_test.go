@@ -1,6 +1,9 @@
 package types
 
 import (
+	"github.com/golang/protobuf/proto"
+	"github.com/idena-network/idena-go/common"
+	models "github.com/idena-network/idena-go/protobuf"
 	"github.com/stretchr/testify/require"
 	"math/big"
 	"testing"
@@ -72,3 +75,118 @@ func TestBlockCert_Empty(t *testing.T) {
 	var cert *BlockCert
 	require.True(t, cert.Empty())
 }
+
+func TestBlock_FromBytesStrict(t *testing.T) {
+	block := &Block{
+		Header: &Header{
+			ProposedHeader: &ProposedHeader{
+				ParentHash: common.Hash{0x1},
+				Height:     2,
+			},
+		},
+		Body: &Body{},
+	}
+	data, _ := block.ToBytes()
+	decoded := new(Block)
+	require.NoError(t, decoded.FromBytes(data))
+	require.Equal(t, block.Hash(), decoded.Hash())
+
+	protoBlock := new(models.ProtoBlock)
+	require.NoError(t, proto.Unmarshal(data, protoBlock))
+	protoBlock.Header.ProposedHeader.ParentHash = protoBlock.Header.ProposedHeader.ParentHash[1:]
+	data, _ = proto.Marshal(protoBlock)
+	require.Error(t, new(Block).FromBytes(data))
+}
+
+func FuzzBlock_FromBytes(f *testing.F) {
+	block := &Block{
+		Header: &Header{
+			ProposedHeader: &ProposedHeader{
+				Height: 2,
+			},
+		},
+		Body: &Body{Transactions: []*Transaction{{AccountNonce: 1, To: &common.Address{0x1}}}},
+	}
+	data, _ := block.ToBytes()
+	f.Add(data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		block := new(Block)
+		if block.FromBytes(data) != nil || !block.IsValid() {
+			return
+		}
+		block.Hash()
+		block.Height()
+		for _, tx := range block.Body.Transactions {
+			tx.Hash()
+		}
+	})
+}
+
+func FuzzBlockProposal_FromBytes(f *testing.F) {
+	proposal := &BlockProposal{
+		Block: &Block{
+			Header: &Header{
+				ProposedHeader: &ProposedHeader{
+					Height:         2,
+					ProposerPubKey: []byte{0x1},
+				},
+			},
+			Body: &Body{},
+		},
+		Signature: []byte{0x1},
+	}
+	data, _ := proposal.ToBytes()
+	f.Add(data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		proposal := new(BlockProposal)
+		if proposal.FromBytes(data) != nil || !proposal.IsValid() {
+			return
+		}
+		proposal.Hash128()
+	})
+}
+
+func FuzzVote_FromBytes(f *testing.F) {
+	vote := &Vote{
+		Header:    &VoteHeader{Round: 1, VotedHash: common.Hash{0x1}},
+		Signature: []byte{0x1},
+	}
+	data, _ := vote.ToBytes()
+	f.Add(data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		vote := new(Vote)
+		if vote.FromBytes(data) != nil || !vote.IsValid() {
+			return
+		}
+		vote.Hash()
+		vote.Hash128()
+	})
+}
+
+func FuzzTransaction_FromBytes(f *testing.F) {
+	tx := &Transaction{AccountNonce: 1, To: &common.Address{0x1}, Amount: big.NewInt(1), Payload: []byte{0x1}}
+	data, _ := tx.ToBytes()
+	f.Add(data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tx := new(Transaction)
+		if tx.FromBytes(data) != nil {
+			return
+		}
+		tx.Hash()
+		tx.Hash128()
+		Sender(tx)
+	})
+}
+
+func FuzzFlip_FromBytes(f *testing.F) {
+	flip := &Flip{Tx: &Transaction{AccountNonce: 1}, PublicPart: []byte{0x1}, PrivatePart: []byte{0x2}}
+	data, _ := flip.ToBytes()
+	f.Add(data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		flip := new(Flip)
+		if flip.FromBytes(data) != nil || !flip.IsValid() {
+			return
+		}
+		flip.Hash128()
+	})
+}
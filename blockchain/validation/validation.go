@@ -734,7 +734,9 @@ func validateDeployContractTx(appState *appstate.AppState, tx *types.Transaction
 		return InvalidPayload
 	}
 
-	if _, ok := embedded.AvailableContracts[attachment.CodeHash]; ok {
+	enableUpgrade13 := appCfg != nil && appCfg.Consensus.EnableUpgrade13
+	isEmbedded := embedded.IsAvailable(attachment.CodeHash, enableUpgrade13)
+	if isEmbedded {
 		minStake := big.NewInt(0).Mul(appState.State.FeePerGas(), big.NewInt(3000000))
 		if tx.AmountOrZero().Cmp(minStake) < 0 {
 			return InvalidDeployAmount
@@ -744,7 +746,7 @@ func validateDeployContractTx(appState *appstate.AppState, tx *types.Transaction
 	if len(attachment.Code) > 0 && (appCfg == nil || !appCfg.Consensus.EnableUpgrade11) {
 		return InvalidPayload
 	}
-	if _, ok := embedded.AvailableContracts[attachment.CodeHash]; !ok && len(attachment.Code) == 0 {
+	if !isEmbedded && len(attachment.Code) == 0 {
 		return InvalidPayload
 	}
 	return nil
@@ -760,7 +762,7 @@ func validateTerminateContractTx(appState *appstate.AppState, tx *types.Transact
 		return InvalidRecipient
 	}
 
-	if _, ok := embedded.AvailableContracts[*codeHash]; !ok && appCfg != nil && appCfg.Consensus.EnableUpgrade11 {
+	if appCfg != nil && appCfg.Consensus.EnableUpgrade11 && !embedded.IsAvailable(*codeHash, appCfg.Consensus.EnableUpgrade13) {
 		return InvalidRecipient
 	}
 
@@ -1,12 +1,14 @@
 package validation
 
 import (
+	"github.com/idena-network/idena-go/blockchain/attachments"
 	"github.com/idena-network/idena-go/blockchain/types"
 	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-go/common/eventbus"
 	"github.com/idena-network/idena-go/config"
 	"github.com/idena-network/idena-go/core/appstate"
 	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-go/vm/embedded"
 	"github.com/stretchr/testify/require"
 	db "github.com/tendermint/tm-db"
 	"math/big"
@@ -164,3 +166,36 @@ func Test_validateDelegateTx(t *testing.T) {
 		require.NoError(t, err)
 	}
 }
+
+func Test_validateDeployVestingContractTx(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	buildTx := func() *types.Transaction {
+		payload, _ := attachments.CreateDeployContractAttachment(embedded.VestingContract, nil, nil).ToBytes()
+		tx := types.Transaction{
+			AccountNonce: 1,
+			Type:         types.DeployContractTx,
+			Amount:       big.NewInt(0).Mul(common.DnaBase, big.NewInt(100)),
+			MaxFee:       big.NewInt(1000),
+			Payload:      payload,
+		}
+		signedTx, _ := types.SignTx(&tx, key)
+		return signedTx
+	}
+	appState, _ := appstate.NewAppState(db.NewMemDB(), eventbus.New())
+	require.NoError(t, appState.Initialize(0))
+
+	SetAppConfig(&config.Config{
+		Consensus: &config.ConsensusConf{
+			EnableUpgrade11: true,
+		},
+	})
+	require.Equal(t, InvalidPayload, validateDeployContractTx(appState, buildTx(), InBlockTx))
+
+	SetAppConfig(&config.Config{
+		Consensus: &config.ConsensusConf{
+			EnableUpgrade11: true,
+			EnableUpgrade13: true,
+		},
+	})
+	require.NoError(t, validateDeployContractTx(appState, buildTx(), InBlockTx))
+}
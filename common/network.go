@@ -63,6 +63,10 @@ func ShortSessionFlipsCount() uint {
 
 func LongSessionFlipsCount(networkSize int) uint {
 	_, flipsPerIdentity := NetworkParams(networkSize)
+	return LongSessionFlipsCountFor(flipsPerIdentity, networkSize)
+}
+
+func LongSessionFlipsCountFor(flipsPerIdentity int, networkSize int) uint {
 	totalFlips := uint64(flipsPerIdentity * networkSize)
 	return uint(math2.Max(5, math2.Min(totalFlips, uint64(flipsPerIdentity*LongSessionTesters))))
 }
@@ -0,0 +1,26 @@
+// +build !windows
+
+package util
+
+import (
+	unix "golang.org/x/sys/unix"
+)
+
+// FreeDiskSpace returns the number of bytes available to the unprivileged user on the file system of the path
+func FreeDiskSpace(path string) (uint64, error) {
+	stat := unix.Statfs_t{}
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
+
+// FreeInodes returns the number of free and total inodes of the file system of the path,
+// file systems which allocate inodes dynamically report zero total
+func FreeInodes(path string) (free uint64, total uint64, err error) {
+	stat := unix.Statfs_t{}
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	return uint64(stat.Ffree), uint64(stat.Files), nil
+}
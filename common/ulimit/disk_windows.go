@@ -0,0 +1,25 @@
+// +build windows
+
+package util
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// FreeDiskSpace returns the number of bytes available to the current user on the disk of the path
+func FreeDiskSpace(path string) (uint64, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(pathPtr, &free, nil, nil); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
+
+// FreeInodes reports zero total since NTFS doesn't limit the number of files
+func FreeInodes(path string) (free uint64, total uint64, err error) {
+	return 0, 0, nil
+}
@@ -14,3 +14,23 @@ func TestManageFdLimit(t *testing.T) {
 		t.Logf("Setup new fd limit - %v", newLimit)
 	}
 }
+
+func TestFreeDiskSpace(t *testing.T) {
+	free, err := FreeDiskSpace(".")
+	if err != nil {
+		t.Fatalf("Cannot get free disk space - %v", err)
+	}
+	if free == 0 {
+		t.Error("Free disk space should be positive")
+	}
+}
+
+func TestFreeInodes(t *testing.T) {
+	free, total, err := FreeInodes(".")
+	if err != nil {
+		t.Fatalf("Cannot get free inodes - %v", err)
+	}
+	if free > total {
+		t.Errorf("Free inodes %v should not exceed total %v", free, total)
+	}
+}
@@ -5,4 +5,8 @@ type BlockchainConfig struct {
 	StoreCertRange uint64
 	BurnTxRange    uint64
 	WriteAllEvents bool
+	// publish BalanceChangedEvent for every address whose balance or stake is changed by the block
+	PublishBalanceEvents bool
+	// keep the certificates of the recent blocks and of the StoreCertRange, epoch boundary, identity update, snapshot and upgrade blocks only
+	PruneCertificates bool
 }
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// BuildInfo identifies the node binary, the version, commit and date are injected by the linker
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"gitCommit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+func NewBuildInfo(version, gitCommit, buildDate string) *BuildInfo {
+	return &BuildInfo{
+		Version:   version,
+		GitCommit: gitCommit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  fmt.Sprintf("%v/%v", runtime.GOOS, runtime.GOARCH),
+	}
+}
+
+func (b *BuildInfo) String() string {
+	return fmt.Sprintf("Version: %v\nGit commit: %v\nBuild date: %v\nGo version: %v\nPlatform: %v",
+		b.Version, b.GitCommit, b.BuildDate, b.GoVersion, b.Platform)
+}
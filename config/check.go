@@ -0,0 +1,242 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"github.com/idena-network/idena-go/log"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const redacted = "<redacted>"
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// UnknownConfigKeys returns paths of the config file keys which don't match any config field,
+// such keys are silently ignored by the node
+func UnknownConfigKeys(file string) ([]string, error) {
+	if file == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read config file")
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, errors.Wrap(err, "failed to parse config file")
+	}
+	var result []string
+	collectUnknownKeys(value, reflect.TypeOf(Config{}), "", &result)
+	sort.Strings(result)
+	return result, nil
+}
+
+func collectUnknownKeys(value interface{}, t reflect.Type, path string, result *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(jsonUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for key, item := range object {
+			field, ok := findJsonField(t, key)
+			if !ok {
+				*result = append(*result, path+key)
+				continue
+			}
+			collectUnknownKeys(item, field.Type, path+key+".", result)
+		}
+	case reflect.Map:
+		if object, ok := value.(map[string]interface{}); ok {
+			for key, item := range object {
+				collectUnknownKeys(item, t.Elem(), path+key+".", result)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if items, ok := value.([]interface{}); ok {
+			for i, item := range items {
+				collectUnknownKeys(item, t.Elem(), fmt.Sprintf("%v%v.", path, i), result)
+			}
+		}
+	}
+}
+
+// findJsonField matches the key with the struct field the same way as encoding/json does
+func findJsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// Validate returns the problems of the resolved configuration which would make the node misbehave
+func (c *Config) Validate() []error {
+	var problems []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, errors.Errorf(format, args...))
+		}
+	}
+	check(c.DataDir != "", "DataDir should not be empty")
+	check(c.Profile == "" || c.Profile == DefaultProfile || c.Profile == LowPowerProfile || c.Profile == SharedNodeProfile,
+		"unknown Profile %v", c.Profile)
+	check(c.P2P.MaxInboundPeers >= 0 && c.P2P.MaxOutboundPeers >= 0 && c.P2P.MaxInboundOwnShardPeers >= 0 && c.P2P.MaxOutboundOwnShardPeers >= 0,
+		"P2P peer limits should not be negative")
+	check(c.P2P.MaxPeers >= 0 && c.P2P.MaxInbound >= 0 && c.P2P.MaxOutbound >= 0, "P2P.MaxPeers, P2P.MaxInbound and P2P.MaxOutbound should not be negative")
+	check(!c.P2P.Shared || c.P2P.Multishard, "P2P.Shared requires P2P.Multishard")
+	check(c.P2P.MaxPeerMsgRate >= 0 && c.P2P.MaxPeerByteRate >= 0, "P2P rate limits should not be negative")
+	if c.RPC != nil {
+		check(c.RPC.HTTPPort >= 0 && c.RPC.HTTPPort <= 65535, "RPC.HTTPPort %v is out of range", c.RPC.HTTPPort)
+	}
+	if c.IpfsConf != nil {
+		check(c.IpfsConf.IpfsPort >= 0 && c.IpfsConf.IpfsPort <= 65535, "IpfsConf.IpfsPort %v is out of range", c.IpfsConf.IpfsPort)
+		check(c.RPC == nil || c.IpfsConf.IpfsPort == 0 || c.RPC.HTTPHost == "" || c.IpfsConf.IpfsPort != c.RPC.HTTPPort,
+			"IpfsConf.IpfsPort and RPC.HTTPPort should differ")
+		check(c.IpfsConf.LowWater <= c.IpfsConf.HighWater, "IpfsConf.LowWater %v should not exceed IpfsConf.HighWater %v", c.IpfsConf.LowWater, c.IpfsConf.HighWater)
+		check(c.IpfsConf.BlockPinThreshold >= 0 && c.IpfsConf.BlockPinThreshold <= 1, "IpfsConf.BlockPinThreshold should be in range [0, 1]")
+		check(c.IpfsConf.FlipPinThreshold >= 0 && c.IpfsConf.FlipPinThreshold <= 1, "IpfsConf.FlipPinThreshold should be in range [0, 1]")
+		for _, transport := range c.IpfsConf.SecurityTransports {
+			check(transport == "tls" || transport == "noise", "unknown IpfsConf.SecurityTransports item %q", transport)
+		}
+		check(!c.IpfsConf.Gc.Enabled || c.IpfsConf.Gc.Interval > 0, "IpfsConf.Gc.Interval should be positive when IPFS GC is enabled")
+	}
+	if c.Sync != nil {
+		check(c.Sync.MaxDownloadRate >= 0, "Sync.MaxDownloadRate should not be negative")
+	}
+	if c.Db != nil {
+		switch c.Db.Backend {
+		case "", GoLevelDbBackend, BadgerDbBackend:
+		default:
+			check(false, "unknown Db.Backend %q", c.Db.Backend)
+		}
+		check(c.Db.Cache >= 0 && c.Db.Handles >= 0, "Db.Cache and Db.Handles should not be negative")
+		check(c.Db.StateCache > 0, "Db.StateCache should be positive")
+	}
+	if c.Consensus != nil {
+		check(c.Consensus.MinBlockDistance > 0, "Consensus.MinBlockDistance should be positive")
+		check(c.Consensus.MinBlockDistance == GetDefaultConsensusConfig().MinBlockDistance || c.Network != mainnetNetworkId,
+			"Consensus.MinBlockDistance is not configurable for mainnet")
+		check(c.Consensus.IdleBlockInterval >= 0, "Consensus.IdleBlockInterval should not be negative")
+		check(c.Consensus.IdleBlockInterval == 0 || c.Network != mainnetNetworkId, "Consensus.IdleBlockInterval is not available for mainnet")
+		check(c.Consensus.RequiredFlips >= 0 && c.Consensus.RequiredFlips <= math.MaxUint8, "Consensus.RequiredFlips %v is out of range", c.Consensus.RequiredFlips)
+		check(c.Consensus.RequiredFlips == 0 || c.Network != mainnetNetworkId, "Consensus.RequiredFlips is not available for mainnet")
+	}
+	if c.Runtime != nil {
+		check(c.Runtime.MaxProcs >= 0 && c.Runtime.GCPercent >= 0, "Runtime.MaxProcs and Runtime.GCPercent should not be negative")
+		check(c.Runtime.MinFreeDisk >= 0 && c.Runtime.MinFreeInodes >= 0, "Runtime.MinFreeDisk and Runtime.MinFreeInodes should not be negative")
+	}
+	if c.Metrics != nil && c.Metrics.Enabled() {
+		check(c.Metrics.Interval >= time.Second, "Metrics.Interval %v should be at least 1s", c.Metrics.Interval)
+		if c.Metrics.InfluxUrl != "" {
+			u, err := url.Parse(c.Metrics.InfluxUrl)
+			check(err == nil && (u.Scheme == "http" || u.Scheme == "https"), "Metrics.InfluxUrl %q should be an http(s) url", c.Metrics.InfluxUrl)
+		}
+	}
+	if c.Pprof != nil && c.Pprof.Enabled {
+		check(c.Pprof.Port > 0 && c.Pprof.Port <= 65535, "Pprof.Port %v is out of range", c.Pprof.Port)
+		check(c.RPC == nil || c.RPC.HTTPHost == "" || c.Pprof.Port != c.RPC.HTTPPort, "Pprof.Port and RPC.HTTPPort should differ")
+	}
+	if c.Webhooks != nil && c.Webhooks.Enabled() {
+		for _, webhookUrl := range c.Webhooks.Urls {
+			u, err := url.Parse(webhookUrl)
+			check(err == nil && (u.Scheme == "http" || u.Scheme == "https"), "Webhooks.Urls item %q should be an http(s) url", webhookUrl)
+		}
+		for _, event := range c.Webhooks.Events {
+			check(containsString(WebhookEvents, event), "unknown Webhooks.Events item %q, known events: %v", event, strings.Join(WebhookEvents, ", "))
+		}
+	}
+	if c.Webhooks != nil {
+		// the deposits of the watched addresses are sent to their own urls even without the configured ones
+		check(c.Webhooks.Timeout > 0, "Webhooks.Timeout should be positive")
+		check(c.Webhooks.Retries >= 0, "Webhooks.Retries should not be negative")
+	}
+	if c.Tracing != nil && c.Tracing.Enabled() {
+		check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "Tracing.SampleRatio %v should be in range 0-1", c.Tracing.SampleRatio)
+	}
+	if c.Log != nil {
+		check(c.Log.Verbosity >= 0 && c.Log.Verbosity <= 5, "Log.Verbosity %v should be in range 0-5", c.Log.Verbosity)
+		_, err := c.Log.Vmodule()
+		check(err == nil, "invalid Log.Modules: %v", err)
+		for _, format := range []string{c.Log.Format, c.Log.FileFormat} {
+			_, err := log.FormatByName(format, false)
+			check(err == nil, "invalid Log format: %v", err)
+		}
+	}
+	return problems
+}
+
+// Redacted returns a copy of the configuration without secrets, so it can be shared in bug reports
+func (c *Config) Redacted() (*Config, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	result := new(Config)
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	if result.RPC != nil && result.RPC.APIKey != "" {
+		result.RPC.APIKey = redacted
+	}
+	if result.IpfsConf != nil {
+		if result.IpfsConf.SwarmKey != "" && result.IpfsConf.SwarmKey != DefaultSwarmKey {
+			result.IpfsConf.SwarmKey = redacted
+		}
+		result.IpfsConf.Socks5Proxy = redactProxyCredentials(result.IpfsConf.Socks5Proxy)
+	}
+	result.P2P.Socks5Proxy = redactProxyCredentials(result.P2P.Socks5Proxy)
+	if result.Metrics != nil && result.Metrics.InfluxToken != "" {
+		result.Metrics.InfluxToken = redacted
+	}
+	return result, nil
+}
+
+func redactProxyCredentials(proxy string) string {
+	if !strings.Contains(proxy, "@") {
+		return proxy
+	}
+	u, err := url.Parse(proxy)
+	if err != nil || u.User == nil {
+		return redacted
+	}
+	u.User = url.User(redacted)
+	return u.String()
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
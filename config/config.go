@@ -24,11 +24,14 @@ const (
 	LowPowerProfile   = "lowpower"
 	SharedNodeProfile = "shared"
 	DefaultProfile    = "default"
+	mainnetNetworkId  = 0x1
 )
 
 type Config struct {
-	DataDir          string
-	Network          uint32
+	DataDir string
+	Network uint32
+	// Node profile: "default", "lowpower" or "shared", the --profile flag overrides it
+	Profile          string
 	AutoOnline       bool
 	IsDebug          bool
 	Consensus        *ConsensusConf
@@ -41,6 +44,16 @@ type Config struct {
 	OfflineDetection *OfflineDetectionConfig
 	Blockchain       *BlockchainConfig
 	Mempool          *Mempool
+	Db               *DbConfig
+	Log              *LogConfig
+	Runtime          *RuntimeConfig
+	Metrics          *MetricsConfig
+	Tracing          *TracingConfig
+	Pprof            *PprofConfig
+	Webhooks         *WebhooksConfig
+
+	// reload re-reads the config file and the command line flags the config was made from
+	reload func() (*Config, error)
 }
 
 func (c *Config) ProvideNodeKey(key string, password string, withBackup bool) error {
@@ -117,6 +130,15 @@ func (c *Config) NodeKey() (*ecdsa.PrivateKey, error) {
 	return key, errors.Wrap(err, "failed to load node key")
 }
 
+// ReadNodeKey loads the persisted node key without generating a new one
+func (c *Config) ReadNodeKey() (*ecdsa.PrivateKey, error) {
+	key, err := crypto.LoadECDSA(filepath.Join(c.DataDir, "keystore", datadirPrivateKey))
+	if os.IsNotExist(err) {
+		return nil, errors.New("node key is not found")
+	}
+	return key, errors.Wrap(err, "failed to load node key")
+}
+
 // NodeDB returns the path to the discovery node database.
 func (c *Config) NodeDB() string {
 	if c.DataDir == "" {
@@ -174,51 +196,97 @@ func MakeMobileConfig(path string, cfg string) (*Config, error) {
 	} else {
 		log.Info("using default config")
 	}
+	if conf.Profile != "" {
+		conf.applyProfile()
+	}
 
 	return conf, nil
 }
 
 func MakeConfig(ctx *cli.Context, cfgTransform func(cfg *Config)) (*Config, error) {
-	cfg, err := MakeConfigFromFile(ctx.String(CfgFileFlag.Name))
+	preset, err := networkPresetFromFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := makeConfigFromFile(ctx.String(CfgFileFlag.Name), preset)
 	if err != nil {
 		return nil, err
 	}
 	if ctx.IsSet(DataDirFlag.Name) {
 		cfg.DataDir = ctx.String(DataDirFlag.Name)
 	}
+	// the database is opened by cfgTransform, so the profile and the db flags are applied before it
+	applyProfile(ctx, cfg)
+	applyDbFlags(ctx, cfg)
 	cfgTransform(cfg)
 	applyFlags(ctx, cfg)
+	if err := applyDevnetGodAddress(cfg); err != nil {
+		return nil, errors.Wrap(err, "cannot set devnet god address")
+	}
+	cfg.reload = func() (*Config, error) {
+		reloaded, err := makeConfigFromFile(ctx.String(CfgFileFlag.Name), preset)
+		if err != nil {
+			return nil, err
+		}
+		if ctx.IsSet(DataDirFlag.Name) {
+			reloaded.DataDir = ctx.String(DataDirFlag.Name)
+		}
+		applyProfile(ctx, reloaded)
+		applyDbFlags(ctx, reloaded)
+		applyFlags(ctx, reloaded)
+		return reloaded, nil
+	}
 	return cfg, nil
 }
 
+// Reload reads the config file again, the command line flags keep overriding it.
+// Only a part of the settings can be applied to the running node, see Node.ReloadConfig
+func (c *Config) Reload() (*Config, error) {
+	if c.reload == nil {
+		return nil, errors.New("config is not loaded from a file")
+	}
+	return c.reload()
+}
+
 func applyProfile(ctx *cli.Context, cfg *Config) {
 	if ctx.IsSet(ProfileFlag.Name) {
-		switch ctx.String(ProfileFlag.Name) {
-		case LowPowerProfile:
-			applyLowPowerProfile(cfg)
-		case SharedNodeProfile:
-			applySharedNodeProfile(cfg)
-		case DefaultProfile:
-			applyDefaultProfile(cfg)
-		default:
-			println("unknown node profile")
-		}
-	} else {
-		applyDefaultProfile(cfg)
+		cfg.Profile = ctx.String(ProfileFlag.Name)
 	}
-	if cfg.IpfsConf.GracePeriod == "" {
-		cfg.IpfsConf.GracePeriod = "40s"
+	cfg.applyProfile()
+}
+
+func (c *Config) applyProfile() {
+	switch c.Profile {
+	case LowPowerProfile:
+		applyLowPowerProfile(c)
+	case SharedNodeProfile:
+		applySharedNodeProfile(c)
+	case DefaultProfile, "":
+		applyDefaultProfile(c)
+	default:
+		println("unknown node profile")
 	}
-	if cfg.IpfsConf.ReproviderInterval == "" {
-		cfg.IpfsConf.ReproviderInterval = "12h"
+	if c.IpfsConf.GracePeriod == "" {
+		c.IpfsConf.GracePeriod = "40s"
 	}
-	if cfg.IpfsConf.Routing == "" {
-		cfg.IpfsConf.Routing = "dht"
+	if c.IpfsConf.ReproviderInterval == "" {
+		c.IpfsConf.ReproviderInterval = "12h"
+	}
+	if c.IpfsConf.Routing == "" {
+		c.IpfsConf.Routing = "dht"
 	}
 }
 
 func MakeConfigFromFile(file string) (*Config, error) {
+	return makeConfigFromFile(file, nil)
+}
+
+// makeConfigFromFile applies the network preset to the defaults, so the config file overrides the preset
+func makeConfigFromFile(file string, preset *networkPreset) (*Config, error) {
 	cfg := getDefaultConfig(DefaultDataDir)
+	if preset != nil {
+		preset.apply(cfg)
+	}
 	if file != "" {
 		if err := loadConfig(file, cfg); err != nil {
 			log.Error(err.Error())
@@ -238,13 +306,16 @@ func getDefaultConfig(dataDir string) *Config {
 
 	return &Config{
 		DataDir: dataDir,
-		Network: 0x1, // mainnet
+		Network: mainnetNetworkId, // mainnet
 		P2P: P2P{
 			MaxInboundPeers:          DefaultMaxInboundNotOwnShardPeers,
 			MaxOutboundPeers:         DefaultMaxOutboundNotOwnShardPeers,
 			MaxInboundOwnShardPeers:  DefaultMaxInboundOwnShardPeers,
 			MaxOutboundOwnShardPeers: DefaultMaxOutboundOwnShardPeers,
 			DisableMetrics:           false,
+			BanDuration:              DefaultPeerBanDuration,
+			MaxPeerMsgRate:           DefaultMaxPeerMsgRate,
+			MaxPeerByteRate:          DefaultMaxPeerByteRate,
 		},
 		Consensus: GetDefaultConsensusConfig(),
 		RPC:       rpc.GetDefaultRPCConfig(DefaultRpcHost, DefaultRpcPort),
@@ -264,13 +335,20 @@ func getDefaultConfig(dataDir string) *Config {
 			StoreCertRange: DefaultStoreCertRange,
 			BurnTxRange:    DefaultBurntTxRange,
 		},
-		Mempool: GetDefaultMempoolConfig(),
+		Mempool:  GetDefaultMempoolConfig(),
+		Db:       GetDefaultDbConfig(),
+		Log:      GetDefaultLogConfig(),
+		Runtime:  GetDefaultRuntimeConfig(),
+		Metrics:  GetDefaultMetricsConfig(),
+		Tracing:  GetDefaultTracingConfig(),
+		Pprof:    GetDefaultPprofConfig(),
+		Webhooks: GetDefaultWebhooksConfig(),
 	}
 }
 
 func applyFlags(ctx *cli.Context, cfg *Config) {
-	applyProfile(ctx, cfg)
 	applyCommonFlags(ctx, cfg)
+	applyLogFlags(ctx, cfg)
 	applyP2PFlags(ctx, cfg)
 	applyConsensusFlags(ctx, cfg)
 	applyRpcFlags(ctx, cfg)
@@ -278,6 +356,33 @@ func applyFlags(ctx *cli.Context, cfg *Config) {
 	applyIpfsFlags(ctx, cfg)
 	applyValidationFlags(ctx, cfg)
 	applySyncFlags(ctx, cfg)
+	applyPprofFlags(ctx, cfg)
+}
+
+func applyDbFlags(ctx *cli.Context, cfg *Config) {
+	if cfg.Db == nil {
+		cfg.Db = GetDefaultDbConfig()
+	}
+	if ctx.IsSet(DbBackendFlag.Name) {
+		cfg.Db.Backend = ctx.String(DbBackendFlag.Name)
+	}
+	if ctx.IsSet(RepairDbFlag.Name) {
+		cfg.Db.Repair = ctx.Bool(RepairDbFlag.Name)
+	}
+	if ctx.IsSet(DbCacheFlag.Name) {
+		cfg.Db.Cache = ctx.Int(DbCacheFlag.Name)
+	}
+	if ctx.IsSet(DbHandlesFlag.Name) {
+		cfg.Db.Handles = ctx.Int(DbHandlesFlag.Name)
+	}
+	if cfg.Db.Cache <= 0 {
+		cfg.Db.Cache = defaultDbCache()
+	} else if cfg.Db.Cache < MinDbCache {
+		cfg.Db.Cache = MinDbCache
+	}
+	if cfg.Db.Handles <= 0 {
+		cfg.Db.Handles = DefaultDbHandles
+	}
 }
 
 func applyCommonFlags(ctx *cli.Context, cfg *Config) {
@@ -286,6 +391,27 @@ func applyCommonFlags(ctx *cli.Context, cfg *Config) {
 	}
 }
 
+func applyLogFlags(ctx *cli.Context, cfg *Config) {
+	if cfg.Log == nil {
+		cfg.Log = GetDefaultLogConfig()
+	}
+	if ctx.IsSet(VerbosityFlag.Name) {
+		cfg.Log.Verbosity = ctx.Int(VerbosityFlag.Name)
+	}
+}
+
+func applyPprofFlags(ctx *cli.Context, cfg *Config) {
+	if cfg.Pprof == nil {
+		cfg.Pprof = GetDefaultPprofConfig()
+	}
+	if ctx.IsSet(PprofFlag.Name) {
+		cfg.Pprof.Enabled = ctx.Bool(PprofFlag.Name)
+	}
+	if ctx.IsSet(PprofPortFlag.Name) {
+		cfg.Pprof.Port = ctx.Int(PprofPortFlag.Name)
+	}
+}
+
 func applySyncFlags(ctx *cli.Context, cfg *Config) {
 	if ctx.IsSet(FastSyncFlag.Name) {
 		cfg.Sync.FastSync = ctx.Bool(FastSyncFlag.Name)
@@ -293,18 +419,39 @@ func applySyncFlags(ctx *cli.Context, cfg *Config) {
 	if ctx.IsSet(ForceFullSyncFlag.Name) {
 		cfg.Sync.ForceFullSync = ctx.Uint64(ForceFullSyncFlag.Name)
 	}
+	if ctx.IsSet(SyncRateLimitFlag.Name) {
+		cfg.Sync.MaxDownloadRate = ctx.Int(SyncRateLimitFlag.Name) * 1024
+	}
 }
 
 func applyP2PFlags(ctx *cli.Context, cfg *Config) {
 	if ctx.IsSet(MaxNetworkDelayFlag.Name) {
 		cfg.P2P.MaxDelay = ctx.Int(MaxNetworkDelayFlag.Name)
 	}
+	if ctx.IsSet(MaxPeersFlag.Name) {
+		cfg.P2P.MaxPeers = ctx.Int(MaxPeersFlag.Name)
+	}
+	if ctx.IsSet(MaxInboundFlag.Name) {
+		cfg.P2P.MaxInbound = ctx.Int(MaxInboundFlag.Name)
+	}
+	if ctx.IsSet(MaxOutboundFlag.Name) {
+		cfg.P2P.MaxOutbound = ctx.Int(MaxOutboundFlag.Name)
+	}
+	if ctx.IsSet(Socks5ProxyFlag.Name) {
+		cfg.P2P.Socks5Proxy = ctx.String(Socks5ProxyFlag.Name)
+	}
+	if cfg.P2P.MaxPeers > 0 && cfg.P2P.MaxPeers <= cfg.P2P.OutboundLimit() {
+		log.Warn("maxpeers doesn't leave slots for inbound peers", "maxpeers", cfg.P2P.MaxPeers, "outbound", cfg.P2P.OutboundLimit())
+	}
 }
 
 func applyConsensusFlags(ctx *cli.Context, cfg *Config) {
 	if ctx.IsSet(AutomineFlag.Name) {
 		cfg.Consensus.Automine = ctx.Bool(AutomineFlag.Name)
 	}
+	if ctx.IsSet(MinBlockIntervalFlag.Name) {
+		cfg.Consensus.MinBlockDistance = ctx.Duration(MinBlockIntervalFlag.Name)
+	}
 }
 
 func applyRpcFlags(ctx *cli.Context, cfg *Config) {
@@ -330,6 +477,8 @@ func applyGenesisFlags(ctx *cli.Context, cfg *Config) {
 
 func applyIpfsFlags(ctx *cli.Context, cfg *Config) {
 	cfg.IpfsConf.DataDir = filepath.Join(cfg.DataDir, DefaultIpfsDataDir)
+	// p2p and IPFS share the same libp2p host
+	cfg.IpfsConf.Socks5Proxy = cfg.P2P.Socks5Proxy
 
 	if ctx.IsSet(IpfsPortFlag.Name) {
 		cfg.IpfsConf.IpfsPort = ctx.Int(IpfsPortFlag.Name)
@@ -338,12 +487,31 @@ func applyIpfsFlags(ctx *cli.Context, cfg *Config) {
 		cfg.IpfsConf.StaticPort = ctx.Bool(IpfsPortStaticFlag.Name)
 	}
 	if ctx.IsSet(IpfsBootNodeFlag.Name) {
-		cfg.IpfsConf.BootNodes = []string{ctx.String(IpfsBootNodeFlag.Name)}
+		cfg.IpfsConf.BootNodes = splitList(ctx.String(IpfsBootNodeFlag.Name))
+	}
+	if ctx.IsSet(BootNodesDnsFlag.Name) {
+		cfg.IpfsConf.BootNodesDns = ctx.String(BootNodesDnsFlag.Name)
+	}
+	if ctx.IsSet(NoNatPortMapFlag.Name) {
+		cfg.IpfsConf.NatPortMap = !ctx.Bool(NoNatPortMapFlag.Name)
+	}
+	if ctx.IsSet(ExternalAddressFlag.Name) {
+		cfg.IpfsConf.ExternalAddresses = []string{ctx.String(ExternalAddressFlag.Name)}
 	}
 }
 
 func applyValidationFlags(ctx *cli.Context, cfg *Config) {
-
+	if ctx.IsSet(CeremonySimulationFlag.Name) && ctx.Bool(CeremonySimulationFlag.Name) {
+		cfg.Validation.Simulation = true
+	}
+	if cfg.Validation.Simulation {
+		if cfg.Network == mainnetNetworkId {
+			log.Warn("ceremony simulation is not available for mainnet")
+			cfg.Validation.Simulation = false
+			return
+		}
+		cfg.Validation.ApplySimulation()
+	}
 }
 
 func loadConfig(configPath string, conf *Config) error {
@@ -362,3 +530,14 @@ func loadConfig(configPath string, conf *Config) error {
 		return nil
 	}
 }
+
+// splitList splits a comma separated flag value, empty items are skipped
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
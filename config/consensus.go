@@ -1,6 +1,7 @@
 package config
 
 import (
+	"github.com/idena-network/idena-go/common"
 	"math/big"
 	"time"
 )
@@ -60,6 +61,15 @@ type ConsensusConf struct {
 	UnlockStakeAge                    uint8
 	EnableUpgrade11                   bool
 	EnableUpgrade12                   bool
+	EnableUpgrade13                   bool
+
+	// IdleBlockInterval delays the round after a block without transactions of a private network until this interval
+	// passes since that block, unless the ceremony approaches, 0 disables the delay
+	IdleBlockInterval time.Duration
+
+	// RequiredFlips overrides the number of flips every validated identity should submit per epoch in a private network,
+	// it changes the identity state, so all nodes of the network should use the same value, 0 disables the override
+	RequiredFlips int
 }
 
 type ConsensusVerson uint16
@@ -69,11 +79,12 @@ const (
 	ConsensusV10 ConsensusVerson = 10
 	ConsensusV11 ConsensusVerson = 11
 	ConsensusV12 ConsensusVerson = 12
+	ConsensusV13 ConsensusVerson = 13
 )
 
 var (
-	v9, v10, v11, v12 ConsensusConf
-	ConsensusVersions map[ConsensusVerson]*ConsensusConf
+	v9, v10, v11, v12, v13 ConsensusConf
+	ConsensusVersions      map[ConsensusVerson]*ConsensusConf
 )
 
 func init() {
@@ -137,6 +148,10 @@ func init() {
 	v12 = v11
 	ApplyConsensusVersion(ConsensusV12, &v12)
 	ConsensusVersions[ConsensusV12] = &v12
+
+	v13 = v12
+	ApplyConsensusVersion(ConsensusV13, &v13)
+	ConsensusVersions[ConsensusV13] = &v13
 }
 
 func ApplyConsensusVersion(ver ConsensusVerson, cfg *ConsensusConf) {
@@ -160,9 +175,24 @@ func ApplyConsensusVersion(ver ConsensusVerson, cfg *ConsensusConf) {
 		cfg.EnableUpgrade12 = true
 		cfg.StartActivationDate = time.Date(2023, time.July, 10, 8, 0, 0, 0, time.UTC).Unix()
 		cfg.EndActivationDate = time.Date(2023, time.July, 20, 0, 0, 0, 0, time.UTC).Unix()
+	case ConsensusV13:
+		cfg.Version = ConsensusV13
+		cfg.EnableUpgrade13 = true
+		cfg.StartActivationDate = time.Date(2026, time.December, 1, 8, 0, 0, 0, time.UTC).Unix()
+		cfg.EndActivationDate = time.Date(2026, time.December, 10, 0, 0, 0, 0, time.UTC).Unix()
 	}
 }
 
 func GetDefaultConsensusConfig() *ConsensusConf {
 	return &v9
 }
+
+// GetRequiredFlips returns the number of flips every validated identity should submit in the next epoch,
+// the override is ignored on mainnet even if the config was not validated
+func (cfg *ConsensusConf) GetRequiredFlips(networkSize int, network uint32) int {
+	if cfg.RequiredFlips > 0 && network != mainnetNetworkId {
+		return cfg.RequiredFlips
+	}
+	_, flips := common.NetworkParams(networkSize)
+	return flips
+}
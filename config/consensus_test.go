@@ -0,0 +1,17 @@
+package config
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestConsensusConf_GetRequiredFlips(t *testing.T) {
+	conf := &ConsensusConf{
+		RequiredFlips: 1,
+	}
+	const privateNetwork = 0x99
+
+	require.Equal(t, 1, conf.GetRequiredFlips(100, privateNetwork))
+	require.Equal(t, 3, conf.GetRequiredFlips(100, mainnetNetworkId))
+	require.Equal(t, 3, (&ConsensusConf{}).GetRequiredFlips(100, privateNetwork))
+}
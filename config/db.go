@@ -0,0 +1,57 @@
+package config
+
+import "github.com/pbnjay/memory"
+
+const (
+	GoLevelDbBackend = "goleveldb"
+	// requires the badgerdb build tag
+	BadgerDbBackend = "badgerdb"
+
+	MinDbCache     = 16
+	MaxDbCache     = 1024
+	DefaultDbCache = 64
+	// the node reserves 1/dbCacheMemoryShare of the physical memory for the chain database cache by default
+	dbCacheMemoryShare = 32
+	DefaultDbHandles   = 64
+	// number of nodes kept in the LRU cache of every state tree
+	DefaultStateCache = 1024
+)
+
+type DbConfig struct {
+	// Key-value storage backend of the chain database, switching it starts the chain database from scratch
+	Backend string
+	// Recover a corrupted goleveldb database on start, some recent records may be lost
+	Repair bool
+	// Memory for the chain database caches in MB, 0 means a default value scaled to the available RAM
+	Cache int
+	// Number of open files kept by the chain database, 0 means the default value
+	Handles int
+	// Number of nodes kept in the LRU cache of every state tree, the other nodes are read from the database on access
+	StateCache int
+	// Read only the roots of the recent state versions on start instead of all saved roots
+	LazyStateLoad bool
+}
+
+func GetDefaultDbConfig() *DbConfig {
+	return &DbConfig{
+		Backend:    GoLevelDbBackend,
+		Cache:      defaultDbCache(),
+		Handles:    DefaultDbHandles,
+		StateCache: DefaultStateCache,
+	}
+}
+
+func defaultDbCache() int {
+	total := memory.TotalMemory()
+	if total == 0 {
+		return DefaultDbCache
+	}
+	cache := int(total / dbCacheMemoryShare >> 20)
+	if cache < MinDbCache {
+		return MinDbCache
+	}
+	if cache > MaxDbCache {
+		return MaxDbCache
+	}
+	return cache
+}
@@ -21,6 +21,9 @@ const (
 	DefaultMaxInboundNotOwnShardPeers  = 4
 	DefaultMaxOutboundNotOwnShardPeers = 2
 
+	DefaultMaxPeerMsgRate  = 2000
+	DefaultMaxPeerByteRate = 20 * 1024 * 1024
+
 	DefaultBurntTxRange = 4320
 
 	LowPowerMaxInboundOwnShardPeers     = 3
@@ -49,6 +52,14 @@ var (
 		Name:  "datadir",
 		Usage: "datadir for blockchain",
 	}
+	TestnetFlag = cli.BoolFlag{
+		Name:  "testnet",
+		Usage: "Connect to the test network, it uses own genesis, bootnodes, ports and datadir",
+	}
+	DevnetFlag = cli.BoolFlag{
+		Name:  "devnet",
+		Usage: "Run a local single node network, the node key becomes the god address",
+	}
 	TcpPortFlag = cli.IntFlag{
 		Name:  "port",
 		Usage: "Network listening port",
@@ -69,9 +80,17 @@ var (
 		Name:  "automine",
 		Usage: "Mine blocks alone without peers",
 	}
+	MinBlockIntervalFlag = cli.DurationFlag{
+		Name:  "minblockinterval",
+		Usage: "Minimum time between blocks, e.g. 1m, private networks only",
+	}
 	IpfsBootNodeFlag = cli.StringFlag{
 		Name:  "ipfsbootnode",
-		Usage: "Ipfs bootstrap node (overrides existing)",
+		Usage: "Comma separated list of ipfs bootstrap nodes (overrides existing)",
+	}
+	BootNodesDnsFlag = cli.StringFlag{
+		Name:  "bootnodesdns",
+		Usage: "Domain with TXT records listing bootstrap node multiaddresses",
 	}
 	IpfsPortFlag = cli.IntFlag{
 		Name:  "ipfsport",
@@ -84,7 +103,7 @@ var (
 	VerbosityFlag = cli.IntFlag{
 		Name:  "verbosity",
 		Usage: "Log verbosity",
-		Value: 3,
+		Value: DefaultLogVerbosity,
 	}
 	GodAddressFlag = cli.StringFlag{
 		Name:  "godaddress",
@@ -106,10 +125,46 @@ var (
 		Name:  "forcefullsync",
 		Usage: "Force full sync on last blocks",
 	}
+	SyncRateLimitFlag = cli.IntFlag{
+		Name:  "syncratelimit",
+		Usage: "Download rate limit during sync in KB/s, 0 means no limit",
+	}
+	DbBackendFlag = cli.StringFlag{
+		Name:  "dbbackend",
+		Usage: "Database backend (goleveldb, badgerdb), badgerdb requires building with -tags badgerdb",
+	}
+	RepairDbFlag = cli.BoolFlag{
+		Name:  "repairdb",
+		Usage: "Recover the corrupted database on start",
+	}
+	DbCacheFlag = cli.IntFlag{
+		Name:  "dbcache",
+		Usage: "Memory allocated to the chain database caches in MB, scaled to the available RAM by default",
+	}
+	DbHandlesFlag = cli.IntFlag{
+		Name:  "dbhandles",
+		Usage: "Number of open files kept by the chain database",
+	}
+	PasswordFileFlag = cli.StringFlag{
+		Name:  "passwordfile",
+		Usage: "File with passwords for account commands, one per line",
+	}
+	NodeKeyFlag = cli.BoolFlag{
+		Name:  "nodekey",
+		Usage: "Manage the node key instead of keystore accounts",
+	}
 	ProfileFlag = cli.StringFlag{
 		Name:  "profile",
 		Usage: "Configuration profile",
 	}
+	NoNatPortMapFlag = cli.BoolFlag{
+		Name:  "nonatportmap",
+		Usage: "Disable UPnP and NAT-PMP port mapping, the server IPFS profile disables it as well",
+	}
+	ExternalAddressFlag = cli.StringFlag{
+		Name:  "extaddr",
+		Usage: "External multiaddress to announce, e.g. /ip4/1.2.3.4/tcp/40405",
+	}
 	IpfsPortStaticFlag = cli.BoolFlag{
 		Name:  "ipfsportstatic",
 		Usage: "Enable static ipfs port",
@@ -131,4 +186,32 @@ var (
 		Name:  "autoonline",
 		Usage: "Node will automatically turn on online mining status",
 	}
+	MaxPeersFlag = cli.IntFlag{
+		Name:  "maxpeers",
+		Usage: "Maximum number of peers, outbound slots are reserved",
+	}
+	MaxInboundFlag = cli.IntFlag{
+		Name:  "maxinbound",
+		Usage: "Maximum number of inbound peers",
+	}
+	MaxOutboundFlag = cli.IntFlag{
+		Name:  "maxoutbound",
+		Usage: "Maximum number of outbound peers",
+	}
+	Socks5ProxyFlag = cli.StringFlag{
+		Name:  "socks5proxy",
+		Usage: "SOCKS5 proxy address for outbound p2p connections, e.g. 127.0.0.1:9050",
+	}
+	CeremonySimulationFlag = cli.BoolFlag{
+		Name:  "ceremonysimulation",
+		Usage: "Run shortened epochs with synthetic flips and answers (private networks only)",
+	}
+	PprofFlag = cli.BoolFlag{
+		Name:  "pprof",
+		Usage: "Enable the pprof HTTP server on localhost",
+	}
+	PprofPortFlag = cli.IntFlag{
+		Name:  "pprofport",
+		Usage: "pprof HTTP server listening port",
+	}
 )
@@ -5,6 +5,7 @@ import "time"
 type IpfsConfig struct {
 	DataDir            string
 	BootNodes          []string
+	BootNodesDns       string
 	IpfsPort           int
 	StaticPort         bool
 	SwarmKey           string
@@ -17,6 +18,11 @@ type IpfsConfig struct {
 	BlockPinThreshold  float32
 	FlipPinThreshold   float32
 	PublishPeers       bool
+	NatPortMap         bool
+	ExternalAddresses  []string
+	Socks5Proxy        string
+	// Connection security protocols ("tls", "noise") in order of preference, both authenticate peers by the node key
+	SecurityTransports []string
 	Gc                 IpfsGcConfig
 }
 
@@ -30,9 +36,11 @@ type IpfsGcConfig struct {
 
 func GetDefaultIpfsConfig() *IpfsConfig {
 	return &IpfsConfig{
-		BlockPinThreshold: 0.3,
-		FlipPinThreshold:  0.5,
-		Profile:           "server",
+		BlockPinThreshold:  0.3,
+		FlipPinThreshold:   0.5,
+		Profile:            "server",
+		NatPortMap:         true,
+		SecurityTransports: []string{"tls"},
 		Gc: IpfsGcConfig{
 			Enabled:                  true,
 			Interval:                 time.Hour * 24,
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-go/log"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+)
+
+const DefaultLogVerbosity = 3
+
+// logModuleAliases maps the subsystem names to the package directories
+var logModuleAliases = map[string]string{
+	"p2p": "protocol",
+}
+
+type LogConfig struct {
+	// Verbosity of the console and file logs from 0 (critical) to 5 (trace), it can be changed without restart
+	Verbosity int
+	// Format of the console and output.log records: terminal (default), logfmt or json
+	Format     string
+	FileFormat string
+	// Levels of the subsystems overriding Verbosity, e.g. {"consensus": "debug", "ipfs": "warn", "p2p": "info"}.
+	// A subsystem is a package directory, its subpackages are included
+	Modules map[string]string
+}
+
+func GetDefaultLogConfig() *LogConfig {
+	return &LogConfig{
+		Verbosity: DefaultLogVerbosity,
+	}
+}
+
+// Vmodule converts the subsystem levels to the glog rules, e.g. consensus/*=4
+func (c *LogConfig) Vmodule() (string, error) {
+	modules := make([]string, 0, len(c.Modules))
+	for module := range c.Modules {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	var rules []string
+	for _, module := range modules {
+		lvl, err := log.LvlFromString(c.Modules[module])
+		if err != nil {
+			return "", errors.Wrapf(err, "module %v", module)
+		}
+		dir := strings.Trim(module, "/")
+		if alias, ok := logModuleAliases[dir]; ok {
+			dir = alias
+		}
+		if dir == "" {
+			return "", errors.Errorf("module name %q is invalid", module)
+		}
+		rules = append(rules, fmt.Sprintf("%v/*=%d", dir, lvl))
+	}
+	return strings.Join(rules, ","), nil
+}
+
+// Apply sets the verbosity and the subsystem levels of the root logger configured by the node command
+func (c *LogConfig) Apply() error {
+	glogger, ok := log.Root().GetHandler().(*log.GlogHandler)
+	if !ok {
+		return errors.New("log levels cannot be changed at runtime")
+	}
+	vmodule, err := c.Vmodule()
+	if err != nil {
+		return err
+	}
+	if err := glogger.Vmodule(vmodule); err != nil {
+		return err
+	}
+	glogger.Verbosity(log.Lvl(c.Verbosity))
+	return nil
+}
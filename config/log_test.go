@@ -0,0 +1,27 @@
+package config
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLogConfig_Vmodule(t *testing.T) {
+	cfg := GetDefaultLogConfig()
+	vmodule, err := cfg.Vmodule()
+	require.NoError(t, err)
+	require.Empty(t, vmodule)
+
+	cfg.Modules = map[string]string{
+		"consensus":     "debug",
+		"p2p":           "info",
+		"ipfs":          "warn",
+		"core/ceremony": "trace",
+	}
+	vmodule, err = cfg.Vmodule()
+	require.NoError(t, err)
+	require.Equal(t, "consensus/*=4,core/ceremony/*=5,ipfs/*=2,protocol/*=3", vmodule)
+
+	cfg.Modules["consensus"] = "verbose"
+	_, err = cfg.Vmodule()
+	require.Error(t, err)
+}
@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+const (
+	DefaultMetricsPrefix   = "idena"
+	DefaultMetricsInterval = 10 * time.Second
+)
+
+// MetricsConfig configures pushing the core node metrics to StatsD or InfluxDB, empty addresses disable reporting
+type MetricsConfig struct {
+	// StatsD UDP address, e.g. 127.0.0.1:8125
+	StatsdAddr string
+	// InfluxDB write endpoint including the database or bucket parameters,
+	// e.g. http://127.0.0.1:8086/write?db=idena or http://127.0.0.1:8086/api/v2/write?org=org&bucket=idena
+	InfluxUrl   string
+	InfluxToken string
+	// Prefix of the metric names
+	Prefix   string
+	Interval time.Duration
+}
+
+func GetDefaultMetricsConfig() *MetricsConfig {
+	return &MetricsConfig{
+		Prefix:   DefaultMetricsPrefix,
+		Interval: DefaultMetricsInterval,
+	}
+}
+
+func (c *MetricsConfig) Enabled() bool {
+	return c.StatsdAddr != "" || c.InfluxUrl != ""
+}
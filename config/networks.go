@@ -0,0 +1,128 @@
+package config
+
+import (
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/crypto"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"math/big"
+	"path/filepath"
+	"time"
+)
+
+const (
+	TestnetNetworkId = 0x2
+	DevnetNetworkId  = 0x3
+
+	TestnetSwarmKey     = "674fdedd2e762bbfd521b82b8c9179bfc99bc18ecbbbfc6289c37de497d9ec12"
+	TestnetCeremonyTime = int64(1798761600) // 01.01.2027
+
+	// initial balance of the devnet god address in DNA
+	devnetGodBalance = 1000000
+	// the ports of the local multi-node devnet follow the ports of the single node devnet
+	localDevnetPortOffset = 10
+)
+
+var TestnetIpfsBootstrapNodes []string
+
+// networkPreset holds the defaults of a test network which differ from mainnet, so test nodes can run
+// next to the mainnet node on one machine without extra configuration
+type networkPreset struct {
+	network           uint32
+	dataDirSuffix     string
+	portOffset        int
+	swarmKey          string
+	bootNodes         []string
+	godAddress        common.Address
+	firstCeremonyTime int64
+	automine          bool
+}
+
+var (
+	testnetPreset = &networkPreset{
+		network:           TestnetNetworkId,
+		dataDirSuffix:     "-testnet",
+		portOffset:        1,
+		swarmKey:          TestnetSwarmKey,
+		bootNodes:         TestnetIpfsBootstrapNodes,
+		godAddress:        common.HexToAddress(DefaultGodAddress),
+		firstCeremonyTime: TestnetCeremonyTime,
+	}
+	// devnet is a local single node network, the node key becomes the god address
+	devnetPreset = &networkPreset{
+		network:       DevnetNetworkId,
+		dataDirSuffix: "-devnet",
+		portOffset:    2,
+		swarmKey:      TestnetSwarmKey,
+		automine:      true,
+	}
+)
+
+func networkPresetFromFlags(ctx *cli.Context) (*networkPreset, error) {
+	testnet, devnet := ctx.Bool(TestnetFlag.Name), ctx.Bool(DevnetFlag.Name)
+	switch {
+	case testnet && devnet:
+		return nil, errors.New("--testnet and --devnet flags cannot be used together")
+	case testnet:
+		return testnetPreset, nil
+	case devnet:
+		return devnetPreset, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (p *networkPreset) apply(cfg *Config) {
+	cfg.Network = p.network
+	cfg.DataDir = DefaultDataDir + p.dataDirSuffix
+	cfg.RPC.HTTPPort = DefaultRpcPort + p.portOffset
+	cfg.IpfsConf.IpfsPort = DefaultIpfsPort + p.portOffset
+	cfg.IpfsConf.SwarmKey = p.swarmKey
+	cfg.IpfsConf.BootNodes = p.bootNodes
+	cfg.GenesisConf.GodAddress = p.godAddress
+	cfg.GenesisConf.FirstCeremonyTime = p.firstCeremonyTime
+	if cfg.GenesisConf.FirstCeremonyTime == 0 {
+		cfg.GenesisConf.FirstCeremonyTime = time.Now().Add(time.Hour * 24).Unix()
+	}
+	cfg.Consensus.Automine = p.automine
+}
+
+// applyDevnetGodAddress makes the node key the god address of a new devnet, so the node mines blocks alone
+func applyDevnetGodAddress(cfg *Config) error {
+	if cfg.Network != DevnetNetworkId || cfg.GenesisConf.GodAddress != (common.Address{}) {
+		return nil
+	}
+	key, err := cfg.NodeKey()
+	if err != nil {
+		return err
+	}
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	cfg.GenesisConf.GodAddress = addr
+	if cfg.GenesisConf.Alloc == nil {
+		cfg.GenesisConf.Alloc = make(map[common.Address]GenesisAllocation)
+	}
+	if _, ok := cfg.GenesisConf.Alloc[addr]; !ok {
+		cfg.GenesisConf.Alloc[addr] = GenesisAllocation{
+			Balance: new(big.Int).Mul(big.NewInt(devnetGodBalance), common.DnaBase),
+		}
+	}
+	return nil
+}
+
+// MakeLocalDevnetConfig returns the config of the index-th node of a local multi-node devnet: the devnet preset
+// with the ports shifted by the index, the shortened ceremony phases and the genesis shared by all nodes
+func MakeLocalDevnetConfig(dataDir string, index int, genesis *GenesisConf) *Config {
+	cfg := getDefaultConfig(dataDir)
+	devnetPreset.apply(cfg)
+	cfg.DataDir = dataDir
+	cfg.IpfsConf.DataDir = filepath.Join(dataDir, DefaultIpfsDataDir)
+	cfg.RPC.HTTPPort = DefaultRpcPort + localDevnetPortOffset + index
+	cfg.IpfsConf.IpfsPort = DefaultIpfsPort + localDevnetPortOffset + index
+	cfg.IpfsConf.NatPortMap = false
+	cfg.IpfsConf.StaticPort = true
+	cfg.Consensus.Automine = false
+	cfg.GenesisConf = genesis
+	cfg.Validation.ApplySimulation()
+	cfg.applyProfile()
+	return cfg
+}
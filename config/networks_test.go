@@ -0,0 +1,44 @@
+package config
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNetworkPreset_apply(t *testing.T) {
+	cfg, err := makeConfigFromFile("", testnetPreset)
+	require.NoError(t, err)
+	require.Equal(t, uint32(TestnetNetworkId), cfg.Network)
+	require.Equal(t, "datadir-testnet", cfg.DataDir)
+	require.Equal(t, DefaultRpcPort+1, cfg.RPC.HTTPPort)
+	require.Equal(t, DefaultIpfsPort+1, cfg.IpfsConf.IpfsPort)
+	require.Equal(t, TestnetSwarmKey, cfg.IpfsConf.SwarmKey)
+	require.Equal(t, TestnetCeremonyTime, cfg.GenesisConf.FirstCeremonyTime)
+	require.Empty(t, cfg.Validate())
+
+	cfg, err = makeConfigFromFile("", devnetPreset)
+	require.NoError(t, err)
+	require.Equal(t, uint32(DevnetNetworkId), cfg.Network)
+	require.True(t, cfg.Consensus.Automine)
+	require.NotZero(t, cfg.GenesisConf.FirstCeremonyTime)
+
+	cfg.DataDir = t.TempDir()
+	require.NoError(t, applyDevnetGodAddress(cfg))
+	key, err := cfg.ReadNodeKey()
+	require.NoError(t, err)
+	require.Contains(t, cfg.GenesisConf.Alloc, cfg.GenesisConf.GodAddress)
+	require.NotNil(t, key)
+}
+
+func TestMakeLocalDevnetConfig(t *testing.T) {
+	genesis := &GenesisConf{FirstCeremonyTime: 1700000000}
+	first := MakeLocalDevnetConfig(t.TempDir(), 0, genesis)
+	second := MakeLocalDevnetConfig(t.TempDir(), 1, genesis)
+	require.Equal(t, uint32(DevnetNetworkId), second.Network)
+	require.False(t, second.Consensus.Automine)
+	require.True(t, second.Validation.Simulation)
+	require.Equal(t, first.RPC.HTTPPort+1, second.RPC.HTTPPort)
+	require.Equal(t, first.IpfsConf.IpfsPort+1, second.IpfsConf.IpfsPort)
+	require.Same(t, genesis, second.GenesisConf)
+	require.Empty(t, second.Validate())
+}
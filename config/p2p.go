@@ -1,5 +1,9 @@
 package config
 
+import "time"
+
+const DefaultPeerBanDuration = time.Hour * 6
+
 type P2P struct {
 	MaxInboundPeers  int
 	MaxOutboundPeers int
@@ -7,8 +11,77 @@ type P2P struct {
 	MaxInboundOwnShardPeers  int
 	MaxOutboundOwnShardPeers int
 
+	// Overall limits on top of the shard limits, zero means no extra limit.
+	// Outbound slots are reserved within MaxPeers so inbound connections cannot occupy all of them.
+	MaxPeers    int
+	MaxInbound  int
+	MaxOutbound int
+
 	MaxDelay       int
 	DisableMetrics bool
 	Multishard     bool
 	Shared         bool
+	// Multiaddresses of peers the node always maintains connections to, e.g. /ip4/1.2.3.4/tcp/40405/ipfs/<peer id>
+	StaticPeers []string
+	// Ids of peers which are never disconnected because of slot limits, scoring or bans
+	TrustedPeers []string
+	BanDuration  time.Duration
+	// Disables compression of outgoing messages, incoming compressed messages are still accepted
+	DisableCompression bool
+	// SOCKS5 proxy address (e.g. Tor 127.0.0.1:9050) for outbound connections, it is shared with IPFS
+	Socks5Proxy string
+	// Limits of inbound messages and bytes per second from a single peer, zero means no limit
+	MaxPeerMsgRate  int
+	MaxPeerByteRate int
+}
+
+// SetPeerLimits copies the peer limits from the other config, other settings are kept
+func (p *P2P) SetPeerLimits(other P2P) {
+	p.MaxInboundPeers = other.MaxInboundPeers
+	p.MaxOutboundPeers = other.MaxOutboundPeers
+	p.MaxInboundOwnShardPeers = other.MaxInboundOwnShardPeers
+	p.MaxOutboundOwnShardPeers = other.MaxOutboundOwnShardPeers
+	p.MaxPeers = other.MaxPeers
+	p.MaxInbound = other.MaxInbound
+	p.MaxOutbound = other.MaxOutbound
+}
+
+// PeerLimitsEqual reports whether both configs have the same peer limits
+func (p *P2P) PeerLimitsEqual(other P2P) bool {
+	return p.MaxInboundPeers == other.MaxInboundPeers &&
+		p.MaxOutboundPeers == other.MaxOutboundPeers &&
+		p.MaxInboundOwnShardPeers == other.MaxInboundOwnShardPeers &&
+		p.MaxOutboundOwnShardPeers == other.MaxOutboundOwnShardPeers &&
+		p.MaxPeers == other.MaxPeers &&
+		p.MaxInbound == other.MaxInbound &&
+		p.MaxOutbound == other.MaxOutbound
+}
+
+// OutboundLimit returns the overall number of outbound peers
+func (p *P2P) OutboundLimit() int {
+	limit := p.MaxOutboundPeers + p.MaxOutboundOwnShardPeers
+	if p.MaxOutbound > 0 && p.MaxOutbound < limit {
+		limit = p.MaxOutbound
+	}
+	if p.MaxPeers > 0 && p.MaxPeers < limit {
+		limit = p.MaxPeers
+	}
+	return limit
+}
+
+// InboundLimit returns the overall number of inbound peers, outbound slots are reserved within MaxPeers
+func (p *P2P) InboundLimit() int {
+	limit := p.MaxInboundPeers + p.MaxInboundOwnShardPeers
+	if p.MaxInbound > 0 && p.MaxInbound < limit {
+		limit = p.MaxInbound
+	}
+	if p.MaxPeers > 0 {
+		if free := p.MaxPeers - p.OutboundLimit(); free < limit {
+			limit = free
+		}
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	return limit
 }
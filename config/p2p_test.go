@@ -0,0 +1,51 @@
+package config
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestP2P_Limits(t *testing.T) {
+	cfg := P2P{
+		MaxInboundPeers:          4,
+		MaxOutboundPeers:         2,
+		MaxInboundOwnShardPeers:  8,
+		MaxOutboundOwnShardPeers: 4,
+	}
+	require.Equal(t, 12, cfg.InboundLimit())
+	require.Equal(t, 6, cfg.OutboundLimit())
+
+	cfg.MaxInbound = 5
+	cfg.MaxOutbound = 3
+	require.Equal(t, 5, cfg.InboundLimit())
+	require.Equal(t, 3, cfg.OutboundLimit())
+
+	cfg.MaxPeers = 7
+	require.Equal(t, 4, cfg.InboundLimit())
+	require.Equal(t, 3, cfg.OutboundLimit())
+
+	cfg.MaxPeers = 2
+	require.Equal(t, 0, cfg.InboundLimit())
+	require.Equal(t, 2, cfg.OutboundLimit())
+}
+
+func TestP2P_SetPeerLimits(t *testing.T) {
+	cfg := P2P{
+		MaxInboundPeers: 4,
+		MaxDelay:        100,
+		StaticPeers:     []string{"peer"},
+	}
+	reloaded := P2P{
+		MaxInboundPeers:  6,
+		MaxOutboundPeers: 3,
+		MaxPeers:         10,
+		MaxDelay:         200,
+	}
+	require.False(t, cfg.PeerLimitsEqual(reloaded))
+
+	cfg.SetPeerLimits(reloaded)
+	require.True(t, cfg.PeerLimitsEqual(reloaded))
+	require.Equal(t, 10, cfg.MaxPeers)
+	require.Equal(t, 100, cfg.MaxDelay)
+	require.Equal(t, []string{"peer"}, cfg.StaticPeers)
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+const (
+	DefaultPprofHost = "localhost"
+	DefaultPprofPort = 6060
+)
+
+// PprofConfig exposes the net/http/pprof handlers for capturing CPU and heap profiles of a running node
+type PprofConfig struct {
+	Enabled bool
+	Host    string
+	Port    int
+}
+
+func GetDefaultPprofConfig() *PprofConfig {
+	return &PprofConfig{
+		Host: DefaultPprofHost,
+		Port: DefaultPprofPort,
+	}
+}
+
+func (c *PprofConfig) Endpoint() string {
+	return net.JoinHostPort(c.Host, fmt.Sprint(c.Port))
+}
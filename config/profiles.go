@@ -1,5 +1,15 @@
 package config
 
+import "time"
+
+const (
+	// chain database cache of the lowpower profile in MB
+	lowPowerDbCache = 32
+	// number of nodes kept in the LRU cache of every state tree by the lowpower profile
+	lowPowerStateCache     = 256
+	lowPowerIpfsGcInterval = time.Hour * 6
+)
+
 func applyLowPowerProfile(cfg *Config) {
 	cfg.P2P.MaxInboundPeers = LowPowerMaxInboundNotOwnShardPeers
 	cfg.P2P.MaxOutboundPeers = LowPowerMaxOutboundNotOwnShardPeers
@@ -10,6 +20,13 @@ func applyLowPowerProfile(cfg *Config) {
 	cfg.IpfsConf.GracePeriod = "30s"
 	cfg.IpfsConf.ReproviderInterval = "0"
 	cfg.IpfsConf.Routing = "dhtclient"
+	// blocks and flips of other identities are not pinned, so the frequent GC removes them after the sync
+	cfg.IpfsConf.BlockPinThreshold = 0
+	cfg.IpfsConf.FlipPinThreshold = 0
+	cfg.IpfsConf.Gc.Interval = lowPowerIpfsGcInterval
+	cfg.Db.Cache = lowPowerDbCache
+	cfg.Db.StateCache = lowPowerStateCache
+	cfg.Db.LazyStateLoad = true
 }
 
 func applySharedNodeProfile(cfg *Config) {
@@ -0,0 +1,29 @@
+package config
+
+const (
+	DefaultMinFreeDisk   = 10 * 1024
+	DefaultMinMemory     = 2 * 1024
+	DefaultMinFreeInodes = 10000
+)
+
+type RuntimeConfig struct {
+	// Number of OS threads executing Go code (GOMAXPROCS), 0 keeps the number of CPUs
+	MaxProcs int
+	// Garbage collection target percentage (GOGC), lower values save memory at the cost of CPU, 0 keeps the default
+	GCPercent int
+	// Free disk space and total memory in MB, the node warns at startup if they are below the thresholds.
+	// The required free disk space grows with the data directory size
+	MinFreeDisk int
+	MinMemory   int
+	// Below MinFreeDisk or MinFreeInodes under the data or IPFS directory the node stops pinning flips of other
+	// identities, so the chain database isn't corrupted by a full disk
+	MinFreeInodes int
+}
+
+func GetDefaultRuntimeConfig() *RuntimeConfig {
+	return &RuntimeConfig{
+		MinFreeDisk:   DefaultMinFreeDisk,
+		MinMemory:     DefaultMinMemory,
+		MinFreeInodes: DefaultMinFreeInodes,
+	}
+}
@@ -7,4 +7,6 @@ type SyncConfig struct {
 	ForceFullSync       uint64
 	LoadAllFlips        bool
 	AllFlipsLoadingTime time.Duration
+	// Download rate limit in bytes per second while catching up, zero means no limit
+	MaxDownloadRate int
 }
@@ -0,0 +1,23 @@
+package config
+
+const DefaultTracingSampleRatio = 1.0
+
+// TracingConfig configures exporting the block, proposal and ceremony spans via OTLP, an empty endpoint disables tracing
+type TracingConfig struct {
+	// OTLP/HTTP collector address, e.g. 127.0.0.1:4318
+	Endpoint string
+	// Insecure disables TLS for the collector connection
+	Insecure bool
+	// Fraction of the traces to export, in range 0-1
+	SampleRatio float64
+}
+
+func GetDefaultTracingConfig() *TracingConfig {
+	return &TracingConfig{
+		SampleRatio: DefaultTracingSampleRatio,
+	}
+}
+
+func (c *TracingConfig) Enabled() bool {
+	return c.Endpoint != ""
+}
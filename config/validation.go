@@ -9,6 +9,11 @@ const (
 	FlipLottery      = 5 * time.Minute
 	ShortSession     = 2 * time.Minute
 	AfterLongSession = 1 * time.Minute
+
+	SimulationValidationInterval = 20 * time.Minute
+	SimulationFlipLottery        = 2 * time.Minute
+	SimulationShortSession       = 1 * time.Minute
+	SimulationLongSession        = 3 * time.Minute
 )
 
 type ValidationConfig struct {
@@ -20,6 +25,25 @@ type ValidationConfig struct {
 	ShortSessionDuration time.Duration
 	// Do not use directly
 	LongSessionDuration time.Duration
+	// Ceremony simulation mode: shortened epochs, node generates flips and submits answers automatically
+	Simulation bool
+}
+
+// ApplySimulation shortens epoch and ceremony phases unless they are set explicitly
+func (cfg *ValidationConfig) ApplySimulation() {
+	cfg.Simulation = true
+	if cfg.ValidationInterval == 0 {
+		cfg.ValidationInterval = SimulationValidationInterval
+	}
+	if cfg.FlipLotteryDuration == 0 {
+		cfg.FlipLotteryDuration = SimulationFlipLottery
+	}
+	if cfg.ShortSessionDuration == 0 {
+		cfg.ShortSessionDuration = SimulationShortSession
+	}
+	if cfg.LongSessionDuration == 0 {
+		cfg.LongSessionDuration = SimulationLongSession
+	}
 }
 
 func (cfg *ValidationConfig) GetNextValidationTime(validationTime time.Time, networkSize int, enableUpgrade12 bool) time.Time {
@@ -50,9 +74,11 @@ func (cfg *ValidationConfig) GetShortSessionDuration() time.Duration {
 	return ShortSession
 }
 
-func (cfg *ValidationConfig) GetLongSessionDuration(networkSize int) time.Duration {
+// GetLongSessionDuration returns the long session duration for the network size and the number of flips every
+// identity should submit, see ConsensusConf.GetRequiredFlips
+func (cfg *ValidationConfig) GetLongSessionDuration(networkSize int, requiredFlips int) time.Duration {
 	if cfg.LongSessionDuration > 0 {
 		return cfg.LongSessionDuration
 	}
-	return time.Minute * time.Duration(common.LongSessionFlipsCount(networkSize))
+	return time.Minute * time.Duration(common.LongSessionFlipsCountFor(requiredFlips, networkSize))
 }
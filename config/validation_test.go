@@ -27,3 +27,16 @@ func TestGetNextValidationTime(t *testing.T) {
 	require.Equal(t, time.Date(2020, 1, 1, 2, 3, 0, 0, time.UTC),
 		nextValidationTime)
 }
+
+func TestValidationConfig_ApplySimulation(t *testing.T) {
+	conf := &ValidationConfig{
+		ShortSessionDuration: time.Second * 30,
+	}
+	conf.ApplySimulation()
+
+	require.True(t, conf.Simulation)
+	require.Equal(t, SimulationValidationInterval, conf.ValidationInterval)
+	require.Equal(t, SimulationFlipLottery, conf.GetFlipLotteryDuration())
+	require.Equal(t, time.Second*30, conf.GetShortSessionDuration())
+	require.Equal(t, SimulationLongSession, conf.GetLongSessionDuration(100, 3))
+}
@@ -0,0 +1,63 @@
+package config
+
+import "time"
+
+const (
+	DefaultWebhookMinPeers = 3
+	DefaultWebhookTimeout  = 10 * time.Second
+	DefaultWebhookRetries  = 3
+)
+
+// Names of the events sent to the webhooks
+const (
+	WebhookCeremonyPhase    = "ceremony-phase"
+	WebhookMissedValidation = "missed-validation"
+	WebhookPeersCollapse    = "peers-collapse"
+	WebhookFork             = "fork"
+	WebhookLowDisk          = "low-disk"
+	WebhookWatchedAddress   = "watched-address"
+	WebhookInviteActivated  = "invite-activated"
+	WebhookDeposit          = "deposit"
+)
+
+var WebhookEvents = []string{WebhookCeremonyPhase, WebhookMissedValidation, WebhookPeersCollapse, WebhookFork, WebhookLowDisk, WebhookWatchedAddress,
+	WebhookInviteActivated, WebhookDeposit}
+
+// WebhooksConfig configures POSTing critical node events as JSON to the operator's urls
+type WebhooksConfig struct {
+	Urls []string
+	// Events to send, all events are sent if empty
+	Events []string
+	// The peers collapse is reported when the number of peers drops below MinPeers
+	MinPeers int
+	Timeout  time.Duration
+	// Number of repeated attempts to deliver an event after a failure, the delay doubles after every attempt
+	Retries int
+	// Secret signs the payloads, the hex HMAC-SHA256 of the body is sent in the X-Idena-Signature header
+	Secret string
+}
+
+func GetDefaultWebhooksConfig() *WebhooksConfig {
+	return &WebhooksConfig{
+		MinPeers: DefaultWebhookMinPeers,
+		Timeout:  DefaultWebhookTimeout,
+		Retries:  DefaultWebhookRetries,
+	}
+}
+
+func (c *WebhooksConfig) Enabled() bool {
+	return len(c.Urls) > 0
+}
+
+// Sends reports whether the event is selected for sending
+func (c *WebhooksConfig) Sends(event string) bool {
+	if len(c.Events) == 0 {
+		return true
+	}
+	for _, e := range c.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
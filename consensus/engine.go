@@ -1,6 +1,7 @@
 package consensus
 
 import (
+	"context"
 	"fmt"
 	"github.com/idena-network/idena-go/blockchain"
 	"github.com/idena-network/idena-go/blockchain/attachments"
@@ -26,11 +27,20 @@ import (
 	"github.com/shopspring/decimal"
 	math2 "math"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
 	MaxStoredAvgTimeDiffs = 20
+	engineStopTimeout     = time.Minute
+)
+
+// states of the consensus loop
+const (
+	engineIdle int32 = iota
+	engineStarted
+	engineStoppedBeforeStart
 )
 
 var (
@@ -38,6 +48,8 @@ var (
 )
 
 type Engine struct {
+	// unix nanoseconds of the last consensus loop iteration, the first field keeps it aligned for atomic access
+	lastLoopTime      int64
 	chain             *blockchain.Blockchain
 	pm                *protocol.IdenaGossipHandler
 	log               log.Logger
@@ -65,6 +77,9 @@ type Engine struct {
 	upgrader          *upgrade.Upgrader
 	eventBus          eventbus.Bus
 	statsCollector    collector.StatsCollector
+	stop              chan struct{}
+	stopped           chan struct{}
+	loopState         int32
 }
 
 func NewEngine(chain *blockchain.Blockchain, gossipHandler *protocol.IdenaGossipHandler, proposals *pengings.Proposals, config *config.Config,
@@ -94,6 +109,8 @@ func NewEngine(chain *blockchain.Blockchain, gossipHandler *protocol.IdenaGossip
 		ipfsProxy:         ipfsProxy,
 		eventBus:          eventBus,
 		statsCollector:    statsCollector,
+		stop:              make(chan struct{}),
+		stopped:           make(chan struct{}),
 	}
 }
 
@@ -101,11 +118,42 @@ func (engine *Engine) Start() {
 	engine.pubKey = engine.secStore.GetPubKey()
 	engine.addr = engine.secStore.GetAddress()
 	log.Info("Start consensus protocol", "pubKey", hexutil.Encode(engine.pubKey))
+	// the engine stopped before the start doesn't run the loop
+	if !atomic.CompareAndSwapInt32(&engine.loopState, engineIdle, engineStarted) {
+		return
+	}
 	engine.forkResolver.Start()
 	go engine.loop()
 	go engine.ntpTimeDriftUpdate()
 }
 
+// Stop ends the consensus loop after the current round, it returns false if the round isn't completed in time
+func (engine *Engine) Stop() bool {
+	close(engine.stop)
+	if atomic.CompareAndSwapInt32(&engine.loopState, engineIdle, engineStoppedBeforeStart) {
+		return true
+	}
+	select {
+	case <-engine.stopped:
+		return true
+	case <-time.After(engineStopTimeout):
+		return false
+	}
+}
+
+// sleep pauses the consensus loop, it returns earlier if the engine is stopping
+func (engine *Engine) sleep(d time.Duration) {
+	select {
+	case <-engine.stop:
+	case <-time.After(d):
+	}
+}
+
+// LastLoopTime returns the start time of the last consensus loop iteration, a single iteration may take long while syncing
+func (engine *Engine) LastLoopTime() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&engine.lastLoopTime))
+}
+
 func (engine *Engine) GetProcess() string {
 	return engine.process
 }
@@ -114,6 +162,11 @@ func (engine *Engine) ReadonlyAppState() (*appstate.AppState, error) {
 	return engine.appState.Readonly(engine.chain.Head.Height())
 }
 
+// ReadonlyStateAt loads the state of the block at the height without replacing the cached readonly app state
+func (engine *Engine) ReadonlyStateAt(height uint64) (*state.StateDB, error) {
+	return engine.appState.State.Readonly(int64(height))
+}
+
 func (engine *Engine) AppStateForCheck() (*appstate.AppState, error) {
 	return engine.appState.ForCheck(engine.chain.Head.Height())
 }
@@ -144,11 +197,41 @@ func (engine *Engine) alignTime() {
 		diff := engine.cfg.Consensus.MinBlockDistance - correctedNow.Sub(headTime)
 		diff = time.Duration(math.MinInt(int(diff), int(maxDelay)))
 		if diff > 0 {
-			time.Sleep(diff)
+			// long block intervals of private networks should not delay the node stop
+			engine.sleep(diff)
 		}
 	}
 }
 
+// waitForActivity delays the round of a quiet private network until the idle block interval passes since the head
+// block or the ceremony approaches, so the network doesn't grow with empty blocks. The delay depends on the chain state
+// only, so all nodes of the network start the round together regardless of their local mempools
+func (engine *Engine) waitForActivity() {
+	interval := engine.cfg.Consensus.IdleBlockInterval
+	if interval <= 0 {
+		return
+	}
+	head := engine.chain.Head
+	// the network which has just included transactions keeps the regular pace
+	if head.ProposedHeader != nil && head.ProposedHeader.TxHash != (common.Hash{}) {
+		return
+	}
+	appState, err := engine.ReadonlyAppState()
+	if err != nil || appState.State.ValidationPeriod() != state.NonePeriod {
+		return
+	}
+	deadline := time.Unix(head.Time(), 0).Add(interval)
+	// the block which starts the flip lottery should not be late
+	flipLottery := appState.State.NextValidationTime().Add(-engine.cfg.Validation.GetFlipLotteryDuration() - engine.cfg.Consensus.MinBlockDistance)
+	if flipLottery.Before(deadline) {
+		deadline = flipLottery
+	}
+	if delay := time.Until(deadline); delay > 0 {
+		engine.log.Info("Waiting for the idle block interval", "delay", delay.String())
+		engine.sleep(delay)
+	}
+}
+
 func (engine *Engine) calculateTimeDiff(round uint64, roundStart time.Time) {
 	engine.avgTimeDiffs = append(engine.avgTimeDiffs, engine.proposals.AvgTimeDiff(round, roundStart.Unix()))
 	if len(engine.avgTimeDiffs) > MaxStoredAvgTimeDiffs {
@@ -157,10 +240,19 @@ func (engine *Engine) calculateTimeDiff(round uint64, roundStart time.Time) {
 }
 
 func (engine *Engine) loop() {
+	defer close(engine.stopped)
 	for {
+		select {
+		case <-engine.stop:
+			engine.log.Info("Consensus protocol stopped")
+			return
+		default:
+		}
+		atomic.StoreInt64(&engine.lastLoopTime, time.Now().UnixNano())
+
 		if err := engine.chain.EnsureIntegrity(); err != nil {
 			engine.log.Error("Failed to recover blockchain", "err", err)
-			time.Sleep(time.Second * 30)
+			engine.sleep(time.Second * 30)
 			continue
 		}
 
@@ -178,13 +270,13 @@ func (engine *Engine) loop() {
 				}
 			} else {
 				engine.log.Warn("syncing error", "err", err)
-				time.Sleep(time.Second * 5)
+				engine.sleep(time.Second * 5)
 			}
 			continue
 		}
 
 		if !engine.cfg.Consensus.Automine && !engine.pm.HasPeers() {
-			time.Sleep(time.Second * 5)
+			engine.sleep(time.Second * 5)
 			engine.synced = false
 			continue
 		}
@@ -205,6 +297,7 @@ func (engine *Engine) loop() {
 		engine.completeRound(round - 1)
 
 		engine.alignTime()
+		engine.waitForActivity()
 
 		engine.prevRoundDuration = 0
 		roundStart := time.Now().UTC()
@@ -347,10 +440,10 @@ func (engine *Engine) proposeBlock(proof []byte) *types.Block {
 	hash := crypto.SignatureHash(proofProposal)
 	proofProposal.Signature = engine.secStore.Sign(hash[:])
 
-	engine.pm.ProposeProof(proofProposal)
-	engine.pm.ProposeBlock(proposal)
+	engine.pm.ProposeOwnProof(proofProposal)
+	engine.pm.ProposeOwnBlock(proposal)
 
-	engine.proposals.AddProposedBlock(proposal, "", time.Now().UTC())
+	engine.proposals.AddProposedBlock(context.Background(), proposal, "", time.Now().UTC())
 	engine.proposals.AddProposeProof(proofProposal)
 
 	return proposal.Block
@@ -482,7 +575,7 @@ func (engine *Engine) vote(round uint64, step uint8, block common.Hash) {
 		}
 		hash := crypto.SignatureHash(&vote)
 		vote.Signature = engine.secStore.Sign(hash[:])
-		engine.pm.SendVote(&vote)
+		engine.pm.SendOwnVote(&vote)
 
 		engine.log.Info("Voted for", "step", step, "block", block.Hex())
 
@@ -61,9 +61,17 @@ func (resolver *ForkResolver) loadAndVerifyFork() {
 
 	resolver.triedPeers.Add(peerId)
 
-	lastBlocksHashes := resolver.chain.GetTopBlockHashes(100)
-
-	blocks := resolver.downloader.SeekForkedBlocks(lastBlocksHashes, peerId)
+	var blocks chan types.BlockBundle
+	if commonHeight, diverged, err := resolver.downloader.FindForkPoint(peerId); err != nil {
+		resolver.log.Debug("Fork point search failed, requesting fork by top hashes", "peerId", peerId, "err", err)
+		lastBlocksHashes := resolver.chain.GetTopBlockHashes(100)
+		blocks = resolver.downloader.SeekForkedBlocks(lastBlocksHashes, peerId)
+	} else if !diverged {
+		resolver.log.Info("Peer is not in fork", "peerId", peerId, "height", commonHeight)
+		return
+	} else {
+		blocks = forkSuffix(resolver.downloader.SeekForkSuffix(peerId, commonHeight))
+	}
 	if err := resolver.processBlocks(blocks, peerId); err != nil {
 		resolver.downloader.BanPeer(peerId, err)
 		resolver.log.Warn("invalid fork", "err", err)
@@ -103,6 +111,25 @@ func (resolver *ForkResolver) processBlocks(blocks chan types.BlockBundle, peerI
 	return nil
 }
 
+// forkSuffix collects the peer's blocks after the fork point, blocks after the last certified one are dropped
+// since the fork is applied up to a certificate
+func forkSuffix(bundles chan *types.BlockBundle) chan types.BlockBundle {
+	var suffix []types.BlockBundle
+	for bundle := range bundles {
+		suffix = append(suffix, *bundle)
+	}
+	suffix = sortBlocks(suffix)
+	for len(suffix) > 0 && suffix[len(suffix)-1].Cert.Empty() {
+		suffix = suffix[:len(suffix)-1]
+	}
+	result := make(chan types.BlockBundle, len(suffix))
+	for _, bundle := range suffix {
+		result <- bundle
+	}
+	close(result)
+	return result
+}
+
 func sortBlocks(blocks []types.BlockBundle) []types.BlockBundle {
 	sort.SliceStable(blocks, func(i, j int) bool {
 		return blocks[i].Block.Height() < blocks[j].Block.Height()
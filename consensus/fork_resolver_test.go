@@ -136,3 +136,27 @@ func TestForkResolver_ResolveFork2(t *testing.T) {
 	err = resolver.processBlocks(blocks, "test-peer")
 	require.Error(t, err)
 }
+
+func TestForkSuffix(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	chain, _ := blockchain.NewCustomTestBlockchain(10, 0, key)
+	defer chain.SecStore().Destroy()
+
+	bundles := make(chan *types.BlockBundle, 5)
+	for h := uint64(7); h >= 3; h-- {
+		block := chain.GetBlockByHeight(h)
+		bundle := &types.BlockBundle{Block: block}
+		if h <= 5 {
+			bundle.Cert = chain.GetCertificate(block.Hash())
+			require.False(t, bundle.Cert.Empty())
+		}
+		bundles <- bundle
+	}
+	close(bundles)
+
+	var heights []uint64
+	for bundle := range forkSuffix(bundles) {
+		heights = append(heights, bundle.Block.Height())
+	}
+	require.Equal(t, []uint64{3, 4, 5}, heights)
+}
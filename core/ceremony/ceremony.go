@@ -27,13 +27,16 @@ import (
 	"github.com/idena-network/idena-go/protocol"
 	"github.com/idena-network/idena-go/secstore"
 	"github.com/idena-network/idena-go/stats/collector"
+	"github.com/idena-network/idena-go/stats/tracing"
 	statsTypes "github.com/idena-network/idena-go/stats/types"
 	"github.com/ipfs/go-cid"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 	dbm "github.com/tendermint/tm-db"
+	"go.opentelemetry.io/otel/attribute"
 	"math/big"
 	"math/rand"
+	"runtime"
 	"sync"
 	"time"
 )
@@ -46,6 +49,8 @@ const (
 	FlipKeysSyncTimeFrame            = 60 * 4 // seconds
 	ClientTypeDesktop                = 1
 	ClientTypeDesktopWithBuiltInNode = 2
+	MaxFlipDecryptionWorkers         = 8
+	FlipDecryptionRetryInterval      = time.Second * 5
 )
 
 type ValidationCeremony struct {
@@ -83,6 +88,14 @@ type ValidationCeremony struct {
 	newTxQueue               chan *types.Transaction
 	lottery                  *lottery
 	allFlipsIsLoading        bool
+	simulation               *simulation
+	telemetry                *telemetry
+	clockSkew                *clockSkewGuard
+	// background work using the databases is tracked, so they are closed only after it is stopped
+	stop       chan struct{}
+	stopMutex  sync.Mutex
+	stopped    bool
+	background sync.WaitGroup
 }
 
 type flipWordsInfo struct {
@@ -138,6 +151,10 @@ func NewValidationCeremony(appState *appstate.AppState, bus eventbus.Bus, flippe
 		newTxQueue:         make(chan *types.Transaction, 10000),
 		flipWordsInfo:      &flipWordsInfo{pool: &sync.Map{}},
 		lottery:            &lottery{},
+		simulation:         newSimulation(),
+		telemetry:          newTelemetry(),
+		clockSkew:          &clockSkewGuard{},
+		stop:               make(chan struct{}),
 	}
 
 	vc.blockHandlers = map[state.ValidationPeriod]blockHandler{
@@ -151,6 +168,7 @@ func NewValidationCeremony(appState *appstate.AppState, bus eventbus.Bus, flippe
 }
 
 func (vc *ValidationCeremony) Initialize(currentBlock *types.Block) {
+	vc.telemetry.setPeriod(vc.appState.State.ValidationPeriod())
 	vc.epochDb = database.NewEpochDb(vc.db, vc.appState.State.Epoch())
 	vc.epoch = vc.appState.State.Epoch()
 	vc.qualification = NewQualification(vc.config, vc.epochDb)
@@ -165,6 +183,33 @@ func (vc *ValidationCeremony) Initialize(currentBlock *types.Block) {
 		vc.restoreState()
 	})
 
+	_ = vc.bus.Subscribe(events.NewFlipKeyID,
+		func(e eventbus.Event) {
+			if !e.(*events.NewFlipKeyEvent).Own {
+				vc.track(telemetryKeysReceived)
+			}
+		})
+
+	_ = vc.bus.Subscribe(events.NewFlipKeysPackageID,
+		func(e eventbus.Event) {
+			if !e.(*events.NewFlipKeysPackageEvent).Own {
+				vc.track(telemetryKeyPackagesReceived)
+			}
+		})
+
+	_ = vc.bus.Subscribe(events.ClockSkewEventID,
+		func(e eventbus.Event) {
+			vc.clockSkew.update(e.(*events.ClockSkewEvent))
+		})
+
+	vc.flipper.SetLoadingListener(func(loaded bool) {
+		if loaded {
+			vc.track(telemetryFlipsFetched)
+		} else {
+			vc.track(telemetryFlipsFailed)
+		}
+	})
+
 	_ = vc.bus.Subscribe(events.DeleteFlipEventID,
 		func(e eventbus.Event) {
 			event := e.(*events.DeleteFlipEvent)
@@ -197,22 +242,87 @@ func (vc *ValidationCeremony) Initialize(currentBlock *types.Block) {
 	})
 
 	go vc.newTxLoop()
+	if vc.simulationEnabled() {
+		vc.goBackground(vc.simulationLoop)
+	}
 	vc.restoreState()
 	vc.addBlock(currentBlock)
 }
 
+// goBackground runs f in a goroutine waited by Stop, f is skipped if the ceremony is stopped
+func (vc *ValidationCeremony) goBackground(f func()) {
+	vc.stopMutex.Lock()
+	defer vc.stopMutex.Unlock()
+	if vc.stopped {
+		return
+	}
+	vc.background.Add(1)
+	go func() {
+		defer vc.background.Done()
+		f()
+	}()
+}
+
+// Stop cancels the background work of the ceremony, it returns false if the work isn't completed within the timeout
+func (vc *ValidationCeremony) Stop(timeout time.Duration) bool {
+	vc.stopMutex.Lock()
+	vc.stopped = true
+	close(vc.stop)
+	if vc.validationStartCtxCancel != nil {
+		vc.validationStartCtxCancel()
+	}
+	vc.stopMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		vc.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 func (vc *ValidationCeremony) addBlock(block *types.Block) {
 	vc.handleBlock(block)
 	vc.qualification.persist()
+	if vc.simulationEnabled() {
+		vc.simulation.enqueue(block.Height(), vc.appState.State.ValidationPeriod())
+	}
 
 	// completeEpoch if finished
 	if block.Header.Flags().HasFlag(types.ValidationFinished) {
+		vc.publishValidationResult(block.Height())
 		vc.completeEpoch()
 		vc.startValidationShortSessionTimer()
 		vc.generateFlipKeyWordPairs(vc.appState.State.FlipWordsSeed().Bytes())
 	}
 }
 
+// publishValidationResult notifies about the ceremony outcome of the node identity if it took part in the ceremony
+func (vc *ValidationCeremony) publishValidationResult(height uint64) {
+	vc.applyEpochMutex.Lock()
+	applyingCache, ok := vc.epochApplyingCache[height]
+	vc.applyEpochMutex.Unlock()
+	if !ok || applyingCache.validationFailed {
+		return
+	}
+	addr := vc.secStore.GetAddress()
+	value, ok := applyingCache.epochApplyingResult[addr]
+	if !ok {
+		return
+	}
+	vc.bus.Publish(&events.ValidationResultEvent{
+		Address:   addr,
+		Epoch:     vc.epoch,
+		Validated: value.state.NewbieOrBetter(),
+		Missed:    value.missed,
+	})
+}
+
 func (vc *ValidationCeremony) isParticipant() bool {
 	identity := vc.appState.State.GetIdentity(vc.secStore.GetAddress())
 	return state.IsCeremonyCandidate(identity)
@@ -305,18 +415,22 @@ func (vc *ValidationCeremony) SubmitShortAnswers(answers *types.Answers) (common
 	h, err := vc.sendTx(types.SubmitAnswersHashTx, hash[:])
 	if err != nil {
 		vc.log.Error("cannot send short answers hash tx", "err", err)
+	} else {
+		vc.track(telemetryAnswersSubmitted)
 	}
 
 	return h, err
 }
 
 func (vc *ValidationCeremony) SubmitLongAnswers(answers *types.Answers) (common.Hash, error) {
+	vc.applyOwnFlipReports(answers)
 
 	key := vc.flipper.GetFlipPublicEncryptionKey()
 	salt := getShortAnswersSalt(vc.epoch, vc.secStore)
 
 	hash, err := vc.sendTx(types.SubmitLongAnswersTx, attachments.CreateLongAnswerAttachment(answers.Bytes(), vc.flipWordsInfo.proof, salt, key))
 	if err == nil {
+		vc.track(telemetryAnswersSubmitted)
 		vc.broadcastEvidenceMap()
 		vc.broadcastShortAnswersTx()
 	} else {
@@ -325,6 +439,88 @@ func (vc *ValidationCeremony) SubmitLongAnswers(answers *types.Answers) (common.
 	return hash, err
 }
 
+// ReportFlip marks a flip of the coinbase long session as irrelevant or inappropriate, the report is sent along with long answers
+func (vc *ValidationCeremony) ReportFlip(cid []byte) error {
+	flips, err := vc.ownLongFlipsForReport(cid)
+	if err != nil {
+		return err
+	}
+	reports := vc.epochDb.ReadOwnFlipReports()
+	for _, reported := range reports {
+		if bytes.Equal(reported, cid) {
+			return nil
+		}
+	}
+	if ExceedsReportsLimit(len(reports)+1, len(flips)) {
+		return errors.New("reports limit is reached")
+	}
+	vc.epochDb.WriteOwnFlipReport(cid)
+	return nil
+}
+
+// CancelFlipReport removes the report made by ReportFlip
+func (vc *ValidationCeremony) CancelFlipReport(cid []byte) error {
+	if _, err := vc.ownLongFlipsForReport(cid); err != nil {
+		return err
+	}
+	vc.epochDb.RemoveOwnFlipReport(cid)
+	return nil
+}
+
+func (vc *ValidationCeremony) OwnFlipReports() [][]byte {
+	return vc.epochDb.ReadOwnFlipReports()
+}
+
+func (vc *ValidationCeremony) ownLongFlipsForReport(cid []byte) ([][]byte, error) {
+	if vc.appState.State.ValidationPeriod() != state.LongSessionPeriod {
+		return nil, errors.New("flips can be reported during long session only")
+	}
+	if vc.epochDb.ReadOwnTx(types.SubmitLongAnswersTx) != nil {
+		return nil, errors.New("long answers are already submitted")
+	}
+	coinbase := vc.secStore.GetAddress()
+	identity := vc.appState.State.GetIdentity(coinbase)
+	flips := vc.GetLongFlipsToSolve(coinbase, identity.ShiftedShardId())
+	for _, flip := range flips {
+		if bytes.Equal(flip, cid) {
+			return flips, nil
+		}
+	}
+	return nil, errors.New("flip is not found among long session flips")
+}
+
+func (vc *ValidationCeremony) applyOwnFlipReports(answers *types.Answers) {
+	reports := vc.epochDb.ReadOwnFlipReports()
+	if len(reports) == 0 {
+		return
+	}
+	coinbase := vc.secStore.GetAddress()
+	identity := vc.appState.State.GetIdentity(coinbase)
+	flips := vc.GetLongFlipsToSolve(coinbase, identity.ShiftedShardId())
+	reportsCount := 0
+	for i := range flips {
+		if _, grade := answers.Answer(uint(i)); grade == types.GradeReported {
+			reportsCount++
+		}
+	}
+	for i, flip := range flips {
+		if _, grade := answers.Answer(uint(i)); grade != types.GradeNone {
+			continue
+		}
+		for _, reported := range reports {
+			if !bytes.Equal(reported, flip) {
+				continue
+			}
+			if ExceedsReportsLimit(reportsCount+1, len(flips)) {
+				return
+			}
+			answers.Grade(uint(i), types.GradeReported)
+			reportsCount++
+			break
+		}
+	}
+}
+
 func (vc *ValidationCeremony) restoreState() {
 	vc.generateFlipKeyWordPairs(vc.appState.State.FlipWordsSeed().Bytes())
 	vc.appState.EvidenceMap.SetShortSessionTime(vc.appState.State.NextValidationTime(), vc.config.Validation.GetShortSessionDuration())
@@ -373,7 +569,9 @@ func (vc *ValidationCeremony) startValidationShortSessionTimer() {
 					// load all flips in case of public node
 					if vc.config.Sync.LoadAllFlips && !vc.allFlipsIsLoading && time.Now().UTC().Add(vc.config.Sync.AllFlipsLoadingTime).After(validationTime) {
 						vc.allFlipsIsLoading = true
-						go vc.loadAllFlips(ctx)
+						vc.goBackground(func() {
+							vc.loadAllFlips(ctx)
+						})
 					}
 					if time.Now().UTC().After(validationTime) {
 						if appState, err := vc.appState.Readonly(vc.chain.Head.Height()); err == nil {
@@ -395,10 +593,10 @@ func (vc *ValidationCeremony) startValidationShortSessionTimer() {
 func (vc *ValidationCeremony) completeEpoch() {
 	if vc.epoch != vc.appState.State.Epoch() {
 		edb := vc.epochDb
-		go func() {
+		vc.goBackground(func() {
 			vc.dropFlips(edb)
 			edb.Clear()
-		}()
+		})
 	}
 	vc.epochDb = database.NewEpochDb(vc.db, vc.appState.State.Epoch())
 	vc.epoch = vc.appState.State.Epoch()
@@ -425,10 +623,19 @@ func (vc *ValidationCeremony) completeEpoch() {
 	vc.flipWordsInfo = &flipWordsInfo{pool: &sync.Map{}}
 	vc.lottery = &lottery{}
 	vc.allFlipsIsLoading = false
+	vc.simulation.reset()
+	vc.telemetry.reset()
+	vc.clockSkew.reset()
 }
 
 func (vc *ValidationCeremony) handleBlock(block *types.Block) {
-	vc.blockHandlers[vc.appState.State.ValidationPeriod()](block)
+	period := vc.appState.State.ValidationPeriod()
+	vc.telemetry.setPeriod(period)
+	if period != state.NonePeriod {
+		_, span := tracing.StartSpan(context.Background(), "ceremony."+periodName(period), tracing.Block(block.Height(), block.Hash().Hex())...)
+		defer span.End()
+	}
+	vc.blockHandlers[period](block)
 }
 
 func (vc *ValidationCeremony) handleFlipLotteryPeriod(block *types.Block) {
@@ -445,12 +652,16 @@ func (vc *ValidationCeremony) handleFlipLotteryPeriod(block *types.Block) {
 
 		vc.epochDb.WriteLotterySeed(seedBlock.Seed().Bytes())
 
-		go vc.asyncFlipLotteryCalculations()
+		vc.goBackground(vc.asyncFlipLotteryCalculations)
 	}
 
 	if vc.lottery.finished {
 		vc.tryToBroadcastFlipKeysPackage()
 	}
+
+	if !vc.shortSessionStarted {
+		vc.checkClockSkew()
+	}
 }
 
 func (vc *ValidationCeremony) tryToBroadcastFlipKeysPackage() {
@@ -572,8 +783,14 @@ func (vc *ValidationCeremony) calculateCeremonyCandidates(restore bool) {
 	longToSolve := vc.GetLongFlipsToSolve(coinbase, coinbaseIdentity.ShiftedShardId())
 
 	if vc.shouldInteractWithNetwork() {
-		go vc.flipper.LoadInMemory(shortToSolve)
-		go vc.flipper.LoadInMemory(longToSolve)
+		vc.goBackground(func() {
+			vc.flipper.LoadInMemory(shortToSolve)
+			vc.decryptFlips(shortToSolve)
+		})
+		vc.goBackground(func() {
+			vc.flipper.LoadInMemory(longToSolve)
+			vc.decryptFlips(longToSolve)
+		})
 	}
 
 	for shardId, shard := range vc.shardCandidates {
@@ -599,9 +816,10 @@ func (vc *ValidationCeremony) shouldInteractWithNetwork() bool {
 	}
 
 	conf := vc.chain.Config().Validation
+	networkSize := vc.appState.ValidatorsCache.NetworkSize()
 	ceremonyDuration := conf.GetFlipLotteryDuration() +
 		conf.GetShortSessionDuration() +
-		conf.GetLongSessionDuration(vc.appState.ValidatorsCache.NetworkSize()) +
+		conf.GetLongSessionDuration(networkSize, vc.config.Consensus.GetRequiredFlips(networkSize, vc.config.Network)) +
 		time.Minute*15 // added extra minutes to prevent time lags
 	headTime := time.Unix(vc.chain.Head.Time(), 0)
 
@@ -812,8 +1030,10 @@ func (vc *ValidationCeremony) processCeremonyTxs(block *types.Block) {
 			}
 		case types.SubmitShortAnswersTx:
 			vc.qualification.addAnswers(true, sender, tx.Payload)
+			vc.track(telemetryShortAnswersMined)
 		case types.SubmitLongAnswersTx:
 			vc.qualification.addAnswers(false, sender, tx.Payload)
+			vc.track(telemetryLongAnswersMined)
 		case types.EvidenceTx:
 			if !vc.epochDb.HasEvidenceMap(sender) {
 				vc.epochDb.WriteEvidenceMap(sender, tx.Payload)
@@ -1028,6 +1248,8 @@ func determineStakeShareToBurn(identityState state.IdentityState, birthday uint1
 }
 
 func (vc *ValidationCeremony) ApplyNewEpoch(height uint64, appState *appstate.AppState, statsCollector collector.StatsCollector) types.TotalValidationResult {
+	_, span := tracing.StartSpan(context.Background(), "ceremony.ApplyNewEpoch", attribute.Int64("block.height", int64(height)))
+	defer span.End()
 
 	var identitiesCount int
 
@@ -1711,7 +1933,12 @@ func (vc *ValidationCeremony) addNewTx(tx *types.Transaction) {
 
 func (vc *ValidationCeremony) newTxLoop() {
 	for {
-		tx := <-vc.newTxQueue
+		var tx *types.Transaction
+		select {
+		case <-vc.stop:
+			return
+		case tx = <-vc.newTxQueue:
+		}
 		if tx.Type == types.SubmitShortAnswersTx {
 			sender, _ := types.Sender(tx)
 			attachment := attachments.ParseShortAnswerAttachment(tx)
@@ -1805,6 +2032,68 @@ func (vc *ValidationCeremony) IsFlipReadyToSolve(key []byte) bool {
 	return ready
 }
 
+// decryptFlips decrypts loaded flips using a bounded pool of workers, flips which keys are not received yet
+// are retried until the long session is over
+func (vc *ValidationCeremony) decryptFlips(cids [][]byte) {
+	workers := math.MinInt(runtime.NumCPU(), MaxFlipDecryptionWorkers)
+	for len(cids) > 0 {
+		queue := make(chan []byte, len(cids))
+		for _, key := range cids {
+			queue <- key
+		}
+		close(queue)
+
+		var notReady [][]byte
+		notReadyMutex := sync.Mutex{}
+		wg := sync.WaitGroup{}
+		wg.Add(workers)
+		for i := 0; i < workers; i++ {
+			go func() {
+				defer wg.Done()
+				for key := range queue {
+					if !vc.prepareFlip(key) {
+						notReadyMutex.Lock()
+						notReady = append(notReady, key)
+						notReadyMutex.Unlock()
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(notReady) == 0 {
+			vc.log.Info("All flips were decrypted", "cnt", len(cids))
+			return
+		}
+		if vc.appState.State.ValidationPeriod() > state.LongSessionPeriod {
+			vc.log.Warn("Some flips were not decrypted", "cnt", len(notReady))
+			return
+		}
+		cids = notReady
+		select {
+		case <-vc.stop:
+			return
+		case <-time.After(FlipDecryptionRetryInterval):
+		}
+	}
+}
+
+func (vc *ValidationCeremony) prepareFlip(key []byte) bool {
+	hash := common.Hash(crypto.Hash(key))
+	if !vc.flipper.HasFlipInMemory(hash) {
+		return false
+	}
+	if vc.flipper.GetFlipReadiness(hash) {
+		return true
+	}
+	if _, _, err := vc.GetDecryptedFlip(key); err != nil {
+		return false
+	}
+	vc.flipper.SetFlipReadiness(hash)
+	vc.track(telemetryFlipsDecrypted)
+	return true
+}
+
 func (vc *ValidationCeremony) IsFlipInMemory(key []byte) bool {
 	hash := common.Hash(crypto.Hash(key))
 	return vc.flipper.HasFlipInMemory(hash)
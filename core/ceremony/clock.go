@@ -0,0 +1,96 @@
+package ceremony
+
+import (
+	"github.com/idena-network/idena-go/events"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxClockSkew is the local clock skew at which the identity risks to miss the short session
+	MaxClockSkew = time.Second * 5
+	// minimal number of peers to rely on the clocks they reported
+	minClockSkewPeers = 3
+)
+
+type ClockSkew struct {
+	NtpDrift    time.Duration `json:"ntpDrift"`
+	NtpMeasured bool          `json:"ntpMeasured"`
+	PeersOffset time.Duration `json:"peersOffset"`
+	PeersCount  int           `json:"peersCount"`
+	Exceeded    bool          `json:"exceeded"`
+}
+
+type clockSkewGuard struct {
+	last   events.ClockSkewEvent
+	warned bool
+	mutex  sync.Mutex
+}
+
+func (g *clockSkewGuard) update(e *events.ClockSkewEvent) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.last = *e
+}
+
+func (g *clockSkewGuard) reset() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.warned = false
+}
+
+func (g *clockSkewGuard) skew() ClockSkew {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return ClockSkew{
+		NtpDrift:    g.last.NtpDrift,
+		NtpMeasured: g.last.NtpMeasured,
+		PeersOffset: g.last.PeersOffset,
+		PeersCount:  g.last.PeersCount,
+		Exceeded:    exceedsClockSkew(g.last),
+	}
+}
+
+func exceedsClockSkew(e events.ClockSkewEvent) bool {
+	exceeds := func(d time.Duration) bool {
+		return d > MaxClockSkew || d < -MaxClockSkew
+	}
+	if e.NtpMeasured && exceeds(e.NtpDrift) {
+		return true
+	}
+	return e.PeersCount >= minClockSkewPeers && exceeds(e.PeersOffset)
+}
+
+// checkClockSkew warns the participant once per epoch before the short session if the local clock is off
+func (vc *ValidationCeremony) checkClockSkew() {
+	if !vc.isParticipant() {
+		return
+	}
+	skew := vc.clockSkew.skew()
+	if !skew.Exceeded {
+		return
+	}
+	vc.clockSkew.mutex.Lock()
+	warned := vc.clockSkew.warned
+	vc.clockSkew.warned = true
+	vc.clockSkew.mutex.Unlock()
+	if warned {
+		return
+	}
+	value := skew.PeersOffset
+	if skew.NtpMeasured {
+		value = skew.NtpDrift
+	}
+	vc.log.Error("!!! SYSTEM CLOCK IS OFF, YOU MAY MISS THE SHORT SESSION AND FAIL VALIDATION !!!", "skew", value,
+		"ntpDrift", skew.NtpDrift, "peersOffset", skew.PeersOffset, "peers", skew.PeersCount)
+	vc.log.Error("Please enable network time synchronisation in system settings before the validation starts")
+	vc.bus.Publish(&events.ClockSkewWarningEvent{
+		Epoch: vc.epoch,
+		Skew:  value,
+	})
+}
+
+// ClockSkew returns the latest local clock skew measured against NTP and peers
+func (vc *ValidationCeremony) ClockSkew() ClockSkew {
+	return vc.clockSkew.skew()
+}
@@ -0,0 +1,17 @@
+package ceremony
+
+import (
+	"github.com/idena-network/idena-go/events"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_exceedsClockSkew(t *testing.T) {
+	require.False(t, exceedsClockSkew(events.ClockSkewEvent{}))
+	require.False(t, exceedsClockSkew(events.ClockSkewEvent{NtpDrift: time.Minute}))
+	require.True(t, exceedsClockSkew(events.ClockSkewEvent{NtpDrift: -time.Minute, NtpMeasured: true}))
+	require.False(t, exceedsClockSkew(events.ClockSkewEvent{NtpDrift: time.Second, NtpMeasured: true}))
+	require.False(t, exceedsClockSkew(events.ClockSkewEvent{PeersOffset: time.Minute, PeersCount: minClockSkewPeers - 1}))
+	require.True(t, exceedsClockSkew(events.ClockSkewEvent{PeersOffset: time.Minute, PeersCount: minClockSkewPeers}))
+}
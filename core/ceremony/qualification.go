@@ -17,6 +17,9 @@ import (
 	"sync"
 )
 
+// MaxReportedFlipsShare is the share of reported flips at which all reports of the candidate are ignored
+const MaxReportedFlipsShare = 0.34
+
 type qualification struct {
 	config       *config.Config
 	shortAnswers map[common.Address][]byte
@@ -169,7 +172,7 @@ func (q *qualification) qualifyFlips(totalFlipsCount uint, candidates []*candida
 			}
 		}
 		if flipsCount > 0 {
-			ignoreReports := float32(reportersToReward.getReportedFlipsCountByReporter(candidate.Address))/float32(flipsCount) >= 0.34
+			ignoreReports := ExceedsReportsLimit(reportersToReward.getReportedFlipsCountByReporter(candidate.Address), flipsCount)
 			if q.config.Consensus.EnableUpgrade11 {
 				ignoreGrades := ignoreReports || !hasApprove || increasedGradeCnt > 1
 				if ignoreGrades {
@@ -379,6 +382,11 @@ func getAnswersCount(a []types.Answer) (left uint, right uint, none uint) {
 	return left, right, none
 }
 
+// ExceedsReportsLimit returns true if reports of a candidate should be ignored because too many flips are reported
+func ExceedsReportsLimit(reportsCount int, flipsCount int) bool {
+	return float32(reportsCount)/float32(flipsCount) >= MaxReportedFlipsShare
+}
+
 func (q *qualification) qualifyOneFlip(answers []types.Answer, reportsCount, totalGradeScore, approveCnt, reportCommitteeSize, gradeScoreCommitteeSize int) FlipQualification {
 	reported := false
 	switch reportCommitteeSize {
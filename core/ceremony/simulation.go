@@ -0,0 +1,206 @@
+package ceremony
+
+import (
+	"crypto/rand"
+	"github.com/idena-network/idena-go/blockchain"
+	"github.com/idena-network/idena-go/blockchain/attachments"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/shopspring/decimal"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	syntheticFlipPublicPartSize  = 1024
+	syntheticFlipPrivatePartSize = 1024
+)
+
+// simulation generates synthetic flips and answers for the coinbase identity when ceremony simulation mode is enabled,
+// every flip is answered with the left option so all flips are qualified and the identity passes the validation
+type simulation struct {
+	// accessed atomically, kept first for 64-bit alignment on 32-bit platforms
+	latestHeight          uint64
+	mutex                 sync.Mutex
+	submittedPairs        map[uint8]struct{}
+	shortAnswersSubmitted bool
+	longAnswersSubmitted  bool
+	// the latest block is simulated by a single goroutine, a pending task is replaced by the newer one
+	queue chan simulationTask
+}
+
+type simulationTask struct {
+	height uint64
+	period state.ValidationPeriod
+}
+
+func newSimulation() *simulation {
+	return &simulation{
+		submittedPairs: make(map[uint8]struct{}),
+		queue:          make(chan simulationTask, 1),
+	}
+}
+
+// reset clears the epoch progress, it waits for the running simulation step to finish
+func (s *simulation) reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.submittedPairs = make(map[uint8]struct{})
+	s.shortAnswersSubmitted = false
+	s.longAnswersSubmitted = false
+}
+
+// enqueue must be called from a single goroutine only
+func (s *simulation) enqueue(height uint64, period state.ValidationPeriod) {
+	atomic.StoreUint64(&s.latestHeight, height)
+	select {
+	case <-s.queue:
+	default:
+	}
+	s.queue <- simulationTask{height: height, period: period}
+}
+
+func (vc *ValidationCeremony) simulationEnabled() bool {
+	return vc.config.Validation.Simulation
+}
+
+func (vc *ValidationCeremony) simulationLoop() {
+	for {
+		var task simulationTask
+		select {
+		case <-vc.stop:
+			return
+		case task = <-vc.simulation.queue:
+		}
+		if task.height != atomic.LoadUint64(&vc.simulation.latestHeight) {
+			continue
+		}
+		vc.simulate(task.period)
+	}
+}
+
+func (vc *ValidationCeremony) simulate(period state.ValidationPeriod) {
+	if !vc.shouldInteractWithNetwork() {
+		return
+	}
+	switch period {
+	case state.NonePeriod:
+		vc.submitSyntheticFlips()
+	case state.ShortSessionPeriod:
+		vc.submitSyntheticShortAnswers()
+	case state.LongSessionPeriod:
+		vc.submitSyntheticLongAnswers()
+	}
+}
+
+func (vc *ValidationCeremony) submitSyntheticFlips() {
+	vc.simulation.mutex.Lock()
+	defer vc.simulation.mutex.Unlock()
+
+	identity := vc.appState.State.GetIdentity(vc.secStore.GetAddress())
+	if identity.RequiredFlips == 0 {
+		return
+	}
+	usedPairs := make(map[uint8]struct{})
+	for _, f := range identity.Flips {
+		usedPairs[f.Pair] = struct{}{}
+	}
+	flipsCount := len(identity.Flips) + len(vc.simulation.submittedPairs)
+	for pair := 0; pair < identity.GetTotalWordPairsCount() && flipsCount < int(identity.RequiredFlips); pair++ {
+		pairId := uint8(pair)
+		if _, ok := usedPairs[pairId]; ok {
+			continue
+		}
+		if _, ok := vc.simulation.submittedPairs[pairId]; ok {
+			continue
+		}
+		if err := vc.submitSyntheticFlip(pairId); err != nil {
+			vc.log.Warn("Cannot submit synthetic flip", "pair", pairId, "err", err)
+			return
+		}
+		vc.simulation.submittedPairs[pairId] = struct{}{}
+		flipsCount++
+	}
+}
+
+func (vc *ValidationCeremony) submitSyntheticFlip(pairId uint8) error {
+	publicPart := make([]byte, syntheticFlipPublicPartSize)
+	privatePart := make([]byte, syntheticFlipPrivatePartSize)
+	if _, err := rand.Read(publicPart); err != nil {
+		return err
+	}
+	if _, err := rand.Read(privatePart); err != nil {
+		return err
+	}
+	cid, encryptedPublicPart, encryptedPrivatePart, err := vc.flipper.PrepareFlip(publicPart, privatePart)
+	if err != nil {
+		return err
+	}
+	addr := vc.secStore.GetAddress()
+	tx := blockchain.BuildTx(vc.appState, addr, nil, types.SubmitFlipTx, decimal.Zero, decimal.Zero, decimal.Zero, 0, 0, attachments.CreateFlipSubmitAttachment(cid.Bytes(), pairId))
+	signedTx, err := vc.secStore.SignTx(tx)
+	if err != nil {
+		return err
+	}
+	if err := vc.flipper.AddNewFlip(&types.Flip{
+		Tx:          signedTx,
+		PublicPart:  encryptedPublicPart,
+		PrivatePart: encryptedPrivatePart,
+	}, true); err != nil {
+		return err
+	}
+	vc.log.Info("Synthetic flip submitted", "cid", cid.String(), "pair", pairId, "tx", signedTx.Hash().Hex())
+	return nil
+}
+
+func (vc *ValidationCeremony) submitSyntheticShortAnswers() {
+	if !vc.isParticipant() || !vc.shortSessionStarted {
+		return
+	}
+	vc.simulation.mutex.Lock()
+	defer vc.simulation.mutex.Unlock()
+	if vc.simulation.shortAnswersSubmitted {
+		return
+	}
+	coinbase := vc.secStore.GetAddress()
+	identity := vc.appState.State.GetIdentity(coinbase)
+	flips := vc.GetShortFlipsToSolve(coinbase, identity.ShiftedShardId())
+	if len(flips) == 0 {
+		return
+	}
+	if _, err := vc.SubmitShortAnswers(syntheticAnswers(len(flips))); err != nil {
+		vc.log.Warn("Cannot submit synthetic short answers", "err", err)
+		return
+	}
+	vc.simulation.shortAnswersSubmitted = true
+}
+
+func (vc *ValidationCeremony) submitSyntheticLongAnswers() {
+	if !vc.isParticipant() {
+		return
+	}
+	vc.simulation.mutex.Lock()
+	defer vc.simulation.mutex.Unlock()
+	if vc.simulation.longAnswersSubmitted {
+		return
+	}
+	coinbase := vc.secStore.GetAddress()
+	identity := vc.appState.State.GetIdentity(coinbase)
+	flips := vc.GetLongFlipsToSolve(coinbase, identity.ShiftedShardId())
+	if len(flips) == 0 {
+		return
+	}
+	if _, err := vc.SubmitLongAnswers(syntheticAnswers(len(flips))); err != nil {
+		vc.log.Warn("Cannot submit synthetic long answers", "err", err)
+		return
+	}
+	vc.simulation.longAnswersSubmitted = true
+}
+
+func syntheticAnswers(flipsCount int) *types.Answers {
+	answers := types.NewAnswers(uint(flipsCount))
+	for i := 0; i < flipsCount; i++ {
+		answers.Left(uint(i))
+	}
+	return answers
+}
@@ -0,0 +1,144 @@
+package ceremony
+
+import (
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/rcrowley/go-metrics"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	telemetryKeysReceived        = "keysReceived"
+	telemetryKeyPackagesReceived = "keyPackagesReceived"
+	telemetryFlipsFetched        = "flipsFetched"
+	telemetryFlipsFailed         = "flipsFailed"
+	telemetryFlipsDecrypted      = "flipsDecrypted"
+	telemetryAnswersSubmitted    = "answersSubmitted"
+	telemetryShortAnswersMined   = "shortAnswersMined"
+	telemetryLongAnswersMined    = "longAnswersMined"
+)
+
+type PhaseTelemetry struct {
+	KeysReceived        int64 `json:"keysReceived"`
+	KeyPackagesReceived int64 `json:"keyPackagesReceived"`
+	FlipsFetched        int64 `json:"flipsFetched"`
+	FlipsFailed         int64 `json:"flipsFailed"`
+	FlipsDecrypted      int64 `json:"flipsDecrypted"`
+	AnswersSubmitted    int64 `json:"answersSubmitted"`
+	ShortAnswersMined   int64 `json:"shortAnswersMined"`
+	LongAnswersMined    int64 `json:"longAnswersMined"`
+}
+
+type CeremonyTelemetry struct {
+	Epoch  uint16                     `json:"epoch"`
+	Phases map[string]*PhaseTelemetry `json:"phases"`
+	// Qualification progress
+	Candidates   int `json:"candidates"`
+	ShortAnswers int `json:"shortAnswers"`
+	LongAnswers  int `json:"longAnswers"`
+}
+
+// telemetry counts ceremony events of the current epoch split by validation periods,
+// totals are also exposed as "ceremony.<counter>" metrics
+type telemetry struct {
+	counters map[state.ValidationPeriod]map[string]int64
+	mutex    sync.Mutex
+	// the validation period is captured on applied blocks, the events come from other goroutines
+	// which can't read the state safely
+	period uint32
+}
+
+func newTelemetry() *telemetry {
+	return &telemetry{
+		counters: make(map[state.ValidationPeriod]map[string]int64),
+	}
+}
+
+func (t *telemetry) setPeriod(period state.ValidationPeriod) {
+	atomic.StoreUint32(&t.period, uint32(period))
+}
+
+func (t *telemetry) inc(name string) {
+	period := state.ValidationPeriod(atomic.LoadUint32(&t.period))
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	counters, ok := t.counters[period]
+	if !ok {
+		counters = make(map[string]int64)
+		t.counters[period] = counters
+	}
+	counters[name]++
+	metrics.GetOrRegisterCounter("ceremony."+name, metrics.DefaultRegistry).Inc(1)
+}
+
+func (t *telemetry) reset() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.counters = make(map[state.ValidationPeriod]map[string]int64)
+	for _, name := range []string{telemetryKeysReceived, telemetryKeyPackagesReceived, telemetryFlipsFetched, telemetryFlipsFailed,
+		telemetryFlipsDecrypted, telemetryAnswersSubmitted, telemetryShortAnswersMined, telemetryLongAnswersMined} {
+		metrics.GetOrRegisterCounter("ceremony."+name, metrics.DefaultRegistry).Clear()
+	}
+}
+
+func (t *telemetry) phases() map[string]*PhaseTelemetry {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	result := make(map[string]*PhaseTelemetry, len(t.counters))
+	for period, counters := range t.counters {
+		result[periodName(period)] = &PhaseTelemetry{
+			KeysReceived:        counters[telemetryKeysReceived],
+			KeyPackagesReceived: counters[telemetryKeyPackagesReceived],
+			FlipsFetched:        counters[telemetryFlipsFetched],
+			FlipsFailed:         counters[telemetryFlipsFailed],
+			FlipsDecrypted:      counters[telemetryFlipsDecrypted],
+			AnswersSubmitted:    counters[telemetryAnswersSubmitted],
+			ShortAnswersMined:   counters[telemetryShortAnswersMined],
+			LongAnswersMined:    counters[telemetryLongAnswersMined],
+		}
+	}
+	return result
+}
+
+func periodName(period state.ValidationPeriod) string {
+	switch period {
+	case state.NonePeriod:
+		return "None"
+	case state.FlipLotteryPeriod:
+		return "FlipLottery"
+	case state.ShortSessionPeriod:
+		return "ShortSession"
+	case state.LongSessionPeriod:
+		return "LongSession"
+	case state.AfterLongSessionPeriod:
+		return "AfterLongSession"
+	}
+	return "Unknown"
+}
+
+func (vc *ValidationCeremony) track(name string) {
+	vc.telemetry.inc(name)
+}
+
+// Telemetry returns ceremony counters of the current epoch, they make post-mortems of failed validations possible
+func (vc *ValidationCeremony) Telemetry() CeremonyTelemetry {
+	candidates := 0
+	vc.mutex.Lock()
+	for _, shard := range vc.shardCandidates {
+		candidates += len(shard.candidates)
+	}
+	vc.mutex.Unlock()
+
+	q := vc.qualification
+	q.lock.RLock()
+	shortAnswers, longAnswers := len(q.shortAnswers), len(q.longAnswers)
+	q.lock.RUnlock()
+
+	return CeremonyTelemetry{
+		Epoch:        vc.epoch,
+		Phases:       vc.telemetry.phases(),
+		Candidates:   candidates,
+		ShortAnswers: shortAnswers,
+		LongAnswers:  longAnswers,
+	}
+}
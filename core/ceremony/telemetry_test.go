@@ -0,0 +1,31 @@
+package ceremony
+
+import (
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTelemetry(t *testing.T) {
+	tm := newTelemetry()
+	tm.setPeriod(state.FlipLotteryPeriod)
+	tm.inc(telemetryFlipsFetched)
+	tm.inc(telemetryFlipsFetched)
+	tm.setPeriod(state.ShortSessionPeriod)
+	tm.inc(telemetryFlipsFailed)
+	tm.setPeriod(state.LongSessionPeriod)
+	tm.inc(telemetryAnswersSubmitted)
+
+	phases := tm.phases()
+	require.Len(t, phases, 3)
+	require.Equal(t, int64(2), phases["FlipLottery"].FlipsFetched)
+	require.Equal(t, int64(1), phases["ShortSession"].FlipsFailed)
+	require.Equal(t, int64(0), phases["ShortSession"].FlipsFetched)
+	require.Equal(t, int64(1), phases["LongSession"].AnswersSubmitted)
+
+	// the period survives the epoch reset
+	tm.reset()
+	require.Empty(t, tm.phases())
+	tm.inc(telemetryAnswersSubmitted)
+	require.Equal(t, int64(1), tm.phases()["LongSession"].AnswersSubmitted)
+}
@@ -4,13 +4,13 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
-	"fmt"
 	"github.com/golang/protobuf/proto"
 	"github.com/idena-network/idena-go/blockchain/attachments"
 	"github.com/idena-network/idena-go/blockchain/types"
 	"github.com/idena-network/idena-go/blockchain/validation"
 	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-go/common/math"
 	"github.com/idena-network/idena-go/core/appstate"
 	"github.com/idena-network/idena-go/core/mempool"
 	"github.com/idena-network/idena-go/crypto"
@@ -25,6 +25,14 @@ import (
 	"github.com/pkg/errors"
 	dbm "github.com/tendermint/tm-db"
 	"sync"
+	"time"
+)
+
+const (
+	MaxFlipLoadingWorkers = 8
+	// a missing flip is requested once again after the delay which doubles up to FlipLoadingMaxRetryDelay
+	FlipLoadingRetryDelay    = time.Second * 2
+	FlipLoadingMaxRetryDelay = time.Minute
 )
 
 var (
@@ -50,6 +58,9 @@ type Flipper struct {
 	flipsQueue       chan *types.Flip
 	flipPublicKey    *ecies.PrivateKey
 	flipPrivateKey   *ecies.PrivateKey
+	loadingListener  func(loaded bool)
+	stop             chan struct{}
+	writeLoopDone    chan struct{}
 }
 
 type IpfsFlip struct {
@@ -93,6 +104,8 @@ func NewFlipper(db dbm.DB, ipfsProxy ipfs.Proxy, keyspool *mempool.KeysPool, txp
 		cancelLoadingCtx: cancel,
 		bus:              bus,
 		flipsQueue:       make(chan *types.Flip, 1000),
+		stop:             make(chan struct{}),
+		writeLoopDone:    make(chan struct{}),
 	}
 	go fp.writeLoop()
 	return fp
@@ -103,8 +116,11 @@ func (fp *Flipper) Initialize() {
 }
 
 func (fp *Flipper) writeLoop() {
+	defer close(fp.writeLoopDone)
 	for {
 		select {
+		case <-fp.stop:
+			return
 		case flip := <-fp.flipsQueue:
 			if err := fp.addNewFlip(flip, false); err != nil && err != DuplicateFlipError {
 				fp.log.Error("invalid flip", "err", err)
@@ -186,6 +202,15 @@ func (fp *Flipper) addNewFlip(flip *types.Flip, local bool) error {
 	return nil
 }
 
+// Stop cancels the flip loading and waits for the flip being written
+func (fp *Flipper) Stop() {
+	fp.mutex.Lock()
+	fp.cancelLoadingCtx()
+	fp.mutex.Unlock()
+	close(fp.stop)
+	<-fp.writeLoopDone
+}
+
 func (fp *Flipper) AddNewFlip(flip *types.Flip, local bool) error {
 	if local {
 		return fp.addNewFlip(flip, local)
@@ -270,56 +295,103 @@ func (fp *Flipper) GetFlipPrivateEncryptionKey() *ecies.PrivateKey {
 }
 
 func (fp *Flipper) generateFlipEncryptionKey(public bool) *ecies.PrivateKey {
-	var seed []byte
-	if public {
-		seed = []byte(fmt.Sprintf("flip-key-for-epoch-%v", fp.appState.State.Epoch()))
-	} else {
-		seed = []byte(fmt.Sprintf("flip-private-key-for-epoch-%v", fp.appState.State.Epoch()))
-	}
-
-	hash := common.Hash(crypto.Hash(seed))
-
-	sig := fp.secStore.Sign(hash.Bytes())
-
-	flipKey, _ := crypto.GenerateKeyFromSeed(bytes.NewReader(sig))
-
-	return ecies.ImportECDSA(flipKey)
+	return generateFlipEncryptionKey(fp.secStore.Sign, fp.appState.State.Epoch(), public)
 }
 
 func (fp *Flipper) LoadInMemory(cids [][]byte) {
+	if len(cids) == 0 {
+		return
+	}
 	ctx := fp.loadingCtx
 
-	for len(cids) > 0 {
+	type loadingTask struct {
+		key   []byte
+		delay time.Duration
+	}
+	// every task is either in the queue, being processed or waiting for a retry, so the buffers never overflow
+	queue := make(chan loadingTask, len(cids))
+	for _, key := range cids {
+		queue <- loadingTask{key: key}
+	}
+	results := make(chan struct{}, len(cids))
+	stop := make(chan struct{})
+	defer close(stop)
+
+	workers := math.MinInt(MaxFlipLoadingWorkers, len(cids))
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-stop:
+					return
+				case task := <-queue:
+					if fp.loadFlip(task.key) {
+						results <- struct{}{}
+						continue
+					}
+					// flips are required for the ceremony, so a missing flip is retried until the loading is cancelled
+					task.delay *= 2
+					if task.delay < FlipLoadingRetryDelay {
+						task.delay = FlipLoadingRetryDelay
+					}
+					if task.delay > FlipLoadingMaxRetryDelay {
+						task.delay = FlipLoadingMaxRetryDelay
+					}
+					time.AfterFunc(task.delay, func() {
+						queue <- task
+					})
+				}
+			}
+		}()
+	}
 
+	for loaded := 0; loaded < len(cids); loaded++ {
 		select {
 		case <-ctx.Done():
+			fp.log.Warn("Flips loading is cancelled", "loaded", loaded, "total", len(cids))
 			return
-		default:
+		case <-results:
 		}
+	}
+	fp.log.Info("All flips were loaded")
+}
 
-		key := cids[0]
-		cids = cids[1:]
+// SetLoadingListener sets the callback invoked after every attempt to load a flip into memory
+func (fp *Flipper) SetLoadingListener(listener func(loaded bool)) {
+	fp.loadingListener = listener
+}
 
-		cid, _ := cid.Cast(key)
+func (fp *Flipper) notifyLoading(loaded bool) {
+	if fp.loadingListener != nil {
+		fp.loadingListener(loaded)
+	}
+}
 
-		data, err := fp.ipfsProxy.Get(key, ipfs.Flip)
+// loadFlip returns true if the flip is received and decoded
+func (fp *Flipper) loadFlip(key []byte) bool {
+	cid, _ := cid.Cast(key)
 
-		if err != nil {
-			fp.log.Warn("Can't get flip by cid", "cid", cid.String(), "err", err)
-			cids = append(cids, key)
-			continue
-		}
+	data, err := fp.ipfsProxy.Get(key, ipfs.Flip)
 
-		ipfsFlip := new(IpfsFlip)
-		if err := ipfsFlip.FromBytes(data); err != nil {
-			fp.log.Warn("Can't decode flip", "cid", cid.String(), "err", err)
-			continue
-		}
-		fp.mutex.Lock()
-		fp.flips[common.Hash(crypto.Hash(key))] = ipfsFlip
-		fp.mutex.Unlock()
+	if err != nil {
+		fp.log.Warn("Can't get flip by cid", "cid", cid.String(), "err", err)
+		fp.notifyLoading(false)
+		return false
 	}
-	fp.log.Info("All flips were loaded")
+
+	ipfsFlip := new(IpfsFlip)
+	if err := ipfsFlip.FromBytes(data); err != nil {
+		fp.log.Warn("Can't decode flip", "cid", cid.String(), "err", err)
+		fp.notifyLoading(false)
+		return false
+	}
+	fp.mutex.Lock()
+	fp.flips[common.Hash(crypto.Hash(key))] = ipfsFlip
+	fp.mutex.Unlock()
+	fp.notifyLoading(true)
+	return true
 }
 
 func (fp *Flipper) Clear() {
@@ -344,8 +416,8 @@ func (fp *Flipper) HasFlipInMemory(hash common.Hash) bool {
 }
 
 func (fp *Flipper) SetFlipReadiness(hash common.Hash) {
-	fp.mutex.RLock()
-	defer fp.mutex.RUnlock()
+	fp.mutex.Lock()
+	defer fp.mutex.Unlock()
 
 	fp.flipReadiness[hash] = true
 }
@@ -0,0 +1,138 @@
+package flip
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"github.com/idena-network/idena-go/blockchain"
+	"github.com/idena-network/idena-go/blockchain/attachments"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/hexutil"
+	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-go/crypto/ecies"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// FlipPackage is a flip prepared and encrypted offline by the identity key owner, the node publishes it to IPFS and submits it
+type FlipPackage struct {
+	Epoch                uint16        `json:"epoch"`
+	PairId               uint8         `json:"pairId"`
+	PubKey               hexutil.Bytes `json:"pubKey"`
+	EncryptedPublicPart  hexutil.Bytes `json:"encryptedPublicPart"`
+	EncryptedPrivatePart hexutil.Bytes `json:"encryptedPrivatePart"`
+	// Optional signed submit flip tx, the node builds and signs the tx by the coinbase key if it is omitted
+	Tx hexutil.Bytes `json:"tx,omitempty"`
+}
+
+func (p *FlipPackage) ToBytes() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+func (p *FlipPackage) FromBytes(data []byte) error {
+	return json.Unmarshal(data, p)
+}
+
+// BuildFlipPackage encrypts the flip with the epoch flip keys of the given identity key,
+// it doesn't require access to the node and may be used by offline tools
+func BuildFlipPackage(key *ecdsa.PrivateKey, epoch uint16, pairId uint8, publicPart []byte, privatePart []byte) (*FlipPackage, error) {
+	sign := func(hash []byte) []byte {
+		sig, _ := crypto.Sign(hash, key)
+		return sig
+	}
+	publicEncryptionKey := generateFlipEncryptionKey(sign, epoch, true)
+	privateEncryptionKey := generateFlipEncryptionKey(sign, epoch, false)
+
+	encryptedPublic, err := ecies.Encrypt(rand.Reader, &publicEncryptionKey.PublicKey, publicPart, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var encryptedPrivate []byte
+	if len(privatePart) > 0 {
+		encryptedPrivate, err = ecies.Encrypt(rand.Reader, &privateEncryptionKey.PublicKey, privatePart, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &FlipPackage{
+		Epoch:                epoch,
+		PairId:               pairId,
+		PubKey:               crypto.FromECDSAPub(&key.PublicKey),
+		EncryptedPublicPart:  encryptedPublic,
+		EncryptedPrivatePart: encryptedPrivate,
+	}, nil
+}
+
+func generateFlipEncryptionKey(sign func(hash []byte) []byte, epoch uint16, public bool) *ecies.PrivateKey {
+	var seed []byte
+	if public {
+		seed = []byte(fmt.Sprintf("flip-key-for-epoch-%v", epoch))
+	} else {
+		seed = []byte(fmt.Sprintf("flip-private-key-for-epoch-%v", epoch))
+	}
+
+	hash := common.Hash(crypto.Hash(seed))
+
+	sig := sign(hash.Bytes())
+
+	flipKey, _ := crypto.GenerateKeyFromSeed(bytes.NewReader(sig))
+
+	return ecies.ImportECDSA(flipKey)
+}
+
+// ImportFlipPackage publishes the offline prepared flip to IPFS and submits it
+func (fp *Flipper) ImportFlipPackage(p *FlipPackage) (*types.Flip, error) {
+	if p.Epoch != fp.appState.State.Epoch() {
+		return nil, errors.Errorf("flip package epoch mismatch, expected %v, actual %v", fp.appState.State.Epoch(), p.Epoch)
+	}
+	if len(p.EncryptedPublicPart) == 0 {
+		return nil, errors.New("flip package is empty")
+	}
+
+	var tx *types.Transaction
+	if len(p.Tx) > 0 {
+		tx = new(types.Transaction)
+		if err := tx.FromBytes(p.Tx); err != nil {
+			return nil, errors.Wrap(err, "flip package tx is invalid")
+		}
+		if tx.Type != types.SubmitFlipTx {
+			return nil, errors.New("flip package tx is not a submit flip tx")
+		}
+		pubKey, err := types.SenderPubKey(tx)
+		if err != nil || !bytes.Equal(pubKey, p.PubKey) {
+			return nil, errors.New("flip package tx sender mismatch")
+		}
+	} else {
+		if !bytes.Equal(p.PubKey, fp.secStore.GetPubKey()) {
+			return nil, errors.New("flip package is prepared for another identity, signed tx is required")
+		}
+		ipf := &IpfsFlip{
+			PublicPart:  p.EncryptedPublicPart,
+			PrivatePart: p.EncryptedPrivatePart,
+			PubKey:      p.PubKey,
+		}
+		ipfsData, _ := ipf.ToBytes()
+		c, err := fp.ipfsProxy.Cid(ipfsData)
+		if err != nil {
+			return nil, err
+		}
+		unsignedTx := blockchain.BuildTx(fp.appState, fp.secStore.GetAddress(), nil, types.SubmitFlipTx, decimal.Zero, decimal.Zero, decimal.Zero, 0, 0, attachments.CreateFlipSubmitAttachment(c.Bytes(), p.PairId))
+		tx, err = fp.secStore.SignTx(unsignedTx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	flip := &types.Flip{
+		Tx:          tx,
+		PublicPart:  p.EncryptedPublicPart,
+		PrivatePart: p.EncryptedPrivatePart,
+	}
+	if err := fp.AddNewFlip(flip, true); err != nil {
+		return nil, err
+	}
+	return flip, nil
+}
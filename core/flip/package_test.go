@@ -0,0 +1,68 @@
+package flip
+
+import (
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-go/core/appstate"
+	"github.com/idena-network/idena-go/crypto"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+	"testing"
+)
+
+func TestBuildFlipPackage(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sign := func(hash []byte) []byte {
+		sig, _ := crypto.Sign(hash, key)
+		return sig
+	}
+	p, err := BuildFlipPackage(key, 3, 1, []byte{0x1, 0x2}, []byte{0x3})
+	require.NoError(t, err)
+	require.Equal(t, crypto.FromECDSAPub(&key.PublicKey), []byte(p.PubKey))
+
+	data, err := p.ToBytes()
+	require.NoError(t, err)
+	restored := new(FlipPackage)
+	require.NoError(t, restored.FromBytes(data))
+	require.Equal(t, p, restored)
+
+	// the parts are decrypted with the epoch keys the node derives for its own flips
+	publicPart, err := generateFlipEncryptionKey(sign, 3, true).Decrypt(restored.EncryptedPublicPart, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x1, 0x2}, publicPart)
+	privatePart, err := generateFlipEncryptionKey(sign, 3, false).Decrypt(restored.EncryptedPrivatePart, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x3}, privatePart)
+
+	_, err = generateFlipEncryptionKey(sign, 4, true).Decrypt(restored.EncryptedPublicPart, nil, nil)
+	require.Error(t, err)
+}
+
+func TestFlipper_ImportFlipPackageValidation(t *testing.T) {
+	appState, _ := appstate.NewAppState(dbm.NewMemDB(), eventbus.New())
+	appState.State.IncEpoch()
+	appState.State.Commit(true)
+	fp := &Flipper{appState: appState}
+	key, _ := crypto.GenerateKey()
+
+	p, _ := BuildFlipPackage(key, 0, 0, []byte{0x1}, nil)
+	_, err := fp.ImportFlipPackage(p)
+	require.Error(t, err)
+
+	p, _ = BuildFlipPackage(key, 1, 0, []byte{0x1}, nil)
+	p.EncryptedPublicPart = nil
+	_, err = fp.ImportFlipPackage(p)
+	require.Error(t, err)
+
+	p, _ = BuildFlipPackage(key, 1, 0, []byte{0x1}, nil)
+	tx, _ := types.SignTx(&types.Transaction{Type: types.SendTx, Epoch: 1}, key)
+	p.Tx, _ = tx.ToBytes()
+	_, err = fp.ImportFlipPackage(p)
+	require.EqualError(t, err, "flip package tx is not a submit flip tx")
+
+	other, _ := crypto.GenerateKey()
+	tx, _ = types.SignTx(&types.Transaction{Type: types.SubmitFlipTx, Epoch: 1}, other)
+	p.Tx, _ = tx.ToBytes()
+	_, err = fp.ImportFlipPackage(p)
+	require.EqualError(t, err, "flip package tx sender mismatch")
+}
@@ -29,6 +29,7 @@ const (
 var (
 	DuplicateTxError = errors.New("tx with same hash already exists")
 	MempoolFullError = errors.New("mempool is full")
+	StoppedError     = errors.New("mempool is stopped")
 	priorityTypes    = validation.CeremonialTxs
 )
 
@@ -62,6 +63,10 @@ type TxPool struct {
 	statsCollector   collector.StatsCollector
 	txKeeper         *txKeeper
 	pushTracker      pushpull.PendingPushTracker
+	// calls reading the app state are tracked, so the database isn't closed under them
+	stopMutex sync.Mutex
+	stopped   bool
+	inflight  sync.WaitGroup
 }
 
 func NewTxPool(appState *appstate.AppState, bus eventbus.Bus, cfg *config.Config, statsCollector collector.StatsCollector) *TxPool {
@@ -170,8 +175,35 @@ func (pool *TxPool) addDeferredTx(tx *types.Transaction) {
 	pool.knownDeferredTxs.Add(tx.Hash())
 }
 
+// enter returns false if the pool is stopped, otherwise the call must be completed with leave
+func (pool *TxPool) enter() bool {
+	pool.stopMutex.Lock()
+	defer pool.stopMutex.Unlock()
+	if pool.stopped {
+		return false
+	}
+	pool.inflight.Add(1)
+	return true
+}
+
+func (pool *TxPool) leave() {
+	pool.inflight.Done()
+}
+
+// Stop rejects new transactions and waits for the transactions being added
+func (pool *TxPool) Stop() {
+	pool.stopMutex.Lock()
+	pool.stopped = true
+	pool.stopMutex.Unlock()
+	pool.inflight.Wait()
+}
+
 // validate tx as inbound transaction
 func (pool *TxPool) Validate(tx *types.Transaction) error {
+	if !pool.enter() {
+		return StoppedError
+	}
+	defer pool.leave()
 	if _, ok := pool.all.Get(tx.Hash()); ok {
 		return DuplicateTxError
 	}
@@ -250,6 +282,10 @@ func (pool *TxPool) validate(tx *types.Transaction, appState *appstate.AppState,
 }
 
 func (pool *TxPool) AddExternalTxs(txType validation.TxType, txs ...*types.Transaction) error {
+	if !pool.enter() {
+		return StoppedError
+	}
+	defer pool.leave()
 	appState, err := pool.appState.Readonly(pool.head.Height())
 
 	if err != nil {
@@ -286,6 +322,10 @@ func (pool *TxPool) AddExternalTxs(txType validation.TxType, txs ...*types.Trans
 }
 
 func (pool *TxPool) AddInternalTx(tx *types.Transaction) error {
+	if !pool.enter() {
+		return StoppedError
+	}
+	defer pool.leave()
 	tx.SetHighPriority(true)
 	if pool.IsSyncing() {
 		pool.addDeferredTx(tx)
@@ -443,6 +483,11 @@ func (pool *TxPool) put(tx *types.Transaction) error {
 	return nil
 }
 
+// Size returns the number of transactions in the pool
+func (pool *TxPool) Size() int {
+	return pool.all.Len()
+}
+
 func (pool *TxPool) GetPriorityTransaction() []*types.Transaction {
 	all := pool.all.List(Priority)
 	var result []*types.Transaction
@@ -806,6 +851,12 @@ func (m *txMap) Remove(hash common.Hash) {
 	delete(m.txs, hash)
 }
 
+func (m *txMap) Len() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.txs)
+}
+
 func (m *txMap) Empty() bool {
 	return len(m.txs) == 0
 }
@@ -133,7 +133,7 @@ func (s *IdentityStateDB) LoadPreliminary(height uint64) (*IdentityStateDB, erro
 }
 
 func (s *IdentityStateDB) Load(height uint64) error {
-	_, err := s.tree.LoadVersion(int64(height))
+	_, err := s.tree.LoadRecentVersion(int64(height))
 	return err
 }
 
@@ -455,6 +455,10 @@ func (s *IdentityStateDB) SetPredefinedIdentities(state *models.ProtoPredefinedS
 	}
 }
 
+func (s *IdentityStateDB) WriteSnapshot2(height uint64, to io.Writer) (root common.Hash, err error) {
+	return WriteTreeTo2(s.db, height, to)
+}
+
 func (s *IdentityStateDB) RecoverSnapshot2(height uint64, treeRoot common.Hash, from io.Reader) error {
 	pdb := dbm.NewPrefixDB(s.original, IdentityStateDbKeys.buildDbPrefix(height))
 	return ReadTreeFrom2(pdb, height, treeRoot, from)
@@ -126,6 +126,13 @@ func (m *SnapshotManager) createSnapshot(height uint64) (root common.Hash) {
 	if cidV2 != nil {
 		m.clearFs([]string{filePath})
 		m.writeLastManifest(cidV2, root, height, filePath)
+		m.bus.Publish(&events.NewSnapshotEvent{
+			Manifest: &snapshot.Manifest{
+				CidV2:  cidV2,
+				Root:   root,
+				Height: height,
+			},
+		})
 	}
 	return root
 }
@@ -3,19 +3,31 @@ package state
 import (
 	"github.com/idena-network/idena-go/common"
 	"sync"
+	"time"
 )
 
+// NonceReservationTtl is the time after the last reservation of the sender when its unused reserved nonces are released
+const NonceReservationTtl = 5 * time.Minute
+
 type account struct {
 	stateObject *stateAccount
 	nonce       uint32
 }
 
+// reservation is the last nonce reserved for the sender, it survives the cache clearing on new blocks
+type reservation struct {
+	epoch   uint16
+	nonce   uint32
+	expires time.Time
+}
+
 type NonceCache struct {
 	fallback *StateDB
 
 	mu sync.Mutex
 
 	accounts map[common.Address]map[uint16]*account
+	reserved map[common.Address]*reservation
 }
 
 func NewNonceCache(sdb *StateDB) (*NonceCache, error) {
@@ -26,6 +38,7 @@ func NewNonceCache(sdb *StateDB) (*NonceCache, error) {
 	return &NonceCache{
 		fallback: readonly,
 		accounts: make(map[common.Address]map[uint16]*account),
+		reserved: make(map[common.Address]*reservation),
 	}, nil
 }
 
@@ -38,6 +51,49 @@ func (ns *NonceCache) GetNonce(addr common.Address, epoch uint16) uint32 {
 	return ns.getAccount(addr, epoch).nonce
 }
 
+// ReservedNonce returns the last nonce reserved for the sender or the canonical one if there are no active reservations
+func (ns *NonceCache) ReservedNonce(addr common.Address, epoch uint16) uint32 {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	return ns.lastNonce(addr, epoch)
+}
+
+// ReserveNonce returns the next nonce of the sender after the pending and the previously reserved ones,
+// so the concurrent senders from one address get distinct nonces
+func (ns *NonceCache) ReserveNonce(addr common.Address, epoch uint16) uint32 {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	nonce := ns.lastNonce(addr, epoch) + 1
+	ns.reserved[addr] = &reservation{
+		epoch:   epoch,
+		nonce:   nonce,
+		expires: time.Now().Add(NonceReservationTtl),
+	}
+	return nonce
+}
+
+func (ns *NonceCache) lastNonce(addr common.Address, epoch uint16) uint32 {
+	nonce := ns.getAccount(addr, epoch).nonce
+	r, ok := ns.reserved[addr]
+	if !ok {
+		return nonce
+	}
+	if r.epoch != epoch {
+		if r.epoch < epoch {
+			delete(ns.reserved, addr)
+		}
+		return nonce
+	}
+	// the reservations are released when they are used or expired
+	if r.nonce <= nonce || time.Now().After(r.expires) {
+		delete(ns.reserved, addr)
+		return nonce
+	}
+	return r.nonce
+}
+
 func (ns *NonceCache) Lock() {
 	ns.mu.Lock()
 }
@@ -46,15 +102,32 @@ func (ns *NonceCache) UnLock() {
 	ns.mu.Unlock()
 }
 
+// ReloadFallback switches the cache to the new state on a new block and releases the reservations which are expired
+// or used by the state, so the reservations of inactive senders don't accumulate
 func (ns *NonceCache) ReloadFallback(sdb *StateDB) error {
 	readonly, err := sdb.Readonly(-1)
 	if err != nil {
 		return err
 	}
 	ns.fallback = readonly
+	ns.pruneReservations()
 	return nil
 }
 
+func (ns *NonceCache) pruneReservations() {
+	now := time.Now()
+	epoch := ns.fallback.Epoch()
+	for addr, r := range ns.reserved {
+		if r.epoch < epoch || now.After(r.expires) {
+			delete(ns.reserved, addr)
+			continue
+		}
+		if so := ns.fallback.getStateAccount(addr); so != nil && so.Epoch() == r.epoch && so.Nonce() >= r.nonce {
+			delete(ns.reserved, addr)
+		}
+	}
+}
+
 // SetNonce sets the new canonical nonce for the managed state
 func (ns *NonceCache) SetNonce(addr common.Address, txEpoch uint16, nonce uint32) {
 	ns.mu.Lock()
@@ -4,6 +4,7 @@ import (
 	"github.com/idena-network/idena-go/common"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 import dbm "github.com/tendermint/tm-db"
 
@@ -40,3 +41,63 @@ func TestNonceCache_Set_And_Get_Nonce(t *testing.T) {
 	require.Equal(uint32(0), ns.GetNonce(addr, epoch+2))
 
 }
+
+func TestNonceCache_ReserveNonce(t *testing.T) {
+	require := require.New(t)
+
+	stateDb, _ := NewLazy(dbm.NewMemDB())
+	stateDb.IncEpoch()
+	epoch := uint16(1)
+	addr := common.Address{0x1}
+	stateDb.SetNonce(addr, 5)
+	stateDb.SetEpoch(addr, epoch)
+	stateDb.Commit(false)
+
+	ns, _ := NewNonceCache(stateDb)
+	ns.SetNonce(addr, epoch, 6)
+	require.Equal(uint32(7), ns.ReserveNonce(addr, epoch))
+	require.Equal(uint32(8), ns.ReserveNonce(addr, epoch))
+	require.Equal(uint32(8), ns.ReservedNonce(addr, epoch))
+	// the canonical nonce ignores the reservations
+	require.Equal(uint32(6), ns.GetNonce(addr, epoch))
+
+	// the reservations survive the cache clearing
+	ns.Clear()
+	require.Equal(uint32(9), ns.ReserveNonce(addr, epoch))
+
+	// the used reservations are released
+	ns.SetNonce(addr, epoch, 10)
+	require.Equal(uint32(10), ns.ReservedNonce(addr, epoch))
+	require.Empty(ns.reserved)
+
+	// the unused reservations expire
+	ns.ReserveNonce(addr, epoch)
+	ns.reserved[addr].expires = time.Now().Add(-time.Second)
+	require.Equal(uint32(10), ns.ReservedNonce(addr, epoch))
+}
+
+func TestNonceCache_ReloadFallbackPrunesReservations(t *testing.T) {
+	require := require.New(t)
+
+	stateDb, _ := NewLazy(dbm.NewMemDB())
+	stateDb.IncEpoch()
+	epoch := uint16(1)
+	used, expired, active := common.Address{0x1}, common.Address{0x2}, common.Address{0x3}
+	for _, addr := range []common.Address{used, expired, active} {
+		stateDb.SetEpoch(addr, epoch)
+	}
+	stateDb.Commit(false)
+
+	ns, _ := NewNonceCache(stateDb)
+	require.Equal(uint32(1), ns.ReserveNonce(used, epoch))
+	ns.ReserveNonce(expired, epoch)
+	ns.reserved[expired].expires = time.Now().Add(-time.Second)
+	ns.ReserveNonce(active, epoch)
+
+	// the new block includes the reserved nonce of the first sender
+	stateDb.SetNonce(used, 1)
+	stateDb.Commit(false)
+	require.NoError(ns.ReloadFallback(stateDb))
+	require.Len(ns.reserved, 1)
+	require.Contains(ns.reserved, active)
+}
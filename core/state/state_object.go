@@ -59,6 +59,29 @@ func (f DiscriminationFlag) HasFlag(flag DiscriminationFlag) bool {
 	return f&flag != 0
 }
 
+func (s IdentityState) String() string {
+	switch s {
+	case Invite:
+		return "Invite"
+	case Candidate:
+		return "Candidate"
+	case Newbie:
+		return "Newbie"
+	case Verified:
+		return "Verified"
+	case Suspended:
+		return "Suspended"
+	case Zombie:
+		return "Zombie"
+	case Killed:
+		return "Killed"
+	case Human:
+		return "Human"
+	default:
+		return "Undefined"
+	}
+}
+
 func (s IdentityState) IsInShard() bool {
 	return s.NewbieOrBetter() || s == Candidate || s == Suspended || s == Zombie
 }
@@ -185,7 +185,7 @@ func (s *StateDB) Readonly(height int64) (*StateDB, error) {
 }
 
 func (s *StateDB) Load(height uint64) error {
-	_, err := s.tree.LoadVersion(int64(height))
+	_, err := s.tree.LoadRecentVersion(int64(height))
 	return err
 }
 
@@ -1489,9 +1489,91 @@ func (s *StateDB) Root() common.Hash {
 }
 
 func (s *StateDB) IterateIdentities(fn func(key []byte, value []byte) bool) bool {
-	start := StateDbKeys.IdentityKey(common.MinAddr)
+	return s.IterateIdentitiesFrom(common.MinAddr, fn)
+}
+
+// IterateIdentitiesFrom iterates the identities in the address order starting with the address (inclusive)
+func (s *StateDB) IterateIdentitiesFrom(start common.Address, fn func(key []byte, value []byte) bool) bool {
 	end := StateDbKeys.IdentityKey(common.MaxAddr)
-	return s.tree.GetImmutable().IterateRange(start, end, true, fn)
+	return s.tree.GetImmutable().IterateRange(StateDbKeys.IdentityKey(start), end, true, fn)
+}
+
+// BalanceChange is the balance and the stake of the address before and after the state diff
+type BalanceChange struct {
+	Address     common.Address
+	PrevBalance *big.Int
+	Balance     *big.Int
+	PrevStake   *big.Int
+	Stake       *big.Int
+}
+
+// BalanceChanges compares the balances and the stakes in the diff with the current tree, so it must be called
+// before the diff is added. The addresses with the same balance and stake are skipped
+func (s *StateDB) BalanceChanges(diff []*StateTreeDiff) []*BalanceChange {
+	tree := s.tree.GetImmutable()
+	changes := make(map[common.Address]*BalanceChange)
+	getChange := func(addr common.Address) *BalanceChange {
+		change, ok := changes[addr]
+		if !ok {
+			change = &BalanceChange{Address: addr}
+			_, prevAccount := tree.Get(StateDbKeys.AddressKey(addr))
+			change.PrevBalance = accountBalance(prevAccount)
+			change.Balance = change.PrevBalance
+			_, prevIdentity := tree.Get(StateDbKeys.IdentityKey(addr))
+			change.PrevStake = identityStake(prevIdentity)
+			change.Stake = change.PrevStake
+			changes[addr] = change
+		}
+		return change
+	}
+	var addresses []common.Address
+	for _, item := range diff {
+		if len(item.Key) != len(addressPrefix)+common.AddressLength {
+			continue
+		}
+		var value []byte
+		if !item.Deleted {
+			value = item.Value
+		}
+		switch {
+		case bytes.HasPrefix(item.Key, addressPrefix):
+			addr := StateDbKeys.AddressKeyToAddress(item.Key)
+			if _, ok := changes[addr]; !ok {
+				addresses = append(addresses, addr)
+			}
+			getChange(addr).Balance = accountBalance(value)
+		case bytes.HasPrefix(item.Key, identityPrefix):
+			addr := StateDbKeys.IdentityKeyToAddress(item.Key)
+			if _, ok := changes[addr]; !ok {
+				addresses = append(addresses, addr)
+			}
+			getChange(addr).Stake = identityStake(value)
+		}
+	}
+	var result []*BalanceChange
+	for _, addr := range addresses {
+		change := changes[addr]
+		if change.Balance.Cmp(change.PrevBalance) != 0 || change.Stake.Cmp(change.PrevStake) != 0 {
+			result = append(result, change)
+		}
+	}
+	return result
+}
+
+func accountBalance(data []byte) *big.Int {
+	var account Account
+	if len(data) == 0 || account.FromBytes(data) != nil || account.Balance == nil {
+		return new(big.Int)
+	}
+	return account.Balance
+}
+
+func identityStake(data []byte) *big.Int {
+	var identity Identity
+	if len(data) == 0 || identity.FromBytes(data) != nil || identity.Stake == nil {
+		return new(big.Int)
+	}
+	return identity.Stake
 }
 
 func (s *StateDB) IterateAccounts(fn func(key []byte, value []byte) bool) bool {
@@ -1558,6 +1640,10 @@ func (s *StateDB) GetIdentityWithProof(addr common.Address) ([]byte, error) {
 	return s.tree.GetImmutable().GetWithProof(StateDbKeys.IdentityKey(addr))
 }
 
+func (s *StateDB) GetAccountWithProof(addr common.Address) ([]byte, error) {
+	return s.tree.GetImmutable().GetWithProof(StateDbKeys.AddressKey(addr))
+}
+
 func (s *StateDB) IterateOverIdentities(callback func(addr common.Address, identity Identity)) {
 	s.IterateIdentities(func(key []byte, value []byte) bool {
 		if key == nil {
@@ -575,3 +575,39 @@ func TestStateDb_AddNewScore(t *testing.T) {
 		require.Equal(t, uint32(6), flips)
 	}
 }
+
+func TestStateDB_BalanceChanges(t *testing.T) {
+	prepare := func() *StateDB {
+		stateDb, _ := NewLazy(db.NewMemDB())
+		stateDb.SetBalance(common.Address{0x1}, big.NewInt(10))
+		stateDb.SetBalance(common.Address{0x2}, big.NewInt(20))
+		stateDb.SetState(common.Address{0x2}, Verified)
+		stateDb.AddStake(common.Address{0x2}, big.NewInt(5))
+		stateDb.SetBalance(common.Address{0x3}, big.NewInt(30))
+		_, _, _, err := stateDb.Commit(true)
+		require.NoError(t, err)
+		return stateDb
+	}
+	stateDb, changed := prepare(), prepare()
+
+	changed.SubBalance(common.Address{0x1}, big.NewInt(10))
+	changed.AddStake(common.Address{0x2}, big.NewInt(1))
+	changed.SetNonce(common.Address{0x3}, 1)
+	changed.SetBalance(common.Address{0x4}, big.NewInt(40))
+	diff := changed.Precommit(true)
+
+	changes := stateDb.BalanceChanges(diff)
+	require.Len(t, changes, 3)
+	byAddress := make(map[common.Address]*BalanceChange)
+	for _, change := range changes {
+		byAddress[change.Address] = change
+	}
+	require.Equal(t, int64(10), byAddress[common.Address{0x1}].PrevBalance.Int64())
+	require.Equal(t, int64(0), byAddress[common.Address{0x1}].Balance.Int64())
+	require.Equal(t, int64(5), byAddress[common.Address{0x2}].PrevStake.Int64())
+	require.Equal(t, int64(6), byAddress[common.Address{0x2}].Stake.Int64())
+	require.Equal(t, int64(20), byAddress[common.Address{0x2}].Balance.Int64())
+	require.Equal(t, int64(0), byAddress[common.Address{0x4}].PrevBalance.Int64())
+	require.Equal(t, int64(40), byAddress[common.Address{0x4}].Balance.Int64())
+	require.Nil(t, byAddress[common.Address{0x3}])
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/idena-network/idena-go/common"
 	models "github.com/idena-network/idena-go/protobuf"
+	"github.com/pkg/errors"
 	dbm "github.com/tendermint/tm-db"
 	"sync"
 )
@@ -14,6 +15,7 @@ type Tree interface {
 	Set(key, value []byte) bool
 	Remove(key []byte) ([]byte, bool)
 	LoadVersion(targetVersion int64) (int64, error)
+	LoadRecentVersion(targetVersion int64) (int64, error)
 	Load() (int64, error)
 	SaveVersion() ([]byte, int64, error)
 	DeleteVersion(version int64) error
@@ -30,8 +32,23 @@ type Tree interface {
 	ValidateTree() bool
 }
 
+var (
+	treeCacheSize = 1024
+	lazyLoad      bool
+)
+
+// SetTreeCacheSize sets the number of nodes kept in the LRU cache of the trees created after the call
+func SetTreeCacheSize(size int) {
+	treeCacheSize = size
+}
+
+// SetLazyLoad makes the state trees read only the roots of the recent versions on load
+func SetLazyLoad(lazy bool) {
+	lazyLoad = lazy
+}
+
 func NewMutableTree(db dbm.DB) *MutableTree {
-	tree, err := iavl.NewMutableTree(db, 1024)
+	tree, err := iavl.NewMutableTree(db, treeCacheSize)
 	if err != nil {
 		panic(err)
 	}
@@ -139,6 +156,26 @@ func (t *MutableTree) LoadVersion(targetVersion int64) (int64, error) {
 	return t.tree.LoadVersion(targetVersion)
 }
 
+// LoadRecentVersion loads the version of the tree. In the lazy mode only the roots of the target version
+// and of the versions kept for the readonly states are read, so the old versions are still pruned
+func (t *MutableTree) LoadRecentVersion(targetVersion int64) (int64, error) {
+	if !lazyLoad {
+		return t.LoadVersion(targetVersion)
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	version, err := t.tree.LazyLoadVersion(targetVersion)
+	if err != nil {
+		return version, err
+	}
+	for v := version - MaxSavedStatesCount; v < version; v++ {
+		if v > 0 {
+			t.tree.VersionExists(v)
+		}
+	}
+	return version, nil
+}
+
 func (t *MutableTree) SaveVersion() ([]byte, int64, error) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -254,6 +291,10 @@ func (t *ImmutableTree) LoadVersion(targetVersion int64) (int64, error) {
 	panic("Not implemented")
 }
 
+func (t *ImmutableTree) LoadRecentVersion(targetVersion int64) (int64, error) {
+	panic("Not implemented")
+}
+
 func (t *ImmutableTree) SaveVersion() ([]byte, int64, error) {
 	panic("Not implemented")
 }
@@ -320,3 +361,37 @@ func (t *ImmutableTree) GetWithProof(key []byte) ([]byte, error) {
 		Proof: proof.LeftPath,
 	}).toBytes()
 }
+
+// VerifyValueWithProof checks the proof produced by GetWithProof against the state root and returns the proven value
+func VerifyValueWithProof(root common.Hash, key []byte, data []byte) ([]byte, error) {
+	protoObj := new(models.ValueWithProof)
+	if err := proto.Unmarshal(data, protoObj); err != nil {
+		return nil, err
+	}
+	if protoObj.Leaf == nil {
+		return nil, errors.New("proof leaf is missing")
+	}
+	proof := &iavl.RangeProof{
+		Leaves: []iavl.ProofLeafNode{{
+			Key:       protoObj.Leaf.Key,
+			ValueHash: protoObj.Leaf.ValueHash,
+			Version:   int64(protoObj.Leaf.Version),
+		}},
+	}
+	for _, item := range protoObj.Proof {
+		proof.LeftPath = append(proof.LeftPath, iavl.ProofInnerNode{
+			Height:  int8(item.Height),
+			Size:    int64(item.Size),
+			Version: int64(item.Version),
+			Left:    item.Left,
+			Right:   item.Right,
+		})
+	}
+	if err := proof.Verify(root.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := proof.VerifyItem(key, protoObj.Value); err != nil {
+		return nil, err
+	}
+	return protoObj.Value, nil
+}
@@ -18,6 +18,32 @@ func TestMutableTree_Hash(t *testing.T) {
 	require.NotEqual(t, common.Hash{}, tree.WorkingHash())
 }
 
+func TestImmutableTree_GetWithProof(t *testing.T) {
+	db := dbm.NewMemDB()
+	tree := NewMutableTree(db)
+	for i := byte(0); i < 50; i++ {
+		tree.Set([]byte{i}, []byte{i, i})
+	}
+	_, _, err := tree.SaveVersion()
+	require.NoError(t, err)
+	root := tree.Hash()
+
+	proof, err := tree.GetImmutable().GetWithProof([]byte{10})
+	require.NoError(t, err)
+	value, err := VerifyValueWithProof(root, []byte{10}, proof)
+	require.NoError(t, err)
+	require.Equal(t, []byte{10, 10}, value)
+
+	_, err = VerifyValueWithProof(root, []byte{11}, proof)
+	require.Error(t, err)
+	_, err = VerifyValueWithProof(common.Hash{0x1}, []byte{10}, proof)
+	require.Error(t, err)
+
+	proof, err = tree.GetImmutable().GetWithProof([]byte{100})
+	require.NoError(t, err)
+	require.Nil(t, proof)
+}
+
 func TestMutableTree_Root(t *testing.T) {
 	db := dbm.NewMemDB()
 	tree := NewMutableTree(db)
@@ -106,3 +132,27 @@ func TestMutableTree_LoadVersion(t *testing.T) {
 
 	require.Equal(t, hash, tree.WorkingHash())
 }
+
+func TestMutableTree_LoadRecentVersion(t *testing.T) {
+	db := dbm.NewMemDB()
+	tree := NewMutableTree(db)
+	versions := MaxSavedStatesCount + 20
+	for i := 1; i <= versions; i++ {
+		tree.Set([]byte{0x1}, []byte{byte(i)})
+		_, _, err := tree.SaveVersion()
+		require.NoError(t, err)
+	}
+
+	SetLazyLoad(true)
+	defer SetLazyLoad(false)
+	lazyTree := NewMutableTree(db)
+	version, err := lazyTree.LoadRecentVersion(int64(versions))
+	require.NoError(t, err)
+	require.Equal(t, int64(versions), version)
+	_, value := lazyTree.Get([]byte{0x1})
+	require.Equal(t, []byte{byte(versions)}, value)
+	available := lazyTree.AvailableVersions()
+	require.Len(t, available, MaxSavedStatesCount+1)
+	require.Equal(t, versions-MaxSavedStatesCount, available[0])
+	require.NoError(t, lazyTree.DeleteVersion(int64(available[0])))
+}
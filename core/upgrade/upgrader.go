@@ -13,7 +13,17 @@ import (
 	"time"
 )
 
-const TargetVersion = config.ConsensusV12
+const TargetVersion = config.ConsensusV13
+
+type Status struct {
+	Version             config.ConsensusVerson `json:"version"`
+	Target              config.ConsensusVerson `json:"target"`
+	StartActivationDate int64                  `json:"startActivationDate"`
+	EndActivationDate   int64                  `json:"endActivationDate"`
+	Votes               int                    `json:"votes"`
+	RequiredVotes       int                    `json:"requiredVotes"`
+	CanUpgrade          bool                   `json:"canUpgrade"`
+}
 
 type Upgrader struct {
 	config           *config.Config
@@ -105,6 +115,10 @@ func (u *Upgrader) CanUpgrade() bool {
 	if validationDate.Sub(time.Now().UTC()) < u.config.Consensus.UpgradeIntervalBeforeValidation {
 		return false
 	}
+	return u.targetVotes() >= u.requiredVotes()
+}
+
+func (u *Upgrader) targetVotes() int {
 	var cnt int
 	u.mutex.RLock()
 	for voter, upgrade := range u.votes.Dict {
@@ -115,8 +129,29 @@ func (u *Upgrader) CanUpgrade() bool {
 		}
 	}
 	u.mutex.RUnlock()
+	return cnt
+}
+
+func (u *Upgrader) requiredVotes() int {
 	committeeSize := u.appState.ValidatorsCache.ForkCommitteeSize()
-	return cnt >= int(0.80*float64(committeeSize))
+	return int(0.80 * float64(committeeSize))
+}
+
+// Status returns the state of the voting for the next consensus version
+func (u *Upgrader) Status() *Status {
+	target := u.Target()
+	status := &Status{
+		Version:       u.config.Consensus.Version,
+		Target:        target,
+		Votes:         u.targetVotes(),
+		RequiredVotes: u.requiredVotes(),
+		CanUpgrade:    u.CanUpgrade(),
+	}
+	if target > u.config.Consensus.Version {
+		status.StartActivationDate = config.ConsensusVersions[target].StartActivationDate
+		status.EndActivationDate = config.ConsensusVersions[target].EndActivationDate
+	}
+	return status
 }
 
 func (u *Upgrader) processVote(vote *types.Vote) {
@@ -144,4 +144,10 @@ func TestUpgrader_CanUpgrade(t *testing.T) {
 	upgrader.votes.Add(common.Address{0x1, 0x6}, uint32(TargetVersion))
 	require.True(t, upgrader.CanUpgrade())
 
+	status := upgrader.Status()
+	require.Equal(t, TargetVersion, status.Target)
+	require.Equal(t, config.ConsensusVersions[TargetVersion].StartActivationDate, status.StartActivationDate)
+	require.True(t, status.Votes >= status.RequiredVotes)
+	require.True(t, status.CanUpgrade)
+
 }
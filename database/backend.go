@@ -0,0 +1,16 @@
+package database
+
+import (
+	"github.com/pkg/errors"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// OpenBackend opens the database using the key-value storage backend provided by tm-db.
+// goleveldb is always available, badgerdb is compiled in with `go build -tags badgerdb` only
+func OpenBackend(backend string, name string, dir string) (dbm.DB, error) {
+	db, err := dbm.NewDB(name, dbm.BackendType(backend), dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open %v database, make sure the node is built with the %v build tag", backend, backend)
+	}
+	return db, nil
+}
@@ -0,0 +1,19 @@
+//go:build badgerdb
+// +build badgerdb
+
+package database
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestOpenBackend_Badger(t *testing.T) {
+	db, err := OpenBackend("badgerdb", "test", t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, db.Set([]byte{0x1}, []byte{0x2}))
+	value, err := db.Get([]byte{0x1})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x2}, value)
+	require.NoError(t, db.Close())
+}
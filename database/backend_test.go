@@ -0,0 +1,21 @@
+package database
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestOpenBackend(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := OpenBackend("goleveldb", "test", dir)
+	require.NoError(t, err)
+	require.NoError(t, db.Set([]byte{0x1}, []byte{0x2}))
+	value, err := db.Get([]byte{0x1})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x2}, value)
+	require.NoError(t, db.Close())
+
+	_, err = OpenBackend("unknown", "test", dir)
+	require.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// number of records written to the backup at once
+const backupBatchSize = 10000
+
+var ErrBackupNotSupported = errors.New("database backend doesn't support online backup")
+
+// Backup copies a consistent snapshot of the running database to a new goleveldb database at the path
+func Backup(db dbm.DB, path string) error {
+	source, ok := db.(*dbm.GoLevelDB)
+	if !ok {
+		return ErrBackupNotSupported
+	}
+	snapshot, err := source.DB().GetSnapshot()
+	if err != nil {
+		return err
+	}
+	defer snapshot.Release()
+
+	target, err := leveldb.OpenFile(path, &opt.Options{ErrorIfExist: true})
+	if err != nil {
+		return errors.Wrap(err, "failed to create backup database")
+	}
+	defer target.Close()
+
+	it := snapshot.NewIterator(nil, nil)
+	defer it.Release()
+	batch := new(leveldb.Batch)
+	for it.Next() {
+		batch.Put(it.Key(), it.Value())
+		if batch.Len() >= backupBatchSize {
+			if err := target.Write(batch, nil); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return target.Write(batch, &opt.WriteOptions{Sync: true})
+}
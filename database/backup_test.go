@@ -0,0 +1,41 @@
+package database
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/tendermint/tm-db"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackup(t *testing.T) {
+	require := require.New(t)
+	dir := t.TempDir()
+
+	source, err := db.NewGoLevelDB("source", dir)
+	require.NoError(err)
+	defer source.Close()
+	for i := 0; i < backupBatchSize+10; i++ {
+		require.NoError(source.Set([]byte{byte(i >> 16), byte(i >> 8), byte(i)}, []byte{byte(i)}))
+	}
+
+	path := filepath.Join(dir, "backup.db")
+	require.NoError(Backup(source, path))
+	require.Error(Backup(source, path), "existing backup shouldn't be overwritten")
+
+	backup, err := leveldb.OpenFile(path, nil)
+	require.NoError(err)
+	defer backup.Close()
+	value, err := backup.Get([]byte{0, 0, 5}, nil)
+	require.NoError(err)
+	require.Equal([]byte{5}, value)
+	it := backup.NewIterator(nil, nil)
+	cnt := 0
+	for it.Next() {
+		cnt++
+	}
+	it.Release()
+	require.Equal(backupBatchSize+10, cnt)
+
+	require.Equal(ErrBackupNotSupported, Backup(db.NewMemDB(), filepath.Join(dir, "mem.db")))
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"github.com/pkg/errors"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// number of key ranges compacted one by one, keys are split by the first byte
+const compactionRanges = 256
+
+var ErrCompactionNotSupported = errors.New("database backend doesn't support compaction")
+
+type compactable interface {
+	ForceCompact(start, limit []byte) error
+}
+
+// Compact runs a full compaction range by range, so the progress can be reported and
+// a single compaction doesn't hold the whole database
+func Compact(db dbm.DB, onProgress func(done, total int)) error {
+	c, ok := db.(compactable)
+	if !ok {
+		return ErrCompactionNotSupported
+	}
+	for i := 0; i < compactionRanges; i++ {
+		var start, limit []byte
+		if i > 0 {
+			start = []byte{byte(i)}
+		}
+		if i < compactionRanges-1 {
+			limit = []byte{byte(i + 1)}
+		}
+		if err := c.ForceCompact(start, limit); err != nil {
+			return errors.Wrapf(err, "failed to compact range %v", i)
+		}
+		if onProgress != nil {
+			onProgress(i+1, compactionRanges)
+		}
+	}
+	return nil
+}
@@ -24,6 +24,9 @@ var (
 	PublicFlipKeyPrefix   = []byte("pubk")
 	PrivateFlipKeyPrefix  = []byte("pk")
 	LotteryIdentities     = []byte("li")
+	OwnFlipReportPrefix   = []byte("own-rep")
+
+	epochDbPrefix = []byte("epoch")
 )
 
 type EpochDb struct {
@@ -55,7 +58,7 @@ type DbLotteryIdentity struct {
 }
 
 func NewEpochDb(db dbm.DB, epoch uint16) *EpochDb {
-	prefix := append([]byte("epoch"), uint8(epoch>>8), uint8(epoch&0xff))
+	prefix := append(append([]byte{}, epochDbPrefix...), uint8(epoch>>8), uint8(epoch&0xff))
 	return &EpochDb{db: dbm.NewPrefixDB(db, prefix)}
 }
 
@@ -292,6 +295,27 @@ func (edb *EpochDb) IterateOverFlipCids(callback func(cid []byte)) {
 	}
 }
 
+func (edb *EpochDb) WriteOwnFlipReport(cid []byte) {
+	assertNoError(edb.db.Set(append(OwnFlipReportPrefix, cid...), []byte{}))
+}
+
+func (edb *EpochDb) RemoveOwnFlipReport(cid []byte) {
+	assertNoError(edb.db.Delete(append(OwnFlipReportPrefix, cid...)))
+}
+
+func (edb *EpochDb) ReadOwnFlipReports() [][]byte {
+	it, err := edb.db.Iterator(append(OwnFlipReportPrefix, ipfs.MinCid[:]...), append(OwnFlipReportPrefix, ipfs.MaxCid[:]...))
+	assertNoError(err)
+	defer it.Close()
+	var result [][]byte
+	for ; it.Valid(); it.Next() {
+		cid := make([]byte, len(it.Key())-len(OwnFlipReportPrefix))
+		copy(cid, it.Key()[len(OwnFlipReportPrefix):])
+		result = append(result, cid)
+	}
+	return result
+}
+
 func (edb *EpochDb) HasEvidenceMap(addr common.Address) bool {
 	key := append(EvidencePrefix, addr[:]...)
 	has, err := edb.db.Has(key)
@@ -424,3 +448,74 @@ func (edb *EpochDb) ReadLotteryIdentities() []DbLotteryIdentity {
 	}
 	return res
 }
+
+func init() {
+	registerMigration(Migration{
+		Version:     1,
+		Description: "move flip and ceremony data to the separate database",
+		Run: func(db dbm.DB, ceremonyDb dbm.DB) error {
+			moved, err := MigrateEpochData(db, ceremonyDb)
+			if err != nil {
+				return err
+			}
+			log.Info("Ceremony data moved to the separate database", "records", moved)
+			return nil
+		},
+	})
+}
+
+// MigrateEpochData moves flip and ceremony data of all epochs from the chain database to the separate one
+// in bounded batches, it returns the number of moved records
+func MigrateEpochData(from dbm.DB, to dbm.DB) (int, error) {
+	moved := 0
+	for {
+		it, err := dbm.IteratePrefix(from, epochDbPrefix)
+		if err != nil {
+			return moved, err
+		}
+		var keys, values [][]byte
+		for ; it.Valid() && len(keys) < migrationBatchSize; it.Next() {
+			keys = append(keys, it.Key())
+			values = append(values, it.Value())
+		}
+		err = it.Error()
+		it.Close()
+		if err != nil {
+			return moved, err
+		}
+		if len(keys) == 0 {
+			return moved, nil
+		}
+		// the records are removed only after their copies are synced, an interrupted move is repeated
+		if err := writeBatch(to, func(batch dbm.Batch) error {
+			for i, key := range keys {
+				if err := batch.Set(key, values[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return moved, err
+		}
+		if err := writeBatch(from, func(batch dbm.Batch) error {
+			for _, key := range keys {
+				if err := batch.Delete(key); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return moved, err
+		}
+		moved += len(keys)
+	}
+}
+
+func writeBatch(db dbm.DB, fill func(batch dbm.Batch) error) error {
+	batch := db.NewBatch()
+	defer batch.Close()
+	if err := fill(batch); err != nil {
+		return err
+	}
+	return batch.WriteSync()
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fmt"
 	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-go/tests"
 	"github.com/stretchr/testify/require"
@@ -135,3 +136,49 @@ func TestEpochDb_HasSuccessfulOwnTx(t *testing.T) {
 	require.True(edb.HasSuccessfulOwnTx(common.Hash{0x1}))
 	require.False(edb.HasSuccessfulOwnTx(common.Hash{0x2}))
 }
+
+func TestEpochDb_OwnFlipReports(t *testing.T) {
+	require := require.New(t)
+	mdb := db.NewMemDB()
+
+	edb := NewEpochDb(mdb, 1)
+
+	edb.WriteOwnFlipReport([]byte{0x1, 0x2})
+	edb.WriteOwnFlipReport([]byte{0x3})
+	edb.WriteFlipCid([]byte{0x4})
+
+	require.Equal([][]byte{{0x1, 0x2}, {0x3}}, edb.ReadOwnFlipReports())
+
+	edb.RemoveOwnFlipReport([]byte{0x1, 0x2})
+	require.Equal([][]byte{{0x3}}, edb.ReadOwnFlipReports())
+}
+
+func TestMigrateEpochData(t *testing.T) {
+	require := require.New(t)
+
+	chainDb := db.NewMemDB()
+	ceremonyDb := db.NewMemDB()
+
+	addr := tests.GetRandAddr()
+	NewEpochDb(chainDb, 1).WriteAnswerHash(addr, common.Hash{0x1}, time.Now())
+	NewEpochDb(chainDb, 2).WriteFlipCid([]byte{0x1, 0x2})
+	// the records are moved in several batches
+	for i := 0; i < migrationBatchSize; i++ {
+		NewEpochDb(chainDb, 3).WriteFlipCid([]byte(fmt.Sprintf("cid%v", i)))
+	}
+	require.NoError(chainDb.Set([]byte("other"), []byte{0x1}))
+
+	moved, err := MigrateEpochData(chainDb, ceremonyDb)
+	require.NoError(err)
+	require.Equal(migrationBatchSize+2, moved)
+
+	require.True(NewEpochDb(ceremonyDb, 1).HasAnswerHash(addr))
+	require.True(NewEpochDb(ceremonyDb, 2).HasFlipCid([]byte{0x1, 0x2}))
+	require.False(NewEpochDb(chainDb, 1).HasAnswerHash(addr))
+	has, _ := chainDb.Has([]byte("other"))
+	require.True(has)
+
+	moved, err = MigrateEpochData(chainDb, ceremonyDb)
+	require.NoError(err)
+	require.Zero(moved)
+}
@@ -0,0 +1,322 @@
+package database
+
+import (
+	"encoding/binary"
+	"github.com/idena-network/idena-go/log"
+	"github.com/pkg/errors"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// Migration changes the format of stored data, all writes to the chain database go through the backup of touched
+// keys, so a failed or interrupted migration is rolled back. Writes to the ceremony database are not backed up,
+// they should be repeatable
+type Migration struct {
+	Version     uint32
+	Description string
+	Run         func(db dbm.DB, ceremonyDb dbm.DB) error
+}
+
+// migrations are ordered by version without gaps starting from 1
+var migrations []Migration
+
+func registerMigration(m Migration) {
+	if m.Version != uint32(len(migrations)+1) {
+		panic("migration versions should be sequential")
+	}
+	migrations = append(migrations, m)
+}
+
+// SchemaVersion returns the current version of the database schema
+func SchemaVersion() uint32 {
+	return uint32(len(migrations))
+}
+
+func readSchemaVersion(db dbm.DB) (version uint32, exists bool, err error) {
+	data, err := db.Get(schemaVersionKey)
+	if err != nil || data == nil {
+		return 0, false, err
+	}
+	if len(data) != 4 {
+		return 0, false, errors.New("invalid schema version")
+	}
+	return binary.BigEndian.Uint32(data), true, nil
+}
+
+func writeSchemaVersion(db dbm.DB, version uint32) error {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, version)
+	return db.SetSync(schemaVersionKey, data)
+}
+
+// MigrateSchema runs the migrations which were not applied to the database yet
+func MigrateSchema(db dbm.DB, ceremonyDb dbm.DB) error {
+	return migrateSchema(db, ceremonyDb, migrations)
+}
+
+func migrateSchema(db dbm.DB, ceremonyDb dbm.DB, migrations []Migration) error {
+	latest := uint32(len(migrations))
+	version, exists, err := readSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if head, err := db.Get(headBlockKey); err != nil {
+			return err
+		} else if head == nil {
+			// a new database has the current schema
+			return writeSchemaVersion(db, latest)
+		}
+	}
+	if version > latest {
+		return errors.Errorf("database schema version %v is newer than supported %v, update the node", version, latest)
+	}
+	for _, m := range migrations[version:] {
+		mdb := newMigrationDb(db, m.Version)
+		// the previous run of the migration was interrupted
+		if err := mdb.restore(); err != nil {
+			return errors.Wrapf(err, "failed to restore keys of interrupted migration %v", m.Version)
+		}
+		log.Info("Running database migration", "version", m.Version, "description", m.Description)
+		if err := m.Run(mdb, ceremonyDb); err != nil {
+			if restoreErr := mdb.restore(); restoreErr != nil {
+				return errors.Wrapf(restoreErr, "migration %v failed (%v) and cannot be rolled back", m.Version, err)
+			}
+			return errors.Wrapf(err, "migration %v failed", m.Version)
+		}
+		if err := writeSchemaVersion(db, m.Version); err != nil {
+			return err
+		}
+		if err := mdb.clearBackup(); err != nil {
+			return err
+		}
+		log.Info("Database migration completed", "version", m.Version)
+	}
+	return nil
+}
+
+// number of records written or removed by a single batch of a migration
+const migrationBatchSize = 10000
+
+// migrationDb saves the original value of every key before its first modification
+type migrationDb struct {
+	dbm.DB
+	backup       dbm.DB
+	backupPrefix []byte
+	saved        map[string]struct{}
+}
+
+func newMigrationDb(db dbm.DB, version uint32) *migrationDb {
+	prefix := make([]byte, len(migrationBackupPrefix)+4)
+	copy(prefix, migrationBackupPrefix)
+	binary.BigEndian.PutUint32(prefix[len(migrationBackupPrefix):], version)
+	return &migrationDb{
+		DB:           db,
+		backup:       dbm.NewPrefixDB(db, prefix),
+		backupPrefix: prefix,
+		saved:        make(map[string]struct{}),
+	}
+}
+
+// backupRecord returns the original value of the key prefixed with 1 if the key existed and 0 otherwise
+func (m *migrationDb) backupRecord(key []byte) ([]byte, error) {
+	value, err := m.DB.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	record := []byte{0}
+	if value != nil {
+		record = append([]byte{1}, value...)
+	}
+	return record, nil
+}
+
+// save writes the backup record before the direct modification of the key, the writes reach the database log
+// in order, so the modification never survives a crash without its backup and no sync is needed
+func (m *migrationDb) save(key []byte) error {
+	if _, ok := m.saved[string(key)]; ok {
+		return nil
+	}
+	record, err := m.backupRecord(key)
+	if err != nil {
+		return err
+	}
+	if err := m.backup.Set(key, record); err != nil {
+		return err
+	}
+	m.saved[string(key)] = struct{}{}
+	return nil
+}
+
+func (m *migrationDb) Set(key []byte, value []byte) error {
+	if err := m.save(key); err != nil {
+		return err
+	}
+	return m.DB.Set(key, value)
+}
+
+func (m *migrationDb) SetSync(key []byte, value []byte) error {
+	if err := m.save(key); err != nil {
+		return err
+	}
+	return m.DB.SetSync(key, value)
+}
+
+func (m *migrationDb) Delete(key []byte) error {
+	if err := m.save(key); err != nil {
+		return err
+	}
+	return m.DB.Delete(key)
+}
+
+func (m *migrationDb) DeleteSync(key []byte) error {
+	if err := m.save(key); err != nil {
+		return err
+	}
+	return m.DB.DeleteSync(key)
+}
+
+func (m *migrationDb) NewBatch() dbm.Batch {
+	return &migrationBatch{
+		Batch: m.DB.NewBatch(),
+		db:    m,
+		saved: make(map[string]struct{}),
+	}
+}
+
+// forEachBackupChunk passes the backup records to fn in chunks of migrationBatchSize, the iterator is closed
+// before every call, so fn may write to the database
+func (m *migrationDb) forEachBackupChunk(fn func(keys [][]byte, records [][]byte) error) error {
+	var start []byte
+	for {
+		it, err := m.backup.Iterator(start, nil)
+		if err != nil {
+			return err
+		}
+		var keys, records [][]byte
+		for ; it.Valid() && len(keys) < migrationBatchSize; it.Next() {
+			keys = append(keys, it.Key())
+			records = append(records, it.Value())
+		}
+		err = it.Error()
+		it.Close()
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		if err := fn(keys, records); err != nil {
+			return err
+		}
+		if len(keys) < migrationBatchSize {
+			return nil
+		}
+		last := keys[len(keys)-1]
+		start = append(append(make([]byte, 0, len(last)+1), last...), 0)
+	}
+}
+
+// restore writes back the original values of all saved keys and removes the backup
+func (m *migrationDb) restore() error {
+	err := m.forEachBackupChunk(func(keys [][]byte, records [][]byte) error {
+		return writeBatch(m.DB, func(batch dbm.Batch) error {
+			for i, key := range keys {
+				var err error
+				if record := records[i]; len(record) > 0 && record[0] == 1 {
+					err = batch.Set(key, record[1:])
+				} else {
+					err = batch.Delete(key)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return m.clearBackup()
+}
+
+func (m *migrationDb) clearBackup() error {
+	err := m.forEachBackupChunk(func(keys [][]byte, _ [][]byte) error {
+		return writeBatch(m.backup, func(batch dbm.Batch) error {
+			for _, key := range keys {
+				if err := batch.Delete(key); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	m.saved = make(map[string]struct{})
+	return nil
+}
+
+// migrationBatch writes the backup records within the batch, so they are committed atomically with the changes
+type migrationBatch struct {
+	dbm.Batch
+	db    *migrationDb
+	saved map[string]struct{}
+}
+
+func (b *migrationBatch) save(key []byte) error {
+	if _, ok := b.db.saved[string(key)]; ok {
+		return nil
+	}
+	if _, ok := b.saved[string(key)]; ok {
+		return nil
+	}
+	record, err := b.db.backupRecord(key)
+	if err != nil {
+		return err
+	}
+	backupKey := append(append(make([]byte, 0, len(b.db.backupPrefix)+len(key)), b.db.backupPrefix...), key...)
+	if err := b.Batch.Set(backupKey, record); err != nil {
+		return err
+	}
+	b.saved[string(key)] = struct{}{}
+	return nil
+}
+
+func (b *migrationBatch) Set(key, value []byte) error {
+	if err := b.save(key); err != nil {
+		return err
+	}
+	return b.Batch.Set(key, value)
+}
+
+func (b *migrationBatch) Delete(key []byte) error {
+	if err := b.save(key); err != nil {
+		return err
+	}
+	return b.Batch.Delete(key)
+}
+
+func (b *migrationBatch) Write() error {
+	if err := b.Batch.Write(); err != nil {
+		return err
+	}
+	b.commit()
+	return nil
+}
+
+func (b *migrationBatch) WriteSync() error {
+	if err := b.Batch.WriteSync(); err != nil {
+		return err
+	}
+	b.commit()
+	return nil
+}
+
+func (b *migrationBatch) commit() {
+	for key := range b.saved {
+		b.db.saved[key] = struct{}{}
+	}
+	b.saved = make(map[string]struct{})
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tm-db"
+	"testing"
+)
+
+func testMigrations(fail bool) []Migration {
+	return []Migration{
+		{
+			Version: 1,
+			Run: func(db db.DB, _ db.DB) error {
+				return db.Set([]byte("k1"), []byte("v1"))
+			},
+		},
+		{
+			Version: 2,
+			Run: func(db db.DB, _ db.DB) error {
+				batch := db.NewBatch()
+				batch.Set([]byte("k1"), []byte("v2"))
+				batch.Delete([]byte("k0"))
+				batch.Set([]byte("k2"), []byte("v2"))
+				if err := batch.Write(); err != nil {
+					return err
+				}
+				if fail {
+					return errors.New("failed")
+				}
+				return nil
+			},
+		},
+	}
+}
+
+func TestMigrateSchema_newDb(t *testing.T) {
+	mdb := db.NewMemDB()
+	require.NoError(t, migrateSchema(mdb, nil, testMigrations(false)))
+	version, exists, err := readSchemaVersion(mdb)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, uint32(2), version)
+	has, _ := mdb.Has([]byte("k1"))
+	require.False(t, has)
+}
+
+func TestMigrateSchema(t *testing.T) {
+	require := require.New(t)
+	mdb := db.NewMemDB()
+	require.NoError(mdb.Set(headBlockKey, []byte{0x1}))
+	require.NoError(mdb.Set([]byte("k0"), []byte("v0")))
+
+	err := migrateSchema(mdb, nil, testMigrations(true))
+	require.Error(err)
+	version, _, _ := readSchemaVersion(mdb)
+	require.Equal(uint32(1), version)
+	value, _ := mdb.Get([]byte("k1"))
+	require.Equal([]byte("v1"), value)
+	value, _ = mdb.Get([]byte("k0"))
+	require.Equal([]byte("v0"), value)
+	has, _ := mdb.Has([]byte("k2"))
+	require.False(has)
+
+	require.NoError(migrateSchema(mdb, nil, testMigrations(false)))
+	version, _, _ = readSchemaVersion(mdb)
+	require.Equal(uint32(2), version)
+	value, _ = mdb.Get([]byte("k1"))
+	require.Equal([]byte("v2"), value)
+	has, _ = mdb.Has([]byte("k0"))
+	require.False(has)
+	it, _ := db.IteratePrefix(mdb, migrationBackupPrefix)
+	require.False(it.Valid())
+	it.Close()
+
+	require.Error(migrateSchema(mdb, nil, testMigrations(false)[:1]))
+}
+
+func TestMigrateSchema_interrupted(t *testing.T) {
+	require := require.New(t)
+	mdb := db.NewMemDB()
+	require.NoError(mdb.Set(headBlockKey, []byte{0x1}))
+	require.NoError(mdb.Set([]byte("k0"), []byte("v0")))
+
+	// the node was stopped during the migration
+	interrupted := newMigrationDb(mdb, 1)
+	require.NoError(interrupted.Set([]byte("k0"), []byte("broken")))
+
+	migrations := []Migration{
+		{
+			Version: 1,
+			Run: func(db db.DB, _ db.DB) error {
+				value, err := db.Get([]byte("k0"))
+				if err != nil {
+					return err
+				}
+				return db.Set([]byte("k1"), value)
+			},
+		},
+	}
+	require.NoError(migrateSchema(mdb, nil, migrations))
+	value, _ := mdb.Get([]byte("k1"))
+	require.Equal([]byte("v0"), value)
+}
+
+func TestMigrationDb_restore(t *testing.T) {
+	require := require.New(t)
+	mdb := db.NewMemDB()
+	keys := 2*migrationBatchSize + 1
+	for i := 0; i < keys; i++ {
+		require.NoError(mdb.Set([]byte(fmt.Sprintf("k%v", i)), []byte("v0")))
+	}
+
+	m := newMigrationDb(mdb, 1)
+	// a discarded batch leaves no backup records
+	discarded := m.NewBatch()
+	require.NoError(discarded.Set([]byte("k0"), []byte("discarded")))
+	require.NoError(discarded.Close())
+	require.Empty(m.saved)
+
+	batch := m.NewBatch()
+	for i := 0; i < keys; i++ {
+		require.NoError(batch.Set([]byte(fmt.Sprintf("k%v", i)), []byte("v1")))
+	}
+	require.NoError(batch.Set([]byte("new"), []byte("v1")))
+	require.NoError(batch.WriteSync())
+	require.NoError(batch.Close())
+	require.Len(m.saved, keys+1)
+	require.NoError(m.Set([]byte("k0"), []byte("v2")))
+
+	require.NoError(m.restore())
+	for i := 0; i < keys; i++ {
+		value, err := mdb.Get([]byte(fmt.Sprintf("k%v", i)))
+		require.NoError(err)
+		require.Equal([]byte("v0"), value)
+	}
+	has, _ := mdb.Has([]byte("new"))
+	require.False(has)
+	it, _ := db.IteratePrefix(mdb, migrationBackupPrefix)
+	require.False(it.Valid())
+	it.Close()
+	require.Empty(m.saved)
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"bytes"
 	"encoding/binary"
 	"github.com/golang/protobuf/proto"
 	"github.com/idena-network/idena-go/blockchain/types"
@@ -94,6 +95,10 @@ func identityStateDiffKey(height uint64) []byte {
 	return append(identityStateDiffPrefix, encodeUint64Number(height)...)
 }
 
+func epochSummaryKey(epoch uint16) []byte {
+	return append(epochSummaryPrefix, uint8(epoch>>8), uint8(epoch&0xff))
+}
+
 func applyTxLogKey(hash common.Hash) []byte {
 	return append(applyTxLogPrefix, hash.Bytes()...)
 }
@@ -185,6 +190,24 @@ func (r *Repo) ReadCanonicalHash(height uint64) common.Hash {
 	return common.BytesToHash(data)
 }
 
+// ReadLastCanonicalHeight returns the highest height not lower than from which has a canonical hash or 0 if there is no one.
+// Heights may have gaps since fast synced and cloned chains don't keep canonical hashes of old blocks.
+func (r *Repo) ReadLastCanonicalHeight(from uint64) uint64 {
+	it, err := r.db.ReverseIterator(headerHashKey(from), headerHashKey(math2.MaxUint32))
+	assertNoError(err)
+	defer it.Close()
+	keyLength := len(headerPrefix) + 8 + len(headerHashSuffix)
+	for ; it.Valid(); it.Next() {
+		// header keys share the prefix with canonical hash keys
+		key := it.Key()
+		if len(key) != keyLength || !bytes.HasSuffix(key, headerHashSuffix) {
+			continue
+		}
+		return binary.BigEndian.Uint64(key[len(headerPrefix) : len(headerPrefix)+8])
+	}
+	return 0
+}
+
 func (r *Repo) WriteFinalConsensus(hash common.Hash) {
 	key := finalConsensusKey(hash)
 	r.db.Set(key, []byte{0x1})
@@ -262,6 +285,12 @@ func (r *Repo) ReadCertificate(hash common.Hash) *types.BlockCert {
 	return cert
 }
 
+func (r *Repo) HasCertificate(hash common.Hash) bool {
+	has, err := r.db.Has(certKey(hash))
+	assertNoError(err)
+	return has
+}
+
 func (r *Repo) readWeakCertificates() *models.ProtoWeakCertificates {
 	data, err := r.db.Get(weakCertificatesKey)
 	assertNoError(err)
@@ -397,6 +426,29 @@ func (r *Repo) WriteActivity(monitor *types.ActivityMonitor) {
 	r.db.Set(activityMonitorKey, data)
 }
 
+func (r *Repo) WriteEpochSummary(summary *types.EpochSummary) {
+	data, err := summary.ToBytes()
+	if err != nil {
+		log.Error("failed to encode epoch summary", "err", err)
+		return
+	}
+	assertNoError(r.db.Set(epochSummaryKey(summary.Epoch), data))
+}
+
+func (r *Repo) ReadEpochSummary(epoch uint16) *types.EpochSummary {
+	data, err := r.db.Get(epochSummaryKey(epoch))
+	assertNoError(err)
+	if data == nil {
+		return nil
+	}
+	summary := new(types.EpochSummary)
+	if err := summary.FromBytes(data); err != nil {
+		log.Error("invalid epoch summary", "err", err)
+		return nil
+	}
+	return summary
+}
+
 func (r *Repo) SaveTx(address common.Address, blockHash common.Hash, timestamp int64, feePerGas *big.Int, transaction *types.Transaction) {
 	s := &types.SavedTransaction{
 		Tx:        transaction,
@@ -442,6 +494,24 @@ func (r *Repo) GetSavedTxs(address common.Address, count int, token []byte) (txs
 	return txs, nil
 }
 
+// IterateSavedTxs calls fn for the saved transactions of the address from the oldest one until fn returns true
+func (r *Repo) IterateSavedTxs(address common.Address, fn func(tx *types.SavedTransaction) (stop bool)) {
+	it, err := r.db.Iterator(savedTxKey(address, 0, 0, common.BytesToHash(common.MinHash[:])),
+		savedTxKey(address, math.MaxInt64, uint32(math.MaxUint32), common.BytesToHash(common.MaxHash)))
+	assertNoError(err)
+	defer it.Close()
+	for ; it.Valid(); it.Next() {
+		tx := new(types.SavedTransaction)
+		if err := tx.FromBytes(it.Value()); err != nil {
+			log.Error("cannot parse tx", "key", it.Key())
+			continue
+		}
+		if fn(tx) {
+			return
+		}
+	}
+}
+
 func (r *Repo) DeleteOutdatedBurntCoins(blockHeight uint64, blockRange uint64) {
 	if blockHeight <= blockRange {
 		return
@@ -6,6 +6,7 @@ import (
 	"github.com/idena-network/idena-go/common"
 	"github.com/stretchr/testify/require"
 	"github.com/tendermint/tm-db"
+	"math/big"
 	"testing"
 	"time"
 )
@@ -122,3 +123,29 @@ func TestRepo_GetSavedEvents(t *testing.T) {
 	require.Equal(t, "ZZZZZZZZZZZZZZZ ZZZZZZZZZZZZZZZZZZ", events2[2].Event)
 
 }
+
+func TestRepo_WriteEpochSummary(t *testing.T) {
+	repo := NewRepo(db.NewMemDB())
+	require.Nil(t, repo.ReadEpochSummary(5))
+
+	summary := &types.EpochSummary{
+		Epoch:        5,
+		Height:       100,
+		TotalBalance: big.NewInt(1000),
+		TotalStake:   big.NewInt(10),
+		Identities: []*types.EpochSummaryIdentity{
+			{Address: common.Address{0x1}, State: 3, Stake: big.NewInt(10)},
+			{Address: common.Address{0x2}, State: 8, Stake: big.NewInt(0)},
+		},
+	}
+	repo.WriteEpochSummary(summary)
+
+	read := repo.ReadEpochSummary(5)
+	require.NotNil(t, read)
+	require.Equal(t, uint64(100), read.Height)
+	require.Equal(t, 0, big.NewInt(1000).Cmp(read.TotalBalance))
+	require.Len(t, read.Identities, 2)
+	require.Equal(t, uint8(8), read.IdentitiesByAddress()[common.Address{0x2}].State)
+	require.Equal(t, 0, big.NewInt(10).Cmp(read.Identities[0].Stake))
+	require.Nil(t, repo.ReadEpochSummary(4))
+}
@@ -49,4 +49,10 @@ var (
 	applyTxLogPrefix = []byte("applytxlog")
 
 	blackListedTxPrefix = []byte("blacktx")
+
+	schemaVersionKey = []byte("schema-version")
+
+	migrationBackupPrefix = []byte("migration-backup")
+
+	epochSummaryPrefix = []byte("summary")
 )
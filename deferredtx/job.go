@@ -35,6 +35,7 @@ type Job struct {
 
 	txs       *DeferredTxs
 	mutex     sync.Mutex
+	stopped   bool
 	datadir   string
 	bc        *blockchain.Blockchain
 	txpool    mempool.TransactionPool
@@ -84,10 +85,17 @@ func NewJob(bus eventbus.Bus, datadir string, appState *appstate.AppState, bc *b
 	return job, nil
 }
 
+// Stop waits for the current broadcast, the deferred txs are not sent after that
+func (j *Job) Stop() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.stopped = true
+}
+
 func (j *Job) broadcast() {
 	j.mutex.Lock()
 	defer j.mutex.Unlock()
-	if j.txpool.IsSyncing() {
+	if j.stopped || j.txpool.IsSyncing() {
 		return
 	}
 	newTxs := new(DeferredTxs)
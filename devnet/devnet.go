@@ -0,0 +1,137 @@
+// Package devnet runs a local network of several nodes in one process, so consensus and ceremony changes
+// can be tested without deploying real machines. The nodes talk over loopback, share the genesis where all of them
+// are verified validators and run the shortened ceremony simulation
+package devnet
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/config"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-go/node"
+	"github.com/idena-network/idena-go/rpc"
+	"github.com/pkg/errors"
+	"math/big"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// initial balance and stake of every devnet identity in DNA
+	identityBalance = 10000
+	identityStake   = 1000
+	// the first ceremony starts after the nodes connect and produce some blocks
+	firstCeremonyDelay = 10 * time.Minute
+)
+
+type Config struct {
+	Nodes int
+	// Dir contains the data directories of the nodes named node0, node1, ...
+	Dir string
+	// FirstCeremonyTime is the unix time of the first ceremony, 10 minutes after the start by default
+	FirstCeremonyTime int64
+	// Validation overrides the epoch duration and ceremony timings of the ceremony simulation,
+	// the zero fields keep the simulation defaults. It is applied to every node since they should agree on the epochs
+	Validation config.ValidationConfig
+	// RequiredFlips overrides the number of flips every identity should submit per epoch, 0 keeps the default
+	RequiredFlips int
+	// Transform changes the config of every node before it is created, e.g. to enable a consensus upgrade
+	Transform func(index int, cfg *config.Config)
+}
+
+type Network struct {
+	Nodes     []*node.Node
+	Configs   []*config.Config
+	Addresses []common.Address
+}
+
+// Start creates the nodes and starts them one by one, the first node is the god node and the bootnode of the others
+func Start(cfg Config) (*Network, error) {
+	if cfg.Nodes < 1 {
+		return nil, errors.New("at least one node is required")
+	}
+	genesis := &config.GenesisConf{
+		Alloc:             make(map[common.Address]config.GenesisAllocation),
+		FirstCeremonyTime: cfg.FirstCeremonyTime,
+	}
+	if genesis.FirstCeremonyTime == 0 {
+		genesis.FirstCeremonyTime = time.Now().Add(firstCeremonyDelay).Unix()
+	}
+	network := &Network{}
+	for i := 0; i < cfg.Nodes; i++ {
+		nodeCfg := config.MakeLocalDevnetConfig(filepath.Join(cfg.Dir, fmt.Sprintf("node%v", i)), i, genesis)
+		validation := cfg.Validation
+		validation.ApplySimulation()
+		nodeCfg.Validation = &validation
+		// the default consensus config is shared, so the override is applied to a copy
+		consensus := *nodeCfg.Consensus
+		consensus.RequiredFlips = cfg.RequiredFlips
+		nodeCfg.Consensus = &consensus
+		key, err := nodeCfg.NodeKey()
+		if err != nil {
+			return nil, err
+		}
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		genesis.Alloc[addr] = config.GenesisAllocation{
+			Balance: new(big.Int).Mul(big.NewInt(identityBalance), common.DnaBase),
+			Stake:   new(big.Int).Mul(big.NewInt(identityStake), common.DnaBase),
+			State:   uint8(state.Verified),
+		}
+		if i == 0 {
+			genesis.GodAddress = addr
+		}
+		network.Configs = append(network.Configs, nodeCfg)
+		network.Addresses = append(network.Addresses, addr)
+	}
+
+	buildInfo := config.NewBuildInfo("devnet", "", "")
+	for i, nodeCfg := range network.Configs {
+		if i > 0 {
+			nodeCfg.IpfsConf.BootNodes = []string{network.Nodes[0].P2PAddress()}
+		}
+		if cfg.Transform != nil {
+			cfg.Transform(i, nodeCfg)
+		}
+		n, err := node.NewNode(nodeCfg, buildInfo)
+		if err != nil {
+			network.Stop()
+			return nil, errors.Wrapf(err, "cannot create node %v", i)
+		}
+		if err := n.Start(); err != nil {
+			network.Stop()
+			return nil, errors.Wrapf(err, "cannot start node %v", i)
+		}
+		network.Nodes = append(network.Nodes, n)
+	}
+	return network, nil
+}
+
+// Stop stops the started nodes and waits until they release their data directories
+func (n *Network) Stop() {
+	for _, nd := range n.Nodes {
+		nd.Stop()
+	}
+	for _, nd := range n.Nodes {
+		nd.WaitForStop()
+	}
+}
+
+// Client returns an RPC client connected to the node in-process
+func (n *Network) Client(index int) (*rpc.Client, error) {
+	return n.Nodes[index].Attach()
+}
+
+// WaitForHeight blocks until every node reaches the height
+func (n *Network) WaitForHeight(height uint64, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for _, nd := range n.Nodes {
+		for nd.Status().Height < height {
+			if time.Now().After(deadline) {
+				return errors.Errorf("height %v is not reached in %v", height, timeout)
+			}
+			time.Sleep(time.Second)
+		}
+	}
+	return nil
+}
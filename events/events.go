@@ -4,9 +4,11 @@ import (
 	"github.com/idena-network/idena-go/blockchain/types"
 	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-go/core/state/snapshot"
 	iface "github.com/ipfs/interface-go-ipfs-core"
 	"github.com/libp2p/go-libp2p-core"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"math/big"
 	"time"
 )
 
@@ -24,6 +26,19 @@ const (
 	DatabaseInitEventId          = eventbus.EventID("db-init")
 	DatabaseInitCompletedEventId = eventbus.EventID("db-init-completed")
 	IpfsGcEventId                = eventbus.EventID("ipfc-gc")
+	ClockSkewEventID             = eventbus.EventID("clock-skew")
+	ClockSkewWarningEventID      = eventbus.EventID("clock-skew-warning")
+	PeerConnectedEventID         = eventbus.EventID("peer-connected")
+	PeerDisconnectedEventID      = eventbus.EventID("peer-disconnected")
+	PeerHandshakeFailedEventID   = eventbus.EventID("peer-handshake-failed")
+	SyncProgressEventID          = eventbus.EventID("sync-progress")
+	ValidationResultEventID      = eventbus.EventID("validation-result")
+	LowDiskSpaceEventID          = eventbus.EventID("low-disk-space")
+	BalanceChangedEventID        = eventbus.EventID("balance-changed")
+	WatchedAddressEventID        = eventbus.EventID("watched-address")
+	InviteActivatedEventID       = eventbus.EventID("invite-activated")
+	DepositEventID               = eventbus.EventID("deposit")
+	NewSnapshotEventID           = eventbus.EventID("snapshot-new")
 )
 
 type NewTxEvent struct {
@@ -138,3 +153,177 @@ type IpfsGcEvent struct {
 func (e *IpfsGcEvent) EventID() eventbus.EventID {
 	return IpfsGcEventId
 }
+
+type ClockSkewEvent struct {
+	NtpDrift    time.Duration
+	NtpMeasured bool
+	// median difference between the local clock and clocks reported by peers during handshake
+	PeersOffset time.Duration
+	PeersCount  int
+}
+
+func (e *ClockSkewEvent) EventID() eventbus.EventID {
+	return ClockSkewEventID
+}
+
+type ClockSkewWarningEvent struct {
+	Epoch uint16
+	Skew  time.Duration
+}
+
+func (e *ClockSkewWarningEvent) EventID() eventbus.EventID {
+	return ClockSkewWarningEventID
+}
+
+type PeerConnectedEvent struct {
+	PeerId     string
+	Addr       string
+	Inbound    bool
+	ShardId    common.ShardId
+	AppVersion string
+	Peers      int
+}
+
+func (e *PeerConnectedEvent) EventID() eventbus.EventID {
+	return PeerConnectedEventID
+}
+
+type PeerDisconnectedEvent struct {
+	PeerId  string
+	Addr    string
+	ShardId common.ShardId
+	Reason  string
+	Peers   int
+}
+
+func (e *PeerDisconnectedEvent) EventID() eventbus.EventID {
+	return PeerDisconnectedEventID
+}
+
+type PeerHandshakeFailedEvent struct {
+	PeerId     string
+	Addr       string
+	AppVersion string
+	Err        error
+}
+
+func (e *PeerHandshakeFailedEvent) EventID() eventbus.EventID {
+	return PeerHandshakeFailedEventID
+}
+
+type SyncProgressEvent struct {
+	Head            uint64
+	Top             uint64
+	BlocksPerSecond float64
+	Remaining       uint64
+	// zero if the rate is unknown yet
+	Eta time.Duration
+}
+
+func (e *SyncProgressEvent) EventID() eventbus.EventID {
+	return SyncProgressEventID
+}
+
+// ValidationResultEvent is published when the ceremony is completed for the node identity
+type ValidationResultEvent struct {
+	Address   common.Address
+	Epoch     uint16
+	Validated bool
+	Missed    bool
+}
+
+func (e *ValidationResultEvent) EventID() eventbus.EventID {
+	return ValidationResultEventID
+}
+
+// LowDiskSpaceEvent is published when the free space or inodes under the data or IPFS directory drop below the thresholds
+type LowDiskSpaceEvent struct {
+	Path       string
+	Free       uint64
+	Required   uint64
+	FreeInodes uint64
+}
+
+func (e *LowDiskSpaceEvent) EventID() eventbus.EventID {
+	return LowDiskSpaceEventID
+}
+
+// BalanceChangedEvent is published for every address whose balance or stake is changed by the added block,
+// it is enabled by the Blockchain.PublishBalanceEvents config
+type BalanceChangedEvent struct {
+	Address     common.Address
+	Height      uint64
+	PrevBalance *big.Int
+	Balance     *big.Int
+	PrevStake   *big.Int
+	Stake       *big.Int
+}
+
+func (e *BalanceChangedEvent) EventID() eventbus.EventID {
+	return BalanceChangedEventID
+}
+
+// WatchedAddressEvent is published when a transaction of the block touches a watched address
+// or the identity state of the watched address is changed
+type WatchedAddressEvent struct {
+	Type      string
+	Address   common.Address
+	Height    uint64
+	TxHash    *common.Hash
+	TxType    *types.TxType
+	PrevState string
+	State     string
+}
+
+func (e *WatchedAddressEvent) EventID() eventbus.EventID {
+	return WatchedAddressEventID
+}
+
+// InviteActivatedEvent is published when the block activates an invite, Identity is the activated candidate
+type InviteActivatedEvent struct {
+	Inviter  common.Address
+	Invite   common.Address
+	Identity common.Address
+	TxHash   common.Hash
+	Height   uint64
+}
+
+func (e *InviteActivatedEvent) EventID() eventbus.EventID {
+	return InviteActivatedEventID
+}
+
+// Statuses of the deposits to the watched addresses
+const (
+	DepositSeen      = "seen"
+	DepositConfirmed = "confirmed"
+	// the block of the deposit is dropped by a fork
+	DepositReverted = "reverted"
+)
+
+// DepositEvent is published when a transfer to a watched address is included in a block
+// and then once when it gets the required confirmations or its block is reverted
+type DepositEvent struct {
+	Status        string
+	Address       common.Address
+	From          common.Address
+	Amount        *big.Int
+	TxHash        common.Hash
+	Height        uint64
+	Confirmations uint64
+	Final         bool
+	// Url is the webhook of the watched address, the configured webhooks are used if it is empty
+	Url string
+}
+
+func (e *DepositEvent) EventID() eventbus.EventID {
+	return DepositEventID
+}
+
+// NewSnapshotEvent is published when the node has created a state snapshot and added it to ipfs
+type NewSnapshotEvent struct {
+	Manifest *snapshot.Manifest
+}
+
+func (e *NewSnapshotEvent) EventID() eventbus.EventID {
+	return NewSnapshotEventID
+}
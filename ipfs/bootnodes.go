@@ -0,0 +1,114 @@
+package ipfs
+
+import (
+	"context"
+	"github.com/idena-network/idena-go/log"
+	core2 "github.com/libp2p/go-libp2p-core"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/pkg/errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	bootNodeDialTimeout = 15 * time.Second
+	// a failed bootnode is moved to the end of the list for the backoff, it doubles with every failure in a row
+	bootNodeMinBackoff = 30 * time.Second
+	bootNodeMaxBackoff = 30 * time.Minute
+)
+
+var errNoBootNodes = errors.New("all bootnodes are unreachable")
+
+type bootNodeHealth struct {
+	failures    int
+	lastFailure time.Time
+}
+
+// bootNodeRotation dials bootnodes in a randomized order preferring the healthy ones,
+// so a single dead bootnode cannot strand a fresh node without peers
+type bootNodeRotation struct {
+	health map[peer.ID]*bootNodeHealth
+	mutex  sync.Mutex
+	logger log.Logger
+}
+
+func newBootNodeRotation(logger log.Logger) *bootNodeRotation {
+	return &bootNodeRotation{
+		health: make(map[peer.ID]*bootNodeHealth),
+		logger: logger,
+	}
+}
+
+// order shuffles the bootnodes, the ones in backoff go last starting from the least failed
+func (r *bootNodeRotation) order(nodes []peer.AddrInfo, now time.Time) []peer.AddrInfo {
+	result := append([]peer.AddrInfo{}, nodes...)
+	rand.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	sort.SliceStable(result, func(i, j int) bool {
+		return r.rank(result[i].ID, now) < r.rank(result[j].ID, now)
+	})
+	return result
+}
+
+func (r *bootNodeRotation) rank(id peer.ID, now time.Time) int {
+	health, ok := r.health[id]
+	if !ok || now.Sub(health.lastFailure) >= bootNodeBackoff(health.failures) {
+		return 0
+	}
+	return health.failures
+}
+
+func bootNodeBackoff(failures int) time.Duration {
+	backoff := bootNodeMinBackoff
+	for i := 1; i < failures && backoff < bootNodeMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > bootNodeMaxBackoff {
+		return bootNodeMaxBackoff
+	}
+	return backoff
+}
+
+func (r *bootNodeRotation) reportFailure(id peer.ID, now time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	health, ok := r.health[id]
+	if !ok {
+		health = &bootNodeHealth{}
+		r.health[id] = health
+	}
+	health.failures++
+	health.lastFailure = now
+}
+
+func (r *bootNodeRotation) reportSuccess(id peer.ID) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.health, id)
+}
+
+// connect dials the bootnodes one by one until one of them accepts the connection
+func (r *bootNodeRotation) connect(ctx context.Context, host core2.Host, nodes []peer.AddrInfo) error {
+	for _, node := range r.order(nodes, time.Now()) {
+		dialCtx, cancel := context.WithTimeout(ctx, bootNodeDialTimeout)
+		err := host.Connect(dialCtx, node)
+		cancel()
+		if err != nil {
+			r.reportFailure(node.ID, time.Now())
+			r.logger.Debug("bootnode is unreachable", "id", node.ID.Pretty(), "err", err)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+		r.reportSuccess(node.ID)
+		r.logger.Info("connected to bootnode", "id", node.ID.Pretty())
+		return nil
+	}
+	return errNoBootNodes
+}
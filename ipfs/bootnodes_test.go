@@ -0,0 +1,38 @@
+package ipfs
+
+import (
+	"github.com/idena-network/idena-go/log"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBootNodeRotation_Order(t *testing.T) {
+	rotation := newBootNodeRotation(log.New())
+	nodes := []peer.AddrInfo{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	now := time.Now()
+
+	rotation.reportFailure("a", now)
+	rotation.reportFailure("a", now)
+	rotation.reportFailure("b", now)
+	for i := 0; i < 10; i++ {
+		order := rotation.order(nodes, now)
+		require.Equal(t, peer.ID("c"), order[0].ID)
+		require.Equal(t, peer.ID("b"), order[1].ID)
+		require.Equal(t, peer.ID("a"), order[2].ID)
+	}
+
+	// backoff of the single failure is over, the node is healthy again
+	order := rotation.order(nodes, now.Add(bootNodeMinBackoff))
+	require.Equal(t, peer.ID("a"), order[2].ID)
+
+	rotation.reportSuccess("a")
+	require.Equal(t, 0, rotation.rank("a", now))
+}
+
+func TestBootNodeBackoff(t *testing.T) {
+	require.Equal(t, bootNodeMinBackoff, bootNodeBackoff(1))
+	require.Equal(t, bootNodeMinBackoff*4, bootNodeBackoff(3))
+	require.Equal(t, bootNodeMaxBackoff, bootNodeBackoff(100))
+}
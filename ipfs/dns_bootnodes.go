@@ -0,0 +1,111 @@
+package ipfs
+
+import (
+	"context"
+	"github.com/idena-network/idena-go/config"
+	"github.com/idena-network/idena-go/log"
+	ipfsConf "github.com/ipfs/kubo/config"
+	"github.com/multiformats/go-multiaddr"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	dnsBootNodesRefreshInterval = time.Hour
+	// DNS lookups block the node start and config updates, so they are bounded
+	dnsBootNodesResolveTimeout = time.Second * 10
+	dnsAddrPrefix              = "dnsaddr="
+)
+
+var lookupTXT = net.DefaultResolver.LookupTXT
+
+// resolveDnsBootNodes reads bootnode multiaddresses from TXT records of the domain,
+// both plain multiaddresses and "dnsaddr=<multiaddr>" records are accepted
+func resolveDnsBootNodes(ctx context.Context, domain string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, dnsBootNodesResolveTimeout)
+	defer cancel()
+	records, err := lookupTXT(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, record := range records {
+		record = strings.TrimPrefix(strings.TrimSpace(record), dnsAddrPrefix)
+		if _, err := multiaddr.NewMultiaddr(record); err != nil {
+			continue
+		}
+		result = append(result, record)
+	}
+	return result, nil
+}
+
+// bootNodes returns configured bootnodes extended with the ones resolved from DNS
+func bootNodes(ctx context.Context, cfg *config.IpfsConfig, logger log.Logger) []string {
+	if cfg.BootNodesDns == "" {
+		return cfg.BootNodes
+	}
+	resolved, err := resolveDnsBootNodes(ctx, cfg.BootNodesDns)
+	if err != nil {
+		logger.Warn("cannot resolve bootnodes", "domain", cfg.BootNodesDns, "err", err)
+		return cfg.BootNodes
+	}
+	result := append([]string{}, cfg.BootNodes...)
+	known := make(map[string]struct{}, len(result))
+	for _, node := range result {
+		known[node] = struct{}{}
+	}
+	for _, node := range resolved {
+		if _, ok := known[node]; !ok {
+			known[node] = struct{}{}
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
+// refreshDnsBootNodes periodically re-resolves bootnodes until the context is cancelled on close,
+// the bootstrapper reads them from the repo config
+func (p *ipfsProxy) refreshDnsBootNodes(ctx context.Context) {
+	if p.cfg.BootNodesDns == "" {
+		return
+	}
+	logger := log.New("component", "dns bootnodes")
+	ticker := time.NewTicker(dnsBootNodesRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.updateBootNodes(ctx, logger); err != nil {
+				logger.Warn("cannot update bootnodes", "err", err)
+			}
+		}
+	}
+}
+
+// SetBootNodes replaces the configured bootnodes, they are used by the next bootstrap round
+func (p *ipfsProxy) SetBootNodes(nodes []string) error {
+	if _, err := ipfsConf.ParseBootstrapPeers(nodes); err != nil {
+		return err
+	}
+	p.bootNodesMutex.Lock()
+	p.cfg.BootNodes = nodes
+	p.bootNodesMutex.Unlock()
+	return p.updateBootNodes(p.closeCtx, p.log)
+}
+
+func (p *ipfsProxy) updateBootNodes(ctx context.Context, logger log.Logger) error {
+	p.bootNodesMutex.Lock()
+	defer p.bootNodesMutex.Unlock()
+	bps, err := ipfsConf.ParseBootstrapPeers(bootNodes(ctx, p.cfg, logger))
+	if err != nil {
+		return err
+	}
+	if err := p.node.Repo.SetConfigKey("Bootstrap", ipfsConf.BootstrapPeerStrings(bps)); err != nil {
+		return err
+	}
+	logger.Debug("bootnodes updated", "count", len(bps))
+	return nil
+}
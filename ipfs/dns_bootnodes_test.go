@@ -0,0 +1,60 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"github.com/idena-network/idena-go/config"
+	"github.com/idena-network/idena-go/log"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const (
+	testBootNode1 = "/ip4/1.2.3.4/tcp/40405/ipfs/QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN"
+	testBootNode2 = "/ip4/1.2.3.5/tcp/40405/ipfs/QmQCU2EcMqAqQPR2i9bChDtGNJchTbq5TbXJJ16u19uLTa"
+)
+
+func mockLookupTXT(t *testing.T, records []string, err error) {
+	lookup := lookupTXT
+	t.Cleanup(func() {
+		lookupTXT = lookup
+	})
+	lookupTXT = func(ctx context.Context, domain string) ([]string, error) {
+		return records, err
+	}
+}
+
+func TestResolveDnsBootNodes(t *testing.T) {
+	mockLookupTXT(t, []string{" " + testBootNode1 + " ", dnsAddrPrefix + testBootNode2, "v=spf1 -all"}, nil)
+	nodes, err := resolveDnsBootNodes(context.Background(), "bootnodes.example.com")
+	require.NoError(t, err)
+	require.Equal(t, []string{testBootNode1, testBootNode2}, nodes)
+}
+
+func TestResolveDnsBootNodes_Cancel(t *testing.T) {
+	lookup := lookupTXT
+	t.Cleanup(func() {
+		lookupTXT = lookup
+	})
+	lookupTXT = func(ctx context.Context, domain string) ([]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := resolveDnsBootNodes(ctx, "bootnodes.example.com")
+	require.Equal(t, context.Canceled, err)
+}
+
+func TestBootNodes(t *testing.T) {
+	cfg := &config.IpfsConfig{BootNodes: []string{testBootNode1}}
+	require.Equal(t, []string{testBootNode1}, bootNodes(context.Background(), cfg, log.New()))
+
+	cfg.BootNodesDns = "bootnodes.example.com"
+	mockLookupTXT(t, []string{testBootNode2, testBootNode1}, nil)
+	require.Equal(t, []string{testBootNode1, testBootNode2}, bootNodes(context.Background(), cfg, log.New()))
+	require.Equal(t, []string{testBootNode1}, cfg.BootNodes)
+
+	mockLookupTXT(t, nil, errors.New("no such host"))
+	require.Equal(t, []string{testBootNode1}, bootNodes(context.Background(), cfg, log.New()))
+}
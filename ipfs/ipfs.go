@@ -26,6 +26,7 @@ import (
 	"github.com/ipfs/kubo/core/coreapi"
 	"github.com/ipfs/kubo/core/corerepo"
 	"github.com/ipfs/kubo/core/coreunix"
+	"github.com/ipfs/kubo/core/node/libp2p"
 	"github.com/ipfs/kubo/plugin/loader"
 	"github.com/ipfs/kubo/repo/fsrepo"
 	core2 "github.com/libp2p/go-libp2p-core"
@@ -43,6 +44,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -92,8 +94,17 @@ type Proxy interface {
 	GetWithSizeLimit(key []byte, dataType DataType, size int64) ([]byte, error)
 	PubSub() *pubsub.PubSub
 	GC() (ctx context.Context, cancel context.CancelFunc)
+	SetBootNodes(nodes []string) error
+	// SetLowDiskSpace stops pinning flips of other identities while the disk is almost full
+	SetLowDiskSpace(low bool)
+	Close() error
 }
 
+var (
+	pluginsOnce sync.Once
+	pluginsErr  error
+)
+
 type ipfsProxy struct {
 	node                 *core.IpfsNode
 	log                  log.Logger
@@ -102,10 +113,15 @@ type ipfsProxy struct {
 	cfg                  *config.IpfsConfig
 	nodeCtx              context.Context
 	nodeCtxCancel        context.CancelFunc
+	closeCtx             context.Context // unlike nodeCtx it lives until the proxy is closed
+	closeCancel          context.CancelFunc
 	nilNode              *core.IpfsNode
 	lastPeersUpdatedTime time.Time
 	bus                  eventbus.Bus
 	gcMutex              sync.RWMutex
+	bootNodesMutex       sync.Mutex
+	bootNodeRotation     *bootNodeRotation
+	lowDiskSpace         int32
 }
 
 func (p *ipfsProxy) Host() core2.Host {
@@ -142,6 +158,7 @@ func NewIpfsProxy(cfg *config.IpfsConfig, bus eventbus.Bus) (Proxy, error) {
 	logger.Info("Ipfs initialized", "peerId", node.PeerHost.ID().Pretty())
 
 	c := cache.New(2*time.Minute, 5*time.Minute)
+	closeCtx, closeCancel := context.WithCancel(context.Background())
 	p := &ipfsProxy{
 		node:                 node,
 		log:                  logger,
@@ -152,9 +169,13 @@ func NewIpfsProxy(cfg *config.IpfsConfig, bus eventbus.Bus) (Proxy, error) {
 		lastPeersUpdatedTime: time.Now().UTC(),
 		nilNode:              nilNode,
 		bus:                  bus,
+		bootNodeRotation:     newBootNodeRotation(log.New("component", "bootnodes")),
+		closeCtx:             closeCtx,
+		closeCancel:          closeCancel,
 	}
 
 	go p.watchPeers()
+	go p.refreshDnsBootNodes(closeCtx)
 	return p, nil
 }
 
@@ -174,7 +195,7 @@ func createNode(cfg *config.IpfsConfig, eventBus eventbus.Bus) (*core.IpfsNode,
 
 	ctx, cancelCtx := context.WithCancel(context.Background())
 
-	node, err := core.NewNode(ctx, getNodeConfig(dataDir))
+	node, err := core.NewNode(ctx, getNodeConfig(dataDir, cfg.Socks5Proxy))
 	if err != nil {
 		cancelCtx()
 		return nil, nil, func() {}, err
@@ -198,6 +219,19 @@ func (p *ipfsProxy) gc(ctx context.Context, cancel context.CancelFunc) {
 	cancel()
 }
 
+// Close stops the IPFS node together with the libp2p host shared with the gossip protocol
+func (p *ipfsProxy) Close() error {
+	p.rwLock.Lock()
+	defer p.rwLock.Unlock()
+	p.closeCancel()
+	err := p.node.Close()
+	p.nodeCtxCancel()
+	if p.nilNode != nil {
+		p.nilNode.Close()
+	}
+	return err
+}
+
 func (p *ipfsProxy) changePort() {
 	p.rwLock.Lock()
 	defer p.rwLock.Unlock()
@@ -236,6 +270,7 @@ func (p *ipfsProxy) changePort() {
 func (p *ipfsProxy) watchPeers() {
 	api, _ := coreapi.NewCoreAPI(p.node)
 	logger := log.New("component", "ipfs watch")
+	extAddrs := newExternalAddrs(logger)
 
 	for {
 		if !p.cfg.StaticPort && time.Now().UTC().Sub(p.lastPeersUpdatedTime) > ZeroPeersTimeout {
@@ -249,6 +284,8 @@ func (p *ipfsProxy) watchPeers() {
 		}
 		if len(info) > 0 {
 			p.lastPeersUpdatedTime = time.Now().UTC()
+		} else if err == nil {
+			p.connectBootNodes()
 		}
 
 		if p.cfg.PublishPeers {
@@ -258,22 +295,56 @@ func (p *ipfsProxy) watchPeers() {
 			})
 		}
 
+		if p.natPortMapEnabled() {
+			extAddrs.update(p)
+		}
+
 		logger.Trace("last time with non-peers", "time", p.lastPeersUpdatedTime, "peers count", len(info))
 		time.Sleep(time.Second * 10)
 	}
 }
 
+func (p *ipfsProxy) natPortMapEnabled() bool {
+	repoCfg, err := p.node.Repo.Config()
+	return err == nil && !repoCfg.Swarm.DisableNatPortMap
+}
+
+// connectBootNodes falls back across the bootnodes while the node has no peers
+func (p *ipfsProxy) connectBootNodes() {
+	repoCfg, err := p.node.Repo.Config()
+	if err != nil {
+		return
+	}
+	nodes, err := repoCfg.BootstrapPeers()
+	if err != nil || len(nodes) == 0 {
+		return
+	}
+	if err := p.bootNodeRotation.connect(context.Background(), p.node.PeerHost, nodes); err != nil {
+		p.log.Warn("cannot connect to bootnodes", "count", len(nodes), "err", err)
+	}
+}
+
 func (p *ipfsProxy) ShouldPin(dataType DataType) bool {
 	q := rand.Float32()
 	if dataType == Block {
 		return q <= p.cfg.BlockPinThreshold
 	}
 	if dataType == Flip {
-		return q <= p.cfg.FlipPinThreshold
+		return q <= p.cfg.FlipPinThreshold && atomic.LoadInt32(&p.lowDiskSpace) == 0
 	}
 	return true
 }
 
+func (p *ipfsProxy) SetLowDiskSpace(low bool) {
+	var value int32
+	if low {
+		value = 1
+	}
+	if atomic.SwapInt32(&p.lowDiskSpace, value) != value {
+		p.log.Info("Flip pinning is switched", "enabled", !low)
+	}
+}
+
 func (p *ipfsProxy) maxSize(dataType DataType) int64 {
 	switch dataType {
 	case Flip:
@@ -598,6 +669,23 @@ func (p *ipfsProxy) Cid(data []byte) (cid.Cid, error) {
 	return nd.Cid(), nil
 }
 
+// configureSocksProxy disables the transports that bypass the proxy, the stored repo config keeps them
+// disabled, so they are reset to defaults once the proxy is removed
+func configureSocksProxy(ipfsConfig *ipfsConf.Config, proxy string) {
+	if proxy == "" {
+		ipfsConfig.Swarm.Transports.Network.TCP = ipfsConf.Default
+		ipfsConfig.Swarm.Transports.Network.QUIC = ipfsConf.Default
+		ipfsConfig.Swarm.Transports.Network.Websocket = ipfsConf.Default
+		return
+	}
+	// the default TCP transport is replaced with the proxied one, other transports would leak the real address
+	ipfsConfig.Swarm.Transports.Network.TCP = ipfsConf.False
+	ipfsConfig.Swarm.Transports.Network.QUIC = ipfsConf.False
+	ipfsConfig.Swarm.Transports.Network.Websocket = ipfsConf.False
+	ipfsConfig.Swarm.EnableHolePunching = ipfsConf.False
+	ipfsConfig.Swarm.DisableNatPortMap = true
+}
+
 func configureIpfs(cfg *config.IpfsConfig, eventBus eventbus.Bus) (*ipfsConf.Config, error) {
 	updateIpfsConfig := func(ipfsConfig *ipfsConf.Config) error {
 		ipfsConfig.Addresses.Swarm = []string{
@@ -605,7 +693,7 @@ func configureIpfs(cfg *config.IpfsConfig, eventBus eventbus.Bus) (*ipfsConf.Con
 			fmt.Sprintf("/ip6/::/tcp/%d", cfg.IpfsPort),
 		}
 
-		bps, err := ipfsConf.ParseBootstrapPeers(cfg.BootNodes)
+		bps, err := ipfsConf.ParseBootstrapPeers(bootNodes(context.Background(), cfg, log.New("component", "dns bootnodes")))
 		if err != nil {
 			return err
 		}
@@ -617,11 +705,15 @@ func configureIpfs(cfg *config.IpfsConfig, eventBus eventbus.Bus) (*ipfsConf.Con
 		ipfsConfig.Swarm.ConnMgr.HighWater = cfg.HighWater
 		ipfsConfig.Reprovider.Interval = cfg.ReproviderInterval
 		ipfsConfig.Reprovider.Strategy = "pinned"
-		ipfsConfig.Swarm.Transports.Security.Noise = ipfsConf.Disabled
+		if err := configureSecurity(ipfsConfig, cfg.SecurityTransports); err != nil {
+			return err
+		}
 
 		ipfsConfig.Swarm.RelayClient.Enabled = ipfsConf.True
 		ipfsConfig.Swarm.EnableHolePunching = ipfsConf.True
 		ipfsConfig.Swarm.EnableAutoRelay = false
+		// profiles may disable port mapping further, e.g. the server profile for nodes with public addresses
+		ipfsConfig.Swarm.DisableNatPortMap = !cfg.NatPortMap
 
 		if cfg.Profile != "" {
 			transformer, ok := ipfsConf.Profiles[cfg.Profile]
@@ -634,6 +726,10 @@ func configureIpfs(cfg *config.IpfsConfig, eventBus eventbus.Bus) (*ipfsConf.Con
 			}
 		}
 
+		ipfsConfig.Addresses.AppendAnnounce = cfg.ExternalAddresses
+
+		configureSocksProxy(ipfsConfig, cfg.Socks5Proxy)
+
 		return nil
 	}
 	var ipfsConfig *ipfsConf.Config
@@ -701,10 +797,16 @@ func writeSwarmKey(dataDir string, swarmKey string) {
 	}
 }
 
-func getNodeConfig(dataDir string) *core.BuildCfg {
+func getNodeConfig(dataDir string, socks5Proxy string) *core.BuildCfg {
 	repo, _ := fsrepo.Open(dataDir)
 
+	var hostOption libp2p.HostOption
+	if socks5Proxy != "" {
+		hostOption = socksHostOption(socks5Proxy)
+	}
+
 	return &core.BuildCfg{
+		Host:                        hostOption,
 		Repo:                        repo,
 		Permanent:                   true,
 		Online:                      true,
@@ -718,7 +820,16 @@ func getNodeConfig(dataDir string) *core.BuildCfg {
 
 }
 
+// loadPlugins injects the IPFS plugins, they are global for the process, so the nodes started later in the same process
+// reuse the plugins of the first one
 func loadPlugins(cfg *config.IpfsConfig) error {
+	pluginsOnce.Do(func() {
+		pluginsErr = injectPlugins(cfg)
+	})
+	return pluginsErr
+}
+
+func injectPlugins(cfg *config.IpfsConfig) error {
 	dataDir, _ := filepath.Abs(cfg.DataDir)
 	pluginPath := filepath.Join(dataDir, "plugins")
 
@@ -846,6 +957,17 @@ func configAt(repoPath string) (*ipfsConf.Config, error) {
 	return serialize.Load(configFilename)
 }
 
+func (i *memoryIpfs) SetBootNodes(nodes []string) error {
+	return nil
+}
+
+func (i *memoryIpfs) SetLowDiskSpace(low bool) {
+}
+
+func (i *memoryIpfs) Close() error {
+	return nil
+}
+
 func (i *memoryIpfs) GC() (ctx context.Context, cancel context.CancelFunc) {
 	panic("implement me")
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-go/common/eventbus"
 	"github.com/idena-network/idena-go/config"
+	ipfsConf "github.com/ipfs/kubo/config"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -111,3 +112,18 @@ func TestIpfsProxy_Get_Limit(t *testing.T) {
 	_, err = proxy.Get(cid.Bytes(), Block)
 	require.NoError(err)
 }
+
+func Test_configureSocksProxy(t *testing.T) {
+	ipfsConfig := &ipfsConf.Config{}
+
+	configureSocksProxy(ipfsConfig, "127.0.0.1:9050")
+	require.Equal(t, ipfsConf.False, ipfsConfig.Swarm.Transports.Network.TCP)
+	require.Equal(t, ipfsConf.False, ipfsConfig.Swarm.Transports.Network.QUIC)
+	require.Equal(t, ipfsConf.False, ipfsConfig.Swarm.Transports.Network.Websocket)
+	require.True(t, ipfsConfig.Swarm.DisableNatPortMap)
+
+	configureSocksProxy(ipfsConfig, "")
+	require.Equal(t, ipfsConf.Default, ipfsConfig.Swarm.Transports.Network.TCP)
+	require.Equal(t, ipfsConf.Default, ipfsConfig.Swarm.Transports.Network.QUIC)
+	require.Equal(t, ipfsConf.Default, ipfsConfig.Swarm.Transports.Network.Websocket)
+}
@@ -0,0 +1,40 @@
+package ipfs
+
+import (
+	"github.com/idena-network/idena-go/log"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// externalAddrs tracks public addresses of the host, they appear after successful UPnP or NAT-PMP port mapping
+// and are advertised to other peers via identify and DHT
+type externalAddrs struct {
+	known map[string]struct{}
+	log   log.Logger
+}
+
+func newExternalAddrs(logger log.Logger) *externalAddrs {
+	return &externalAddrs{
+		known: make(map[string]struct{}),
+		log:   logger,
+	}
+}
+
+func (e *externalAddrs) update(p *ipfsProxy) {
+	actual := make(map[string]struct{})
+	for _, addr := range p.node.PeerHost.Addrs() {
+		if !manet.IsPublicAddr(addr) {
+			continue
+		}
+		s := addr.String()
+		actual[s] = struct{}{}
+		if _, ok := e.known[s]; !ok {
+			e.log.Info("External address discovered", "addr", s)
+		}
+	}
+	for s := range e.known {
+		if _, ok := actual[s]; !ok {
+			e.log.Info("External address lost", "addr", s)
+		}
+	}
+	e.known = actual
+}
@@ -0,0 +1,34 @@
+package ipfs
+
+import (
+	ipfsConf "github.com/ipfs/kubo/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	securityTLS   = "tls"
+	securityNoise = "noise"
+)
+
+// configureSecurity enables the listed connection security protocols in order of preference,
+// peers negotiate the first protocol supported by both sides
+func configureSecurity(ipfsConfig *ipfsConf.Config, transports []string) error {
+	if len(transports) == 0 {
+		transports = []string{securityTLS}
+	}
+	ipfsConfig.Swarm.Transports.Security.TLS = ipfsConf.Disabled
+	ipfsConfig.Swarm.Transports.Security.Noise = ipfsConf.Disabled
+	for i, transport := range transports {
+		// lower value means higher priority
+		priority := ipfsConf.Priority(100 * (i + 1))
+		switch transport {
+		case securityTLS:
+			ipfsConfig.Swarm.Transports.Security.TLS = priority
+		case securityNoise:
+			ipfsConfig.Swarm.Transports.Security.Noise = priority
+		default:
+			return errors.Errorf("unknown security transport: %v", transport)
+		}
+	}
+	return nil
+}
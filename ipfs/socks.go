@@ -0,0 +1,105 @@
+package ipfs
+
+import (
+	"context"
+	"github.com/ipfs/kubo/core/node/libp2p"
+	p2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/libp2p/go-libp2p-core/transport"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"github.com/pkg/errors"
+	netproxy "golang.org/x/net/proxy"
+	"net"
+)
+
+// socksTransport dials outbound TCP connections through a SOCKS5 proxy (e.g. Tor),
+// listening is served by the regular TCP transport
+type socksTransport struct {
+	*tcp.TcpTransport
+	upgrader transport.Upgrader
+	rcmgr    network.ResourceManager
+	dialer   netproxy.ContextDialer
+}
+
+func newSocksTransport(proxyAddr string) func(upgrader transport.Upgrader, rcmgr network.ResourceManager) (*socksTransport, error) {
+	return func(upgrader transport.Upgrader, rcmgr network.ResourceManager) (*socksTransport, error) {
+		tcpTransport, err := tcp.NewTCPTransport(upgrader, rcmgr)
+		if err != nil {
+			return nil, err
+		}
+		dialer, err := netproxy.SOCKS5("tcp", proxyAddr, nil, netproxy.Direct)
+		if err != nil {
+			return nil, err
+		}
+		contextDialer, ok := dialer.(netproxy.ContextDialer)
+		if !ok {
+			return nil, errors.New("socks5 dialer doesn't support context")
+		}
+		return &socksTransport{
+			TcpTransport: tcpTransport,
+			upgrader:     upgrader,
+			rcmgr:        rcmgr,
+			dialer:       contextDialer,
+		}, nil
+	}
+}
+
+func (t *socksTransport) Dial(ctx context.Context, raddr multiaddr.Multiaddr, p peer.ID) (transport.CapableConn, error) {
+	scope, err := t.rcmgr.OpenConnection(network.DirOutbound, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := scope.SetPeer(p); err != nil {
+		scope.Done()
+		return nil, err
+	}
+	netw, addr, err := manet.DialArgs(raddr)
+	if err != nil {
+		scope.Done()
+		return nil, err
+	}
+	conn, err := t.dialer.DialContext(ctx, netw, addr)
+	if err != nil {
+		scope.Done()
+		return nil, errors.Wrap(err, "socks5 dial failed")
+	}
+	laddr, err := manet.FromNetAddr(conn.LocalAddr())
+	if err != nil {
+		conn.Close()
+		scope.Done()
+		return nil, err
+	}
+	return t.upgrader.Upgrade(ctx, t, &proxiedConn{Conn: conn, laddr: laddr, raddr: raddr}, network.DirOutbound, p, scope)
+}
+
+func (t *socksTransport) Proxy() bool {
+	return true
+}
+
+// proxiedConn reports the dialed address as remote instead of the proxy address
+type proxiedConn struct {
+	net.Conn
+	laddr multiaddr.Multiaddr
+	raddr multiaddr.Multiaddr
+}
+
+func (c *proxiedConn) LocalMultiaddr() multiaddr.Multiaddr {
+	return c.laddr
+}
+
+func (c *proxiedConn) RemoteMultiaddr() multiaddr.Multiaddr {
+	return c.raddr
+}
+
+// socksHostOption builds the libp2p host with outbound TCP dials going through the proxy,
+// the default TCP transport must be disabled in the IPFS config
+func socksHostOption(proxyAddr string) libp2p.HostOption {
+	return func(id peer.ID, ps peerstore.Peerstore, options ...p2p.Option) (host.Host, error) {
+		return libp2p.DefaultHostOption(id, ps, append(options, p2p.Transport(newSocksTransport(proxyAddr)))...)
+	}
+}
@@ -218,6 +218,21 @@ func keyFileName(keyAddr common.Address) string {
 	return fmt.Sprintf("UTC--%s--%s", toISO8601(ts), hex.EncodeToString(keyAddr[:]))
 }
 
+// KeyFileTime returns the creation time of the key file, it is taken from the name given by the keystore
+// and from the modification time for the imported files with other names
+func KeyFileTime(path string) (time.Time, error) {
+	if parts := strings.Split(filepath.Base(path), "--"); len(parts) == 3 && parts[0] == "UTC" {
+		if t, err := time.Parse("2006-01-02T15-04-05.999999999Z", parts[1]); err == nil {
+			return t, nil
+		}
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return stat.ModTime().UTC(), nil
+}
+
 func toISO8601(t time.Time) string {
 	var tz string
 	name, offset := t.Zone()
@@ -114,6 +114,14 @@ func (ks *KeyStore) Accounts() []Account {
 	return ks.cache.accounts()
 }
 
+// IsUnlocked reports whether the private key of the address is kept in memory
+func (ks *KeyStore) IsUnlocked(addr common.Address) bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	_, found := ks.unlocked[addr]
+	return found
+}
+
 // Delete deletes the key matched by account if the passphrase is correct.
 // If the account contains no filename, the address must match a unique key.
 func (ks *KeyStore) Delete(a Account, passphrase string) error {
@@ -64,6 +64,37 @@ func TestKeyStore(t *testing.T) {
 	}
 }
 
+func TestKeyStore_AccountInfo(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	before := time.Now().UTC().Add(-time.Second)
+	a, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := KeyFileTime(a.URL.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Before(before) || created.After(time.Now().UTC()) {
+		t.Errorf("wrong creation time %v", created)
+	}
+	if ks.IsUnlocked(a.Address) {
+		t.Error("new account should be locked")
+	}
+	if err := ks.Unlock(a, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if !ks.IsUnlocked(a.Address) {
+		t.Error("account should be unlocked")
+	}
+	ks.Lock(a.Address)
+	if ks.IsUnlocked(a.Address) {
+		t.Error("account should be locked")
+	}
+}
+
 func TestSign(t *testing.T) {
 	dir, ks := tmpKeyStore(t, true)
 	defer os.RemoveAll(dir)
@@ -196,6 +196,28 @@ func logfmt(buf *bytes.Buffer, ctx []interface{}, color int, term bool) {
 	buf.WriteByte('\n')
 }
 
+// Names of the formats selectable by the node config
+const (
+	TerminalFormatName = "terminal"
+	LogfmtFormatName   = "logfmt"
+	JSONFormatName     = "json"
+)
+
+// FormatByName returns the format with the name, the empty name selects the terminal format.
+// JSON records are separated by newlines, so they can be ingested by Loki or ELK.
+func FormatByName(name string, usecolor bool) (Format, error) {
+	switch name {
+	case "", TerminalFormatName:
+		return TerminalFormat(usecolor), nil
+	case LogfmtFormatName:
+		return LogfmtFormat(), nil
+	case JSONFormatName:
+		return JSONFormat(), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", name)
+	}
+}
+
 // JSONFormat formats log records as JSON objects separated by newlines.
 // It is the equivalent of JSONFormatEx(false, true).
 func JSONFormat() Format {
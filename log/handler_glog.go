@@ -62,6 +62,9 @@ func (h *GlogHandler) SetHandler(nh Handler) {
 	h.origin = nh
 }
 
+// noPatternLvl marks the cached callsites which don't match any Vmodule pattern
+const noPatternLvl = Lvl(-1)
+
 // pattern contains a filter for the Vmodule option, holding a verbosity level
 // and a file pattern to match.
 type pattern struct {
@@ -70,7 +73,7 @@ type pattern struct {
 }
 
 // Verbosity sets the glog verbosity ceiling. The verbosity of individual packages
-// and source files can be raised using Vmodule.
+// and source files can be raised or lowered using Vmodule.
 func (h *GlogHandler) Verbosity(level Lvl) {
 	atomic.StoreUint32(&h.level, uint32(level))
 }
@@ -112,8 +115,8 @@ func (h *GlogHandler) Vmodule(ruleset string) error {
 		if err != nil {
 			return errVmoduleSyntax
 		}
-		if level <= 0 {
-			continue // Ignore. It's harmless but no point in paying the overhead.
+		if level < 0 {
+			return errVmoduleSyntax
 		}
 		// Compile the rule pattern into a regular expression
 		matcher := ".*"
@@ -197,12 +200,11 @@ func (h *GlogHandler) Log(r *Record) error {
 			r.Msg += "\n\n" + string(buf)
 		}
 	}
-	// If the global log level allows, fast track logging
-	if atomic.LoadUint32(&h.level) >= uint32(r.Lvl) {
-		return h.origin.Log(r)
-	}
-	// If no local overrides are present, fast track skipping
+	// If no local overrides are present, the global log level decides
 	if atomic.LoadUint32(&h.override) == 0 {
+		if atomic.LoadUint32(&h.level) >= uint32(r.Lvl) {
+			return h.origin.Log(r)
+		}
 		return nil
 	}
 	// Check callsite cache for previously calculated log levels
@@ -213,18 +215,20 @@ func (h *GlogHandler) Log(r *Record) error {
 	// If we didn't cache the callsite yet, calculate it
 	if !ok {
 		h.lock.Lock()
+		lvl = noPatternLvl
 		for _, rule := range h.patterns {
 			if rule.pattern.MatchString(fmt.Sprintf("%+s", r.Call)) {
-				h.siteCache[r.Call.PC()], lvl, ok = rule.level, rule.level, true
+				lvl = rule.level
 				break
 			}
 		}
-		// If no rule matched, remember to drop log the next time
-		if !ok {
-			h.siteCache[r.Call.PC()] = 0
-		}
+		h.siteCache[r.Call.PC()] = lvl
 		h.lock.Unlock()
 	}
+	// Patterns may lower the level too, so the global level applies only to the callsites without a pattern
+	if lvl == noPatternLvl {
+		lvl = Lvl(atomic.LoadUint32(&h.level))
+	}
 	if lvl >= r.Lvl {
 		return h.origin.Log(r)
 	}
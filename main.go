@@ -1,16 +1,25 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/coreos/go-semver/semver"
+	"github.com/idena-network/idena-go/blockchain"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-go/config"
 	"github.com/idena-network/idena-go/database"
+	"github.com/idena-network/idena-go/devnet"
 	"github.com/idena-network/idena-go/log"
 	"github.com/idena-network/idena-go/node"
+	"github.com/pkg/errors"
+	dbm "github.com/tendermint/tm-db"
 	"github.com/urfave/cli"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 )
 
 const (
@@ -20,22 +29,32 @@ const (
 )
 
 var (
-	version = "0.0.1"
+	version   = "0.0.1"
+	gitCommit = "unknown"
+	buildDate = "unknown"
 )
 
 func main() {
+	buildInfo := config.NewBuildInfo(version, gitCommit, buildDate)
 	app := cli.NewApp()
 	app.Version = version
+	cli.VersionPrinter = func(c *cli.Context) {
+		fmt.Println(buildInfo)
+	}
 
 	app.Flags = []cli.Flag{
 		config.CfgFileFlag,
 		config.DataDirFlag,
+		config.TestnetFlag,
+		config.DevnetFlag,
 		config.TcpPortFlag,
 		config.RpcHostFlag,
 		config.RpcPortFlag,
 		config.BootNodeFlag,
 		config.AutomineFlag,
+		config.MinBlockIntervalFlag,
 		config.IpfsBootNodeFlag,
+		config.BootNodesDnsFlag,
 		config.IpfsPortFlag,
 		config.NoDiscoveryFlag,
 		config.VerbosityFlag,
@@ -44,80 +63,511 @@ func main() {
 		config.MaxNetworkDelayFlag,
 		config.FastSyncFlag,
 		config.ForceFullSyncFlag,
+		config.SyncRateLimitFlag,
+		config.DbBackendFlag,
+		config.RepairDbFlag,
+		config.DbCacheFlag,
+		config.DbHandlesFlag,
 		config.ProfileFlag,
 		config.IpfsPortStaticFlag,
+		config.NoNatPortMapFlag,
+		config.ExternalAddressFlag,
+		config.Socks5ProxyFlag,
 		config.ApiKeyFlag,
 		config.LogFileSizeFlag,
 		config.LogColoring,
 		config.AutoOnline,
+		config.MaxPeersFlag,
+		config.MaxInboundFlag,
+		config.MaxOutboundFlag,
+		config.CeremonySimulationFlag,
+		config.PprofFlag,
+		config.PprofPortFlag,
 	}
 
-	app.Action = func(context *cli.Context) error {
-		logLvl := log.Lvl(context.Int(config.VerbosityFlag.Name))
-		logFileSize := context.Int(config.LogFileSizeFlag.Name)
+	app.Commands = []cli.Command{
+		{
+			Name:  "version",
+			Usage: "Print the version and build metadata",
+			Action: func(context *cli.Context) error {
+				fmt.Println(buildInfo)
+				return nil
+			},
+		},
+		accountCommand,
+		newServiceCommand(app.Flags, buildInfo),
+		{
+			Name:  "config",
+			Usage: "Configuration tools",
+			Subcommands: []cli.Command{
+				{
+					Name:   "check",
+					Usage:  "Validate the configuration and print it with the flags applied, secrets are redacted",
+					Flags:  app.Flags,
+					Action: configCheck,
+				},
+			},
+		},
+		{
+			Name:  "compactdb",
+			Usage: "Run a full compaction of the chain database, the node must be stopped",
+			Flags: []cli.Flag{
+				config.CfgFileFlag,
+				config.DataDirFlag,
+				config.TestnetFlag,
+				config.DevnetFlag,
+				config.DbBackendFlag,
+				config.RepairDbFlag,
+				config.DbCacheFlag,
+				config.DbHandlesFlag,
+			},
+			Action: func(context *cli.Context) error {
+				log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stdout, log.TerminalFormat(runtime.GOOS != "windows"))))
+				cfg, err := config.MakeConfig(context, func(cfg *config.Config) {})
+				if err != nil {
+					return err
+				}
+				db, err := node.OpenDatabase(cfg.DataDir, cfg.Db, "idenachain", cfg.Db.Cache, cfg.Db.Handles, false)
+				if err != nil {
+					return err
+				}
+				defer db.Close()
+				return node.CompactDatabase(db)
+			},
+		},
+		{
+			Name:      "exportsnapshot",
+			Usage:     "Export the state of the head block to clone the node, the node must be stopped",
+			ArgsUsage: "<dir>",
+			Flags: []cli.Flag{
+				config.CfgFileFlag,
+				config.DataDirFlag,
+				config.TestnetFlag,
+				config.DevnetFlag,
+				config.DbBackendFlag,
+			},
+			Action: func(context *cli.Context) error {
+				return runSnapshotCommand(context, blockchain.ExportSnapshot)
+			},
+		},
+		{
+			Name:      "importsnapshot",
+			Usage:     "Import the exported state into a fresh data directory",
+			ArgsUsage: "<dir>",
+			Flags: []cli.Flag{
+				config.CfgFileFlag,
+				config.DataDirFlag,
+				config.TestnetFlag,
+				config.DevnetFlag,
+				config.DbBackendFlag,
+			},
+			Action: func(context *cli.Context) error {
+				return runSnapshotCommand(context, blockchain.ImportSnapshot)
+			},
+		},
+		{
+			Name:      "exportidentities",
+			Usage:     "Export the identities with stakes and balances at the last epoch snapshot, the node must be stopped",
+			ArgsUsage: "<file>",
+			Flags: []cli.Flag{
+				config.CfgFileFlag,
+				config.DataDirFlag,
+				config.TestnetFlag,
+				config.DevnetFlag,
+				config.DbBackendFlag,
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "Export format: json or csv",
+					Value: blockchain.IdentitiesExportJson,
+				},
+			},
+			Action: exportIdentities,
+		},
+		{
+			Name:      "exporttxs",
+			Usage:     "Export the transaction history of a node account, the node must be stopped",
+			ArgsUsage: "<address> <file>",
+			Flags: []cli.Flag{
+				config.CfgFileFlag,
+				config.DataDirFlag,
+				config.TestnetFlag,
+				config.DevnetFlag,
+				config.DbBackendFlag,
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "Export format: csv or jsonl",
+					Value: blockchain.TxsExportCsv,
+				},
+			},
+			Action: exportTxs,
+		},
+		{
+			Name:      "exportblocks",
+			Usage:     "Export the headers and certificates of the canonical blocks to repair another node, the node must be stopped",
+			ArgsUsage: "<file>",
+			Flags: []cli.Flag{
+				config.CfgFileFlag,
+				config.DataDirFlag,
+				config.TestnetFlag,
+				config.DevnetFlag,
+				config.DbBackendFlag,
+				cli.Uint64Flag{
+					Name:  "from",
+					Usage: "First block height",
+				},
+				cli.Uint64Flag{
+					Name:  "to",
+					Usage: "Last block height, the head by default",
+				},
+			},
+			Action: exportBlocks,
+		},
+		{
+			Name:      "importblocks",
+			Usage:     "Restore the headers and certificates lost by the chain database from a file of exportblocks or another data directory, the node must be stopped",
+			ArgsUsage: "<file or data dir>",
+			Flags: []cli.Flag{
+				config.CfgFileFlag,
+				config.DataDirFlag,
+				config.TestnetFlag,
+				config.DevnetFlag,
+				config.DbBackendFlag,
+				cli.Uint64Flag{
+					Name:  "from",
+					Usage: "Lowest block height to check, the whole chain by default",
+				},
+			},
+			Action: importBlocks,
+		},
+		{
+			Name:  "devnet",
+			Usage: "Run a local network of several nodes in one process with the shortened ceremony",
+			Flags: []cli.Flag{
+				config.DataDirFlag,
+				config.VerbosityFlag,
+				cli.IntFlag{
+					Name:  "nodes",
+					Usage: "Number of nodes",
+					Value: 4,
+				},
+				cli.DurationFlag{
+					Name:  "epoch",
+					Usage: "Epoch duration, 20m by default",
+				},
+				cli.IntFlag{
+					Name:  "flips",
+					Usage: "Number of flips every identity should submit per epoch",
+				},
+			},
+			Action: runDevnet,
+		},
+	}
 
-		useLogColor := true
-		if runtime.GOOS == "windows" {
-			useLogColor = context.Bool(config.LogColoring.Name)
+	app.Action = func(context *cli.Context) error {
+		n, err := startNode(context, buildInfo)
+		if err != nil {
+			return err
+		}
+		n.WaitForStop()
+		if n.RestartRequested() {
+			return restartProcess()
 		}
+		return nil
+	}
+
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+// startNode configures logging and starts the node with the command line configuration
+func startNode(context *cli.Context, buildInfo *config.BuildInfo) (*node.Node, error) {
+	logLvl := log.Lvl(context.Int(config.VerbosityFlag.Name))
+	logFileSize := context.Int(config.LogFileSizeFlag.Name)
+
+	useLogColor := true
+	if runtime.GOOS == "windows" {
+		useLogColor = context.Bool(config.LogColoring.Name)
+	}
+
+	consoleHandler := log.StreamHandler(os.Stdout, log.TerminalFormat(useLogColor))
 
-		handler := log.LvlFilterHandler(logLvl, log.StreamHandler(os.Stdout, log.TerminalFormat(useLogColor)))
-
-		log.Root().SetHandler(handler)
-
-		cfg, err := config.MakeConfig(context, func(cfg *config.Config) {
-			db, err := node.OpenDatabase(cfg.DataDir, "idenachain", 16, 16, false)
-			if err != nil {
-				log.Error("Cannot transform consensus config", "err", err)
-				return
-			}
-			defer db.Close()
-			repo := database.NewRepo(db)
-			consVersion := repo.ReadConsensusVersion()
-			if consVersion <= uint32(cfg.Consensus.Version) {
-				return
-			}
-			for v := cfg.Consensus.Version + 1; v <= config.ConsensusVerson(consVersion); v++ {
-				config.ApplyConsensusVersion(v, cfg.Consensus)
-			}
-			log.Info("Consensus config transformed to", "ver", consVersion)
-		})
+	log.Root().SetHandler(log.LvlFilterHandler(logLvl, consoleHandler))
 
+	if _, err := checkConfig(context); err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.MakeConfig(context, func(cfg *config.Config) {
+		db, err := node.OpenDatabase(cfg.DataDir, cfg.Db, "idenachain", cfg.Db.Cache, cfg.Db.Handles, false)
 		if err != nil {
-			return err
+			log.Error("Cannot transform consensus config", "err", err)
+			return
 		}
-		/*
-			err = dropOldDirOnFork(cfg)
-			if err != nil {
-				return err
-			} */
-
-		fileHandler, err := getLogFileHandler(cfg, logFileSize)
+		defer db.Close()
+		repo := database.NewRepo(db)
+		consVersion := repo.ReadConsensusVersion()
+		if consVersion <= uint32(cfg.Consensus.Version) {
+			return
+		}
+		for v := cfg.Consensus.Version + 1; v <= config.ConsensusVerson(consVersion); v++ {
+			config.ApplyConsensusVersion(v, cfg.Consensus)
+		}
+		log.Info("Consensus config transformed to", "ver", consVersion)
+	})
 
+	if err != nil {
+		return nil, err
+	}
+	/*
+		err = dropOldDirOnFork(cfg)
 		if err != nil {
-			return err
+			return nil, err
+		} */
+
+	consoleFormat, err := log.FormatByName(cfg.Log.Format, useLogColor)
+	if err != nil {
+		return nil, err
+	}
+	consoleHandler = log.StreamHandler(os.Stdout, consoleFormat)
+
+	fileHandler, err := getLogFileHandler(cfg, logFileSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the levels of the glog handler can be changed at runtime, see LogConfig.Apply
+	log.Root().SetHandler(log.NewGlogHandler(log.MultiHandler(consoleHandler, fileHandler)))
+	if err := cfg.Log.Apply(); err != nil {
+		return nil, err
+	}
+
+	log.Info("Idena node is starting", "version", version, "commit", gitCommit, "built", buildDate)
+
+	n, err := node.NewNode(cfg, buildInfo)
+	if err != nil {
+		return nil, err
+	}
+	if err := n.Start(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// checkConfig resolves the configuration without touching the data directory and reports its problems
+func checkConfig(context *cli.Context) (*config.Config, error) {
+	unknownKeys, err := config.UnknownConfigKeys(context.String(config.CfgFileFlag.Name))
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range unknownKeys {
+		log.Warn("Unknown config key is ignored", "key", key)
+	}
+	cfg, err := config.MakeConfig(context, func(cfg *config.Config) {})
+	if err != nil {
+		return nil, err
+	}
+	if problems := cfg.Validate(); len(problems) > 0 {
+		for _, problem := range problems {
+			log.Error("Invalid config", "err", problem)
 		}
+		return nil, errors.New("configuration is invalid")
+	}
+	return cfg, nil
+}
+
+func configCheck(context *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stderr, log.TerminalFormat(runtime.GOOS != "windows"))))
+	cfg, err := checkConfig(context)
+	if err != nil {
+		return err
+	}
+	redacted, err := cfg.Redacted()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(redacted, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func exportIdentities(context *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stdout, log.TerminalFormat(runtime.GOOS != "windows"))))
+	path := context.Args().First()
+	if path == "" {
+		return errors.New("export file is not specified")
+	}
+	cfg, err := config.MakeConfig(context, func(cfg *config.Config) {})
+	if err != nil {
+		return err
+	}
+	db, err := node.OpenDatabase(cfg.DataDir, cfg.Db, "idenachain", cfg.Db.Cache, cfg.Db.Handles, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	result, err := blockchain.ExportIdentities(db, path, context.String("format"))
+	if err != nil {
+		return err
+	}
+	log.Info("Identities exported", "path", result.Path, "height", result.Height, "epoch", result.Epoch, "identities", result.Identities)
+	return nil
+}
 
-		log.Root().SetHandler(log.LvlFilterHandler(logLvl, log.MultiHandler(handler, fileHandler)))
+func exportTxs(context *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stdout, log.TerminalFormat(runtime.GOOS != "windows"))))
+	address, path := context.Args().Get(0), context.Args().Get(1)
+	if !common.IsHexAddress(address) {
+		return errors.New("address is not specified or invalid")
+	}
+	if path == "" {
+		return errors.New("export file is not specified")
+	}
+	cfg, err := config.MakeConfig(context, func(cfg *config.Config) {})
+	if err != nil {
+		return err
+	}
+	db, err := node.OpenDatabase(cfg.DataDir, cfg.Db, "idenachain", cfg.Db.Cache, cfg.Db.Handles, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	result, err := blockchain.ExportAddressTxs(db, common.HexToAddress(address), path, context.String("format"))
+	if err != nil {
+		return err
+	}
+	log.Info("Transactions exported", "path", result.Path, "address", result.Address.Hex(), "transactions", result.Transactions)
+	return nil
+}
 
-		log.Info("Idena node is starting", "version", version)
+func exportBlocks(context *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stdout, log.TerminalFormat(runtime.GOOS != "windows"))))
+	path := context.Args().First()
+	if path == "" {
+		return errors.New("export file is not specified")
+	}
+	cfg, err := config.MakeConfig(context, func(cfg *config.Config) {})
+	if err != nil {
+		return err
+	}
+	db, err := node.OpenDatabase(cfg.DataDir, cfg.Db, "idenachain", cfg.Db.Cache, cfg.Db.Handles, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	result, err := blockchain.ExportBlocks(db, context.Uint64("from"), context.Uint64("to"), path)
+	if err != nil {
+		return err
+	}
+	log.Info("Blocks exported", "path", result.Path, "from", result.From, "to", result.To, "blocks", result.Blocks)
+	return nil
+}
 
-		n, err := node.NewNode(cfg, version)
-		if err != nil {
-			return err
+func importBlocks(context *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stdout, log.TerminalFormat(runtime.GOOS != "windows"))))
+	sourcePath := context.Args().First()
+	if sourcePath == "" {
+		return errors.New("source file or data directory is not specified")
+	}
+	cfg, err := config.MakeConfig(context, func(cfg *config.Config) {})
+	if err != nil {
+		return err
+	}
+	var source blockchain.BlocksSource
+	if info, err := os.Stat(sourcePath); err != nil {
+		return err
+	} else if info.IsDir() {
+		sourceDir, _ := filepath.Abs(sourcePath)
+		if dataDir, _ := filepath.Abs(cfg.DataDir); sourceDir == dataDir {
+			return errors.New("source data directory is the data directory of the node")
 		}
-		if err := n.Start(); err != nil {
-			return err
+		sourceDb, err := node.OpenDatabase(sourcePath, cfg.Db, "idenachain", cfg.Db.Cache, cfg.Db.Handles, false)
+		if err != nil {
+			return errors.Wrap(err, "failed to open the source data directory")
 		}
-		n.WaitForStop()
-		return nil
+		defer sourceDb.Close()
+		source = database.NewRepo(sourceDb)
+	} else if source, err = blockchain.ReadBlocksFile(sourcePath); err != nil {
+		return err
+	}
+	db, err := node.OpenDatabase(cfg.DataDir, cfg.Db, "idenachain", cfg.Db.Cache, cfg.Db.Handles, false)
+	if err != nil {
+		return err
 	}
+	defer db.Close()
+	result, err := blockchain.ImportBlocks(db, cfg.Blockchain, source, context.Uint64("from"))
+	if err != nil {
+		return err
+	}
+	log.Info("Blocks imported", "headers", result.Headers, "canonicalHashes", result.CanonicalHashes, "certificates", result.Certificates, "lowest", result.Lowest)
+	if result.MissingHeader {
+		log.Warn("The header below the lowest height is missing in the source, the older blocks are not checked", "height", result.Lowest-1)
+	}
+	return nil
+}
 
-	err := app.Run(os.Args)
+func runDevnet(context *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(context.Int(config.VerbosityFlag.Name)), log.StreamHandler(os.Stdout, log.TerminalFormat(runtime.GOOS != "windows"))))
+	dir := context.String(config.DataDirFlag.Name)
+	if !context.IsSet(config.DataDirFlag.Name) {
+		dir = config.DefaultDataDir + "-localnet"
+	}
+	network, err := devnet.Start(devnet.Config{
+		Nodes: context.Int("nodes"),
+		Dir:   dir,
+		Validation: config.ValidationConfig{
+			ValidationInterval: context.Duration("epoch"),
+		},
+		RequiredFlips: context.Int("flips"),
+	})
 	if err != nil {
-		log.Error(err.Error())
-		os.Exit(1)
+		return err
+	}
+	for i, cfg := range network.Configs {
+		log.Info("Devnet node is started", "index", i, "address", network.Addresses[i].Hex(), "rpc", cfg.RPC.HTTPPort, "ipfs", cfg.IpfsConf.IpfsPort)
+	}
+	// the first node handles the interrupt and the others are stopped after it
+	network.Nodes[0].WaitForStop()
+	network.Stop()
+	return nil
+}
+
+func runSnapshotCommand(context *cli.Context, run func(db dbm.DB, network types.Network, dir string) (*types.Header, error)) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stdout, log.TerminalFormat(runtime.GOOS != "windows"))))
+	dir := context.Args().First()
+	if dir == "" {
+		return errors.New("snapshot directory is not specified")
+	}
+	cfg, err := config.MakeConfig(context, func(cfg *config.Config) {})
+	if err != nil {
+		return err
 	}
+	db, err := node.OpenDatabase(cfg.DataDir, cfg.Db, "idenachain", cfg.Db.Cache, cfg.Db.Handles, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ceremonyDb, err := node.OpenCeremonyDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer ceremonyDb.Close()
+	if err := database.MigrateSchema(db, ceremonyDb); err != nil {
+		return err
+	}
+	start := time.Now()
+	head, err := run(db, cfg.Network, dir)
+	if err != nil {
+		return err
+	}
+	log.Info("Snapshot completed", "height", head.Height(), "hash", head.Hash().Hex(), "duration", time.Since(start))
+	return nil
 }
 
 func getLogFileHandler(cfg *config.Config, logFileSize int) (log.Handler, error) {
@@ -128,7 +578,11 @@ func getLogFileHandler(cfg *config.Config, logFileSize int) (log.Handler, error)
 		}
 	}
 
-	fileHandler, _ := log.RotatingFileHandler(filepath.Join(path, "output.log"), uint32(logFileSize*1024), log.TerminalFormat(false))
+	format, err := log.FormatByName(cfg.Log.FileFormat, false)
+	if err != nil {
+		return nil, err
+	}
+	fileHandler, _ := log.RotatingFileHandler(filepath.Join(path, "output.log"), uint32(logFileSize*1024), format)
 
 	return fileHandler, nil
 }
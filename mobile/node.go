@@ -0,0 +1,258 @@
+// Package mobile is the facade of the node for the Android and iOS applications.
+// Its API is limited to the types supported by gomobile bind
+package mobile
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-go/config"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-go/events"
+	"github.com/idena-network/idena-go/log"
+	"github.com/idena-network/idena-go/node"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// BlockListener receives the applied blocks
+type BlockListener interface {
+	OnBlockApplied(height int64, hash string)
+}
+
+// SyncListener receives the sync progress while the node is syncing and once when the sync is completed
+type SyncListener interface {
+	OnSyncProgress(current int64, highest int64, syncing bool)
+}
+
+// CeremonyListener receives the validation ceremony phases when their first block is added
+type CeremonyListener interface {
+	OnCeremonyPhase(phase string, height int64)
+}
+
+// TxListener receives the confirmations of the transactions sent from or to the node address
+type TxListener interface {
+	OnTxConfirmed(hash string, height int64)
+}
+
+// ErrorListener receives the start failures and the node problems the user should know about
+type ErrorListener interface {
+	OnError(message string)
+}
+
+type Status struct {
+	Address       string
+	Height        int64
+	HighestBlock  int64
+	Syncing       bool
+	Peers         int
+	Epoch         int
+	Period        string
+	IdentityState string
+}
+
+type Node struct {
+	path string
+	cfg  string
+
+	mutex sync.Mutex
+	node  *node.Node
+
+	listenersMutex   sync.RWMutex
+	blockListener    BlockListener
+	syncListener     SyncListener
+	ceremonyListener CeremonyListener
+	txListener       TxListener
+	errorListener    ErrorListener
+}
+
+// NewNode creates the node with the data directory under the path, cfg is the JSON config overriding the defaults
+func NewNode(path string, cfg string) *Node {
+	return &Node{path: path, cfg: cfg}
+}
+
+// ProvideKey imports the node key encrypted with the password, the node must be stopped
+func ProvideKey(path string, cfg string, key string, password string) error {
+	c, err := config.MakeMobileConfig(path, cfg)
+	if err != nil {
+		return err
+	}
+	return c.ProvideNodeKey(key, password, false)
+}
+
+func (n *Node) SetBlockListener(listener BlockListener) {
+	n.listenersMutex.Lock()
+	defer n.listenersMutex.Unlock()
+	n.blockListener = listener
+}
+
+func (n *Node) SetSyncListener(listener SyncListener) {
+	n.listenersMutex.Lock()
+	defer n.listenersMutex.Unlock()
+	n.syncListener = listener
+}
+
+func (n *Node) SetCeremonyListener(listener CeremonyListener) {
+	n.listenersMutex.Lock()
+	defer n.listenersMutex.Unlock()
+	n.ceremonyListener = listener
+}
+
+func (n *Node) SetTxListener(listener TxListener) {
+	n.listenersMutex.Lock()
+	defer n.listenersMutex.Unlock()
+	n.txListener = listener
+}
+
+func (n *Node) SetErrorListener(listener ErrorListener) {
+	n.listenersMutex.Lock()
+	defer n.listenersMutex.Unlock()
+	n.errorListener = listener
+}
+
+func (n *Node) Start() error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if n.node != nil {
+		return errors.New("node is already started")
+	}
+	fileHandler, _ := log.FileHandler(filepath.Join(n.path, "output.log"), log.TerminalFormat(false))
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.MultiHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()), fileHandler)))
+
+	c, err := config.MakeMobileConfig(n.path, n.cfg)
+	if err != nil {
+		n.reportError(err.Error())
+		return err
+	}
+	nd, err := node.NewNode(c, config.NewBuildInfo("mobile", "", ""))
+	if err != nil {
+		n.reportError(err.Error())
+		return err
+	}
+	nd.AddListener(&nodeListener{n})
+	bus := nd.Bus()
+	bus.Subscribe(events.LowDiskSpaceEventID, n.handleLowDiskSpace)
+	bus.Subscribe(events.BlockchainResetEventID, n.handleBlockchainReset)
+	if err := nd.Start(); err != nil {
+		n.reportError(err.Error())
+		return err
+	}
+	n.node = nd
+	return nil
+}
+
+// Stop stops the node and releases the data directory, the node can be started again
+func (n *Node) Stop() error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if n.node == nil {
+		return errors.New("node is not started")
+	}
+	n.node.Stop()
+	n.node.WaitForStop()
+	n.node = nil
+	return nil
+}
+
+func (n *Node) IsRunning() bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.node != nil
+}
+
+func (n *Node) Status() (*Status, error) {
+	n.mutex.Lock()
+	nd := n.node
+	n.mutex.Unlock()
+	if nd == nil {
+		return nil, errors.New("node is not started")
+	}
+	status := nd.Status()
+	return &Status{
+		Address:       status.Address.Hex(),
+		Height:        int64(status.Height),
+		HighestBlock:  int64(status.HighestBlock),
+		Syncing:       status.Syncing,
+		Peers:         status.Peers,
+		Epoch:         int(status.Epoch),
+		Period:        periodName(status.Period),
+		IdentityState: status.IdentityState.String(),
+	}, nil
+}
+
+// nodeListener passes the node progress to the listeners registered by the application
+type nodeListener struct {
+	n *Node
+}
+
+func (l *nodeListener) OnBlockApplied(height uint64, hash common.Hash) {
+	l.n.listenersMutex.RLock()
+	listener := l.n.blockListener
+	l.n.listenersMutex.RUnlock()
+	if listener != nil {
+		listener.OnBlockApplied(int64(height), hash.Hex())
+	}
+}
+
+func (l *nodeListener) OnSyncProgress(head uint64, top uint64, syncing bool) {
+	l.n.listenersMutex.RLock()
+	listener := l.n.syncListener
+	l.n.listenersMutex.RUnlock()
+	if listener != nil {
+		listener.OnSyncProgress(int64(head), int64(top), syncing)
+	}
+}
+
+func (l *nodeListener) OnCeremonyPhase(phase string, height uint64) {
+	l.n.listenersMutex.RLock()
+	listener := l.n.ceremonyListener
+	l.n.listenersMutex.RUnlock()
+	if listener != nil {
+		listener.OnCeremonyPhase(phase, int64(height))
+	}
+}
+
+func (l *nodeListener) OnTxConfirmed(hash common.Hash, height uint64) {
+	l.n.listenersMutex.RLock()
+	listener := l.n.txListener
+	l.n.listenersMutex.RUnlock()
+	if listener != nil {
+		listener.OnTxConfirmed(hash.Hex(), int64(height))
+	}
+}
+
+func (n *Node) handleLowDiskSpace(e eventbus.Event) {
+	lowDisk := e.(*events.LowDiskSpaceEvent)
+	n.reportError(fmt.Sprintf("Low disk space: %v free under %v", common.StorageSize(lowDisk.Free), lowDisk.Path))
+}
+
+func (n *Node) handleBlockchainReset(e eventbus.Event) {
+	reset := e.(*events.BlockchainResetEvent)
+	n.reportError(fmt.Sprintf("Blockchain is reset to block %v", reset.Header.Height()))
+}
+
+func (n *Node) reportError(message string) {
+	n.listenersMutex.RLock()
+	listener := n.errorListener
+	n.listenersMutex.RUnlock()
+	if listener != nil {
+		listener.OnError(message)
+	}
+}
+
+func periodName(period state.ValidationPeriod) string {
+	switch period {
+	case state.FlipLotteryPeriod:
+		return "FlipLottery"
+	case state.ShortSessionPeriod:
+		return "ShortSession"
+	case state.LongSessionPeriod:
+		return "LongSession"
+	case state.AfterLongSessionPeriod:
+		return "AfterLongSession"
+	default:
+		return "None"
+	}
+}
@@ -0,0 +1,76 @@
+package node
+
+import (
+	"github.com/idena-network/idena-go/database"
+	"github.com/idena-network/idena-go/log"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// backup writes consistent copies of the chain and ceremony databases and the keystore to the empty directory
+func (node *Node) backup(path string) error {
+	if !filepath.IsAbs(path) {
+		return errors.New("backup path should be absolute")
+	}
+	if entries, err := ioutil.ReadDir(path); err == nil && len(entries) > 0 {
+		return errors.New("backup directory is not empty")
+	}
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return err
+	}
+	start := time.Now()
+	log.Info("Start backup", "path", path)
+	if err := database.Backup(node.db, filepath.Join(path, "idenachain.db")); err != nil {
+		return errors.Wrap(err, "failed to backup chain database")
+	}
+	if err := database.Backup(node.ceremonyDb, filepath.Join(path, "ceremony.db")); err != nil {
+		return errors.Wrap(err, "failed to backup ceremony database")
+	}
+	keyStoreDir, err := node.config.KeyStoreDataDir()
+	if err != nil {
+		return err
+	}
+	if err := copyDir(keyStoreDir, filepath.Join(path, "keystore")); err != nil {
+		return errors.Wrap(err, "failed to backup keystore")
+	}
+	log.Info("Backup completed", "path", path, "d", time.Since(start))
+	return nil
+}
+
+func copyDir(src string, dst string) error {
+	return filepath.Walk(src, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, file)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0700)
+		}
+		return copyFile(file, target)
+	})
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
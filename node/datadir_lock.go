@@ -0,0 +1,25 @@
+package node
+
+import (
+	fslock "github.com/ipfs/go-fs-lock"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+)
+
+const dataDirLockFile = "node.lock"
+
+// lockDataDir prevents running several nodes on one data directory, they corrupt the database and can double-sign
+func lockDataDir(dataDir string) (io.Closer, error) {
+	if dataDir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return nil, err
+	}
+	lock, err := fslock.Lock(dataDir, dataDirLockFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "data directory %v is used by another running node", dataDir)
+	}
+	return lock, nil
+}
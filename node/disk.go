@@ -0,0 +1,87 @@
+package node
+
+import (
+	"github.com/idena-network/idena-go/common"
+	util "github.com/idena-network/idena-go/common/ulimit"
+	"github.com/idena-network/idena-go/events"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const diskCheckInterval = time.Minute
+
+// watchDisk checks the free space and inodes under the data and IPFS directories. Below the thresholds flips of other
+// identities are not pinned anymore, the low disk space is reported once until the space is freed
+func (node *Node) watchDisk() {
+	cfg := node.config.Runtime
+	if cfg == nil || cfg.MinFreeDisk == 0 && cfg.MinFreeInodes == 0 {
+		return
+	}
+	paths := node.diskPaths()
+	low := false
+	ticker := time.NewTicker(diskCheckInterval)
+	defer ticker.Stop()
+	for {
+		var lowEvent *events.LowDiskSpaceEvent
+		for _, path := range paths {
+			if lowEvent = node.checkDisk(path); lowEvent != nil {
+				break
+			}
+		}
+		if lowEvent != nil && !low {
+			node.log.Warn("Low disk space, flips of other identities are not pinned", "path", lowEvent.Path,
+				"free", common.StorageSize(lowEvent.Free), "freeInodes", lowEvent.FreeInodes)
+			node.ipfsProxy.SetLowDiskSpace(true)
+			node.bus.Publish(lowEvent)
+		} else if lowEvent == nil && low {
+			node.log.Info("Disk space is freed, flips pinning is resumed")
+			node.ipfsProxy.SetLowDiskSpace(false)
+		}
+		low = lowEvent != nil
+
+		select {
+		case <-node.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// diskPaths returns the data directory and the IPFS directory if it is placed outside of the data directory
+func (node *Node) diskPaths() []string {
+	paths := []string{node.config.DataDir}
+	if node.config.IpfsConf == nil || node.config.IpfsConf.DataDir == "" {
+		return paths
+	}
+	rel, err := filepath.Rel(node.config.DataDir, node.config.IpfsConf.DataDir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		paths = append(paths, node.config.IpfsConf.DataDir)
+	}
+	return paths
+}
+
+// checkDisk returns the event if the path is short of space or inodes
+func (node *Node) checkDisk(path string) *events.LowDiskSpaceEvent {
+	cfg := node.config.Runtime
+	free, err := util.FreeDiskSpace(path)
+	if err != nil {
+		node.log.Debug("Failed to get free disk space", "path", path, "err", err)
+		return nil
+	}
+	freeInodes, totalInodes, err := util.FreeInodes(path)
+	if err != nil {
+		node.log.Debug("Failed to get free inodes", "path", path, "err", err)
+		totalInodes = 0
+	}
+	required := uint64(cfg.MinFreeDisk) << 20
+	if free < required || totalInodes > 0 && freeInodes < uint64(cfg.MinFreeInodes) {
+		return &events.LowDiskSpaceEvent{
+			Path:       path,
+			Free:       free,
+			Required:   required,
+			FreeInodes: freeInodes,
+		}
+	}
+	return nil
+}
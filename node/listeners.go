@@ -0,0 +1,100 @@
+package node
+
+import (
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-go/events"
+)
+
+// Listener receives the node progress, the applications embedding the node register it instead of polling.
+// The methods are called synchronously from the node goroutines and must return quickly
+type Listener interface {
+	OnBlockApplied(height uint64, hash common.Hash)
+	// OnSyncProgress is called periodically while the node is syncing and once when the sync is completed
+	OnSyncProgress(head uint64, top uint64, syncing bool)
+	OnCeremonyPhase(phase string, height uint64)
+	// OnTxConfirmed is called for the transactions sent from or to the node address
+	OnTxConfirmed(hash common.Hash, height uint64)
+}
+
+func (node *Node) AddListener(listener Listener) {
+	node.listenersMutex.Lock()
+	defer node.listenersMutex.Unlock()
+	node.listeners = append(node.listeners, listener)
+}
+
+func (node *Node) RemoveListener(listener Listener) {
+	node.listenersMutex.Lock()
+	defer node.listenersMutex.Unlock()
+	for i, l := range node.listeners {
+		if l == listener {
+			node.listeners = append(node.listeners[:i], node.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+func (node *Node) subscribeListeners() {
+	node.bus.Subscribe(events.AddBlockEventID, node.notifyBlock)
+	node.bus.Subscribe(events.SyncProgressEventID, node.notifySyncProgress)
+}
+
+func (node *Node) currentListeners() []Listener {
+	node.listenersMutex.RLock()
+	defer node.listenersMutex.RUnlock()
+	return append([]Listener(nil), node.listeners...)
+}
+
+func (node *Node) notifyBlock(e eventbus.Event) {
+	listeners := node.currentListeners()
+	if len(listeners) == 0 {
+		return
+	}
+	block := e.(*events.NewBlockEvent).Block
+	height := block.Height()
+	address := node.secStore.GetAddress()
+	var confirmed []common.Hash
+	for _, tx := range block.Body.Transactions {
+		sender, _ := types.Sender(tx)
+		if sender == address || tx.To != nil && *tx.To == address {
+			confirmed = append(confirmed, tx.Hash())
+		}
+	}
+	syncCompleted := node.completeSync()
+	for _, listener := range listeners {
+		listener.OnBlockApplied(height, block.Hash())
+		for _, hash := range confirmed {
+			listener.OnTxConfirmed(hash, height)
+		}
+		for _, phase := range types.CeremonyPhases {
+			if block.Header.Flags().HasFlag(phase.Flag) {
+				listener.OnCeremonyPhase(phase.Name, height)
+			}
+		}
+		if syncCompleted {
+			listener.OnSyncProgress(height, height, false)
+		}
+	}
+}
+
+// completeSync reports whether the listeners are notified about the sync and the node is synced now
+func (node *Node) completeSync() bool {
+	node.listenersMutex.Lock()
+	defer node.listenersMutex.Unlock()
+	if !node.reportedSyncing || node.downloader.IsSyncing() || !node.consensusEngine.Synced() {
+		return false
+	}
+	node.reportedSyncing = false
+	return true
+}
+
+func (node *Node) notifySyncProgress(e eventbus.Event) {
+	progress := e.(*events.SyncProgressEvent)
+	node.listenersMutex.Lock()
+	node.reportedSyncing = true
+	node.listenersMutex.Unlock()
+	for _, listener := range node.currentListeners() {
+		listener.OnSyncProgress(progress.Head, progress.Top, true)
+	}
+}
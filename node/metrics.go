@@ -0,0 +1,101 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	util "github.com/idena-network/idena-go/common/ulimit"
+	"github.com/idena-network/idena-go/stats/reporter"
+	"github.com/idena-network/idena-go/stats/tracing"
+	"github.com/idena-network/idena-go/webhooks"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+const tracingFlushTimeout = 5 * time.Second
+
+// startMetricsReporter pushes the core metrics if StatsD or InfluxDB is configured
+func (node *Node) startMetricsReporter() {
+	if node.config.Metrics == nil || !node.config.Metrics.Enabled() {
+		return
+	}
+	r, err := reporter.NewReporter(node.config.Metrics, node.sampleMetrics)
+	if err != nil {
+		node.log.Warn("Failed to start metrics reporter", "err", err)
+		return
+	}
+	r.Start(node.stop)
+}
+
+// startWebhooks sends the critical events to the operator's webhooks and the deposits to the urls of the watched addresses
+func (node *Node) startWebhooks() {
+	if node.config.Webhooks == nil {
+		return
+	}
+	webhooks.NewNotifier(node.config.Webhooks, node.bus, node.secStore.GetAddress(), node.consensusEngine.Synced).Start(node.stop)
+}
+
+// startTracing exports the block, proposal and ceremony spans if the OTLP collector is configured
+func (node *Node) startTracing() {
+	if node.config.Tracing == nil || !node.config.Tracing.Enabled() {
+		return
+	}
+	stop, err := tracing.Start(node.config.Tracing, node.appVersion)
+	if err != nil {
+		node.log.Warn("Failed to start tracing", "err", err)
+		return
+	}
+	node.stopTracing = stop
+}
+
+func (node *Node) flushTracing() {
+	if node.stopTracing == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+	defer cancel()
+	if err := node.stopTracing(ctx); err != nil {
+		node.log.Warn("Failed to flush traces", "err", err)
+	}
+}
+
+func (node *Node) sampleMetrics() []reporter.Metric {
+	head := node.blockchain.Head
+	metrics := []reporter.Metric{
+		{Name: "height", Value: float64(head.Height())},
+		{Name: "peers", Value: float64(node.pm.PeersCount())},
+		{Name: "mempool", Value: float64(node.txpool.Size())},
+		{Name: "rss", Value: float64(residentMemory())},
+		{Name: "goroutines", Value: float64(runtime.NumGoroutine())},
+	}
+	if free, err := util.FreeDiskSpace(node.config.DataDir); err == nil {
+		metrics = append(metrics, reporter.Metric{Name: "disk_free", Value: float64(free)})
+	}
+	if appState, err := node.appState.Readonly(head.Height()); err == nil && appState != nil {
+		metrics = append(metrics,
+			reporter.Metric{Name: "ceremony_phase", Value: float64(appState.State.ValidationPeriod())},
+			reporter.Metric{Name: "epoch", Value: float64(appState.State.Epoch())},
+		)
+	}
+	synced := 0.0
+	if node.consensusEngine.Synced() {
+		synced = 1
+	}
+	return append(metrics, reporter.Metric{Name: "synced", Value: synced})
+}
+
+// residentMemory reads the resident set size on Linux, other platforms report the memory obtained from the OS by Go
+func residentMemory() uint64 {
+	if statm, err := ioutil.ReadFile("/proc/self/statm"); err == nil {
+		if fields := bytes.Fields(statm); len(fields) > 1 {
+			if pages, err := strconv.ParseUint(string(fields[1]), 10, 64); err == nil {
+				return pages * uint64(os.Getpagesize())
+			}
+		}
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.Sys
+}
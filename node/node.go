@@ -1,10 +1,12 @@
 package node
 
 import (
+	"context"
 	"fmt"
 	"github.com/idena-network/idena-go/api"
 	"github.com/idena-network/idena-go/blockchain"
 	"github.com/idena-network/idena-go/blockchain/validation"
+	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-go/common/eventbus"
 	util "github.com/idena-network/idena-go/common/ulimit"
 	"github.com/idena-network/idena-go/config"
@@ -17,6 +19,7 @@ import (
 	"github.com/idena-network/idena-go/core/state"
 	"github.com/idena-network/idena-go/core/upgrade"
 	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-go/database"
 	"github.com/idena-network/idena-go/deferredtx"
 	"github.com/idena-network/idena-go/events"
 	"github.com/idena-network/idena-go/ipfs"
@@ -31,18 +34,30 @@ import (
 	"github.com/idena-network/idena-go/subscriptions"
 	"github.com/idena-network/idena-go/vm"
 	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	lerrors "github.com/syndtr/goleveldb/leveldb/errors"
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/tendermint/tm-db"
+	"io"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 )
 
+// shutdownDelay lets the RPC response of admin_stop and admin_restart reach the caller
+const shutdownDelay = time.Second
+
+// ceremonyStopTimeout limits the wait for the ceremony background work, e.g. flip decryption, on shutdown
+const ceremonyStopTimeout = time.Second * 30
+
 type Node struct {
 	config          *config.Config
 	blockchain      *blockchain.Blockchain
@@ -50,6 +65,9 @@ type Node struct {
 	secStore        *secstore.SecStore
 	pm              *protocol.IdenaGossipHandler
 	stop            chan struct{}
+	stopOnce        sync.Once
+	restart         bool
+	reloadMutex     sync.Mutex
 	proposals       *pengings.Proposals
 	votes           *pengings.Votes
 	consensusEngine *consensus.Engine
@@ -59,6 +77,7 @@ type Node struct {
 	httpListener    net.Listener // HTTP RPC listener socket to server API requests
 	httpHandler     *rpc.Server  // HTTP RPC request handler to process the API requests
 	httpServer      *http.Server
+	inprocHandler   *rpc.Server // In-process RPC request handler for the programs embedding the node
 	log             log.Logger
 	keyStore        *keystore.KeyStore
 	fp              *flip.Flipper
@@ -68,11 +87,21 @@ type Node struct {
 	downloader      *protocol.Downloader
 	offlineDetector *blockchain.OfflineDetector
 	appVersion      string
+	buildInfo       *config.BuildInfo
+	dataDirLock     io.Closer
 	profileManager  *profile.Manager
 	deferJob        *deferredtx.Job
 	subManager      *subscriptions.Manager
+	watcher         *subscriptions.Watcher
 	upgrader        *upgrade.Upgrader
 	nodeState       *state2.NodeState
+	db              db.DB
+	ceremonyDb      db.DB
+	stopTracing     func(ctx context.Context) error
+
+	listenersMutex  sync.RWMutex
+	listeners       []Listener
+	reportedSyncing bool
 }
 
 type NodeCtx struct {
@@ -101,6 +130,7 @@ func (checker *ceremonyChecker) IsRunning() bool {
 	return false
 }
 
+// Deprecated: use mobile.Node, which reports the start errors, the status and the node events
 func StartMobileNode(path string, cfg string) string {
 	fileHandler, _ := log.FileHandler(filepath.Join(path, "output.log"), log.TerminalFormat(false))
 	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.MultiHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()), fileHandler)))
@@ -111,7 +141,7 @@ func StartMobileNode(path string, cfg string) string {
 		return err.Error()
 	}
 
-	n, err := NewNode(c, "mobile")
+	n, err := NewNode(c, config.NewBuildInfo("mobile", "", ""))
 
 	if err != nil {
 		return err.Error()
@@ -137,17 +167,22 @@ func ProvideMobileKey(path string, cfg string, key string, password string) stri
 	return "done"
 }
 
-func NewNode(config *config.Config, appVersion string) (*Node, error) {
-	nodeCtx, err := NewNodeWithInjections(config, eventbus.New(), collector.NewStatsCollector(), appVersion)
+func NewNode(config *config.Config, buildInfo *config.BuildInfo) (*Node, error) {
+	nodeCtx, err := NewNodeWithInjections(config, eventbus.New(), collector.NewStatsCollector(), buildInfo)
 	if err != nil {
 		return nil, err
 	}
 	return nodeCtx.Node, err
 }
 
-func NewNodeWithInjections(config *config.Config, bus eventbus.Bus, statsCollector collector.StatsCollector, appVersion string) (*NodeCtx, error) {
+func NewNodeWithInjections(config *config.Config, bus eventbus.Bus, statsCollector collector.StatsCollector, buildInfo *config.BuildInfo) (*NodeCtx, error) {
+	appVersion := buildInfo.Version
 
 	logger := log.New()
+	dataDirLock, err := lockDataDir(config.DataDir)
+	if err != nil {
+		return nil, err
+	}
 	nodeState := state2.NewNodeState(bus)
 	httpListener, httpHandler, httpServer, err := startInitialRPC(config, nodeState)
 	if err != nil {
@@ -155,12 +190,23 @@ func NewNodeWithInjections(config *config.Config, bus eventbus.Bus, statsCollect
 	}
 
 	bus.Publish(&events.DatabaseInitEvent{})
-	db, err := OpenDatabase(config.DataDir, "idenachain", 16, 16, true)
-	bus.Publish(&events.DatabaseInitCompletedEvent{})
-
+	db, err := OpenDatabase(config.DataDir, config.Db, "idenachain", config.Db.Cache, config.Db.Handles, true)
+	if err != nil {
+		bus.Publish(&events.DatabaseInitCompletedEvent{})
+		return nil, err
+	}
+	// flip and ceremony data are kept apart from the chain, so they can be pruned or wiped independently
+	ceremonyDb, err := OpenCeremonyDatabase(config)
 	if err != nil {
+		bus.Publish(&events.DatabaseInitCompletedEvent{})
 		return nil, err
 	}
+	if err := database.MigrateSchema(db, ceremonyDb); err != nil {
+		bus.Publish(&events.DatabaseInitCompletedEvent{})
+		ceremonyDb.Close()
+		return nil, errors.Wrap(err, "cannot migrate database")
+	}
+	bus.Publish(&events.DatabaseInitCompletedEvent{})
 
 	keyStoreDir, err := config.KeyStoreDataDir()
 	if err != nil {
@@ -177,6 +223,8 @@ func NewNodeWithInjections(config *config.Config, bus eventbus.Bus, statsCollect
 		return nil, err
 	}
 	validation.SetAppConfig(config)
+	state.SetTreeCacheSize(config.Db.StateCache)
+	state.SetLazyLoad(config.Db.LazyStateLoad)
 	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.StandardScryptN, keystore.StandardScryptP)
 	secStore := secstore.NewSecStore()
 
@@ -192,7 +240,7 @@ func NewNodeWithInjections(config *config.Config, bus eventbus.Bus, statsCollect
 	votes := pengings.NewVotes(appState, bus, offlineDetector, upgrader)
 
 	txpool := mempool.NewTxPool(appState, bus, config, statsCollector)
-	flipKeyPool := mempool.NewKeysPool(db, appState, bus, secStore)
+	flipKeyPool := mempool.NewKeysPool(ceremonyDb, appState, bus, secStore)
 
 	subManager, err := subscriptions.NewManager(config.DataDir)
 	if err != nil {
@@ -200,17 +248,22 @@ func NewNodeWithInjections(config *config.Config, bus eventbus.Bus, statsCollect
 	}
 
 	chain := blockchain.NewBlockchain(config, db, txpool, appState, ipfsProxy, secStore, bus, offlineDetector, keyStore, subManager, upgrader)
+
+	watcher, err := subscriptions.NewWatcher(config.DataDir, bus, appState.State.GetIdentityState, chain.Confirmations)
+	if err != nil {
+		return nil, err
+	}
 	proposals, pendingProofs := pengings.NewProposals(chain, appState, offlineDetector, upgrader, statsCollector)
-	flipper := flip.NewFlipper(db, ipfsProxy, flipKeyPool, txpool, secStore, appState, bus)
+	flipper := flip.NewFlipper(ceremonyDb, ipfsProxy, flipKeyPool, txpool, secStore, appState, bus)
 	pm := protocol.NewIdenaGossipHandler(ipfsProxy.Host(), ipfsProxy.PubSub(), config.P2P, chain, proposals, votes, txpool, flipper, bus, flipKeyPool, appVersion, &ceremonyChecker{
 		appState: appState,
 		chain:    chain,
-	})
+	}, protocol.NewBanList(config.DataDir, config.P2P.BanDuration), protocol.NewRememberedPeers(config.DataDir))
 	sm := state.NewSnapshotManager(db, appState.State, bus, ipfsProxy, config)
 	downloader := protocol.NewDownloader(pm, config, chain, ipfsProxy, appState, sm, bus, secStore, statsCollector, subManager, keyStore, upgrader)
 	consensusEngine := consensus.NewEngine(chain, pm, proposals, config, appState, votes, txpool, secStore,
 		downloader, offlineDetector, upgrader, ipfsProxy, bus, statsCollector)
-	ceremony := ceremony.NewValidationCeremony(appState, bus, flipper, secStore, db, txpool, chain, downloader, flipKeyPool, config)
+	ceremony := ceremony.NewValidationCeremony(appState, bus, flipper, secStore, ceremonyDb, txpool, chain, downloader, flipKeyPool, config)
 	profileManager := profile.NewProfileManager(ipfsProxy)
 
 	deferJob, err := deferredtx.NewJob(bus, config.DataDir, appState, chain, txpool, keyStore, secStore, vm.NewVmImpl)
@@ -220,6 +273,8 @@ func NewNodeWithInjections(config *config.Config, bus eventbus.Bus, statsCollect
 
 	node := &Node{
 		config:          config,
+		db:              db,
+		ceremonyDb:      ceremonyDb,
 		blockchain:      chain,
 		pm:              pm,
 		proposals:       proposals,
@@ -238,9 +293,13 @@ func NewNodeWithInjections(config *config.Config, bus eventbus.Bus, statsCollect
 		offlineDetector: offlineDetector,
 		votes:           votes,
 		appVersion:      appVersion,
+		buildInfo:       buildInfo,
+		dataDirLock:     dataDirLock,
+		stop:            make(chan struct{}),
 		profileManager:  profileManager,
 		deferJob:        deferJob,
 		subManager:      subManager,
+		watcher:         watcher,
 		upgrader:        upgrader,
 		nodeState:       nodeState,
 		httpListener:    httpListener,
@@ -277,6 +336,8 @@ func (node *Node) StartWithHeight(height uint64) error {
 	} else if err != nil {
 		node.log.Warn("Failed to set new fd limit", "err", err)
 	}
+	node.tuneRuntime()
+	node.startPprof()
 
 	if err := node.blockchain.InitializeChain(); err != nil {
 		return errors.Wrap(err, "cannot initialize blockchain")
@@ -306,6 +367,8 @@ func (node *Node) StartWithHeight(height uint64) error {
 	node.fp.Initialize()
 	node.ceremony.Initialize(node.blockchain.GetBlock(node.blockchain.Head.Hash()))
 	node.blockchain.ProvideApplyNewEpochFunc(node.ceremony.ApplyNewEpoch)
+	node.subscribeListeners()
+	node.startTracing()
 	node.offlineDetector.Start(node.blockchain.Head)
 	node.consensusEngine.Start()
 	node.pm.Start()
@@ -316,12 +379,183 @@ func (node *Node) StartWithHeight(height uint64) error {
 	if err := node.startRPC(); err != nil {
 		return errors.Wrap(err, "cannot start RPC endpoint")
 	}
+	node.startMetricsReporter()
+	node.startWebhooks()
+	go node.watchDisk()
+	node.notifySystemdReady()
 	return nil
 }
 
+// WaitForStop blocks until the node is stopped by Stop or SIGINT/SIGTERM, SIGHUP reloads the config
 func (node *Node) WaitForStop() {
-	<-node.stop
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	reloadSignals := make(chan os.Signal, 1)
+	signal.Notify(reloadSignals, syscall.SIGHUP)
+	defer signal.Stop(reloadSignals)
+
+wait:
+	for {
+		select {
+		case <-reloadSignals:
+			if _, err := node.ReloadConfig(); err != nil {
+				node.log.Error("Failed to reload config", "err", err)
+			}
+		case <-signals:
+			node.log.Info("Got interrupt, shutting down...")
+			go node.Stop()
+			select {
+			case <-signals:
+				node.log.Warn("Interrupted again, exiting without graceful shutdown")
+			case <-node.stop:
+			}
+			break wait
+		case <-node.stop:
+			break wait
+		}
+	}
 	node.secStore.Destroy()
+	if node.dataDirLock != nil {
+		node.dataDirLock.Close()
+	}
+}
+
+// Shutdown stops the node in background, so the RPC caller gets the response. The restart is performed by the process
+// after WaitForStop returns, see RestartRequested
+func (node *Node) Shutdown(restart bool, afterCeremony bool) {
+	go func() {
+		if afterCeremony {
+			node.waitForCeremonyEnd()
+		}
+		time.Sleep(shutdownDelay)
+		if restart {
+			node.log.Info("Restarting the node")
+		}
+		node.restart = restart
+		node.Stop()
+	}()
+}
+
+// RestartRequested reports whether the stopped node should be started again
+func (node *Node) RestartRequested() bool {
+	return node.restart
+}
+
+func (node *Node) waitForCeremonyEnd() {
+	checker := &ceremonyChecker{
+		appState: node.appState,
+		chain:    node.blockchain,
+	}
+	for checker.IsRunning() {
+		node.log.Info("Waiting for the validation ceremony to complete before shutdown")
+		select {
+		case <-node.stop:
+			return
+		case <-time.After(time.Minute):
+		}
+	}
+}
+
+// Stop halts consensus, closes p2p, IPFS and RPC and flushes the databases, unclean exits may corrupt them
+func (node *Node) Stop() {
+	node.stopOnce.Do(func() {
+		node.notifySystemdStopping()
+		node.stopHTTP()
+		if node.inprocHandler != nil {
+			node.inprocHandler.Stop()
+		}
+		consensusStopped := node.consensusEngine.Stop()
+		node.pm.Stop()
+		// the components using the databases are stopped after the block and message sources
+		ceremonyStopped := node.ceremony.Stop(ceremonyStopTimeout)
+		node.fp.Stop()
+		node.deferJob.Stop()
+		node.txpool.Stop()
+		if err := node.ipfsProxy.Close(); err != nil {
+			node.log.Warn("Failed to stop IPFS", "err", err)
+		}
+		node.flushTracing()
+		if !consensusStopped || !ceremonyStopped {
+			// the databases are closed anyway to flush the completed writes, late writes of the unfinished work fail
+			node.log.Warn("Consensus round or ceremony work is not completed in time, its writes are dropped")
+		}
+		if err := node.ceremonyDb.Close(); err != nil {
+			node.log.Error("Failed to close ceremony database", "err", err)
+		}
+		if err := node.db.Close(); err != nil {
+			node.log.Error("Failed to close database", "err", err)
+		}
+		node.log.Info("Node stopped")
+		close(node.stop)
+	})
+}
+
+// ReloadConfig re-reads the config file and applies the log levels, IPFS bootnodes, peer limits and RPC CORS origins,
+// other settings require restart. The applied settings are returned
+func (node *Node) exportIdentities(path string, format string) (*blockchain.IdentitiesExport, error) {
+	return blockchain.ExportIdentities(node.db, path, format)
+}
+
+func (node *Node) exportAddressTxs(address common.Address, path string, format string) (*blockchain.TxsExport, error) {
+	return blockchain.ExportAddressTxs(node.db, address, path, format)
+}
+
+func (node *Node) ReloadConfig() ([]string, error) {
+	node.reloadMutex.Lock()
+	defer node.reloadMutex.Unlock()
+
+	cfg, err := node.config.Reload()
+	if err != nil {
+		return nil, err
+	}
+	if problems := cfg.Validate(); len(problems) > 0 {
+		return nil, errors.Errorf("invalid config: %v", problems[0])
+	}
+	var applied []string
+	if cfg.Log.Verbosity != node.config.Log.Verbosity || !reflect.DeepEqual(cfg.Log.Modules, node.config.Log.Modules) {
+		if err := cfg.Log.Apply(); err != nil {
+			return applied, errors.Wrap(err, "cannot apply log levels")
+		}
+		node.config.Log.Verbosity = cfg.Log.Verbosity
+		node.config.Log.Modules = cfg.Log.Modules
+		applied = append(applied, "Log levels")
+	}
+	if !stringSlicesEqual(cfg.IpfsConf.BootNodes, node.config.IpfsConf.BootNodes) {
+		if err := node.ipfsProxy.SetBootNodes(cfg.IpfsConf.BootNodes); err != nil {
+			return applied, errors.Wrap(err, "cannot apply IPFS bootnodes")
+		}
+		applied = append(applied, "IpfsConf.BootNodes")
+	}
+	if !node.config.P2P.PeerLimitsEqual(cfg.P2P) {
+		node.pm.SetPeerLimits(cfg.P2P)
+		node.config.P2P.SetPeerLimits(cfg.P2P)
+		applied = append(applied, "P2P peer limits")
+	}
+	if !stringSlicesEqual(cfg.RPC.HTTPCors, node.config.RPC.HTTPCors) {
+		if node.httpHandler != nil {
+			if err := node.httpHandler.SetHTTPCors(cfg.RPC.HTTPCors); err != nil {
+				return applied, errors.Wrap(err, "cannot apply RPC CORS origins")
+			}
+		}
+		node.config.RPC.HTTPCors = cfg.RPC.HTTPCors
+		applied = append(applied, "RPC.HTTPCors")
+	}
+	node.log.Info("Config reloaded", "applied", strings.Join(applied, ", "))
+	return applied, nil
+}
+
+func stringSlicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
 }
 
 func startInitialRPC(nodeConfig *config.Config, nodeState *state2.NodeState) (net.Listener, *rpc.Server, *http.Server, error) {
@@ -364,6 +598,9 @@ func (node *Node) startRPC() error {
 	// Gather all the possible APIs to surface
 	apis := node.apis()
 
+	if err := node.startInProc(apis); err != nil {
+		return err
+	}
 	if err := node.startHTTP(node.config.RPC.HTTPEndpoint(), apis, node.config.RPC.HTTPModules, node.config.RPC.HTTPCors, node.config.RPC.HTTPVirtualHosts, node.config.RPC.HTTPTimeouts, node.config.RPC.APIKey); err != nil {
 		return err
 	}
@@ -372,6 +609,28 @@ func (node *Node) startRPC() error {
 	return nil
 }
 
+// startInProc registers all the APIs, including the private ones, on the in-process handler.
+// The in-process callers are trusted, so the API key is not required
+func (node *Node) startInProc(apis []rpc.API) error {
+	handler := rpc.NewServer("")
+	for _, api := range apis {
+		if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
+			return err
+		}
+	}
+	node.inprocHandler = handler
+	return nil
+}
+
+// Attach creates an RPC client connected to the node in-process, it serves the same APIs as the HTTP endpoint
+// without opening sockets. The node must be started
+func (node *Node) Attach() (*rpc.Client, error) {
+	if node.inprocHandler == nil {
+		return nil, errors.New("node is not started")
+	}
+	return rpc.DialInProc(node.inprocHandler), nil
+}
+
 // startHTTP initializes and starts the HTTP RPC endpoint.
 func (node *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string, timeouts rpc.HTTPTimeouts, apiKey string) error {
 	// Short circuit if the HTTP endpoint isn't being exposed
@@ -413,13 +672,32 @@ func (node *Node) stopHTTP() {
 	}
 }
 
-func OpenDatabase(datadir string, name string, cache int, handles int, compact bool) (db.DB, error) {
-	res, err := db.NewGoLevelDBWithOpts(name, datadir, &opt.Options{
+// OpenDatabase opens the chain database, the cache, handles and compaction options are applied to goleveldb only.
+// Other backends use own directories, so switching the backend doesn't corrupt the existing database.
+func OpenDatabase(datadir string, dbConfig *config.DbConfig, name string, cache int, handles int, compact bool) (db.DB, error) {
+	if backend := dbConfig.Backend; backend != "" && backend != config.GoLevelDbBackend {
+		log.Info("Opening database", "backend", backend)
+		return database.OpenBackend(backend, fmt.Sprintf("%v-%v", name, backend), datadir)
+	}
+	log.Info("Allocated database cache and file handles", "name", name, "cache", cache, "handles", handles)
+	options := &opt.Options{
 		OpenFilesCacheCapacity: handles,
 		BlockCacheCapacity:     cache / 2 * opt.MiB,
 		WriteBuffer:            cache / 4 * opt.MiB,
 		Filter:                 filter.NewBloomFilter(10),
-	})
+	}
+	res, err := db.NewGoLevelDBWithOpts(name, datadir, options)
+	if err != nil && lerrors.IsCorrupted(err) {
+		if !dbConfig.Repair {
+			return nil, errors.Wrap(err, "database is corrupted, restart the node with --repairdb flag to recover it")
+		}
+		log.Warn("Database is corrupted, recovering", "err", err)
+		if err := recoverGoLevelDb(filepath.Join(datadir, name+".db"), options); err != nil {
+			return nil, errors.Wrap(err, "failed to recover database")
+		}
+		log.Info("Database recovered")
+		res, err = db.NewGoLevelDBWithOpts(name, datadir, options)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -432,6 +710,38 @@ func OpenDatabase(datadir string, name string, cache int, handles int, compact b
 	return res, nil
 }
 
+// OpenCeremonyDatabase opens the database of flip and ceremony data, the data is moved there from the chain
+// database by the schema migration
+func OpenCeremonyDatabase(cfg *config.Config) (db.DB, error) {
+	return OpenDatabase(cfg.DataDir, cfg.Db, "ceremony", 8, 16, false)
+}
+
+func recoverGoLevelDb(path string, options *opt.Options) error {
+	recovered, err := leveldb.RecoverFile(path, options)
+	if err != nil {
+		return err
+	}
+	return recovered.Close()
+}
+
+// CompactDatabase runs a full compaction of the database logging the progress
+func CompactDatabase(chainDb db.DB) error {
+	start := time.Now()
+	lastLogged := 0
+	log.Info("Start compacting DB")
+	err := database.Compact(chainDb, func(done, total int) {
+		if percent := done * 100 / total; percent/10 > lastLogged/10 {
+			lastLogged = percent
+			log.Info("Compacting DB", "progress", fmt.Sprintf("%v%%", percent), "d", time.Since(start))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	log.Info("DB compacted", "d", time.Since(start))
+	return nil
+}
+
 func compactDb(goLevelDB *db.GoLevelDB) error {
 	start := time.Now()
 	logTimeout := time.After(time.Second)
@@ -465,7 +775,7 @@ func (node *Node) apis() []rpc.API {
 		{
 			Namespace: "dna",
 			Version:   "1.0",
-			Service:   api.NewDnaApi(baseApi, node.blockchain, node.ceremony, node.appVersion, node.profileManager),
+			Service:   api.NewDnaApi(baseApi, node.blockchain, node.ceremony, node.appVersion, node.profileManager, node.watcher),
 			Public:    true,
 		},
 		{
@@ -474,6 +784,12 @@ func (node *Node) apis() []rpc.API {
 			Service:   api.NewAccountApi(baseApi),
 			Public:    true,
 		},
+		{
+			Namespace: "invite",
+			Version:   "1.0",
+			Service:   api.NewInviteApi(baseApi),
+			Public:    true,
+		},
 		{
 			Namespace: "flip",
 			Version:   "1.0",
@@ -498,5 +814,17 @@ func (node *Node) apis() []rpc.API {
 			Service:   api.NewContractApi(baseApi, node.blockchain, node.deferJob, node.subManager),
 			Public:    true,
 		},
+		{
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   api.NewAdminApi(node.pm, node.config, node.backup, node.buildInfo, node.ReloadConfig, node.Shutdown, node.exportIdentities, node.exportAddressTxs),
+			Public:    true,
+		},
+		{
+			Namespace: "debug",
+			Version:   "1.0",
+			Service:   api.NewDebugApi(func() error { return CompactDatabase(node.db) }),
+			Public:    true,
+		},
 	}
 }
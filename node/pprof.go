@@ -0,0 +1,38 @@
+package node
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+// startPprof serves the runtime profiles, the handlers use an own mux so they are never exposed through the RPC server
+func (node *Node) startPprof() {
+	if node.config.Pprof == nil || !node.config.Pprof.Enabled {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	endpoint := node.config.Pprof.Endpoint()
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		node.log.Warn("Failed to start pprof server", "err", err)
+		return
+	}
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			node.log.Warn("pprof server stopped", "err", err)
+		}
+	}()
+	go func() {
+		<-node.stop
+		server.Close()
+	}()
+	node.log.Info("pprof server started", "url", "http://"+endpoint+"/debug/pprof/")
+}
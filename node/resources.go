@@ -0,0 +1,59 @@
+package node
+
+import (
+	"github.com/idena-network/idena-go/common"
+	util "github.com/idena-network/idena-go/common/ulimit"
+	"github.com/pbnjay/memory"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+)
+
+// tuneRuntime applies the Go runtime settings and warns about low disk space and memory,
+// they cause most of the crashes which look random
+func (node *Node) tuneRuntime() {
+	cfg := node.config.Runtime
+	if cfg == nil {
+		return
+	}
+	if cfg.MaxProcs > 0 {
+		prev := runtime.GOMAXPROCS(cfg.MaxProcs)
+		node.log.Info("Set GOMAXPROCS", "value", cfg.MaxProcs, "prev", prev)
+	}
+	if cfg.GCPercent > 0 {
+		prev := debug.SetGCPercent(cfg.GCPercent)
+		node.log.Info("Set GC percent", "value", cfg.GCPercent, "prev", prev)
+	}
+	if total := memory.TotalMemory(); total > 0 && total < uint64(cfg.MinMemory)<<20 {
+		node.log.Warn("Low memory, the node may be killed by the OS", "total", common.StorageSize(total), "required", common.StorageSize(uint64(cfg.MinMemory)<<20))
+	}
+	node.checkFreeDisk(uint64(cfg.MinFreeDisk) << 20)
+}
+
+func (node *Node) checkFreeDisk(minFree uint64) {
+	free, err := util.FreeDiskSpace(node.config.DataDir)
+	if err != nil {
+		node.log.Warn("Failed to get free disk space", "err", err)
+		return
+	}
+	required := minFree
+	// database compactions and snapshots temporarily take up to the size of the data
+	if size := dirSize(node.config.DataDir); size > required {
+		required = size
+	}
+	if free < required {
+		node.log.Warn("Low disk space, the node may crash with a corrupted database", "free", common.StorageSize(free), "required", common.StorageSize(required))
+	}
+}
+
+func dirSize(path string) uint64 {
+	var size uint64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,60 @@
+package node
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-go/core/state"
+)
+
+// Status is the snapshot of the node state for the applications embedding the node
+type Status struct {
+	Address       common.Address
+	Height        uint64
+	HighestBlock  uint64
+	Syncing       bool
+	Peers         int
+	Epoch         uint16
+	Period        state.ValidationPeriod
+	IdentityState state.IdentityState
+}
+
+func (node *Node) Status() *Status {
+	syncing := node.downloader.IsSyncing() || !node.consensusEngine.Synced()
+	if node.config.Consensus.Automine {
+		syncing = false
+	}
+	height := node.blockchain.Head.Height()
+	_, highest := node.downloader.SyncProgress()
+	if !syncing || highest < height {
+		highest = height
+	}
+	status := &Status{
+		Address:      node.secStore.GetAddress(),
+		Height:       height,
+		HighestBlock: highest,
+		Syncing:      syncing,
+		Peers:        node.pm.PeersCount(),
+	}
+	if appState, err := node.appState.Readonly(status.Height); err == nil && appState != nil {
+		status.Epoch = appState.State.Epoch()
+		status.Period = appState.State.ValidationPeriod()
+		status.IdentityState = appState.State.GetIdentityState(status.Address)
+	}
+	return status
+}
+
+// Bus returns the event bus of the node, the embedding applications subscribe to the node events with it
+func (node *Node) Bus() eventbus.Bus {
+	return node.bus
+}
+
+// Stopped returns the channel which is closed when the node is stopped
+func (node *Node) Stopped() <-chan struct{} {
+	return node.stop
+}
+
+// P2PAddress returns the loopback multiaddress of the node IPFS host, the local nodes use it as the bootnode
+func (node *Node) P2PAddress() string {
+	return fmt.Sprintf("/ip4/127.0.0.1/tcp/%v/ipfs/%v", node.config.IpfsConf.IpfsPort, node.ipfsProxy.Host().ID().Pretty())
+}
@@ -0,0 +1,96 @@
+package node
+
+import (
+	"github.com/coreos/go-systemd/v22/daemon"
+	"github.com/idena-network/idena-go/config"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const (
+	// a synced mainnet node gets a block every 20 seconds in average
+	minWatchdogHeadTimeout = 10 * time.Minute
+	// syncing runs inside a single consensus loop iteration, so the timeout is generous
+	watchdogConsensusTimeout = time.Hour
+)
+
+// notifySystemdReady reports the started node to systemd (Type=notify) and pings its watchdog (WatchdogSec)
+// while the node is healthy. Nothing is sent if the node isn't run by systemd
+func (node *Node) notifySystemdReady() {
+	sent, err := daemon.SdNotify(false, daemon.SdNotifyReady)
+	if err != nil {
+		node.log.Warn("Failed to notify systemd", "err", err)
+		return
+	}
+	if !sent {
+		return
+	}
+	interval, err := daemon.SdWatchdogEnabled(false)
+	if err != nil {
+		node.log.Warn("Invalid systemd watchdog settings", "err", err)
+		return
+	}
+	if interval > 0 {
+		go node.pingSystemdWatchdog(interval / 2)
+	}
+}
+
+func (node *Node) notifySystemdStopping() {
+	daemon.SdNotify(false, daemon.SdNotifyStopping)
+}
+
+// pingSystemdWatchdog stops pinging once the node is unhealthy, so systemd restarts it
+func (node *Node) pingSystemdWatchdog(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	health := &healthCheck{
+		height:      node.blockchain.Head.Height(),
+		heightTime:  time.Now(),
+		headTimeout: watchdogHeadTimeout(node.config.Consensus),
+	}
+	for {
+		select {
+		case <-node.stop:
+			return
+		case <-ticker.C:
+			if err := health.check(node, time.Now()); err != nil {
+				node.log.Error("Node is unhealthy, systemd watchdog is not notified", "err", err)
+				continue
+			}
+			daemon.SdNotify(false, daemon.SdNotifyWatchdog)
+		}
+	}
+}
+
+// watchdogHeadTimeout allows private networks with long or idle block intervals to wait for two blocks
+func watchdogHeadTimeout(consensus *config.ConsensusConf) time.Duration {
+	interval := consensus.MinBlockDistance
+	if consensus.IdleBlockInterval > interval {
+		interval = consensus.IdleBlockInterval
+	}
+	if timeout := 2 * interval; timeout > minWatchdogHeadTimeout {
+		return timeout
+	}
+	return minWatchdogHeadTimeout
+}
+
+type healthCheck struct {
+	height      uint64
+	heightTime  time.Time
+	headTimeout time.Duration
+}
+
+// check fails if the synced node doesn't get new blocks or the consensus loop is stuck
+func (h *healthCheck) check(node *Node, now time.Time) error {
+	if height := node.blockchain.Head.Height(); height != h.height {
+		h.height = height
+		h.heightTime = now
+	}
+	if node.consensusEngine.Synced() && now.Sub(h.heightTime) > h.headTimeout {
+		return errors.Errorf("head %v is not advancing since %v", h.height, h.heightTime.Format(time.RFC3339))
+	}
+	if lastLoop := node.consensusEngine.LastLoopTime(); now.Sub(lastLoop) > watchdogConsensusTimeout {
+		return errors.Errorf("consensus loop is stuck since %v", lastLoop.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package pengings
 
 import (
 	"bytes"
+	"context"
 	"github.com/deckarep/golang-set"
 	"github.com/idena-network/idena-go/blockchain"
 	"github.com/idena-network/idena-go/blockchain/types"
@@ -12,10 +13,12 @@ import (
 	"github.com/idena-network/idena-go/crypto/vrf"
 	"github.com/idena-network/idena-go/log"
 	"github.com/idena-network/idena-go/stats/collector"
+	"github.com/idena-network/idena-go/stats/tracing"
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/patrickmn/go-cache"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
 	"math/big"
 	"sync"
 	"time"
@@ -203,7 +206,7 @@ func (proposals *Proposals) ProcessPendingBlocks() []*types.BlockProposal {
 	proposals.pendingBlocks.Range(func(key, value interface{}) bool {
 
 		blockPeer := value.(*blockPeer)
-		if added, pending := proposals.AddProposedBlock(blockPeer.proposal, blockPeer.peerId, blockPeer.receivingTime); added {
+		if added, pending := proposals.AddProposedBlock(context.Background(), blockPeer.proposal, blockPeer.peerId, blockPeer.receivingTime); added {
 			result = append(result, blockPeer.proposal)
 		} else if !pending {
 			proposals.pendingBlocks.Delete(key)
@@ -214,8 +217,13 @@ func (proposals *Proposals) ProcessPendingBlocks() []*types.BlockProposal {
 	return result
 }
 
-func (proposals *Proposals) AddProposedBlock(proposal *types.BlockProposal, peerId peer.ID, receivingTime time.Time) (added bool, pending bool) {
+func (proposals *Proposals) AddProposedBlock(ctx context.Context, proposal *types.BlockProposal, peerId peer.ID, receivingTime time.Time) (added bool, pending bool) {
 	block := proposal.Block
+	_, span := tracing.StartSpan(ctx, "pengings.AddProposedBlock", tracing.Block(block.Height(), block.Hash().Hex())...)
+	defer func() {
+		span.SetAttributes(attribute.Bool("added", added), attribute.Bool("pending", pending))
+		span.End()
+	}()
 	currentRound := proposals.chain.Round()
 	if currentRound == block.Height() {
 		if proposals.proposeCache.Add(block.Hash().Hex(), nil, cache.DefaultExpiration) != nil {
@@ -0,0 +1,147 @@
+package protocol
+
+import (
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/log"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// number of distinct peers own answers are sent to during one attempt
+	answersFirstHopPeers = 3
+	// time to wait for own answers coming back from a peer which hasn't received them from us
+	answersEchoTimeout  = time.Second * 20
+	answersMaxAttempts  = 5
+	answersCheckTimeout = time.Second * 5
+)
+
+func isAnswersTx(txType uint16) bool {
+	return txType == types.SubmitAnswersHashTx || txType == types.SubmitShortAnswersTx || txType == types.SubmitLongAnswersTx
+}
+
+type pendingAnswers struct {
+	tx       *types.Transaction
+	shardId  common.ShardId
+	sentTo   map[peer.ID]struct{}
+	attempts int
+	deadline time.Time
+}
+
+// answersBroadcaster sends own answers txs to a few distinct first-hop peers and waits for a gossip echo,
+// i.e. a push of the same tx from a peer the tx wasn't sent to directly. If there is no echo in time
+// the tx is sent once again through alternate peers.
+type answersBroadcaster struct {
+	peers   *peerSet
+	send    func(p *protoPeer, tx *types.Transaction, shardId common.ShardId)
+	pending map[common.Hash128]*pendingAnswers
+	mutex   sync.Mutex
+	log     log.Logger
+}
+
+func newAnswersBroadcaster(peers *peerSet, send func(p *protoPeer, tx *types.Transaction, shardId common.ShardId)) *answersBroadcaster {
+	return &answersBroadcaster{
+		peers:   peers,
+		send:    send,
+		pending: make(map[common.Hash128]*pendingAnswers),
+		log:     log.New("component", "answersBroadcaster"),
+	}
+}
+
+func (b *answersBroadcaster) broadcast(tx *types.Transaction, shardId common.ShardId) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	hash := tx.Hash128()
+	if _, ok := b.pending[hash]; ok {
+		return
+	}
+	entry := &pendingAnswers{
+		tx:      tx,
+		shardId: shardId,
+		sentTo:  make(map[peer.ID]struct{}),
+	}
+	b.pending[hash] = entry
+	b.sendToNextPeers(entry)
+}
+
+func (b *answersBroadcaster) sendToNextPeers(entry *pendingAnswers) bool {
+	candidates := b.selectPeers(entry)
+	if len(candidates) == 0 {
+		return false
+	}
+	for _, p := range candidates {
+		entry.sentTo[p.id] = struct{}{}
+		b.send(p, entry.tx, entry.shardId)
+	}
+	entry.attempts++
+	entry.deadline = time.Now().Add(answersEchoTimeout)
+	b.log.Info("Sent own answers to first-hop peers", "hash", entry.tx.Hash().Hex(), "peers", len(candidates), "attempt", entry.attempts)
+	return true
+}
+
+// selectPeers returns up to answersFirstHopPeers peers which haven't received the tx yet, own shard peers go first.
+func (b *answersBroadcaster) selectPeers(entry *pendingAnswers) []*protoPeer {
+	var sameShard, others []*protoPeer
+	for _, p := range b.peers.Peers() {
+		if _, ok := entry.sentTo[p.id]; ok {
+			continue
+		}
+		if entry.shardId == common.MultiShard || p.shardId == entry.shardId || p.shardId == common.MultiShard {
+			sameShard = append(sameShard, p)
+		} else {
+			others = append(others, p)
+		}
+	}
+	rand.Shuffle(len(sameShard), func(i, j int) {
+		sameShard[i], sameShard[j] = sameShard[j], sameShard[i]
+	})
+	rand.Shuffle(len(others), func(i, j int) {
+		others[i], others[j] = others[j], others[i]
+	})
+	sortPeersByScore(sameShard)
+	sortPeersByScore(others)
+	result := append(sameShard, others...)
+	if len(result) > answersFirstHopPeers {
+		result = result[:answersFirstHopPeers]
+	}
+	return result
+}
+
+func (b *answersBroadcaster) echo(from peer.ID, hash common.Hash128) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	entry, ok := b.pending[hash]
+	if !ok {
+		return
+	}
+	if _, ok := entry.sentTo[from]; ok {
+		return
+	}
+	delete(b.pending, hash)
+	b.log.Info("Own answers propagation confirmed", "hash", entry.tx.Hash().Hex(), "peer", from.Pretty())
+}
+
+func (b *answersBroadcaster) loop() {
+	for {
+		time.Sleep(answersCheckTimeout)
+		b.retry()
+	}
+}
+
+func (b *answersBroadcaster) retry() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	now := time.Now()
+	for hash, entry := range b.pending {
+		if now.Before(entry.deadline) {
+			continue
+		}
+		if entry.attempts >= answersMaxAttempts || !b.sendToNextPeers(entry) {
+			b.log.Warn("Own answers propagation is not confirmed", "hash", entry.tx.Hash().Hex(), "attempts", entry.attempts)
+			delete(b.pending, hash)
+		}
+	}
+}
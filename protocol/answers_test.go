@@ -0,0 +1,92 @@
+package protocol
+
+import (
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestPeer(id string, shardId common.ShardId, score int64) *protoPeer {
+	return &protoPeer{
+		id:      peer.ID(id),
+		shardId: shardId,
+		score:   &peerScore{value: score},
+	}
+}
+
+func newTestAnswersBroadcaster(peers ...*protoPeer) (*answersBroadcaster, *[]peer.ID) {
+	set := newPeerSet()
+	for _, p := range peers {
+		set.Register(p)
+	}
+	var sent []peer.ID
+	b := newAnswersBroadcaster(set, func(p *protoPeer, tx *types.Transaction, shardId common.ShardId) {
+		sent = append(sent, p.id)
+	})
+	return b, &sent
+}
+
+func TestAnswersBroadcaster_broadcast(t *testing.T) {
+	b, sent := newTestAnswersBroadcaster(
+		newTestPeer("other-best", 2, 100),
+		newTestPeer("own-1", 1, 3),
+		newTestPeer("own-2", 1, 2),
+		newTestPeer("multi", common.MultiShard, 1),
+		newTestPeer("own-bad", 1, -10),
+	)
+	tx := &types.Transaction{Type: types.SubmitShortAnswersTx, AccountNonce: 1}
+	b.broadcast(tx, 1)
+	require.Equal(t, []peer.ID{"own-1", "own-2", "multi"}, *sent)
+
+	// the same tx is not sent twice while pending
+	b.broadcast(tx, 1)
+	require.Len(t, *sent, answersFirstHopPeers)
+}
+
+func TestAnswersBroadcaster_echo(t *testing.T) {
+	b, _ := newTestAnswersBroadcaster(newTestPeer("peer1", 1, 0), newTestPeer("peer2", 1, 0))
+	tx := &types.Transaction{Type: types.SubmitLongAnswersTx, AccountNonce: 1}
+	b.broadcast(tx, 1)
+
+	// a push from the first-hop peer doesn't prove the propagation
+	b.echo(peer.ID("peer1"), tx.Hash128())
+	require.Len(t, b.pending, 1)
+
+	b.echo(peer.ID("peer3"), tx.Hash128())
+	require.Empty(t, b.pending)
+}
+
+func TestAnswersBroadcaster_retry(t *testing.T) {
+	var peers []*protoPeer
+	for _, id := range []string{"peer1", "peer2", "peer3", "peer4"} {
+		peers = append(peers, newTestPeer(id, 1, 0))
+	}
+	b, sent := newTestAnswersBroadcaster(peers...)
+	tx := &types.Transaction{Type: types.SubmitAnswersHashTx, AccountNonce: 1}
+	b.broadcast(tx, 1)
+	require.Len(t, *sent, answersFirstHopPeers)
+
+	// nothing is resent before the deadline
+	b.retry()
+	require.Len(t, *sent, answersFirstHopPeers)
+
+	// the retry goes through the alternate peer
+	entry := b.pending[tx.Hash128()]
+	entry.deadline = time.Now().Add(-time.Second)
+	b.retry()
+	require.Len(t, *sent, answersFirstHopPeers+1)
+	seen := make(map[peer.ID]struct{})
+	for _, id := range *sent {
+		seen[id] = struct{}{}
+	}
+	require.Len(t, seen, len(peers))
+	require.Equal(t, 2, entry.attempts)
+
+	// the tx is dropped when there are no more peers to try
+	entry.deadline = time.Now().Add(-time.Second)
+	b.retry()
+	require.Empty(t, b.pending)
+}
@@ -0,0 +1,252 @@
+package protocol
+
+import (
+	"encoding/json"
+	"github.com/idena-network/idena-go/config"
+	"github.com/idena-network/idena-go/log"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	banListFile = "banlist.json"
+	// max number of kept bans, the ones which expire first are evicted
+	maxBans = 10000
+)
+
+type Ban struct {
+	// peer id or IP address
+	Target  string    `json:"target"`
+	Reason  string    `json:"reason"`
+	Expires time.Time `json:"expires"`
+}
+
+// BanList keeps banned peer ids and IP addresses, bans expire after the configured duration and survive restarts
+type BanList struct {
+	path     string
+	duration time.Duration
+	bans     map[string]*Ban
+	mutex    sync.RWMutex
+	// version is incremented on every change, persistedVersion is the version written to disk
+	version          uint64
+	persistedVersion uint64
+	persistMutex     sync.Mutex
+	log              log.Logger
+}
+
+func NewBanList(datadir string, duration time.Duration) *BanList {
+	if duration <= 0 {
+		duration = config.DefaultPeerBanDuration
+	}
+	l := &BanList{
+		duration: duration,
+		bans:     make(map[string]*Ban),
+		log:      log.New("component", "banlist"),
+	}
+	if datadir != "" {
+		l.path = filepath.Join(datadir, banListFile)
+		l.load()
+	}
+	return l
+}
+
+func (l *BanList) load() {
+	data, err := ioutil.ReadFile(l.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			l.log.Warn("cannot read ban list", "err", err)
+		}
+		return
+	}
+	var bans []*Ban
+	if err := json.Unmarshal(data, &bans); err != nil {
+		l.log.Warn("cannot parse ban list", "err", err)
+		return
+	}
+	now := time.Now().UTC()
+	for _, b := range bans {
+		if b.Expires.After(now) {
+			l.bans[b.Target] = b
+		}
+	}
+	l.evict()
+}
+
+// changed bumps the version of the list and returns a snapshot of it, it should be called under the lock
+func (l *BanList) changed() ([]*Ban, uint64) {
+	l.version++
+	return l.evict(), l.version
+}
+
+// persist writes the snapshot to disk unless a newer one is already written, it should be called without the lock
+// to not block ban checks by disk writes
+func (l *BanList) persist(bans []*Ban, version uint64) {
+	if l.path == "" {
+		return
+	}
+	l.persistMutex.Lock()
+	defer l.persistMutex.Unlock()
+	if version <= l.persistedVersion {
+		return
+	}
+	l.persistedVersion = version
+	data, err := json.Marshal(bans)
+	if err != nil {
+		l.log.Warn("cannot serialize ban list", "err", err)
+		return
+	}
+	if err := writeFileAtomic(l.path, data); err != nil {
+		l.log.Warn("cannot persist ban list", "err", err)
+	}
+}
+
+// writeFileAtomic writes the data to a temporary file and renames it, so a crash doesn't leave a truncated file
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
+
+func (l *BanList) list() []*Ban {
+	now := time.Now().UTC()
+	result := make([]*Ban, 0, len(l.bans))
+	for target, b := range l.bans {
+		if !b.Expires.After(now) {
+			delete(l.bans, target)
+			continue
+		}
+		result = append(result, b)
+	}
+	return result
+}
+
+// evict drops expired bans and the ones which expire first if there are more than maxBans bans
+func (l *BanList) evict() []*Ban {
+	bans := l.list()
+	if len(bans) <= maxBans {
+		return bans
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Expires.Before(bans[j].Expires)
+	})
+	evicted := len(bans) - maxBans
+	for _, b := range bans[:evicted] {
+		delete(l.bans, b.Target)
+	}
+	return bans[evicted:]
+}
+
+// Ban bans the targets for the duration, the default duration is used if zero duration is passed
+func (l *BanList) Ban(reason string, duration time.Duration, targets ...string) {
+	if duration == 0 {
+		duration = l.duration
+	}
+	expires := time.Now().UTC().Add(duration)
+	l.mutex.Lock()
+	for _, target := range targets {
+		if target == "" {
+			continue
+		}
+		l.bans[target] = &Ban{
+			Target:  target,
+			Reason:  reason,
+			Expires: expires,
+		}
+	}
+	bans, version := l.changed()
+	l.mutex.Unlock()
+	l.persist(bans, version)
+}
+
+func (l *BanList) Unban(target string) bool {
+	l.mutex.Lock()
+	if _, ok := l.bans[target]; !ok {
+		l.mutex.Unlock()
+		return false
+	}
+	delete(l.bans, target)
+	bans, version := l.changed()
+	l.mutex.Unlock()
+	l.persist(bans, version)
+	return true
+}
+
+func (l *BanList) IsBanned(targets ...string) bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	now := time.Now().UTC()
+	for _, target := range targets {
+		if target == "" {
+			continue
+		}
+		if b, ok := l.bans[target]; ok && b.Expires.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *BanList) List() []*Ban {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.list()
+}
+
+func (l *BanList) IsPeerBanned(id peer.ID, addr multiaddr.Multiaddr) bool {
+	return l.IsBanned(id.Pretty(), addrIP(addr))
+}
+
+func addrIP(addr multiaddr.Multiaddr) string {
+	if addr == nil {
+		return ""
+	}
+	ip, err := manet.ToIP(addr)
+	if err != nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// BanManually bans the peer id or IP address and drops matching connected peers
+func (h *IdenaGossipHandler) BanManually(target string, duration time.Duration) {
+	h.banList.Ban("manual", duration, target)
+	for _, p := range h.peers.Peers() {
+		if h.trustedPeers.contains(p.id) {
+			continue
+		}
+		if p.id.Pretty() == target || addrIP(p.stream.Conn().RemoteMultiaddr()) == target {
+			p.stream.Reset()
+		}
+	}
+}
+
+func (h *IdenaGossipHandler) Unban(target string) bool {
+	return h.banList.Unban(target)
+}
+
+func (h *IdenaGossipHandler) Bans() []*Ban {
+	return h.banList.List()
+}
@@ -0,0 +1,88 @@
+package protocol
+
+import (
+	"context"
+	"github.com/idena-network/idena-go/config"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBanList_Persist(t *testing.T) {
+	dir := t.TempDir()
+	l := NewBanList(dir, time.Hour)
+	l.Ban("test", 0, "peer1", "1.2.3.4")
+	l.Ban("test", time.Nanosecond, "peer2")
+	time.Sleep(time.Millisecond)
+
+	require.True(t, l.IsBanned("peer1"))
+	require.True(t, l.IsBanned("1.2.3.4"))
+	require.False(t, l.IsBanned("peer2"))
+	_, err := os.Stat(filepath.Join(dir, banListFile+".tmp"))
+	require.True(t, os.IsNotExist(err))
+
+	loaded := NewBanList(dir, time.Hour)
+	require.Len(t, loaded.List(), 2)
+	require.True(t, loaded.IsBanned("peer1"))
+
+	require.True(t, loaded.Unban("peer1"))
+	require.False(t, loaded.Unban("peer1"))
+	require.Len(t, NewBanList(dir, time.Hour).List(), 1)
+}
+
+func TestBanList_LoadCorrupted(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, banListFile), []byte(`[{"target":`), 0644))
+	l := NewBanList(dir, time.Hour)
+	require.Empty(t, l.List())
+}
+
+func TestBanList_Evict(t *testing.T) {
+	l := NewBanList("", time.Hour)
+	l.Ban("test", time.Hour*2, "last")
+	targets := make([]string, 0, maxBans)
+	for i := 0; i < maxBans; i++ {
+		targets = append(targets, strconv.Itoa(i))
+	}
+	l.Ban("test", 0, targets...)
+
+	// the ban which expires last is kept
+	require.Len(t, l.List(), maxBans)
+	require.True(t, l.IsBanned("last"))
+}
+
+func TestBanList_IsPeerBanned(t *testing.T) {
+	l := NewBanList("", time.Hour)
+	addr, _ := multiaddr.NewMultiaddr("/ip4/1.2.3.4/tcp/40405")
+	id := peer.ID("peer")
+
+	require.False(t, l.IsPeerBanned(id, addr))
+	l.Ban("test", 0, "1.2.3.4")
+	require.True(t, l.IsPeerBanned(id, addr))
+	require.True(t, l.IsPeerBanned(peer.ID("other"), addr))
+}
+
+func TestConnManager_BanPeer(t *testing.T) {
+	host, err := libp2p.New(libp2p.NoListenAddrs)
+	require.NoError(t, err)
+	defer host.Close()
+	remote, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+	defer remote.Close()
+	require.NoError(t, host.Connect(context.Background(), peer.AddrInfo{ID: remote.ID(), Addrs: remote.Addrs()}))
+
+	l := NewBanList("", time.Hour)
+	m := NewConnManager(host, config.P2P{}, newTrustedPeers(nil), l, NewRememberedPeers(""))
+	m.BanPeer(remote.ID(), "test")
+
+	require.True(t, l.IsBanned(remote.ID().Pretty()))
+	require.True(t, l.IsBanned("127.0.0.1"))
+	require.Len(t, l.List(), 2)
+}
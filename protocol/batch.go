@@ -6,6 +6,7 @@ import (
 	"github.com/idena-network/idena-go/core/state"
 	models "github.com/idena-network/idena-go/protobuf"
 	"github.com/libp2p/go-libp2p-core/peer"
+	"time"
 )
 
 type batch struct {
@@ -13,6 +14,11 @@ type batch struct {
 	from    uint64
 	to      uint64
 	headers chan *block
+	// closed when the peer responds
+	delivered   chan struct{}
+	requestedAt time.Time
+	// size of the response payload
+	size int
 }
 
 type block struct {
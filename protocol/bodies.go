@@ -0,0 +1,205 @@
+package protocol
+
+import (
+	"bytes"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/pkg/errors"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// max number of block bodies in a single request
+	maxBodiesPerRequest  = 100
+	bodiesRequestTimeout = time.Second * 20
+	// max number of block bodies requests served concurrently
+	maxBodiesProviders = 4
+	// max number of block bodies requests of a single peer served concurrently
+	maxPeerBodiesProviders = 1
+)
+
+var bodiesRequestId = uint32(0)
+
+type bodiesRequest struct {
+	peerId peer.ID
+	hashes []common.Hash
+	result chan [][]byte
+}
+
+// Block bodies are sent as msgBatch items: payloads are block hashes in the request and encoded bodies
+// in the response (empty if the body is unknown), shard ids of all items carry the request id
+func newBodiesMsg(requestId uint32, payloads [][]byte) *msgBatch {
+	result := &msgBatch{}
+	for _, payload := range payloads {
+		result.Data = append(result.Data, &batchItem{
+			Payload: payload,
+			ShardId: common.ShardId(requestId),
+		})
+	}
+	return result
+}
+
+// GetBlockBodies requests bodies of the blocks in a single round trip, missing bodies are nil
+func (h *IdenaGossipHandler) GetBlockBodies(peerId peer.ID, hashes []common.Hash) ([][]byte, error) {
+	p := h.peers.Peer(peerId)
+	if p == nil {
+		return nil, errors.New("protoPeer is not found")
+	}
+	if _, ok := p.supportedFeatures[BodiesRequests]; !ok {
+		return nil, errors.New("peer doesn't support block bodies requests")
+	}
+	if len(hashes) == 0 || len(hashes) > maxBodiesPerRequest {
+		return nil, errors.Errorf("invalid number of requested bodies: %v", len(hashes))
+	}
+	id := atomic.AddUint32(&bodiesRequestId, 1)
+	request := &bodiesRequest{
+		peerId: peerId,
+		hashes: hashes,
+		result: make(chan [][]byte, 1),
+	}
+	h.bodiesRequests.Store(id, request)
+	defer h.bodiesRequests.Delete(id)
+
+	payloads := make([][]byte, len(hashes))
+	for i, hash := range hashes {
+		payloads[i] = hash.Bytes()
+	}
+	requestedAt := time.Now()
+	p.sendMsg(GetBlockBodies, newBodiesMsg(id, payloads), common.MultiShard, false)
+
+	timer := time.NewTimer(bodiesRequestTimeout)
+	defer timer.Stop()
+	select {
+	case bodies := <-request.result:
+		p.score.observeLatency(time.Since(requestedAt))
+		return bodies, nil
+	case <-timer.C:
+		p.score.observeSyncError()
+		return nil, errors.New("block bodies request timeout")
+	}
+}
+
+func (h *IdenaGossipHandler) provideBlockBodies(p *protoPeer, request *msgBatch) error {
+	if len(request.Data) == 0 {
+		return nil
+	}
+	if len(request.Data) > maxBodiesPerRequest {
+		return errResp(ProtocolErr, "too many requested bodies: %v", len(request.Data))
+	}
+	requestId := uint32(request.Data[0].ShardId)
+	hashes := make([]common.Hash, len(request.Data))
+	for i, item := range request.Data {
+		if len(item.Payload) != common.HashLength {
+			return errResp(DecodeErr, "invalid block hash length: %v", len(item.Payload))
+		}
+		hashes[i] = common.BytesToHash(item.Payload)
+	}
+	// bodies are read from ipfs, it shouldn't block the peer listening unless all providers are busy
+	// or the peer waits for its previous requests, so a single peer can't occupy all providers
+	select {
+	case p.bodiesProviders <- struct{}{}:
+	case <-p.term:
+		return nil
+	}
+	select {
+	case h.bodiesProviders <- struct{}{}:
+	case <-p.term:
+		<-p.bodiesProviders
+		return nil
+	}
+	go func() {
+		defer func() {
+			<-h.bodiesProviders
+			<-p.bodiesProviders
+		}()
+		payloads := make([][]byte, len(hashes))
+		for i, hash := range hashes {
+			if block := h.bcn.GetBlock(hash); block != nil && !block.IsEmpty() {
+				payloads[i] = block.Body.ToBytes()
+			}
+		}
+		p.sendMsg(BlockBodies, newBodiesMsg(requestId, payloads), common.MultiShard, false)
+	}()
+	return nil
+}
+
+func (h *IdenaGossipHandler) handleBlockBodies(p *protoPeer, response *msgBatch) error {
+	if len(response.Data) == 0 {
+		return nil
+	}
+	requestId := uint32(response.Data[0].ShardId)
+	value, ok := h.bodiesRequests.Load(requestId)
+	if !ok {
+		return nil
+	}
+	request := value.(*bodiesRequest)
+	if request.peerId != p.id {
+		return nil
+	}
+	if len(response.Data) != len(request.hashes) {
+		return errResp(ProtocolErr, "unexpected number of block bodies: %v", len(response.Data))
+	}
+	bodies := make([][]byte, len(response.Data))
+	for i, item := range response.Data {
+		if len(item.Payload) > 0 {
+			bodies[i] = item.Payload
+		}
+	}
+	select {
+	case request.result <- bodies:
+	default:
+	}
+	return nil
+}
+
+// loadBodies fetches bodies of the deferred blocks from the peers which provided the headers,
+// the result contains only bodies matching the headers, the rest should be loaded from ipfs
+func (fs *fullSync) loadBodies(headers []blockPeer) map[common.Hash]*types.Body {
+	result := make(map[common.Hash]*types.Body)
+	byPeer := make(map[peer.ID][]*types.Header)
+	for _, b := range headers {
+		if b.Header.ProposedHeader == nil || len(b.Header.ProposedHeader.IpfsHash) == 0 {
+			continue
+		}
+		byPeer[b.peerId] = append(byPeer[b.peerId], b.Header)
+	}
+	for peerId, peerHeaders := range byPeer {
+		for len(peerHeaders) > 0 {
+			chunk := peerHeaders
+			if len(chunk) > maxBodiesPerRequest {
+				chunk = chunk[:maxBodiesPerRequest]
+			}
+			peerHeaders = peerHeaders[len(chunk):]
+			hashes := make([]common.Hash, len(chunk))
+			for i, header := range chunk {
+				hashes[i] = header.Hash()
+			}
+			bodies, err := fs.pm.GetBlockBodies(peerId, hashes)
+			if err != nil {
+				fs.log.Debug("failed to load block bodies", "peer", peerId, "err", err)
+				break
+			}
+			delivered := 0
+			for i, data := range bodies {
+				if data == nil {
+					continue
+				}
+				fs.bandwidth.wait(len(data))
+				c, err := fs.ipfs.Cid(data)
+				if err != nil || !bytes.Equal(c.Bytes(), chunk[i].ProposedHeader.IpfsHash) {
+					fs.log.Warn("received block body doesn't match the header", "peer", peerId, "height", chunk[i].Height())
+					continue
+				}
+				body := &types.Body{}
+				body.FromBytes(data)
+				result[hashes[i]] = body
+				delivered++
+			}
+			// only bodies matching the headers are credited
+			fs.pm.rewardPeer(peerId, int64(scoreBlockDelivered*delivered))
+		}
+	}
+	return result
+}
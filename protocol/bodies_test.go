@@ -0,0 +1,82 @@
+package protocol
+
+import (
+	"github.com/idena-network/idena-go/common"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestBodiesPeer() *protoPeer {
+	return &protoPeer{
+		term:            make(chan struct{}),
+		bodiesProviders: make(chan struct{}, maxPeerBodiesProviders),
+	}
+}
+
+func TestIdenaGossipHandler_provideBlockBodiesValidation(t *testing.T) {
+	h := &IdenaGossipHandler{bodiesProviders: make(chan struct{}, 1)}
+	p := newTestBodiesPeer()
+
+	payloads := make([][]byte, maxBodiesPerRequest+1)
+	for i := range payloads {
+		payloads[i] = common.Hash{}.Bytes()
+	}
+	require.Error(t, h.provideBlockBodies(p, newBodiesMsg(1, payloads)))
+	require.Error(t, h.provideBlockBodies(p, newBodiesMsg(1, [][]byte{{0x1}})))
+	require.Len(t, h.bodiesProviders, 0)
+}
+
+func TestIdenaGossipHandler_provideBlockBodiesWaitsForProvider(t *testing.T) {
+	h := &IdenaGossipHandler{bodiesProviders: make(chan struct{}, 1)}
+	h.bodiesProviders <- struct{}{}
+	p := newTestBodiesPeer()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- h.provideBlockBodies(p, newBodiesMsg(1, [][]byte{common.Hash{}.Bytes()}))
+	}()
+	select {
+	case <-done:
+		require.Fail(t, "request should wait while all providers are busy")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	close(p.term)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "request should be dropped after the peer is disconnected")
+	}
+	require.Len(t, h.bodiesProviders, 1)
+	require.Len(t, p.bodiesProviders, 0)
+}
+
+func TestIdenaGossipHandler_provideBlockBodiesPeerLimit(t *testing.T) {
+	h := &IdenaGossipHandler{bodiesProviders: make(chan struct{}, maxBodiesProviders)}
+	p := newTestBodiesPeer()
+	for i := 0; i < maxPeerBodiesProviders; i++ {
+		p.bodiesProviders <- struct{}{}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- h.provideBlockBodies(p, newBodiesMsg(1, [][]byte{common.Hash{}.Bytes()}))
+	}()
+	select {
+	case <-done:
+		require.Fail(t, "request should wait for the previous requests of the peer")
+	case <-time.After(time.Millisecond * 100):
+	}
+	require.Len(t, h.bodiesProviders, 0)
+
+	close(p.term)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "request should be dropped after the peer is disconnected")
+	}
+	require.Len(t, h.bodiesProviders, 0)
+}
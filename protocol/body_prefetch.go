@@ -0,0 +1,122 @@
+package protocol
+
+import (
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"sync"
+	"time"
+)
+
+const (
+	// max number of validated headers waiting for their bodies
+	prefetchQueueSize = FullSyncBatchSize * 2
+	prefetchTimeout   = bodiesRequestTimeout * 2
+)
+
+type prefetchedBody struct {
+	body  *types.Body
+	ready chan struct{}
+}
+
+// bodyPrefetcher loads bodies of validated headers in background, so the blocks are ready
+// to be executed when the certificate arrives
+type bodyPrefetcher struct {
+	fs      *fullSync
+	queue   chan blockPeer
+	bodies  map[common.Hash]*prefetchedBody
+	mutex   sync.Mutex
+	timeout time.Duration
+}
+
+func newBodyPrefetcher(fs *fullSync) *bodyPrefetcher {
+	p := &bodyPrefetcher{
+		fs:      fs,
+		queue:   make(chan blockPeer, prefetchQueueSize),
+		bodies:  make(map[common.Hash]*prefetchedBody),
+		timeout: prefetchTimeout,
+	}
+	go p.loop()
+	return p
+}
+
+func (p *bodyPrefetcher) loop() {
+	for b := range p.queue {
+		headers := []blockPeer{b}
+	drain:
+		for len(headers) < maxBodiesPerRequest {
+			select {
+			case next, ok := <-p.queue:
+				if !ok {
+					break drain
+				}
+				headers = append(headers, next)
+			default:
+				break drain
+			}
+		}
+		p.load(headers)
+	}
+}
+
+func (p *bodyPrefetcher) load(headers []blockPeer) {
+	bodies := p.fs.loadBodies(headers)
+	for _, b := range headers {
+		hash := b.Header.Hash()
+		body, ok := bodies[hash]
+		if !ok {
+			if block, err := p.fs.GetBlock(b.Header); err == nil {
+				body = block.Body
+			}
+		}
+		p.mutex.Lock()
+		if item, ok := p.bodies[hash]; ok {
+			item.body = body
+			close(item.ready)
+		}
+		p.mutex.Unlock()
+	}
+}
+
+// schedule queues loading of the body, headers without bodies are skipped
+func (p *bodyPrefetcher) schedule(b blockPeer) {
+	if b.Header.EmptyBlockHeader != nil {
+		return
+	}
+	hash := b.Header.Hash()
+	p.mutex.Lock()
+	if _, ok := p.bodies[hash]; ok {
+		p.mutex.Unlock()
+		return
+	}
+	p.bodies[hash] = &prefetchedBody{ready: make(chan struct{})}
+	p.mutex.Unlock()
+	p.queue <- b
+}
+
+// take waits for the scheduled body, nil means the body should be loaded directly.
+// The body is forgotten after the call even if it's not loaded in time.
+func (p *bodyPrefetcher) take(hash common.Hash) *types.Body {
+	p.mutex.Lock()
+	item, ok := p.bodies[hash]
+	p.mutex.Unlock()
+	if !ok {
+		return nil
+	}
+	defer func() {
+		p.mutex.Lock()
+		delete(p.bodies, hash)
+		p.mutex.Unlock()
+	}()
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+	select {
+	case <-item.ready:
+		return item.body
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (p *bodyPrefetcher) stop() {
+	close(p.queue)
+}
@@ -0,0 +1,38 @@
+package protocol
+
+import (
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestBodyPrefetcher() *bodyPrefetcher {
+	return &bodyPrefetcher{
+		bodies:  make(map[common.Hash]*prefetchedBody),
+		timeout: time.Millisecond * 50,
+	}
+}
+
+func TestBodyPrefetcher_take(t *testing.T) {
+	p := newTestBodyPrefetcher()
+	hash := common.Hash{0x1}
+	item := &prefetchedBody{ready: make(chan struct{})}
+	p.bodies[hash] = item
+	item.body = &types.Body{}
+	close(item.ready)
+
+	require.Equal(t, item.body, p.take(hash))
+	require.Empty(t, p.bodies)
+	require.Nil(t, p.take(hash))
+}
+
+func TestBodyPrefetcher_takeTimeout(t *testing.T) {
+	p := newTestBodyPrefetcher()
+	hash := common.Hash{0x1}
+	p.bodies[hash] = &prefetchedBody{ready: make(chan struct{})}
+
+	require.Nil(t, p.take(hash))
+	require.Empty(t, p.bodies)
+}
@@ -4,7 +4,12 @@ import "github.com/coreos/go-semver/semver"
 
 type PeerFeature = string
 
-const Batches = PeerFeature("batches")
+const (
+	Batches        = PeerFeature("batches")
+	Snappy         = PeerFeature("snappy")
+	PeerExchange   = PeerFeature("pex")
+	BodiesRequests = PeerFeature("bodies")
+)
 
 const (
 	Handshake         = 0x01
@@ -27,16 +32,37 @@ const (
 	BatchPush         = 0x12
 	BatchFlipKey      = 0x13
 	Disconnect        = 0x14
+	GetPeers          = 0x15
+	Peers             = 0x16
+	GetBlockBodies    = 0x17
+	BlockBodies       = 0x18
 )
 
-var batchSupportVersion *semver.Version
+var (
+	batchSupportVersion  *semver.Version
+	snappySupportVersion *semver.Version
+	pexSupportVersion    *semver.Version
+	bodiesSupportVersion *semver.Version
+)
 
 func init() {
 	batchSupportVersion, _ = semver.NewVersion("1.1.0")
+	snappySupportVersion, _ = semver.NewVersion("1.2.0")
+	pexSupportVersion, _ = semver.NewVersion("1.3.0")
+	bodiesSupportVersion, _ = semver.NewVersion("1.4.0")
 }
 
 func SetSupportedFeatures(peer *protoPeer) {
 	if peer.version.Compare(*batchSupportVersion) >= 0 {
 		peer.supportedFeatures[Batches] = struct{}{}
 	}
+	if peer.version.Compare(*snappySupportVersion) >= 0 {
+		peer.supportedFeatures[Snappy] = struct{}{}
+	}
+	if peer.version.Compare(*pexSupportVersion) >= 0 {
+		peer.supportedFeatures[PeerExchange] = struct{}{}
+	}
+	if peer.version.Compare(*bodiesSupportVersion) >= 0 {
+		peer.supportedFeatures[BodiesRequests] = struct{}{}
+	}
 }
@@ -2,31 +2,28 @@ package protocol
 
 import (
 	"context"
-	"github.com/deckarep/golang-set"
 	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-go/config"
 	core "github.com/libp2p/go-libp2p-core"
-	"github.com/libp2p/go-libp2p-core/helpers"
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
-	"github.com/libp2p/go-libp2p-core/protocol"
 	"github.com/libp2p/go-yamux"
 	"github.com/pkg/errors"
-	"math/rand"
-	"strings"
 	"sync"
 	"time"
 )
 
 const (
 	dialPeerAttempts = 10
+	// number of inbound peers accepted above the inbound limit to keep enough peers from the shards
+	maxInboundShardOverflow = 1
 )
 
 var FailedToDialPeer = errors.New("failed to select peer")
 var NoPeersToDial = errors.New("no peers to dial")
 
 type ConnManager struct {
-	bannedPeers       mapset.Set
+	banList           *BanList
 	activeConnections map[peer.ID]network.Conn
 	discTimes         map[peer.ID]time.Time
 	resetTimes        map[peer.ID]time.Time
@@ -34,30 +31,44 @@ type ConnManager struct {
 	inboundPeers  map[peer.ID]common.ShardId
 	outboundPeers map[peer.ID]common.ShardId
 
+	// guards the peer maps and the peer limits of cfg, the limits are replaced on config reload
 	peerMutex sync.RWMutex
 	connMutex sync.Mutex
 	host      core.Host
 	cfg       config.P2P
 
 	ownShardId common.ShardId
+
+	staticPeers  map[peer.ID]struct{}
+	trustedPeers *trustedPeers
+	score        func(id peer.ID) int64
+	// scores of disconnected peers
+	rememberedPeers *RememberedPeers
 }
 
-func NewConnManager(host core.Host, cfg config.P2P) *ConnManager {
+func NewConnManager(host core.Host, cfg config.P2P, trustedPeers *trustedPeers, banList *BanList, rememberedPeers *RememberedPeers) *ConnManager {
+	staticPeers := make(map[peer.ID]struct{})
+	for _, sp := range parseStaticPeers(cfg.StaticPeers) {
+		staticPeers[sp.info.ID] = struct{}{}
+	}
 	return &ConnManager{
+		staticPeers:       staticPeers,
+		trustedPeers:      trustedPeers,
 		host:              host,
 		cfg:               cfg,
-		bannedPeers:       mapset.NewSet(),
+		banList:           banList,
 		activeConnections: make(map[peer.ID]network.Conn),
 		inboundPeers:      make(map[peer.ID]common.ShardId),
 		outboundPeers:     make(map[peer.ID]common.ShardId),
 		discTimes:         make(map[peer.ID]time.Time),
 		resetTimes:        make(map[peer.ID]time.Time),
+		rememberedPeers:   rememberedPeers,
 	}
 }
 
 func (m *ConnManager) CanConnect(id peer.ID) bool {
 
-	if m.bannedPeers.Contains(id) {
+	if m.isBanned(id) {
 		return false
 	}
 	m.peerMutex.RLock()
@@ -76,12 +87,8 @@ func (m *ConnManager) CanConnect(id peer.ID) bool {
 	if err != nil {
 		return false
 	}
-	for _, p := range protos {
-		if strings.Contains(p, IdenaProtocolPath) {
-			return true
-		}
-	}
-	return false
+	_, err = negotiateProtocol(protos)
+	return err == nil
 }
 
 func (m *ConnManager) Connected(id peer.ID, inbound bool, shardId common.ShardId) {
@@ -125,14 +132,52 @@ func (m *ConnManager) Disconnected(id peer.ID, reason error) {
 	delete(m.outboundPeers, id)
 }
 
-func (m *ConnManager) BanPeer(id peer.ID) {
-	m.bannedPeers.Add(id)
-	if m.bannedPeers.Cardinality() > MaxBannedPeers {
-		m.bannedPeers.Pop()
+// IsStatic returns true if the peer is configured as static
+func (m *ConnManager) IsStatic(id peer.ID) bool {
+	_, ok := m.staticPeers[id]
+	return ok
+}
+
+// IsProtected returns true for static and trusted peers
+func (m *ConnManager) IsProtected(id peer.ID) bool {
+	return m.IsStatic(id) || m.trustedPeers.contains(id)
+}
+
+// BanPeer bans the peer id and IP addresses of its connections
+func (m *ConnManager) BanPeer(id peer.ID, reason string) {
+	if m.trustedPeers.contains(id) {
+		return
+	}
+	targets := []string{id.Pretty()}
+	for _, conn := range m.host.Network().ConnsToPeer(id) {
+		targets = append(targets, addrIP(conn.RemoteMultiaddr()))
+	}
+	m.banList.Ban(reason, 0, targets...)
+}
+
+func (m *ConnManager) UnbanPeer(id peer.ID) {
+	m.banList.Unban(id.Pretty())
+}
+
+func (m *ConnManager) isBanned(id peer.ID) bool {
+	if m.trustedPeers.contains(id) {
+		return false
 	}
+	if m.banList.IsBanned(id.Pretty()) {
+		return true
+	}
+	for _, conn := range m.host.Network().ConnsToPeer(id) {
+		if m.banList.IsBanned(addrIP(conn.RemoteMultiaddr())) {
+			return true
+		}
+	}
+	return false
 }
 
-func (m *ConnManager) DialRandomPeer() (network.Stream, error) {
+// DialBestPeer opens a stream to a connected libp2p peer which is not an idena peer yet,
+// peers from subnets without idena peers and peers with better remembered score are dialed first.
+// Peers from attempted are skipped, every dialed peer is added to attempted.
+func (m *ConnManager) DialBestPeer(attempted map[peer.ID]struct{}) (network.Stream, error) {
 	m.connMutex.Lock()
 	conns := make([]network.Conn, 0, len(m.activeConnections))
 	for _, c := range m.activeConnections {
@@ -140,15 +185,10 @@ func (m *ConnManager) DialRandomPeer() (network.Stream, error) {
 	}
 	m.connMutex.Unlock()
 
-	filteredConns := make([]network.Conn, 0, len(conns))
-
-	for _, c := range conns {
-		id := c.RemotePeer()
-		m.peerMutex.RLock()
-		_, inbound := m.inboundPeers[id]
-		_, outbound := m.outboundPeers[id]
-		m.peerMutex.RUnlock()
-		if !inbound && !outbound && m.CanConnect(id) {
+	candidates := m.dialCandidates(conns, attempted)
+	filteredConns := candidates[:0]
+	for _, c := range candidates {
+		if m.CanConnect(c.RemotePeer()) {
 			filteredConns = append(filteredConns, c)
 		}
 	}
@@ -157,28 +197,39 @@ func (m *ConnManager) DialRandomPeer() (network.Stream, error) {
 		return nil, NoPeersToDial
 	}
 
-	for attempt := 0; attempt < dialPeerAttempts; attempt++ {
-		idx := rand.Intn(len(filteredConns))
-		conn := filteredConns[idx]
-
-		if stream, err := m.findOrOpenStream(conn); err == nil {
+	m.sortDialCandidates(filteredConns)
+	for attempt := 0; attempt < dialPeerAttempts && attempt < len(filteredConns); attempt++ {
+		attempted[filteredConns[attempt].RemotePeer()] = struct{}{}
+		if stream, err := m.findOrOpenStream(filteredConns[attempt]); err == nil {
 			return stream, nil
 		}
-		if len(filteredConns) == 1 {
-			break
-		}
-		filteredConns[idx] = filteredConns[len(filteredConns)-1]
-		filteredConns[len(filteredConns)-1] = nil
-		filteredConns = filteredConns[:len(filteredConns)-1]
 	}
 	return nil, FailedToDialPeer
 }
 
+// dialCandidates returns connections to peers which are neither idena peers nor attempted
+func (m *ConnManager) dialCandidates(conns []network.Conn, attempted map[peer.ID]struct{}) []network.Conn {
+	m.peerMutex.RLock()
+	defer m.peerMutex.RUnlock()
+	result := make([]network.Conn, 0, len(conns))
+	for _, c := range conns {
+		id := c.RemotePeer()
+		if _, ok := attempted[id]; ok {
+			continue
+		}
+		_, inbound := m.inboundPeers[id]
+		_, outbound := m.outboundPeers[id]
+		if !inbound && !outbound {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
 func (m *ConnManager) findOrOpenStream(conn network.Conn) (network.Stream, error) {
 	streams := conn.GetStreams()
-	matcher, _ := helpers.MultistreamSemverMatcher(IdenaProtocol)
 	for _, s := range streams {
-		if matcher(string(s.Protocol())) {
+		if isIdenaProtocol(string(s.Protocol())) {
 			return s, nil
 		}
 	}
@@ -195,15 +246,9 @@ func (m *ConnManager) newStream(peerID peer.ID) (network.Stream, error) {
 		return nil, err
 	}
 
-	var idenaProtocol protocol.ID
-	for _, p := range protos {
-		if strings.Contains(p, IdenaProtocolPath) {
-			idenaProtocol = protocol.ID(p)
-			break
-		}
-	}
-	if idenaProtocol == "" {
-		return nil, errors.New("peer doesn't support idena protocol")
+	idenaProtocol, err := negotiateProtocol(protos)
+	if err != nil {
+		return nil, err
 	}
 	stream, err := m.host.NewStream(ctx, peerID, idenaProtocol)
 
@@ -221,7 +266,7 @@ func (m *ConnManager) AddConnection(conn network.Conn) {
 
 	go func() {
 		id := conn.RemotePeer()
-		if m.bannedPeers.Contains(id) {
+		if !m.trustedPeers.contains(id) && m.banList.IsPeerBanned(id, conn.RemoteMultiaddr()) {
 			return
 		}
 		time.Sleep(time.Second * 5)
@@ -241,7 +286,44 @@ func (m *ConnManager) RemoveConnection(conn network.Conn) {
 func (m *ConnManager) CanAcceptStream() bool {
 	m.peerMutex.RLock()
 	defer m.peerMutex.RUnlock()
-	return len(m.inboundPeers) < m.cfg.MaxInboundPeers+m.cfg.MaxInboundOwnShardPeers
+	return m.slotsInUse(m.inboundPeers) < m.cfg.InboundLimit()
+}
+
+// CanOverflowInbound reports whether a shard peer can still be accepted above the inbound limit
+func (m *ConnManager) CanOverflowInbound() bool {
+	m.peerMutex.RLock()
+	defer m.peerMutex.RUnlock()
+	return m.slotsInUse(m.inboundPeers) < m.cfg.InboundLimit()+maxInboundShardOverflow
+}
+
+func (m *ConnManager) InboundLimit() int {
+	m.peerMutex.RLock()
+	defer m.peerMutex.RUnlock()
+	return m.cfg.InboundLimit()
+}
+
+// Config returns a copy of the config with the current peer limits
+func (m *ConnManager) Config() config.P2P {
+	m.peerMutex.RLock()
+	defer m.peerMutex.RUnlock()
+	return m.cfg
+}
+
+// SetPeerLimits replaces the peer limits, connected peers above the new limits are gradually dropped by the peer renewal
+func (m *ConnManager) SetPeerLimits(limits config.P2P) {
+	m.peerMutex.Lock()
+	defer m.peerMutex.Unlock()
+	m.cfg.SetPeerLimits(limits)
+}
+
+func (m *ConnManager) slotsInUse(peers map[peer.ID]common.ShardId) int {
+	cnt := 0
+	for id := range peers {
+		if !m.IsProtected(id) {
+			cnt++
+		}
+	}
+	return cnt
 }
 
 func (m *ConnManager) NeedPeerFromSomeShard(shardsNum int) bool {
@@ -283,24 +365,54 @@ func (m *ConnManager) NeedOutboundOwnShardPeers() bool {
 			cnt++
 		}
 	}
-	return cnt < m.MaxOutboundOwnPeers()
+	return cnt < m.cfg.MaxOutboundOwnShardPeers
 }
 
 func (m *ConnManager) MaxOutboundPeers() int {
+	m.peerMutex.RLock()
+	defer m.peerMutex.RUnlock()
 	return m.cfg.MaxOutboundPeers
 }
 
 func (m *ConnManager) MaxOutboundOwnPeers() int {
+	m.peerMutex.RLock()
+	defer m.peerMutex.RUnlock()
 	return m.cfg.MaxOutboundOwnShardPeers
 }
 
+func (m *ConnManager) OutboundLimit() int {
+	m.peerMutex.RLock()
+	defer m.peerMutex.RUnlock()
+	return m.cfg.OutboundLimit()
+}
+
 func (m *ConnManager) CanDial() bool {
 	m.peerMutex.RLock()
 	defer m.peerMutex.RUnlock()
-	return len(m.outboundPeers) < m.MaxOutboundPeers()+m.MaxOutboundOwnPeers()
+	return m.slotsInUse(m.outboundPeers) < m.cfg.OutboundLimit()
+}
+
+func (m *ConnManager) SetScorer(score func(id peer.ID) int64) {
+	m.score = score
+}
+
+func (m *ConnManager) selectWorst(candidates []peer.ID) peer.ID {
+	if len(candidates) == 0 {
+		return ""
+	}
+	if m.score == nil {
+		return candidates[0]
+	}
+	return worstPeer(candidates, m.score)
 }
 
-func (m *ConnManager) GetRandomPeer(inbound bool) peer.ID {
+// PeerToRenew returns the peer with the lowest score among the ones which may be disconnected
+func (m *ConnManager) PeerToRenew(inbound bool) peer.ID {
+	// the scorer may lock the peer set, so it is called after the connection lock is released
+	return m.selectWorst(m.renewCandidates(inbound))
+}
+
+func (m *ConnManager) renewCandidates(inbound bool) []peer.ID {
 	m.peerMutex.RLock()
 	defer m.peerMutex.RUnlock()
 
@@ -311,25 +423,38 @@ func (m *ConnManager) GetRandomPeer(inbound bool) peer.ID {
 		peersMap = m.outboundPeers
 	}
 
+	var candidates []peer.ID
 	for k, s := range peersMap {
+		if m.IsProtected(k) {
+			continue
+		}
 		if s == common.MultiShard {
-			return k
+			candidates = append(candidates, k)
+			continue
 		}
 		if s != m.ownShardId && m.ownShardId != common.MultiShard {
-			return k
+			candidates = append(candidates, k)
+			continue
 		}
 		if m.peersCntFromShard(s) > m.minimalNumberOfPeersFromShard() {
-			return k
+			candidates = append(candidates, k)
 		}
 	}
-	return ""
+	return candidates
 }
 
 func (m *ConnManager) PeerForDisconnect(inbound bool, newPeerShardId common.ShardId) peer.ID {
+	return m.selectWorst(m.disconnectCandidates(inbound, newPeerShardId))
+}
+
+func (m *ConnManager) disconnectCandidates(inbound bool, newPeerShardId common.ShardId) []peer.ID {
 	m.peerMutex.RLock()
 	defer m.peerMutex.RUnlock()
 
-	canDisconnect := func(oldPeerShardId common.ShardId) bool {
+	canDisconnect := func(id peer.ID, oldPeerShardId common.ShardId) bool {
+		if m.IsProtected(id) {
+			return false
+		}
 		if oldPeerShardId == newPeerShardId {
 			return false
 		}
@@ -339,20 +464,17 @@ func (m *ConnManager) PeerForDisconnect(inbound bool, newPeerShardId common.Shar
 		return oldPeerShardId != newPeerShardId
 	}
 
+	peersMap := m.outboundPeers
 	if inbound {
-		for k, s := range m.inboundPeers {
-			if canDisconnect(s) {
-				return k
-			}
-		}
-	} else {
-		for k, s := range m.outboundPeers {
-			if canDisconnect(s) {
-				return k
-			}
+		peersMap = m.inboundPeers
+	}
+	var candidates []peer.ID
+	for k, s := range peersMap {
+		if canDisconnect(k, s) {
+			candidates = append(candidates, k)
 		}
 	}
-	return ""
+	return candidates
 }
 
 func (m *ConnManager) IsFromOwnShards(id common.ShardId) bool {
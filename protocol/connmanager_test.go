@@ -0,0 +1,142 @@
+package protocol
+
+import (
+	"github.com/idena-network/idena-go/config"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+func newTestConnManager(cfg config.P2P) *ConnManager {
+	return NewConnManager(nil, cfg, newTrustedPeers(nil), nil, NewRememberedPeers(""))
+}
+
+type testConn struct {
+	network.Conn
+	id   peer.ID
+	addr multiaddr.Multiaddr
+}
+
+func (c *testConn) RemotePeer() peer.ID {
+	return c.id
+}
+
+func (c *testConn) RemoteMultiaddr() multiaddr.Multiaddr {
+	return c.addr
+}
+
+func newTestConn(id string) network.Conn {
+	addr, _ := multiaddr.NewMultiaddr("/ip4/1.2.3.4/tcp/40405")
+	return &testConn{id: peer.ID(id), addr: addr}
+}
+
+func TestConnManager_SetPeerLimits(t *testing.T) {
+	m := newTestConnManager(config.P2P{MaxInboundPeers: 1, MaxOutboundPeers: 1})
+	m.Connected(peer.ID("inbound"), true, 1)
+	m.Connected(peer.ID("outbound"), false, 1)
+	require.False(t, m.CanAcceptStream())
+	require.False(t, m.CanDial())
+
+	m.SetPeerLimits(config.P2P{MaxInboundPeers: 2, MaxOutboundPeers: 3})
+	require.True(t, m.CanAcceptStream())
+	require.True(t, m.CanDial())
+	require.Equal(t, 3, m.OutboundLimit())
+	require.Equal(t, 2, m.MissingOutboundPeers())
+}
+
+// run with -race, the limits are replaced on config reload while the gossip handler reads them
+func TestConnManager_SetPeerLimitsConcurrently(t *testing.T) {
+	m := newTestConnManager(config.P2P{MaxInboundPeers: 1, MaxOutboundPeers: 1})
+	m.SetShardId(1)
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			m.SetPeerLimits(config.P2P{MaxInboundPeers: i, MaxOutboundPeers: i, MaxOutboundOwnShardPeers: i})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			m.CanAcceptStream()
+			m.CanDial()
+			m.MaxOutboundPeers()
+			m.MaxOutboundOwnPeers()
+			m.NeedOutboundOwnShardPeers()
+			m.MissingOutboundPeers()
+		}
+	}()
+	wg.Wait()
+	require.Equal(t, 999, m.MaxOutboundPeers())
+}
+
+// the scorer is called without the connection lock, so it may use the conn manager itself
+func TestConnManager_ScorerWithoutLock(t *testing.T) {
+	m := newTestConnManager(config.P2P{MaxInboundPeers: 2})
+	m.SetShardId(1)
+	m.Connected(peer.ID("peer1"), true, 2)
+	m.Connected(peer.ID("peer2"), true, 2)
+	m.SetScorer(func(id peer.ID) int64 {
+		m.SetPeerLimits(config.P2P{MaxInboundPeers: 2})
+		if id == "peer2" {
+			return -1
+		}
+		return 0
+	})
+	require.Equal(t, peer.ID("peer2"), m.PeerToRenew(true))
+	require.Equal(t, peer.ID("peer2"), m.PeerForDisconnect(true, 1))
+}
+
+func TestConnManager_CanOverflowInbound(t *testing.T) {
+	m := newTestConnManager(config.P2P{MaxInboundPeers: 1})
+	m.Connected(peer.ID("peer1"), true, 1)
+	require.False(t, m.CanAcceptStream())
+	require.True(t, m.CanOverflowInbound())
+
+	m.Connected(peer.ID("peer2"), true, 1)
+	require.False(t, m.CanOverflowInbound())
+}
+
+func TestConnManager_Config(t *testing.T) {
+	m := newTestConnManager(config.P2P{MaxInboundPeers: 1, Shared: true})
+	m.SetPeerLimits(config.P2P{MaxInboundPeers: 5})
+
+	cfg := m.Config()
+	require.Equal(t, 5, cfg.MaxInboundPeers)
+	require.True(t, cfg.Shared)
+}
+
+func TestConnManager_DialCandidates(t *testing.T) {
+	m := newTestConnManager(config.P2P{MaxInboundPeers: 5, MaxOutboundPeers: 5})
+	m.Connected(peer.ID("inbound"), true, 1)
+	m.Connected(peer.ID("outbound"), false, 1)
+	conns := []network.Conn{newTestConn("inbound"), newTestConn("peer1"), newTestConn("outbound"), newTestConn("peer2")}
+
+	candidates := m.dialCandidates(conns, map[peer.ID]struct{}{peer.ID("peer1"): {}})
+	require.Len(t, candidates, 1)
+	require.Equal(t, peer.ID("peer2"), candidates[0].RemotePeer())
+}
+
+func TestConnManager_DialCandidatesSkipAttempted(t *testing.T) {
+	m := newTestConnManager(config.P2P{MaxOutboundPeers: 5})
+	m.rememberedPeers.remember(peer.AddrInfo{ID: peer.ID("best")}, 10)
+	m.rememberedPeers.remember(peer.AddrInfo{ID: peer.ID("good")}, 5)
+	conns := []network.Conn{newTestConn("good"), newTestConn("new"), newTestConn("best")}
+
+	attempted := make(map[peer.ID]struct{})
+	var order []peer.ID
+	for i := 0; i < len(conns); i++ {
+		candidates := m.dialCandidates(conns, attempted)
+		require.Len(t, candidates, len(conns)-i)
+		m.sortDialCandidates(candidates)
+		id := candidates[0].RemotePeer()
+		attempted[id] = struct{}{}
+		order = append(order, id)
+	}
+	require.Equal(t, []peer.ID{"best", "good", "new"}, order)
+	require.Empty(t, m.dialCandidates(conns, attempted))
+}
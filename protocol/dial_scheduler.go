@@ -0,0 +1,94 @@
+package protocol
+
+import (
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"math/rand"
+	"net"
+	"sort"
+	"time"
+)
+
+const (
+	// max number of dials per scheduler round
+	maxDialsPerRound = 8
+
+	dialInterval     = time.Second * 15
+	fastDialInterval = time.Second * 5
+)
+
+// subnet returns /16 network for IPv4 and /32 network for IPv6 addresses
+func subnet(addr multiaddr.Multiaddr) string {
+	if addr == nil {
+		return ""
+	}
+	ip, err := manet.ToIP(addr)
+	if err != nil {
+		return ""
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(16, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(32, 128)).String()
+}
+
+func (m *ConnManager) peerSubnets() map[string]int {
+	m.peerMutex.RLock()
+	ids := make([]peer.ID, 0, len(m.inboundPeers)+len(m.outboundPeers))
+	for id := range m.inboundPeers {
+		ids = append(ids, id)
+	}
+	for id := range m.outboundPeers {
+		ids = append(ids, id)
+	}
+	m.peerMutex.RUnlock()
+
+	result := make(map[string]int)
+	for _, id := range ids {
+		for _, conn := range m.host.Network().ConnsToPeer(id) {
+			result[subnet(conn.RemoteMultiaddr())]++
+			break
+		}
+	}
+	return result
+}
+
+func (m *ConnManager) sortDialCandidates(conns []network.Conn) {
+	subnets := m.peerSubnets()
+	scores := make(map[peer.ID]int64, len(conns))
+	for _, c := range conns {
+		scores[c.RemotePeer()] = m.rememberedPeers.score(c.RemotePeer())
+	}
+
+	rand.Shuffle(len(conns), func(i, j int) {
+		conns[i], conns[j] = conns[j], conns[i]
+	})
+	sort.SliceStable(conns, func(i, j int) bool {
+		si, sj := subnets[subnet(conns[i].RemoteMultiaddr())], subnets[subnet(conns[j].RemoteMultiaddr())]
+		if si != sj {
+			return si < sj
+		}
+		return scores[conns[i].RemotePeer()] > scores[conns[j].RemotePeer()]
+	})
+}
+
+// MissingOutboundPeers returns the number of free outbound slots
+func (m *ConnManager) MissingOutboundPeers() int {
+	m.peerMutex.RLock()
+	defer m.peerMutex.RUnlock()
+	missing := m.cfg.OutboundLimit() - m.slotsInUse(m.outboundPeers)
+	if missing < 0 {
+		return 0
+	}
+	return missing
+}
+
+// nextDialInterval returns shorter interval while the node has less than half of the target outbound peers
+func (h *IdenaGossipHandler) nextDialInterval() time.Duration {
+	if h.connManager.MissingOutboundPeers()*2 > h.connManager.OutboundLimit() {
+		return fastDialInterval
+	}
+	return dialInterval
+}
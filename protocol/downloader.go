@@ -26,6 +26,9 @@ import (
 
 const (
 	MaxAttemptsCountPerBatch = 10
+	// time after which a not responded batch is requested from another peer as well
+	batchStallTimeout = time.Second * 10
+	batchWaitTimeout  = time.Second * 20
 )
 
 var (
@@ -69,6 +72,9 @@ type Downloader struct {
 	keyStore             *keystore.KeyStore
 	subManager           *subscriptions.Manager
 	upgrader             *upgrade.Upgrader
+	progress             *syncProgress
+	stopProgress         chan struct{}
+	bandwidth            *bandwidthLimiter
 }
 
 func (d *Downloader) IsSyncing() bool {
@@ -113,6 +119,8 @@ func NewDownloader(
 		subManager:           subManager,
 		keyStore:             keyStore,
 		upgrader:             upgrader,
+		progress:             &syncProgress{},
+		bandwidth:            newBandwidthLimiter(cfg.Sync.MaxDownloadRate),
 	}
 }
 
@@ -186,7 +194,8 @@ func (d *Downloader) Load() {
 	knownHeights := d.pm.GetKnownHeights()
 loop:
 	for from <= toHeight && len(knownHeights) > 0 {
-		for peer, height := range knownHeights {
+		for _, peer := range d.pm.SyncSchedule(knownHeights) {
+			height := knownHeights[peer]
 			if height < from {
 				delete(knownHeights, peer)
 				continue
@@ -225,6 +234,8 @@ func (d *Downloader) consumeBlocks(applier blockApplier, term chan interface{},
 				return true
 			}
 		}
+		batch = d.awaitBatch(batch)
+		d.bandwidth.wait(batch.size)
 
 		if err := applier.processBatch(batch, 1); err != nil {
 			d.log.Warn("failed to process batch", "err", err)
@@ -296,11 +307,15 @@ func (d *Downloader) createBlockApplier() (loader blockApplier, toHeight uint64)
 
 	if canUseFastSync {
 		d.log.Info("Fast sync will be used")
-		return NewFastSync(d.pm, d.log, d.chain, d.ipfs, d.appState, d.potentialForkedPeers, manifest, d.sm, d.bus, d.secStore.GetAddress(), d.keyStore, d.subManager, d.upgrader), manifest.Height
+		fs := NewFastSync(d.pm, d.log, d.chain, d.ipfs, d.appState, d.potentialForkedPeers, manifest, d.sm, d.bus, d.secStore.GetAddress(), d.keyStore, d.subManager, d.upgrader)
+		fs.bandwidth = d.bandwidth
+		return fs, manifest.Height
 	} else {
 		d.log.Info("Full sync will be used")
 		top := d.top
-		return NewFullSync(d.pm, d.log, d.chain, d.ipfs, d.appState, d.potentialForkedPeers, top, d.statsCollector), top
+		fs := NewFullSync(d.pm, d.log, d.chain, d.ipfs, d.appState, d.potentialForkedPeers, top, d.statsCollector)
+		fs.bandwidth = d.bandwidth
+		return fs, top
 	}
 }
 
@@ -335,11 +350,14 @@ func (d *Downloader) startSync() {
 	d.isSyncing = true
 	d.chain.StartSync()
 	d.sm.StartSync()
+	d.stopProgress = make(chan struct{})
+	go d.reportSyncProgress(d.stopProgress)
 }
 
 func (d *Downloader) stopSync() {
 	d.chain.StopSync()
 	d.sm.StopSync()
+	close(d.stopProgress)
 	d.isSyncing = false
 	d.top = 0
 }
@@ -355,7 +373,8 @@ func requestBatch(pm *IdenaGossipHandler, from, to uint64, ignoredPeer peer.ID)
 	if knownHeights == nil {
 		return nil
 	}
-	for peerId, height := range knownHeights {
+	for _, peerId := range pm.SyncSchedule(knownHeights) {
+		height := knownHeights[peerId]
 		if (peerId != ignoredPeer || len(knownHeights) == 1) && height >= to {
 			if batch, err := pm.GetBlocksRange(peerId, from, to); err != nil {
 				continue
@@ -366,3 +385,46 @@ func requestBatch(pm *IdenaGossipHandler, from, to uint64, ignoredPeer peer.ID)
 	}
 	return nil
 }
+
+// awaitBatch waits for the batch response, the range of a stalled peer is requested from another peer
+// and the batch which is delivered first is used
+func (d *Downloader) awaitBatch(b *batch) *batch {
+	delivered := func(b *batch) bool {
+		select {
+		case <-b.delivered:
+			return true
+		default:
+			return false
+		}
+	}
+	if delivered(b) {
+		return b
+	}
+	ticker := time.NewTicker(time.Millisecond * 100)
+	defer ticker.Stop()
+	stall := time.After(batchStallTimeout)
+	timeout := time.After(batchWaitTimeout)
+	var alt *batch
+	for {
+		select {
+		case <-ticker.C:
+			if delivered(b) {
+				return b
+			}
+			if alt != nil && delivered(alt) {
+				d.log.Debug("batch was reassigned to another peer", "from", b.from, "to", b.to, "stalled", b.p.id, "peer", alt.p.id)
+				b.p.score.add(scoreTimeout)
+				b.p.score.observeSyncError()
+				return alt
+			}
+		case <-stall:
+			alt = requestBatch(d.pm, b.from, b.to, b.p.id)
+			if alt != nil && alt.p.id == b.p.id {
+				alt = nil
+			}
+		case <-timeout:
+			// processBatch handles the timeout of the original peer
+			return b
+		}
+	}
+}
@@ -45,6 +45,7 @@ type fastSync struct {
 	subManager           *subscriptions.Manager
 	upgrader             *upgrade.Upgrader
 	prevConfig           *config.ConsensusConf
+	bandwidth            *bandwidthLimiter
 
 	pubKeyToAddrCache map[string]common.Address
 }
@@ -166,7 +167,7 @@ func (fs *fastSync) applyDeferredBlocks() (uint64, error) {
 		}
 		fs.chain.WriteIdentityStateDiff(b.Header.Height(), b.IdentityDiff)
 		if !b.Cert.Empty() {
-			fs.chain.WriteCertificate(b.Header.Hash(), b.Cert, true)
+			fs.chain.WriteCertificate(b.Header.Hash(), b.Cert, fs.chain.IsPermanentSyncedCert(b.Header))
 		}
 		if b.Header.ProposedHeader == nil || len(b.Header.ProposedHeader.TxBloom) == 0 {
 			continue
@@ -214,6 +215,7 @@ func (fs *fastSync) GetBlockTransactions(hash common.Hash, ipfsHash []byte) (typ
 	if txs, err := fs.ipfs.Get(ipfsHash, ipfs.Block); err != nil {
 		return nil, err
 	} else {
+		fs.bandwidth.wait(len(txs))
 		if len(txs) > 0 {
 			fs.log.Debug("Retrieve block body from ipfs", "hash", hash.Hex())
 		}
@@ -227,6 +229,7 @@ func (fs *fastSync) GetTxReceipts(receiptCid []byte) (types.TxReceipts, error) {
 	if data, err := fs.ipfs.Get(receiptCid, ipfs.TxReceipt); err != nil {
 		return nil, err
 	} else {
+		fs.bandwidth.wait(len(data))
 		if len(data) == 0 {
 			return nil, nil
 		}
@@ -0,0 +1,97 @@
+package protocol
+
+import (
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/math"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const (
+	// max depth of the fork point search below the lowest of both heads
+	maxForkSearchDepth = 1000
+	// max number of blocks of the divergent suffix loaded from the peer
+	maxForkSuffixSize       = FullSyncBatchSize
+	forkPointRequestTimeout = time.Second * 10
+)
+
+func (d *Downloader) peerBlockHash(peerId peer.ID, height uint64) (common.Hash, error) {
+	b, err := d.pm.GetBlocksRange(peerId, height, height)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	timer := time.NewTimer(forkPointRequestTimeout)
+	defer timer.Stop()
+	select {
+	case block, ok := <-b.headers:
+		if !ok || block == nil || block.Header == nil || block.Header.Height() != height {
+			return common.Hash{}, errors.Errorf("peer doesn't have block %v", height)
+		}
+		return block.Header.Hash(), nil
+	case <-timer.C:
+		return common.Hash{}, errors.Errorf("timeout while requesting block %v", height)
+	}
+}
+
+func (d *Downloader) isCommonBlock(peerId peer.ID, height uint64) (bool, error) {
+	own := d.chain.GetBlockHeaderByHeight(height)
+	if own == nil {
+		return false, errors.Errorf("block %v is not found", height)
+	}
+	hash, err := d.peerBlockHash(peerId, height)
+	if err != nil {
+		return false, err
+	}
+	return hash == own.Hash(), nil
+}
+
+// FindForkPoint binary-searches the highest block which is common for the local chain and the peer's chain,
+// diverged is false if the peer's chain contains the local chain up to the lowest of both heads
+func (d *Downloader) FindForkPoint(peerId peer.ID) (commonHeight uint64, diverged bool, err error) {
+	peerHeight, ok := d.pm.GetKnownHeights()[peerId]
+	if !ok {
+		return 0, false, errors.New("peer is not connected")
+	}
+	high := math.Min(d.chain.Head.Height(), peerHeight)
+	if high <= 1 {
+		return 0, false, errors.New("chains are too short")
+	}
+	if isCommon, err := d.isCommonBlock(peerId, high); err != nil || isCommon {
+		return high, false, err
+	}
+	low := uint64(1)
+	if high > maxForkSearchDepth {
+		low = high - maxForkSearchDepth
+	}
+	if isCommon, err := d.isCommonBlock(peerId, low); err != nil {
+		return 0, false, err
+	} else if !isCommon {
+		return 0, false, errors.Errorf("fork is deeper than %v blocks", maxForkSearchDepth)
+	}
+	// low is common, high is not
+	for high-low > 1 {
+		mid := low + (high-low)/2
+		isCommon, err := d.isCommonBlock(peerId, mid)
+		if err != nil {
+			return 0, false, err
+		}
+		if isCommon {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	d.log.Info("Fork point is found", "peer", peerId, "height", low)
+	return low, true, nil
+}
+
+// SeekForkSuffix loads the peer's blocks after the common height
+func (d *Downloader) SeekForkSuffix(peerId peer.ID, commonHeight uint64) chan *types.BlockBundle {
+	to := commonHeight + maxForkSuffixSize
+	if peerHeight, ok := d.pm.GetKnownHeights()[peerId]; ok {
+		to = math.Min(to, peerHeight)
+	}
+	return d.SeekBlocks(commonHeight+1, to, []peer.ID{peerId})
+}
@@ -33,6 +33,8 @@ type fullSync struct {
 	deferredHeaders      []blockPeer
 	targetHeight         uint64
 	statsCollector       collector.StatsCollector
+	bandwidth            *bandwidthLimiter
+	prefetcher           *bodyPrefetcher
 }
 
 func (fs *fullSync) batchSize() uint64 {
@@ -70,7 +72,7 @@ func (fs *fullSync) applyDeferredBlocks(checkState *appstate.AppState) (uint64,
 	}()
 
 	for _, b := range fs.deferredHeaders {
-		if block, err := fs.GetBlock(b.Header); err != nil {
+		if block, err := fs.getBlock(b.Header); err != nil {
 			fs.log.Error("fail to retrieve block", "err", err)
 			return b.Header.Height(), err
 		} else {
@@ -86,7 +88,7 @@ func (fs *fullSync) applyDeferredBlocks(checkState *appstate.AppState) (uint64,
 				return block.Height(), err
 			}
 			if !b.Cert.Empty() {
-				fs.chain.WriteCertificate(block.Hash(), b.Cert, true)
+				fs.chain.WriteCertificate(block.Hash(), b.Cert, fs.chain.IsPermanentSyncedCert(block.Header))
 			}
 			if checkState.FinalizePrecommit(block) != nil {
 				return block.Height(), err
@@ -97,10 +99,12 @@ func (fs *fullSync) applyDeferredBlocks(checkState *appstate.AppState) (uint64,
 }
 
 func (fs *fullSync) preConsuming(head *types.Header) (uint64, error) {
+	fs.prefetcher = newBodyPrefetcher(fs)
 	return head.Height() + 1, nil
 }
 
 func (fs *fullSync) postConsuming() error {
+	fs.prefetcher.stop()
 	if len(fs.deferredHeaders) > 0 {
 		fs.log.Warn(fmt.Sprintf("All blocks was consumed but last headers have not been added to chain"))
 	}
@@ -147,6 +151,8 @@ func (fs *fullSync) processBatch(batch *batch, attemptNum int) error {
 				return reload(i)
 			}
 			fs.deferredHeaders = append(fs.deferredHeaders, blockPeer{*block, batch.p.id})
+			// headers are validated before bodies, so bodies of a bogus chain are never downloaded
+			fs.prefetcher.schedule(blockPeer{*block, batch.p.id})
 			if block.Cert != nil && !block.Cert.Empty() {
 				if from, err := fs.applyDeferredBlocks(checkState); err != nil {
 					return reload(from)
@@ -188,6 +194,19 @@ func (fs *fullSync) validateHeader(block *block, p *protoPeer) error {
 	return nil
 }
 
+// getBlock returns the block with the prefetched body if it exists, otherwise the body is loaded from ipfs
+func (fs *fullSync) getBlock(header *types.Header) (*types.Block, error) {
+	if fs.prefetcher != nil {
+		if body := fs.prefetcher.take(header.Hash()); body != nil {
+			return &types.Block{
+				Header: header,
+				Body:   body,
+			}, nil
+		}
+	}
+	return fs.GetBlock(header)
+}
+
 func (fs *fullSync) GetBlock(header *types.Header) (*types.Block, error) {
 	if header.EmptyBlockHeader != nil {
 		return &types.Block{
@@ -198,6 +217,7 @@ func (fs *fullSync) GetBlock(header *types.Header) (*types.Block, error) {
 	if txs, err := fs.ipfs.Get(header.ProposedHeader.IpfsHash, ipfs.Block); err != nil {
 		return nil, err
 	} else {
+		fs.bandwidth.wait(len(txs))
 		if len(txs) > 0 {
 			fs.log.Debug("Retrieve block body from ipfs", "hash", header.Hash().Hex())
 		}
@@ -20,13 +20,17 @@ import (
 	"github.com/idena-network/idena-go/log"
 	"github.com/idena-network/idena-go/pengings"
 	models "github.com/idena-network/idena-go/protobuf"
+	"github.com/idena-network/idena-go/stats/tracing"
 	core "github.com/libp2p/go-libp2p-core"
 	"github.com/libp2p/go-libp2p-core/helpers"
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/multiformats/go-multiaddr"
+	"github.com/patrickmn/go-cache"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -34,7 +38,7 @@ import (
 )
 
 var IdenaProtocolPath = "/idena/gossip"
-var IdenaProtocol = core.ProtocolID(IdenaProtocolPath + "/1.1.0")
+var IdenaProtocol = core.ProtocolID(IdenaProtocolPath + "/1.4.0")
 
 const MempoolSyncDelay = time.Second * 5
 
@@ -60,6 +64,8 @@ type IdenaGossipHandler struct {
 	flipKeyChan         chan *events.NewFlipKeyEvent
 	flipKeysPackageChan chan *events.NewFlipKeysPackageEvent
 	incomeBatches       *sync.Map
+	bodiesRequests      *sync.Map
+	bodiesProviders     chan struct{}
 	batchedLock         sync.Mutex
 	bus                 eventbus.Bus
 	wrongTime           bool
@@ -73,15 +79,25 @@ type IdenaGossipHandler struct {
 	ceremonyChecker  CeremonyChecker
 	connManager      *ConnManager
 	pubsub           *pubsub.PubSub
+	answers          *answersBroadcaster
+	staticPeers      []*staticPeer
+	trustedPeers     *trustedPeers
+	banList          *BanList
+	rememberedPeers  *RememberedPeers
+	peerEvents       *peerEventLog
+	pexDials         int32
+	// ownConsensusMsgs keeps the push hashes of the own proposals and votes, they are served via the consensus queue
+	ownConsensusMsgs *cache.Cache
 }
 
 type metricCollector struct {
 	incomeMessage  func(code uint64, size int, duration time.Duration, peerId string)
 	outcomeMessage func(code uint64, size int, duration time.Duration, peerId string)
 	compress       func(code uint64, size int)
+	traffic        *trafficStats
 }
 
-func NewIdenaGossipHandler(host core.Host, pubsub *pubsub.PubSub, cfg config.P2P, chain *blockchain.Blockchain, proposals *pengings.Proposals, votes *pengings.Votes, txpool *mempool.TxPool, fp *flip.Flipper, bus eventbus.Bus, flipKeyPool *mempool.KeysPool, appVersion string, ceremonyChecker CeremonyChecker) *IdenaGossipHandler {
+func NewIdenaGossipHandler(host core.Host, pubsub *pubsub.PubSub, cfg config.P2P, chain *blockchain.Blockchain, proposals *pengings.Proposals, votes *pengings.Votes, txpool *mempool.TxPool, fp *flip.Flipper, bus eventbus.Bus, flipKeyPool *mempool.KeysPool, appVersion string, ceremonyChecker CeremonyChecker, banList *BanList, rememberedPeers *RememberedPeers) *IdenaGossipHandler {
 	logger := log.New()
 	throttlingLogger := log.NewThrottlingLogger(logger)
 	handler := &IdenaGossipHandler{
@@ -92,6 +108,8 @@ func NewIdenaGossipHandler(host core.Host, pubsub *pubsub.PubSub, cfg config.P2P
 		peers:               newPeerSet(),
 		incomeBlocks:        make(chan *types.Block, 1000),
 		incomeBatches:       &sync.Map{},
+		bodiesRequests:      &sync.Map{},
+		bodiesProviders:     make(chan struct{}, maxBodiesProviders),
 		proposals:           proposals,
 		votes:               votes,
 		pushPullManager:     NewPushPullManager(),
@@ -106,10 +124,16 @@ func NewIdenaGossipHandler(host core.Host, pubsub *pubsub.PubSub, cfg config.P2P
 		log:                 logger,
 		throttlingLogger:    throttlingLogger,
 		pendingPeers:        make(map[peer.ID]struct{}),
-		metrics:             new(metricCollector),
+		metrics:             &metricCollector{traffic: newTrafficStats()},
 		ceremonyChecker:     ceremonyChecker,
-		connManager:         NewConnManager(host, cfg),
+		trustedPeers:        newTrustedPeers(cfg.TrustedPeers),
+		banList:             banList,
+		rememberedPeers:     rememberedPeers,
+		peerEvents:          &peerEventLog{},
+		ownConsensusMsgs:    cache.New(msgCacheAliveTime, msgCacheGcTime),
 	}
+	handler.connManager = NewConnManager(host, cfg, handler.trustedPeers, handler.banList, handler.rememberedPeers)
+	handler.connManager.SetScorer(handler.peerScore)
 	handler.pushPullManager.AddEntryHolder(pushVote, pushpull.NewDefaultHolder(1, pushpull.NewDefaultPushTracker(time.Millisecond*300)))
 	handler.pushPullManager.AddEntryHolder(pushBlock, pushpull.NewDefaultHolder(1, pushpull.NewDefaultPushTracker(time.Second*3)))
 	handler.pushPullManager.AddEntryHolder(pushProof, pushpull.NewDefaultHolder(1, pushpull.NewDefaultPushTracker(time.Second*1)))
@@ -117,6 +141,8 @@ func NewIdenaGossipHandler(host core.Host, pubsub *pubsub.PubSub, cfg config.P2P
 	handler.pushPullManager.AddEntryHolder(pushKeyPackage, flipKeyPool)
 	handler.pushPullManager.AddEntryHolder(pushTx, txpool)
 	handler.pushPullManager.Run()
+	handler.answers = newAnswersBroadcaster(handler.peers, handler.sendTxPush)
+	handler.staticPeers = parseStaticPeers(cfg.StaticPeers)
 	handler.registerMetrics()
 	return handler
 }
@@ -124,9 +150,17 @@ func NewIdenaGossipHandler(host core.Host, pubsub *pubsub.PubSub, cfg config.P2P
 func (h *IdenaGossipHandler) Start() {
 
 	setHandler := func() {
-		matcher, _ := helpers.MultistreamSemverMatcher(IdenaProtocol)
-		h.host.SetStreamHandlerMatch(IdenaProtocol, matcher, h.acceptStream)
-		h.connManager = NewConnManager(h.host, h.cfg)
+		for _, p := range IdenaProtocols {
+			matcher, _ := helpers.MultistreamSemverMatcher(p)
+			h.host.SetStreamHandlerMatch(p, matcher, h.acceptStream)
+		}
+		cfg := h.cfg
+		if h.connManager != nil {
+			// the peer limits may have been reloaded
+			cfg = h.connManager.Config()
+		}
+		h.connManager = NewConnManager(h.host, cfg, h.trustedPeers, h.banList, h.rememberedPeers)
+		h.connManager.SetScorer(h.peerScore)
 		notifiee := &notifiee{
 			connManager: h.connManager,
 		}
@@ -165,42 +199,98 @@ func (h *IdenaGossipHandler) Start() {
 		h.peers.SetOwnShardId(shardId)
 	})
 
+	h.bus.Subscribe(events.NewSnapshotEventID, func(e eventbus.Event) {
+		h.announceManifest(e.(*events.NewSnapshotEvent).Manifest)
+	})
+
 	shardId := h.OwnPeeringShardId()
 	h.connManager.SetShardId(shardId)
 	h.peers.SetOwnShardId(shardId)
 
 	go h.broadcastLoop()
+	go h.answers.loop()
 	go h.checkTime()
 	go h.background()
+	go h.maintainStaticPeers()
+	go h.dialRememberedPeers()
 	go h.watchShardSubscription()
 }
 
+// Stop closes the gossip streams and stops accepting new ones, the libp2p host is closed together with IPFS
+func (h *IdenaGossipHandler) Stop() {
+	for _, p := range IdenaProtocols {
+		h.host.RemoveStreamHandler(p)
+	}
+	for _, peer := range h.peers.Peers() {
+		h.unregisterPeer(peer.id)
+	}
+	h.rememberedPeers.save()
+}
+
+// SetPeerLimits applies new peer limits without reconnecting the peers, the limits are kept by the connection manager only
+func (h *IdenaGossipHandler) SetPeerLimits(limits config.P2P) {
+	h.connManager.SetPeerLimits(limits)
+}
+
 func (h *IdenaGossipHandler) background() {
-	dialTicker := time.NewTicker(time.Second * 15)
+	dialTimer := time.NewTimer(dialInterval)
 	renewTicker := time.NewTicker(time.Minute * 5)
 
 	for {
 		select {
-		case <-dialTicker.C:
+		case <-dialTimer.C:
 			h.dialPeers()
+			dialTimer.Reset(h.nextDialInterval())
 		case <-renewTicker.C:
 			h.renewPeers()
+			h.saveRememberedPeers()
 		}
 	}
 }
 
 func (h *IdenaGossipHandler) checkTime() {
 	for {
-		h.wrongTime = !checkClockDrift()
+		skew := &events.ClockSkewEvent{}
+		if drift, err := SntpDrift(ntpChecks); err == nil {
+			skew.NtpDrift = drift
+			skew.NtpMeasured = true
+			h.wrongTime = !checkClockDrift(drift)
+		} else {
+			h.wrongTime = false
+		}
+		skew.PeersOffset, skew.PeersCount = h.peersClockOffset()
+		h.bus.Publish(skew)
 		time.Sleep(time.Minute)
 	}
 }
 
+// peersClockOffset returns the median difference between the local clock and clocks reported by connected peers
+func (h *IdenaGossipHandler) peersClockOffset() (time.Duration, int) {
+	var offsets durationSlice
+	for _, p := range h.peers.Peers() {
+		offsets = append(offsets, p.timeOffset)
+	}
+	if len(offsets) == 0 {
+		return 0, 0
+	}
+	sort.Sort(offsets)
+	return offsets[len(offsets)/2], len(offsets)
+}
+
 func (h *IdenaGossipHandler) handle(p *protoPeer) error {
 	msg, err := p.ReadMsg()
 	if err != nil {
 		return err
 	}
+	if isRateLimited(msg.Code) && !h.connManager.IsProtected(p.id) {
+		if allowed, exceeded := p.rateLimiter.allow(len(msg.Payload)); !allowed {
+			if exceeded {
+				return errResp(RateLimitErr, "peer exceeds message rate limits")
+			}
+			p.throttlingLogger.Warn("Message dropped due to rate limits", "code", msgCodeToString(msg.Code))
+			return nil
+		}
+	}
 	switch msg.Code {
 	case BlocksRange:
 		var response blockRange
@@ -216,11 +306,16 @@ func (h *IdenaGossipHandler) handle(p *protoPeer) error {
 			peerBatches := ib.(*sync.Map)
 			if pb, ok := peerBatches.Load(response.BatchId); ok {
 				batch := pb.(*batch)
+				p.score.observeLatency(time.Since(batch.requestedAt))
+				p.score.observeDelivery(len(response.Blocks), time.Since(batch.requestedAt))
+				p.score.add(int64(scoreBlockDelivered * len(response.Blocks)))
 				for _, b := range response.Blocks {
 					batch.headers <- b
 					p.setHeight(b.Header.Height())
 				}
 				close(batch.headers)
+				batch.size = len(msg.Payload)
+				close(batch.delivered)
 				h.batchedLock.Lock()
 				peerBatches.Delete(response.BatchId)
 				if maputil.IsSyncMapEmpty(peerBatches) {
@@ -240,6 +335,7 @@ func (h *IdenaGossipHandler) handle(p *protoPeer) error {
 			return nil
 		}
 		p.markKey(key)
+		p.markKnown(pushPullHash{Type: pushProof, Hash: proposal.Hash128()})
 		// if peer proposes this msg it should be on `query.Round-1` height
 		p.setHeight(proposal.Round - 1)
 		if ok, _ := h.proposals.AddProposeProof(proposal); ok {
@@ -261,9 +357,13 @@ func (h *IdenaGossipHandler) handle(p *protoPeer) error {
 		if proposal.Block == nil || len(proposal.Signature) == 0 {
 			return nil
 		}
+		p.markKnown(pushPullHash{Type: pushBlock, Hash: proposal.Hash128()})
 		// if peer proposes this msg it should be on `query.Round-1` height
 		p.setHeight(proposal.Block.Height() - 1)
-		if ok, _ := h.proposals.AddProposedBlock(proposal, p.id, time.Now().UTC()); ok {
+		ctx, span := tracing.StartSpan(context.Background(), "p2p.ReceiveBlockProposal", attribute.String("peer", p.id.Pretty()))
+		ok, _ := h.proposals.AddProposedBlock(ctx, proposal, p.id, time.Now().UTC())
+		span.End()
+		if ok {
 			h.ProposeBlock(proposal)
 		}
 	case Vote:
@@ -279,6 +379,7 @@ func (h *IdenaGossipHandler) handle(p *protoPeer) error {
 			return nil
 		}
 		p.markKey(key)
+		p.markKnown(pushPullHash{Type: pushVote, Hash: vote.Hash128()})
 		p.setPotentialHeight(vote.Header.Round - 1)
 		if h.votes.AddVote(vote) {
 			h.SendVote(vote)
@@ -293,6 +394,7 @@ func (h *IdenaGossipHandler) handle(p *protoPeer) error {
 			return nil
 		}
 		p.markKey(key)
+		p.markKnown(pushPullHash{Type: pushTx, Hash: tx.Hash128()})
 		if err := h.txpool.AddExternalTxs(validation.InboundTx, tx); err != nil {
 			h.throttlingLogger.Warn("Failed to add external txs", "err", err)
 		}
@@ -310,6 +412,9 @@ func (h *IdenaGossipHandler) handle(p *protoPeer) error {
 		if err := proto.Unmarshal(msg.Payload, query); err != nil {
 			return errResp(DecodeErr, "%v: %v", msg, err)
 		}
+		if query.To < query.From || query.To-query.From > FastSyncBatchSize {
+			return errResp(ProtocolErr, "%v", msg)
+		}
 		h.provideBlocks(p, query.BatchId, query.From, query.To)
 	case GetForkBlockRange:
 		query := new(models.ProtoGetForkBlockRangeRequest)
@@ -334,7 +439,10 @@ func (h *IdenaGossipHandler) handle(p *protoPeer) error {
 			return nil
 		}
 		p.markKey(key)
-		h.flipper.AddNewFlip(f, false)
+		p.markKnown(pushPullHash{Type: pushFlip, Hash: f.Hash128()})
+		if err := h.flipper.AddNewFlip(f, false); err == nil {
+			p.score.add(scoreFlipDelivered)
+		}
 	case FlipKey:
 		flipKey := new(types.PublicFlipKey)
 		if err := flipKey.FromBytes(msg.Payload); err != nil {
@@ -388,6 +496,7 @@ func (h *IdenaGossipHandler) handle(p *protoPeer) error {
 			return nil
 		}
 		p.markKeyWithExpiration(key, flipKeyMsgCacheAliveTime)
+		p.markKnown(pushPullHash{Type: pushKeyPackage, Hash: keysPackage.Hash128()})
 		if err := h.flipKeyPool.AddPrivateKeysPackage(keysPackage, false); err == mempool.KeySkipped {
 			h.throttlingLogger.Warn(fmt.Sprintf("Failed to add private keys package: %s", err.Error()))
 			p.unmarkKey(key)
@@ -399,13 +508,11 @@ func (h *IdenaGossipHandler) handle(p *protoPeer) error {
 		}
 
 		if !pushHash.IsValid() {
-			return errResp(ValidationErr, "%v", msg)
+			return errResp(ProtocolErr, "%v", msg)
 		}
-		key := msgKey(msg.Payload)
-		if pushHash.Type == pushKeyPackage {
-			p.markKeyWithExpiration(key, flipKeyMsgCacheAliveTime)
-		} else {
-			p.markKey(key)
+		p.markKnown(*pushHash)
+		if pushHash.Type == pushTx {
+			h.answers.echo(p.id, pushHash.Hash)
 		}
 		h.pushPullManager.addPush(p.id, *pushHash)
 	case BatchPush:
@@ -419,13 +526,11 @@ func (h *IdenaGossipHandler) handle(p *protoPeer) error {
 				return errResp(DecodeErr, "%v: %v", msg, err)
 			}
 			if !pushHash.IsValid() {
-				return errResp(ValidationErr, "%v", msg)
+				return errResp(ProtocolErr, "%v", msg)
 			}
-			key := msgKey(item.Payload)
-			if pushHash.Type == pushKeyPackage {
-				p.markKeyWithExpiration(key, flipKeyMsgCacheAliveTime)
-			} else {
-				p.markKey(key)
+			p.markKnown(*pushHash)
+			if pushHash.Type == pushTx {
+				h.answers.echo(p.id, pushHash.Hash)
 			}
 			h.pushPullManager.addPush(p.id, *pushHash)
 		}
@@ -435,10 +540,11 @@ func (h *IdenaGossipHandler) handle(p *protoPeer) error {
 			return errResp(DecodeErr, "%v: %v", msg, err)
 		}
 		if !pullHash.IsValid() {
-			return errResp(ValidationErr, "%v", msg)
+			return errResp(ProtocolErr, "%v", msg)
 		}
 
 		if entry, shardId, highPriority, ok := h.pushPullManager.GetEntry(*pullHash); ok {
+			p.markKnown(*pullHash)
 			h.sendEntry(p, *pullHash, entry, shardId, highPriority)
 		}
 	case Block:
@@ -468,14 +574,35 @@ func (h *IdenaGossipHandler) handle(p *protoPeer) error {
 			return errResp(DecodeErr, "%v: %v", msg, err)
 		}
 		p.disconnectReason = dc.Reason
+	case GetPeers:
+		h.providePeers(p)
+	case Peers:
+		batch := new(msgBatch)
+		if err := batch.FromBytes(msg.Payload); err != nil {
+			return errResp(DecodeErr, "%v: %v", msg, err)
+		}
+		return h.handlePeers(p, batch)
+	case GetBlockBodies:
+		request := new(msgBatch)
+		if err := request.FromBytes(msg.Payload); err != nil {
+			return errResp(DecodeErr, "%v: %v", msg, err)
+		}
+		return h.provideBlockBodies(p, request)
+	case BlockBodies:
+		response := new(msgBatch)
+		if err := response.FromBytes(msg.Payload); err != nil {
+			return errResp(DecodeErr, "%v: %v", msg, err)
+		}
+		return h.handleBlockBodies(p, response)
 	}
 
 	return nil
 }
 
 func (h *IdenaGossipHandler) acceptStream(stream network.Stream) {
-	if h.connManager.CanConnect(stream.Conn().RemotePeer()) && (h.connManager.CanAcceptStream() ||
-		h.connManager.NeedInboundOwnShardPeers() || h.connManager.NeedPeerFromSomeShard(int(h.bcn.ShardsNum()))) {
+	// shard peers may exceed the inbound limit by maxInboundShardOverflow, the peer renewal drops another peer after the handshake
+	if h.connManager.IsProtected(stream.Conn().RemotePeer()) || h.connManager.InboundLimit() > 0 && h.connManager.CanConnect(stream.Conn().RemotePeer()) && (h.connManager.CanAcceptStream() ||
+		h.connManager.CanOverflowInbound() && (h.connManager.NeedInboundOwnShardPeers() || h.connManager.NeedPeerFromSomeShard(int(h.bcn.ShardsNum())))) {
 		if _, err := h.runPeer(stream, true); err != nil {
 			h.log.Debug("failed to run inbound peer", "err", err)
 		}
@@ -518,18 +645,37 @@ func (h *IdenaGossipHandler) runPeer(stream network.Stream, inbound bool) (*prot
 		h.mutex.Unlock()
 	}()
 
-	peer := newPeer(stream, h.cfg.MaxDelay, h.metrics)
+	peer := newPeer(stream, h.cfg.MaxDelay, h.metrics, h.cfg.DisableCompression)
+	peer.rateLimiter = newRateLimiter(h.cfg.MaxPeerMsgRate, h.cfg.MaxPeerByteRate)
 
 	if err := peer.Handshake(h.bcn.Network(), h.bcn.Head.Height(), h.bcn.GenesisInfo(), h.appVersion, uint32(h.peers.Len()), h.OwnPeeringShardId()); err != nil {
 		current := semver.New(h.appVersion)
 		if other, errS := semver.NewVersion(peer.appVersion); errS != nil || other.Major > current.Major || other.Minor >= current.Minor && other.Major == current.Major {
 			peer.log.Debug("Idena handshake failed", "err", err)
 		}
+		h.publishPeerEvent(&events.PeerHandshakeFailedEvent{
+			PeerId:     peer.id.Pretty(),
+			Addr:       peer.RemoteAddr(),
+			AppVersion: peer.appVersion,
+			Err:        err,
+		})
+		if errors.Cause(err) == errDifferentChain {
+			if !h.connManager.IsProtected(peer.id) {
+				h.banList.Ban("different chain", differentChainBanDuration, peer.id.Pretty())
+			}
+			peer.disconnect("different chain")
+			return nil, err
+		}
 		peer.disconnect("")
 		return nil, err
 	}
 
-	canConnect, shouldDisconnectAnotherPeer := h.connManager.NeedPeerFromShard(inbound, peer.shardId)
+	var canConnect, shouldDisconnectAnotherPeer bool
+	if h.connManager.IsProtected(peer.id) {
+		canConnect = true
+	} else {
+		canConnect, shouldDisconnectAnotherPeer = h.connManager.NeedPeerFromShard(inbound, peer.shardId)
+	}
 
 	if !canConnect {
 		log.Info("no slots for shard, peer will be disconnected", "peerId", peer.id, "shardId", peer.shardId)
@@ -566,8 +712,19 @@ func (h *IdenaGossipHandler) runPeer(stream network.Stream, inbound bool) (*prot
 	}()
 
 	h.sendManifest(peer)
+	if !inbound && h.connManager.CanDial() {
+		h.requestPeers(peer)
+	}
 
 	h.log.Info("Peer connected", "id", peer.id.Pretty(), "inbound", inbound, "shardId", peer.shardId)
+	h.publishPeerEvent(&events.PeerConnectedEvent{
+		PeerId:     peer.id.Pretty(),
+		Addr:       peer.RemoteAddr(),
+		Inbound:    inbound,
+		ShardId:    peer.shardId,
+		AppVersion: peer.appVersion,
+		Peers:      h.peers.Len(),
+	})
 	if shouldDisconnectAnotherPeer {
 		h.log.Info("Selected to dc", "id", dcPeer, "shardId", dcShard)
 	}
@@ -592,6 +749,7 @@ func (h *IdenaGossipHandler) unregisterPeer(peerId peer.ID) {
 	default:
 	}
 
+	h.rememberPeer(peerId, peer.score.total())
 	h.connManager.Disconnected(peerId, err)
 	h.host.ConnManager().UntagPeer(peerId, "idena")
 	if peer.disconnectReason == "" {
@@ -599,28 +757,40 @@ func (h *IdenaGossipHandler) unregisterPeer(peerId peer.ID) {
 	} else {
 		h.log.Info("Peer aborts connection", "id", peerId.Pretty(), "shardId", peer.shardId, "reason", peer.disconnectReason)
 	}
+	h.publishPeerEvent(&events.PeerDisconnectedEvent{
+		PeerId:  peerId.Pretty(),
+		Addr:    peer.RemoteAddr(),
+		ShardId: peer.shardId,
+		Reason:  peer.disconnectReason,
+		Peers:   h.peers.Len(),
+	})
 }
 
 func (h *IdenaGossipHandler) dialPeers() {
 	go func() {
 		attempts := make(map[peer.ID]struct{})
-		for i := 0; i < 5; i++ {
+		dials := h.connManager.MissingOutboundPeers()
+		if dials < 1 {
+			dials = 1
+		}
+		if dials > maxDialsPerRound {
+			dials = maxDialsPerRound
+		}
+		for i := 0; i < dials; i++ {
 			if !h.connManager.CanDial() && !h.connManager.NeedOutboundOwnShardPeers() && !h.connManager.NeedPeerFromSomeShard(int(h.bcn.ShardsNum())) {
 				return
 			}
-			stream, err := h.connManager.DialRandomPeer()
+			stream, err := h.connManager.DialBestPeer(attempts)
 			if err != nil {
+				if err == NoPeersToDial {
+					h.requestPeersFromRandomPeer()
+				}
 				h.log.Error("dial failed", "err", err)
 				return
 			}
-			id := stream.Conn().RemotePeer()
-			peer := h.peers.Peer(id)
-			if peer == nil {
-				if _, ok := attempts[id]; !ok {
-					attempts[id] = struct{}{}
-					if _, err := h.runPeer(stream, false); err != nil {
-						h.log.Debug("failed to run outbound peer", "err", err)
-					}
+			if h.peers.Peer(stream.Conn().RemotePeer()) == nil {
+				if _, err := h.runPeer(stream, false); err != nil {
+					h.log.Debug("failed to run outbound peer", "err", err)
 				}
 			}
 		}
@@ -629,7 +799,7 @@ func (h *IdenaGossipHandler) dialPeers() {
 
 func (h *IdenaGossipHandler) renewPeers() {
 	if !h.connManager.CanDial() {
-		peerId := h.connManager.GetRandomPeer(false)
+		peerId := h.connManager.PeerToRenew(false)
 		peer := h.peers.Peer(peerId)
 		if peer != nil {
 			peer.disconnect("peer was selected to disconnect while renewing peers")
@@ -637,7 +807,7 @@ func (h *IdenaGossipHandler) renewPeers() {
 	}
 
 	if !h.connManager.CanAcceptStream() {
-		peerId := h.connManager.GetRandomPeer(true)
+		peerId := h.connManager.PeerToRenew(true)
 		peer := h.peers.Peer(peerId)
 		if peer != nil {
 			peer.disconnect("peer was selected to disconnect while renewing peers")
@@ -646,7 +816,14 @@ func (h *IdenaGossipHandler) renewPeers() {
 }
 
 func (h *IdenaGossipHandler) BanPeer(peerId peer.ID, reason error) {
-	h.connManager.BanPeer(peerId)
+	if h.trustedPeers.contains(peerId) {
+		return
+	}
+	var reasonStr string
+	if reason != nil {
+		reasonStr = reason.Error()
+	}
+	h.connManager.BanPeer(peerId, reasonStr)
 
 	peer := h.peers.Peer(peerId)
 	if peer != nil {
@@ -706,6 +883,16 @@ func (h *IdenaGossipHandler) runListening(peer *protoPeer) {
 	for {
 		if err := h.handle(peer); err != nil {
 			peer.log.Debug("Idena message handling failed", "err", err)
+			if perr, ok := err.(*protocolError); ok {
+				switch perr.code {
+				case ValidationErr:
+					// only provably invalid consensus data leads to a ban
+					peer.score.add(scoreViolation)
+					h.BanPeer(peer.id, err)
+				case ProtocolErr:
+					peer.score.add(scoreViolation)
+				}
+			}
 			return
 		}
 	}
@@ -760,10 +947,12 @@ func (h *IdenaGossipHandler) GetBlocksRange(peerId peer.ID, from uint64, to uint
 	}
 
 	b := &batch{
-		from:    from,
-		to:      to,
-		p:       peer,
-		headers: make(chan *block, to-from+1),
+		from:        from,
+		to:          to,
+		p:           peer,
+		headers:     make(chan *block, to-from+1),
+		delivered:   make(chan struct{}),
+		requestedAt: time.Now(),
 	}
 	h.batchedLock.Lock()
 	peerBatches, ok := h.incomeBatches.Load(peerId)
@@ -788,8 +977,10 @@ func (h *IdenaGossipHandler) GetForkBlockRange(peerId peer.ID, ownBlocks []commo
 		return nil, errors.New("peer is not found")
 	}
 	b := &batch{
-		p:       peer,
-		headers: make(chan *block, 100),
+		p:           peer,
+		headers:     make(chan *block, 100),
+		delivered:   make(chan struct{}),
+		requestedAt: time.Now(),
 	}
 	h.batchedLock.Lock()
 	peerBatches, ok := h.incomeBatches.Load(peerId)
@@ -838,6 +1029,37 @@ func (h *IdenaGossipHandler) SendVote(vote *types.Vote) {
 	h.sendPush(hash, common.MultiShard)
 }
 
+// ProposeOwnProof announces the proof of the node itself ahead of the other messages
+func (h *IdenaGossipHandler) ProposeOwnProof(proposal *types.ProofProposal) {
+	h.sendOwnConsensusMsg(pushPullHash{Type: pushProof, Hash: proposal.Hash128()}, proposal)
+}
+
+// ProposeOwnBlock announces the block proposed by the node itself ahead of the other messages
+func (h *IdenaGossipHandler) ProposeOwnBlock(block *types.BlockProposal) {
+	h.sendOwnConsensusMsg(pushPullHash{Type: pushBlock, Hash: block.Hash128()}, block)
+}
+
+// SendOwnVote announces the vote of the node itself ahead of the other messages
+func (h *IdenaGossipHandler) SendOwnVote(vote *types.Vote) {
+	h.sendOwnConsensusMsg(pushPullHash{Type: pushVote, Hash: vote.Hash128()}, vote)
+}
+
+// sendOwnConsensusMsg pushes the hash to every peer via the consensus queue instead of the push batches,
+// the following pull of the entry is answered via the same queue
+func (h *IdenaGossipHandler) sendOwnConsensusMsg(hash pushPullHash, entry interface{}) {
+	h.ownConsensusMsgs.SetDefault(string(hash.Hash[:]), struct{}{})
+	h.pushPullManager.AddEntry(hash, entry, common.MultiShard, true)
+	data, _ := hash.ToBytes()
+	key := msgKey(data)
+	for _, p := range h.peers.Peers() {
+		if _, ok := p.msgCache.Get(key); ok {
+			continue
+		}
+		p.markKey(key)
+		p.sendConsensusMsg(Push, hash, common.MultiShard)
+	}
+}
+
 func (h *IdenaGossipHandler) sendPush(hash pushPullHash, shardId common.ShardId) {
 	data, _ := hash.ToBytes()
 	if hash.Type == pushKeyPackage {
@@ -848,6 +1070,19 @@ func (h *IdenaGossipHandler) sendPush(hash pushPullHash, shardId common.ShardId)
 }
 
 func (h *IdenaGossipHandler) sendEntry(p *protoPeer, hash pushPullHash, entry interface{}, shardId common.ShardId, highPriority bool) {
+	if _, own := h.ownConsensusMsgs.Get(string(hash.Hash[:])); own {
+		switch hash.Type {
+		case pushVote:
+			p.sendConsensusMsg(Vote, entry, shardId)
+			return
+		case pushBlock:
+			p.sendConsensusMsg(ProposeBlock, entry, shardId)
+			return
+		case pushProof:
+			p.sendConsensusMsg(ProposeProof, entry, shardId)
+			return
+		}
+	}
 	switch hash.Type {
 	case pushVote:
 		p.sendMsg(Vote, entry, shardId, highPriority)
@@ -870,8 +1105,17 @@ func (h *IdenaGossipHandler) sendEntry(p *protoPeer, hash pushPullHash, entry in
 	}
 }
 
+type protocolError struct {
+	code int
+	msg  string
+}
+
+func (e *protocolError) Error() string {
+	return fmt.Sprintf("%v - %v", e.code, e.msg)
+}
+
 func errResp(code int, format string, v ...interface{}) error {
-	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
+	return &protocolError{code, fmt.Sprintf(format, v...)}
 }
 
 func (h *IdenaGossipHandler) broadcastTx(tx *types.Transaction, shardId common.ShardId, own bool) {
@@ -880,6 +1124,10 @@ func (h *IdenaGossipHandler) broadcastTx(tx *types.Transaction, shardId common.S
 		Hash: tx.Hash128(),
 	}
 	h.pushPullManager.AddEntry(hash, tx, shardId, own)
+	if own && isAnswersTx(tx.Type) {
+		h.answers.broadcast(tx, shardId)
+		return
+	}
 	data, _ := hash.ToBytes()
 	h.peers.SendWithFilter(Push, msgKey(data), hash, shardId, own)
 	if own {
@@ -887,6 +1135,16 @@ func (h *IdenaGossipHandler) broadcastTx(tx *types.Transaction, shardId common.S
 	}
 }
 
+func (h *IdenaGossipHandler) sendTxPush(p *protoPeer, tx *types.Transaction, shardId common.ShardId) {
+	hash := pushPullHash{
+		Type: pushTx,
+		Hash: tx.Hash128(),
+	}
+	data, _ := hash.ToBytes()
+	p.markKey(msgKey(data))
+	p.sendMsg(Push, hash, shardId, true)
+}
+
 func (h *IdenaGossipHandler) sendFlip(flip *types.Flip) {
 	hash := pushPullHash{
 		Type: pushFlip,
@@ -976,6 +1234,13 @@ func (h *IdenaGossipHandler) sendManifest(p *protoPeer) {
 	p.sendMsg(SnapshotManifest, manifest, common.MultiShard, true)
 }
 
+// announceManifest sends the new snapshot manifest to the connected peers, the peers connected later get it with the handshake
+func (h *IdenaGossipHandler) announceManifest(manifest *snapshot.Manifest) {
+	for _, p := range h.peers.Peers() {
+		p.sendMsg(SnapshotManifest, manifest, common.MultiShard, true)
+	}
+}
+
 func (h *IdenaGossipHandler) syncFlipKeyPool(p *protoPeer) {
 	const maximalPeersNumberForFullSync = 3
 
@@ -1040,24 +1305,14 @@ func (h *IdenaGossipHandler) Endpoint() string {
 }
 
 func (h *IdenaGossipHandler) AddPeer(url string) error {
-	ma, err := multiaddr.NewMultiaddr(url)
-
+	info, err := parsePeerAddr(url)
 	if err != nil {
 		return err
 	}
 
-	transportAddr, peerId := peer.SplitAddr(ma)
-
-	if transportAddr == nil || peerId == "" {
-		return errors.New("invalid url")
-	}
-
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
 
-	err = h.host.Connect(ctx, peer.AddrInfo{
-		ID:    peerId,
-		Addrs: []multiaddr.Multiaddr{transportAddr},
-	})
+	err = h.host.Connect(ctx, info)
 	cancel()
 	return err
 }
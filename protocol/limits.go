@@ -0,0 +1,34 @@
+package protocol
+
+import (
+	"github.com/idena-network/idena-go/blockchain/validation"
+	"github.com/idena-network/idena-go/common"
+)
+
+const (
+	// maxCompressedMsgSize caps the length-prefixed frame read from the stream before it is allocated
+	maxCompressedMsgSize = 8 * 1024 * 1024
+	// maxDecodedMsgSize caps the decompressed message, the claimed length is checked before the decompression
+	maxDecodedMsgSize = 32 * 1024 * 1024
+	// maxSmallMsgSize is enough for the messages with a few hashes, keys or signatures
+	maxSmallMsgSize = 64 * 1024
+	// maxListMsgSize is enough for the requests and announcements carrying lists of hashes or addresses
+	maxListMsgSize = 4 * 1024 * 1024
+)
+
+// maxPayloadSize returns the maximum payload size of the message code, the larger payloads are rejected before
+// they are decoded, so a peer cannot make the node allocate huge structures
+func maxPayloadSize(code uint64) int {
+	switch code {
+	case Handshake, ProposeProof, Vote, GetBlockByHash, GetBlocksRange, FlipKey, Push, Pull, UpdateShardId, Disconnect, GetPeers:
+		return maxSmallMsgSize
+	case NewTx:
+		return validation.MaxPayloadSizeUpgrade11 + maxSmallMsgSize
+	case FlipBody:
+		return common.MaxFlipSize + maxSmallMsgSize
+	case GetForkBlockRange, BatchPush, BatchFlipKey, Peers, GetBlockBodies:
+		return maxListMsgSize
+	default:
+		return maxDecodedMsgSize
+	}
+}
@@ -41,13 +41,9 @@ func (s durationSlice) Len() int           { return len(s) }
 func (s durationSlice) Less(i, j int) bool { return s[i] < s[j] }
 func (s durationSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
-// checkClockDrift queries an NTP server for clock drifts and warns the user if
-// one large enough is detected.
-func checkClockDrift() bool{
-	drift, err := SntpDrift(ntpChecks)
-	if err != nil {
-		return true
-	}
+// checkClockDrift warns the user if the clock drift measured against an NTP server
+// is large enough.
+func checkClockDrift(drift time.Duration) bool {
 	if drift < -driftThreshold || drift > driftThreshold {
 		log.Warn(fmt.Sprintf("System clock seems off by %v, which can prevent network connectivity", drift))
 		log.Warn("Please enable network time synchronisation in system settings.")
@@ -25,10 +25,12 @@ import (
 )
 
 const (
-	handshakeTimeout         = 20 * time.Second
-	msgCacheAliveTime        = 3 * time.Minute
-	flipKeyMsgCacheAliveTime = 10 * time.Minute
-	msgCacheGcTime           = 5 * time.Minute
+	handshakeTimeout = 20 * time.Second
+	// peers on another chain are not redialed for this period
+	differentChainBanDuration = time.Hour
+	msgCacheAliveTime         = 3 * time.Minute
+	flipKeyMsgCacheAliveTime  = 10 * time.Minute
+	msgCacheGcTime            = 5 * time.Minute
 
 	maxTimeoutsBeforeBan = 7
 
@@ -39,13 +41,19 @@ const (
 
 	queuedRequestsSize             = 15000
 	queuedHighPriorityRequestsSize = 4000
+	// own proposals and votes go ahead of all other messages, a node produces a few of them per round
+	queuedConsensusRequestsSize = 100
 )
 
+// errDifferentChain is returned by the handshake if the peer runs another network or genesis
+var errDifferentChain = errors.New("peer is on a different chain")
+
 type compression = byte
 
 const (
-	noCompression compression = 0
-	s2Compression compression = 1
+	noCompression     compression = 0
+	s2Compression     compression = 1
+	snappyCompression compression = 2
 )
 
 type syncHeight struct {
@@ -84,6 +92,7 @@ type protoPeer struct {
 	manifest             *snapshot.Manifest
 	queuedRequests       chan *request
 	highPriorityRequests chan *request
+	consensusRequests    chan *request
 	pushQueue            chan *queueItem
 	flipKeyQueue         chan *queueItem
 	term                 chan struct{}
@@ -103,11 +112,19 @@ type protoPeer struct {
 	closed               bool
 	supportedFeatures    map[PeerFeature]struct{}
 	disconnectReason     string
+	timeOffset           time.Duration
+	score                *peerScore
+	compression          compression
+	traffic              *trafficStats
+	rateLimiter          *rateLimiter
+	lastPexRequest       time.Time
+	pexRequested         int32 // 1 while the node waits for the answer to its peers request
+	bodiesProviders      chan struct{}
 }
 
-func newPeer(stream network.Stream, maxDelayMs int, metrics *metricCollector) *protoPeer {
+func newPeer(stream network.Stream, maxDelayMs int, metrics *metricCollector, disableCompression bool) *protoPeer {
 	stream.Conn().RemotePeer()
-	rw := msgio.NewReadWriter(stream)
+	rw := msgio.Combine(msgio.NewWriter(stream), msgio.NewReaderSize(stream, maxCompressedMsgSize))
 
 	id := stream.Conn().RemotePeer()
 	prettyId := id.Pretty()
@@ -121,15 +138,19 @@ func newPeer(stream network.Stream, maxDelayMs int, metrics *metricCollector) *p
 	}
 
 	p := &protoPeer{
+		score:                &peerScore{},
+		traffic:              newTrafficStats(),
 		id:                   id,
 		prettyId:             prettyId,
 		stream:               stream,
 		rw:                   rw,
 		queuedRequests:       make(chan *request, queuedRequestsSize),
 		highPriorityRequests: make(chan *request, queuedHighPriorityRequestsSize),
+		consensusRequests:    make(chan *request, queuedConsensusRequestsSize),
 		pushQueue:            make(chan *queueItem, pushQueueSize),
 		flipKeyQueue:         make(chan *queueItem, flipKeyQueueSize),
 		term:                 make(chan struct{}),
+		bodiesProviders:      make(chan struct{}, maxPeerBodiesProviders),
 		finished:             make(chan struct{}),
 		maxDelayMs:           maxDelayMs,
 		msgCache:             cache.New(msgCacheAliveTime, msgCacheGcTime),
@@ -144,6 +165,13 @@ func newPeer(stream network.Stream, maxDelayMs int, metrics *metricCollector) *p
 		supportedFeatures:    map[PeerFeature]struct{}{},
 	}
 	SetSupportedFeatures(p)
+	p.compression = s2Compression
+	if _, ok := p.supportedFeatures[Snappy]; ok {
+		p.compression = snappyCompression
+	}
+	if disableCompression {
+		p.compression = noCompression
+	}
 	return p
 }
 
@@ -213,6 +241,19 @@ func (p *protoPeer) sendMsg(msgcode uint64, payload interface{}, shardId common.
 	}
 }
 
+// sendConsensusMsg queues the own proposal or vote ahead of the gossip and sync messages
+func (p *protoPeer) sendConsensusMsg(msgcode uint64, payload interface{}, shardId common.ShardId) {
+	timer := time.NewTimer(time.Second * 5)
+	defer timer.Stop()
+	select {
+	case p.consensusRequests <- &request{msgcode: msgcode, data: payload, shardId: shardId}:
+	case <-timer.C:
+		p.log.Error("TIMEOUT while sending message (consensus)", "addr", p.stream.Conn().RemoteMultiaddr().String(), "len", len(p.consensusRequests))
+		p.disconnect("timeout while sending message (consensus)")
+	case <-p.finished:
+	}
+}
+
 func (p *protoPeer) makeBatches() {
 	const batchSize = 100
 
@@ -270,7 +311,7 @@ func (p *protoPeer) broadcast() {
 	defer close(p.finished)
 	defer p.disconnect("")
 	send := func(request *request) error {
-		msg := makeMsg(request.msgcode, request.data, request.shardId)
+		msg := makeMsg(request.msgcode, request.data, request.shardId, p.compression)
 
 		ch := make(chan error, 1)
 		timer := time.NewTimer(time.Minute)
@@ -296,6 +337,8 @@ func (p *protoPeer) broadcast() {
 		}
 		duration := time.Since(startTime)
 		p.metrics.outcomeMessage(request.msgcode, len(msg), duration, p.prettyId)
+		p.traffic.addOut(request.msgcode, len(msg))
+		p.metrics.traffic.addOut(request.msgcode, len(msg))
 		return nil
 	}
 	logIfNeeded := func(r *request) {
@@ -308,6 +351,15 @@ func (p *protoPeer) broadcast() {
 			delay := time.Duration(rand.Int31n(int32(p.maxDelayMs)))
 			time.Sleep(delay * time.Millisecond)
 		}
+		select {
+		case request := <-p.consensusRequests:
+			if send(request) != nil {
+				return
+			}
+			continue
+		default:
+		}
+
 		select {
 		case request := <-p.highPriorityRequests:
 			if send(request) != nil {
@@ -319,6 +371,10 @@ func (p *protoPeer) broadcast() {
 		}
 
 		select {
+		case request := <-p.consensusRequests:
+			if send(request) != nil {
+				return
+			}
 		case request := <-p.highPriorityRequests:
 			if send(request) != nil {
 				return
@@ -334,7 +390,7 @@ func (p *protoPeer) broadcast() {
 	}
 }
 
-func makeMsg(msgcode uint64, payload interface{}, shardId common.ShardId) []byte {
+func makeMsg(msgcode uint64, payload interface{}, shardId common.ShardId, compression compression) []byte {
 	data, err := toBytes(msgcode, payload)
 	if err != nil {
 		panic(err)
@@ -343,7 +399,7 @@ func makeMsg(msgcode uint64, payload interface{}, shardId common.ShardId) []byte
 	if err != nil {
 		panic(err)
 	}
-	return Encode(msgcode, msg)
+	return Encode(msgcode, msg, compression)
 }
 
 func toBytes(msgcode uint64, payload interface{}) ([]byte, error) {
@@ -381,7 +437,7 @@ func toBytes(msgcode uint64, payload interface{}) ([]byte, error) {
 		return payload.(*types.Block).ToBytes()
 	case UpdateShardId:
 		return payload.(*updateShardId).ToBytes()
-	case BatchPush, BatchFlipKey:
+	case BatchPush, BatchFlipKey, GetPeers, Peers:
 		return payload.(*msgBatch).ToBytes()
 	case Disconnect:
 		return payload.(*disconnect).ToBytes()
@@ -408,7 +464,7 @@ func (p *protoPeer) Handshake(network types.Network, height uint64, genesis *typ
 			data.OldGenesis = &hash
 		}
 
-		msg := makeMsg(Handshake, data, 0)
+		msg := makeMsg(Handshake, data, 0, p.compression)
 		errc <- p.rw.WriteMsg(msg)
 		p.log.Trace("handshake message sent", "shardId", shardId)
 	}()
@@ -442,18 +498,48 @@ func Decode(src []byte) ([]byte, error) {
 	switch src[0] {
 	case noCompression:
 		return src[1:], nil
-	case s2Compression:
+	case s2Compression, snappyCompression:
+		size, err := s2.DecodedLen(src[1:])
+		if err != nil {
+			return nil, err
+		}
+		if size > maxDecodedMsgSize {
+			return nil, errors.Errorf("decoded msg is too large, max expected size %v, actual %v", maxDecodedMsgSize, size)
+		}
+		// s2 decoder handles snappy blocks as well
 		return s2.Decode(nil, src[1:])
 	default:
 		return nil, errors.New("unknown compression")
 	}
 }
 
-func Encode(msgcode uint64, src []byte) []byte {
-	if msgcode == FlipKeysPackage || len(src) < minCompressionSize {
-		return append([]byte{noCompression}, src...)
+// isCompressible returns true for messages carrying large payloads, other messages are too small or encrypted
+func isCompressible(msgcode uint64) bool {
+	switch msgcode {
+	case Block, BlocksRange, BlockBodies, ProposeBlock, FlipBody, BatchPush, BatchFlipKey, SnapshotManifest:
+		return true
+	}
+	return false
+}
+
+func Encode(msgcode uint64, src []byte, compression compression) []byte {
+	switch compression {
+	case s2Compression:
+		if msgcode == FlipKeysPackage || len(src) < minCompressionSize {
+			break
+		}
+		return append([]byte{s2Compression}, s2.Encode(nil, src)...)
+	case snappyCompression:
+		if !isCompressible(msgcode) || len(src) < minCompressionSize {
+			break
+		}
+		compressed := s2.EncodeSnappy(nil, src)
+		if len(compressed) >= len(src) {
+			break
+		}
+		return append([]byte{snappyCompression}, compressed...)
 	}
-	return append([]byte{s2Compression}, s2.Encode(nil, src)...)
+	return append([]byte{noCompression}, src...)
 }
 
 func (p *protoPeer) ReadMsg() (*Msg, error) {
@@ -476,7 +562,12 @@ func (p *protoPeer) ReadMsg() (*Msg, error) {
 	if err := result.FromBytes(data); err != nil {
 		return nil, err
 	}
+	if maxSize := maxPayloadSize(result.Code); len(result.Payload) > maxSize {
+		return nil, errors.Errorf("%v payload is too large, max expected size %v, actual %v", msgCodeToString(result.Code), maxSize, len(result.Payload))
+	}
 	p.metrics.incomeMessage(result.Code, len(compressedMsg), duration, p.prettyId)
+	p.traffic.addIn(result.Code, len(compressedMsg))
+	p.metrics.traffic.addIn(result.Code, len(compressedMsg))
 	p.metrics.compress(result.Code, len(data)-len(compressedMsg))
 	return result, nil
 }
@@ -494,13 +585,13 @@ func (p *protoPeer) readStatus(handShake *handshakeData, network types.Network,
 		return errors.New(fmt.Sprintf("can't decode handshake %v: %v", msg, err))
 	}
 	p.appVersion = handShake.AppVersion
-	if !genesis.EqualAny(handShake.GenesisBlock, handShake.OldGenesis) {
-		return errors.New(fmt.Sprintf("bad genesis block %x (!= %x)", handShake.GenesisBlock[:8], genesis.Genesis.Hash().Bytes()[:8]))
-	}
-
 	if handShake.NetworkId != network {
-		return errors.New(fmt.Sprintf("network mismatch: %d (!= %d)", handShake.NetworkId, network))
+		return errors.Wrapf(errDifferentChain, "network mismatch: %d (!= %d)", handShake.NetworkId, network)
+	}
+	if !genesis.EqualAny(handShake.GenesisBlock, handShake.OldGenesis) {
+		return errors.Wrapf(errDifferentChain, "bad genesis block %x (!= %x)", handShake.GenesisBlock[:8], genesis.Genesis.Hash().Bytes()[:8])
 	}
+	p.timeOffset = time.Duration(time.Now().UTC().Unix()-handShake.Timestamp) * time.Second
 	diff := math.Abs(float64(time.Now().UTC().Unix() - int64(handShake.Timestamp)))
 	if diff > MaxTimestampLagSeconds {
 		return errors.New(fmt.Sprintf("time difference is too big (%v sec)", diff))
@@ -540,6 +631,8 @@ func (p *protoPeer) setPotentialHeight(newHeight uint64) {
 
 func (p *protoPeer) addTimeout() (shouldBeBanned bool) {
 	p.timeouts++
+	p.score.add(scoreTimeout)
+	p.score.observeSyncError()
 	return p.timeouts > maxTimeoutsBeforeBan
 }
 
@@ -550,7 +643,7 @@ func (p *protoPeer) resetTimeouts() {
 func (p *protoPeer) disconnect(reason string) {
 	if reason != "" {
 		var dc = &disconnect{reason}
-		msg := makeMsg(Disconnect, dc, common.MultiShard)
+		msg := makeMsg(Disconnect, dc, common.MultiShard, p.compression)
 		p.rw.WriteMsg(msg)
 		time.Sleep(time.Second)
 	}
@@ -563,6 +656,14 @@ func (p *protoPeer) ID() string {
 	return p.id.Pretty()
 }
 
+func (p *protoPeer) Score() int64 {
+	return p.score.total()
+}
+
+func (p *protoPeer) Latency() time.Duration {
+	return p.score.latency()
+}
+
 func (p *protoPeer) RemoteAddr() string {
 	return p.stream.Conn().RemoteMultiaddr().String()
 }
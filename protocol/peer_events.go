@@ -0,0 +1,105 @@
+package protocol
+
+import (
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-go/events"
+	"sync"
+	"time"
+)
+
+const (
+	peerEventLogSize = 1000
+
+	PeerConnected       = "connected"
+	PeerDisconnected    = "disconnected"
+	PeerHandshakeFailed = "handshakeFailed"
+)
+
+type PeerEvent struct {
+	Id         uint64         `json:"id"`
+	Type       string         `json:"type"`
+	Time       time.Time      `json:"time"`
+	PeerId     string         `json:"peerId"`
+	Addr       string         `json:"addr"`
+	Inbound    bool           `json:"inbound,omitempty"`
+	ShardId    common.ShardId `json:"shardId"`
+	AppVersion string         `json:"appVersion,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+	// number of peers after the event
+	Peers int `json:"peers"`
+}
+
+// peerEventLog keeps the latest peer lifecycle events, clients poll them by the id of the last seen event
+type peerEventLog struct {
+	events []*PeerEvent
+	lastId uint64
+	mutex  sync.RWMutex
+}
+
+func (l *peerEventLog) add(e *PeerEvent) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.lastId++
+	e.Id = l.lastId
+	e.Time = time.Now().UTC()
+	l.events = append(l.events, e)
+	if len(l.events) > peerEventLogSize {
+		l.events = l.events[len(l.events)-peerEventLogSize:]
+	}
+}
+
+func (l *peerEventLog) after(id uint64) []*PeerEvent {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	result := make([]*PeerEvent, 0)
+	for _, e := range l.events {
+		if e.Id > id {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func (h *IdenaGossipHandler) publishPeerEvent(e eventbus.Event) {
+	h.bus.Publish(e)
+	switch e := e.(type) {
+	case *events.PeerConnectedEvent:
+		h.peerEvents.add(&PeerEvent{
+			Type:       PeerConnected,
+			PeerId:     e.PeerId,
+			Addr:       e.Addr,
+			Inbound:    e.Inbound,
+			ShardId:    e.ShardId,
+			AppVersion: e.AppVersion,
+			Peers:      e.Peers,
+		})
+	case *events.PeerDisconnectedEvent:
+		h.peerEvents.add(&PeerEvent{
+			Type:    PeerDisconnected,
+			PeerId:  e.PeerId,
+			Addr:    e.Addr,
+			ShardId: e.ShardId,
+			Reason:  e.Reason,
+			Peers:   e.Peers,
+		})
+	case *events.PeerHandshakeFailedEvent:
+		reason := ""
+		if e.Err != nil {
+			reason = e.Err.Error()
+		}
+		h.peerEvents.add(&PeerEvent{
+			Type:       PeerHandshakeFailed,
+			PeerId:     e.PeerId,
+			Addr:       e.Addr,
+			AppVersion: e.AppVersion,
+			Reason:     reason,
+			Peers:      h.peers.Len(),
+		})
+	}
+}
+
+// PeerEvents returns the latest peer lifecycle events with ids greater than the passed one
+func (h *IdenaGossipHandler) PeerEvents(after uint64) []*PeerEvent {
+	return h.peerEvents.after(after)
+}
@@ -0,0 +1,55 @@
+package protocol
+
+import (
+	"errors"
+	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-go/events"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPeerEventLog(t *testing.T) {
+	l := &peerEventLog{}
+	for i := 0; i < peerEventLogSize+10; i++ {
+		l.add(&PeerEvent{Type: PeerConnected})
+	}
+	all := l.after(0)
+	require.Len(t, all, peerEventLogSize)
+	require.Equal(t, uint64(11), all[0].Id)
+	require.Equal(t, uint64(peerEventLogSize+10), all[len(all)-1].Id)
+
+	recent := l.after(peerEventLogSize + 8)
+	require.Len(t, recent, 2)
+	require.NotNil(t, l.after(peerEventLogSize+10))
+	require.Empty(t, l.after(peerEventLogSize+10))
+}
+
+func TestIdenaGossipHandler_publishPeerEvent(t *testing.T) {
+	h := &IdenaGossipHandler{
+		bus:        eventbus.New(),
+		peers:      newPeerSet(),
+		peerEvents: &peerEventLog{},
+	}
+	var published []eventbus.EventID
+	for _, id := range []eventbus.EventID{events.PeerConnectedEventID, events.PeerDisconnectedEventID, events.PeerHandshakeFailedEventID} {
+		h.bus.Subscribe(id, func(e eventbus.Event) {
+			published = append(published, e.EventID())
+		})
+	}
+
+	h.publishPeerEvent(&events.PeerConnectedEvent{PeerId: "peer1", Inbound: true, ShardId: 1, AppVersion: "1.0.0", Peers: 1})
+	h.publishPeerEvent(&events.PeerHandshakeFailedEvent{PeerId: "peer2", Err: errors.New("genesis mismatch")})
+	h.publishPeerEvent(&events.PeerDisconnectedEvent{PeerId: "peer1", ShardId: 1, Reason: "timeout"})
+
+	require.Equal(t, []eventbus.EventID{events.PeerConnectedEventID, events.PeerHandshakeFailedEventID, events.PeerDisconnectedEventID}, published)
+	logged := h.PeerEvents(0)
+	require.Len(t, logged, 3)
+	require.Equal(t, PeerConnected, logged[0].Type)
+	require.True(t, logged[0].Inbound)
+	require.Equal(t, "1.0.0", logged[0].AppVersion)
+	require.Equal(t, PeerHandshakeFailed, logged[1].Type)
+	require.Equal(t, "genesis mismatch", logged[1].Reason)
+	require.Equal(t, PeerDisconnected, logged[2].Type)
+	require.Equal(t, "timeout", logged[2].Reason)
+	require.Len(t, h.PeerEvents(logged[1].Id), 1)
+}
@@ -0,0 +1,111 @@
+package protocol
+
+import (
+	"github.com/idena-network/idena-go/common"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// max number of peers in a single exchange
+	pexMaxPeers = 16
+	// min period between answered peer requests of a single peer
+	pexRequestPeriod = time.Minute
+	// max number of exchanged peers dialed concurrently
+	pexMaxDials = 4
+)
+
+// requestPeers asks the peer to share its good peers, it is used when the node lacks outbound peers
+func (h *IdenaGossipHandler) requestPeers(p *protoPeer) {
+	if _, ok := p.supportedFeatures[PeerExchange]; !ok {
+		return
+	}
+	atomic.StoreInt32(&p.pexRequested, 1)
+	p.sendMsg(GetPeers, &msgBatch{}, common.MultiShard, false)
+}
+
+func (h *IdenaGossipHandler) requestPeersFromRandomPeer() {
+	peers := h.peers.Peers()
+	if len(peers) == 0 {
+		return
+	}
+	h.requestPeers(peers[rand.Intn(len(peers))])
+}
+
+func (h *IdenaGossipHandler) providePeers(p *protoPeer) {
+	if time.Since(p.lastPexRequest) < pexRequestPeriod {
+		return
+	}
+	p.lastPexRequest = time.Now()
+	p.sendMsg(Peers, h.goodPeers(p.id), common.MultiShard, false)
+}
+
+// goodPeers returns public addresses of connected peers which are not penalized, best peers first
+func (h *IdenaGossipHandler) goodPeers(exclude peer.ID) *msgBatch {
+	peers := h.peers.Peers()
+	sortPeersByScore(peers)
+	result := &msgBatch{}
+	for _, p := range peers {
+		if len(result.Data) >= pexMaxPeers {
+			break
+		}
+		if p.id == exclude || p.score.total() < 0 {
+			continue
+		}
+		for _, addr := range h.host.Peerstore().Addrs(p.id) {
+			if !manet.IsPublicAddr(addr) {
+				continue
+			}
+			addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: p.id, Addrs: []multiaddr.Multiaddr{addr}})
+			if err != nil || len(addrs) == 0 {
+				continue
+			}
+			result.Data = append(result.Data, &batchItem{
+				Payload: addrs[0].Bytes(),
+				ShardId: p.shardId,
+			})
+			break
+		}
+	}
+	return result
+}
+
+// handlePeers dials the exchanged peers, only an answer to the outstanding request of the node is accepted
+func (h *IdenaGossipHandler) handlePeers(p *protoPeer, batch *msgBatch) error {
+	if !atomic.CompareAndSwapInt32(&p.pexRequested, 1, 0) {
+		return nil
+	}
+	if len(batch.Data) > pexMaxPeers {
+		return errResp(ProtocolErr, "too many exchanged peers: %v", len(batch.Data))
+	}
+	for _, item := range batch.Data {
+		addr, err := multiaddr.NewMultiaddrBytes(item.Payload)
+		if err != nil {
+			return errResp(DecodeErr, "%v", err)
+		}
+		info, err := peer.AddrInfoFromP2pAddr(addr)
+		if err != nil {
+			return errResp(DecodeErr, "%v", err)
+		}
+		if info.ID == h.host.ID() || h.peers.Peer(info.ID) != nil || h.banList.IsPeerBanned(info.ID, addr) {
+			continue
+		}
+		h.host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.TempAddrTTL)
+		if !h.connManager.CanDial() || atomic.LoadInt32(&h.pexDials) >= pexMaxDials {
+			continue
+		}
+		atomic.AddInt32(&h.pexDials, 1)
+		go func(info peer.AddrInfo) {
+			defer atomic.AddInt32(&h.pexDials, -1)
+			if err := h.dialPeer(info); err != nil {
+				h.log.Debug("failed to dial exchanged peer", "id", info.ID.Pretty(), "err", err)
+			}
+		}(*info)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package protocol
+
+import (
+	"github.com/idena-network/idena-go/config"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// testPeerID returns a valid peer id which survives encoding to a multiaddr
+func testPeerID(t *testing.T, name string) peer.ID {
+	hash, err := multihash.Sum([]byte(name), multihash.IDENTITY, -1)
+	require.NoError(t, err)
+	return peer.ID(hash)
+}
+
+func newTestPexHandler(t *testing.T) *IdenaGossipHandler {
+	host, err := libp2p.New(libp2p.NoListenAddrs)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		host.Close()
+	})
+	banList := NewBanList("", time.Hour)
+	return &IdenaGossipHandler{
+		host:        host,
+		peers:       newPeerSet(),
+		banList:     banList,
+		connManager: NewConnManager(host, config.P2P{}, newTrustedPeers(nil), banList, NewRememberedPeers("")),
+	}
+}
+
+func newTestPexItem(t *testing.T, id peer.ID, addr string) *batchItem {
+	a, err := multiaddr.NewMultiaddr(addr)
+	require.NoError(t, err)
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: id, Addrs: []multiaddr.Multiaddr{a}})
+	require.NoError(t, err)
+	return &batchItem{Payload: addrs[0].Bytes()}
+}
+
+func TestIdenaGossipHandler_goodPeers(t *testing.T) {
+	h := newTestPexHandler(t)
+	addPeer := func(name string, score int64, addr string) peer.ID {
+		p := newTestPeer(name, 1, score)
+		p.id = testPeerID(t, name)
+		h.peers.Register(p)
+		a, _ := multiaddr.NewMultiaddr(addr)
+		h.host.Peerstore().AddAddr(p.id, a, peerstore.PermanentAddrTTL)
+		return p.id
+	}
+	good := addPeer("good", 5, "/ip4/8.8.8.8/tcp/40405")
+	addPeer("penalized", -1, "/ip4/8.8.4.4/tcp/40405")
+	addPeer("private", 10, "/ip4/192.168.1.1/tcp/40405")
+	requester := addPeer("requester", 10, "/ip4/1.1.1.1/tcp/40405")
+
+	batch := h.goodPeers(requester)
+	require.Len(t, batch.Data, 1)
+	addr, err := multiaddr.NewMultiaddrBytes(batch.Data[0].Payload)
+	require.NoError(t, err)
+	info, err := peer.AddrInfoFromP2pAddr(addr)
+	require.NoError(t, err)
+	require.Equal(t, good, info.ID)
+	require.Equal(t, "/ip4/8.8.8.8/tcp/40405", info.Addrs[0].String())
+}
+
+func TestIdenaGossipHandler_handlePeers(t *testing.T) {
+	h := newTestPexHandler(t)
+	p := newTestPeer("sender", 1, 0)
+	handle := func(batch *msgBatch) error {
+		p.pexRequested = 1
+		return h.handlePeers(p, batch)
+	}
+
+	tooMany := &msgBatch{}
+	for i := 0; i <= pexMaxPeers; i++ {
+		tooMany.Data = append(tooMany.Data, newTestPexItem(t, testPeerID(t, "peer"), "/ip4/8.8.8.8/tcp/40405"))
+	}
+	require.Error(t, handle(tooMany))
+	require.Error(t, handle(&msgBatch{Data: []*batchItem{{Payload: []byte{0x1}}}}))
+
+	// peers which the node did not ask for are dropped
+	unsolicited := testPeerID(t, "unsolicited")
+	require.NoError(t, h.handlePeers(p, &msgBatch{Data: []*batchItem{newTestPexItem(t, unsolicited, "/ip4/1.0.0.1/tcp/40405")}}))
+	require.Empty(t, h.host.Peerstore().Addrs(unsolicited))
+
+	exchanged, banned := testPeerID(t, "exchanged"), testPeerID(t, "banned")
+	h.banList.Ban("test", 0, banned.Pretty())
+	batch := &msgBatch{Data: []*batchItem{
+		newTestPexItem(t, h.host.ID(), "/ip4/8.8.8.8/tcp/40405"),
+		newTestPexItem(t, banned, "/ip4/8.8.4.4/tcp/40405"),
+		newTestPexItem(t, exchanged, "/ip4/1.1.1.1/tcp/40405"),
+	}}
+	// the node has no free outbound slots, the exchanged peers are only kept for the next dials
+	require.NoError(t, handle(batch))
+	require.Len(t, h.host.Peerstore().Addrs(exchanged), 1)
+	require.Empty(t, h.host.Peerstore().Addrs(banned))
+	require.Zero(t, h.pexDials)
+	require.Zero(t, p.pexRequested)
+}
@@ -126,3 +126,13 @@ func (m *PushPullManager) loop(entryType pushType, holder pushpull.Holder) {
 		holder.PushTracker().RegisterPull(req.Hash)
 	}
 }
+
+// markKnown remembers that the peer has the entry, so the entry is not announced back to the peer
+func (p *protoPeer) markKnown(hash pushPullHash) {
+	data, _ := hash.ToBytes()
+	if hash.Type == pushKeyPackage {
+		p.markKeyWithExpiration(msgKey(data), flipKeyMsgCacheAliveTime)
+	} else {
+		p.markKey(msgKey(data))
+	}
+}
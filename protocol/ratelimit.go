@@ -0,0 +1,103 @@
+package protocol
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// seconds of the rate a peer may send at once
+	rateLimitBurst = 5
+	// consecutive dropped messages after which the peer is disconnected
+	maxRateLimitDrops = 200
+)
+
+// tokenBucket allows `rate` units per second with bursts up to rateLimitBurst seconds of the rate
+type tokenBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		rate:   float64(rate),
+		tokens: float64(rate * rateLimitBurst),
+		last:   time.Now(),
+	}
+}
+
+func (b *tokenBucket) take(amount int, now time.Time) bool {
+	b.refill(now)
+	if !b.has(amount) {
+		return false
+	}
+	b.consume(amount)
+	return true
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if b == nil {
+		return
+	}
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if max := b.rate * rateLimitBurst; b.tokens > max {
+		b.tokens = max
+	}
+	b.last = now
+}
+
+func (b *tokenBucket) has(amount int) bool {
+	return b == nil || b.tokens >= float64(amount)
+}
+
+func (b *tokenBucket) consume(amount int) {
+	if b != nil {
+		b.tokens -= float64(amount)
+	}
+}
+
+// rateLimiter limits inbound messages and bytes per second of a single peer
+type rateLimiter struct {
+	messages *tokenBucket
+	bytes    *tokenBucket
+	drops    int
+	mutex    sync.Mutex
+}
+
+func newRateLimiter(msgRate, byteRate int) *rateLimiter {
+	return &rateLimiter{
+		messages: newTokenBucket(msgRate),
+		bytes:    newTokenBucket(byteRate),
+	}
+}
+
+// allow returns false if the message should be dropped and exceeded=true if the peer keeps flooding
+func (l *rateLimiter) allow(size int) (allowed bool, exceeded bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := time.Now()
+	l.messages.refill(now)
+	l.bytes.refill(now)
+	// a dropped message takes nothing from the buckets
+	if l.messages.has(1) && l.bytes.has(size) {
+		l.messages.consume(1)
+		l.bytes.consume(size)
+		l.drops = 0
+		return true, false
+	}
+	l.drops++
+	return false, l.drops > maxRateLimitDrops
+}
+
+// isRateLimited returns false for responses to own requests and the handshake
+func isRateLimited(code uint64) bool {
+	switch code {
+	case Handshake, BlocksRange, BlockBodies, Disconnect:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package protocol
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	require.Nil(t, newTokenBucket(0))
+	var unlimited *tokenBucket
+	require.True(t, unlimited.take(1000000, time.Now()))
+
+	b := newTokenBucket(10)
+	now := b.last
+	require.True(t, b.take(10*rateLimitBurst, now))
+	require.False(t, b.take(1, now))
+
+	now = now.Add(time.Millisecond * 500)
+	require.True(t, b.take(5, now))
+	require.False(t, b.take(1, now))
+
+	// the tokens are not accumulated above the burst
+	now = now.Add(time.Hour)
+	require.False(t, b.take(10*rateLimitBurst+1, now))
+	require.True(t, b.take(10*rateLimitBurst, now))
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(1, 0)
+	for i := 0; i < rateLimitBurst; i++ {
+		allowed, _ := l.allow(100)
+		require.True(t, allowed)
+	}
+	for i := 0; i < maxRateLimitDrops; i++ {
+		allowed, exceeded := l.allow(100)
+		require.False(t, allowed)
+		require.False(t, exceeded)
+	}
+	allowed, exceeded := l.allow(100)
+	require.False(t, allowed)
+	require.True(t, exceeded)
+
+	// an allowed message resets the drops
+	l.messages.last = l.messages.last.Add(-time.Second)
+	allowed, _ = l.allow(100)
+	require.True(t, allowed)
+	require.Zero(t, l.drops)
+
+	bytesLimiter := newRateLimiter(0, 100)
+	allowed, _ = bytesLimiter.allow(100 * rateLimitBurst)
+	require.True(t, allowed)
+	allowed, _ = bytesLimiter.allow(1)
+	require.False(t, allowed)
+
+	// a message rejected by the byte rate doesn't use the message rate
+	bothLimiter := newRateLimiter(1, 100)
+	allowed, _ = bothLimiter.allow(100*rateLimitBurst + 1)
+	require.False(t, allowed)
+	require.Equal(t, float64(rateLimitBurst), bothLimiter.messages.tokens)
+	allowed, _ = bothLimiter.allow(100)
+	require.True(t, allowed)
+	require.Equal(t, float64(rateLimitBurst-1), bothLimiter.messages.tokens)
+}
+
+func TestIsRateLimited(t *testing.T) {
+	require.False(t, isRateLimited(Handshake))
+	require.False(t, isRateLimited(BlocksRange))
+	require.False(t, isRateLimited(BlockBodies))
+	require.False(t, isRateLimited(Disconnect))
+	require.True(t, isRateLimited(Vote))
+	require.True(t, isRateLimited(GetBlockBodies))
+}
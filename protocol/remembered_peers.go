@@ -0,0 +1,234 @@
+package protocol
+
+import (
+	"encoding/json"
+	"github.com/idena-network/idena-go/log"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	rememberedPeersFile = "rememberedpeers.json"
+	// max number of the best peers persisted to be dialed after the restart
+	maxRememberedPeers = 50
+	// max number of peer scores kept in memory to prioritize dial candidates
+	maxKnownScores = 5000
+	// peers which are not seen for this period are forgotten
+	rememberedPeerTTL = time.Hour * 24 * 7
+)
+
+type rememberedPeer struct {
+	Id       string    `json:"id"`
+	Addrs    []string  `json:"addrs"`
+	Score    int64     `json:"score"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// RememberedPeers keeps the scores of the disconnected peers to prioritize them on the next dial,
+// the best ones are persisted and dialed first after the restart before the discovery finds others
+type RememberedPeers struct {
+	path  string
+	peers map[peer.ID]*rememberedPeer
+	mutex sync.Mutex
+	log   log.Logger
+}
+
+func NewRememberedPeers(datadir string) *RememberedPeers {
+	r := &RememberedPeers{
+		peers: make(map[peer.ID]*rememberedPeer),
+		log:   log.New("component", "rememberedpeers"),
+	}
+	if datadir != "" {
+		r.path = filepath.Join(datadir, rememberedPeersFile)
+		r.load()
+	}
+	return r
+}
+
+func (r *RememberedPeers) load() {
+	data, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			r.log.Warn("cannot read remembered peers", "err", err)
+		}
+		return
+	}
+	var peers []*rememberedPeer
+	if err := json.Unmarshal(data, &peers); err != nil {
+		r.log.Warn("cannot parse remembered peers", "err", err)
+		return
+	}
+	for _, p := range peers {
+		id, err := peer.Decode(p.Id)
+		if err != nil {
+			continue
+		}
+		r.peers[id] = p
+	}
+	r.prune()
+}
+
+func (r *RememberedPeers) persist() {
+	if r.path == "" {
+		return
+	}
+	data, err := json.Marshal(r.best())
+	if err != nil {
+		r.log.Warn("cannot serialize remembered peers", "err", err)
+		return
+	}
+	if err := writeFileAtomic(r.path, data); err != nil {
+		r.log.Warn("cannot persist remembered peers", "err", err)
+	}
+}
+
+// remember keeps the score of the peer, the known addresses are replaced if the new ones are not empty
+func (r *RememberedPeers) remember(info peer.AddrInfo, score int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	p, ok := r.peers[info.ID]
+	if !ok {
+		if len(r.peers) >= maxKnownScores {
+			r.prune()
+			if len(r.peers) >= maxKnownScores {
+				return
+			}
+		}
+		p = &rememberedPeer{Id: info.ID.Pretty()}
+		r.peers[info.ID] = p
+	}
+	if len(info.Addrs) > 0 {
+		p.Addrs = make([]string, 0, len(info.Addrs))
+		for _, addr := range info.Addrs {
+			p.Addrs = append(p.Addrs, addr.String())
+		}
+	}
+	p.Score = score
+	p.LastSeen = time.Now().UTC()
+}
+
+// score returns the remembered score of the peer, unknown peers have zero score
+func (r *RememberedPeers) score(id peer.ID) int64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if p, ok := r.peers[id]; ok {
+		return p.Score
+	}
+	return 0
+}
+
+func (r *RememberedPeers) save() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.persist()
+}
+
+// prune drops the expired peers and the worst ones above maxKnownScores
+func (r *RememberedPeers) prune() {
+	expired := time.Now().UTC().Add(-rememberedPeerTTL)
+	for id, p := range r.peers {
+		if p.LastSeen.Before(expired) {
+			delete(r.peers, id)
+		}
+	}
+	sorted := r.sorted()
+	for i := maxKnownScores; i < len(sorted); i++ {
+		id, _ := peer.Decode(sorted[i].Id)
+		delete(r.peers, id)
+	}
+}
+
+// sorted returns the peers with the best score first, the recently seen ones go first among equal scores
+func (r *RememberedPeers) sorted() []*rememberedPeer {
+	result := make([]*rememberedPeer, 0, len(r.peers))
+	for _, p := range r.peers {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].LastSeen.After(result[j].LastSeen)
+	})
+	return result
+}
+
+// best returns up to maxRememberedPeers peers with non-negative score and known addresses
+func (r *RememberedPeers) best() []*rememberedPeer {
+	var result []*rememberedPeer
+	for _, p := range r.sorted() {
+		if len(result) == maxRememberedPeers || p.Score < 0 {
+			break
+		}
+		if len(p.Addrs) > 0 {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func (r *RememberedPeers) list() []peer.AddrInfo {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var result []peer.AddrInfo
+	for _, p := range r.best() {
+		id, err := peer.Decode(p.Id)
+		if err != nil {
+			continue
+		}
+		info := peer.AddrInfo{ID: id}
+		for _, s := range p.Addrs {
+			if addr, err := multiaddr.NewMultiaddr(s); err == nil {
+				info.Addrs = append(info.Addrs, addr)
+			}
+		}
+		if len(info.Addrs) > 0 {
+			result = append(result, info)
+		}
+	}
+	return result
+}
+
+func (h *IdenaGossipHandler) rememberPeer(id peer.ID, score int64) {
+	h.rememberedPeers.remember(peer.AddrInfo{ID: id, Addrs: h.host.Peerstore().Addrs(id)}, score)
+}
+
+// saveRememberedPeers remembers the connected peers with their current scores and persists the list
+func (h *IdenaGossipHandler) saveRememberedPeers() {
+	for _, p := range h.peers.Peers() {
+		h.rememberPeer(p.id, p.score.total())
+	}
+	h.rememberedPeers.save()
+}
+
+// dialRememberedPeers dials the remembered peers on start until the outbound slots are filled
+func (h *IdenaGossipHandler) dialRememberedPeers() {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxDialsPerRound)
+	for _, info := range h.rememberedPeers.list() {
+		if h.connManager.MissingOutboundPeers() == 0 {
+			break
+		}
+		if info.ID == h.host.ID() || h.peers.Peer(info.ID) != nil || h.banList.IsBanned(info.ID.Pretty()) {
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(info peer.AddrInfo) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := h.dialPeer(info); err != nil {
+				h.log.Debug("failed to dial remembered peer", "id", info.ID.Pretty(), "err", err)
+			}
+		}(info)
+	}
+	wg.Wait()
+}
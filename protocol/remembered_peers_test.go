@@ -0,0 +1,82 @@
+package protocol
+
+import (
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestAddrInfo(t *testing.T, name string, addr string) peer.AddrInfo {
+	a, err := multiaddr.NewMultiaddr(addr)
+	require.NoError(t, err)
+	return peer.AddrInfo{ID: testPeerID(t, name), Addrs: []multiaddr.Multiaddr{a}}
+}
+
+func TestRememberedPeers_Persist(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRememberedPeers(dir)
+	r.remember(newTestAddrInfo(t, "good", "/ip4/1.2.3.4/tcp/40405"), 5)
+	r.remember(newTestAddrInfo(t, "best", "/ip4/1.2.3.5/tcp/40405"), 10)
+	r.remember(newTestAddrInfo(t, "bad", "/ip4/1.2.3.6/tcp/40405"), -1)
+	r.remember(peer.AddrInfo{ID: testPeerID(t, "noaddrs")}, 20)
+	r.save()
+
+	_, err := os.Stat(filepath.Join(dir, rememberedPeersFile+".tmp"))
+	require.True(t, os.IsNotExist(err))
+
+	loaded := NewRememberedPeers(dir)
+	list := loaded.list()
+	require.Len(t, list, 2)
+	require.Equal(t, testPeerID(t, "best"), list[0].ID)
+	require.Equal(t, "/ip4/1.2.3.5/tcp/40405", list[0].Addrs[0].String())
+	require.Equal(t, testPeerID(t, "good"), list[1].ID)
+	require.Equal(t, int64(5), loaded.score(testPeerID(t, "good")))
+	require.Zero(t, loaded.score(testPeerID(t, "bad")))
+}
+
+func TestRememberedPeers_Ordering(t *testing.T) {
+	r := NewRememberedPeers("")
+	r.remember(newTestAddrInfo(t, "old", "/ip4/1.2.3.4/tcp/40405"), 1)
+	r.remember(newTestAddrInfo(t, "best", "/ip4/1.2.3.5/tcp/40405"), 3)
+	r.remember(newTestAddrInfo(t, "recent", "/ip4/1.2.3.6/tcp/40405"), 1)
+	r.peers[testPeerID(t, "old")].LastSeen = time.Now().UTC().Add(-time.Hour)
+
+	var ids []peer.ID
+	for _, info := range r.list() {
+		ids = append(ids, info.ID)
+	}
+	require.Equal(t, []peer.ID{testPeerID(t, "best"), testPeerID(t, "recent"), testPeerID(t, "old")}, ids)
+}
+
+func TestRememberedPeers_KeepAddrsAndScore(t *testing.T) {
+	r := NewRememberedPeers("")
+	r.remember(newTestAddrInfo(t, "peer", "/ip4/1.2.3.4/tcp/40405"), 1)
+	r.remember(peer.AddrInfo{ID: testPeerID(t, "peer")}, -5)
+	require.Equal(t, int64(-5), r.score(testPeerID(t, "peer")))
+	require.Empty(t, r.list())
+
+	r.remember(peer.AddrInfo{ID: testPeerID(t, "peer")}, 2)
+	require.Len(t, r.list(), 1)
+	require.Equal(t, "/ip4/1.2.3.4/tcp/40405", r.list()[0].Addrs[0].String())
+}
+
+func TestRememberedPeers_LoadExpiredAndCorrupted(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRememberedPeers(dir)
+	r.remember(newTestAddrInfo(t, "expired", "/ip4/1.2.3.4/tcp/40405"), 1)
+	r.remember(newTestAddrInfo(t, "actual", "/ip4/1.2.3.5/tcp/40405"), 1)
+	r.peers[testPeerID(t, "expired")].LastSeen = time.Now().UTC().Add(-rememberedPeerTTL - time.Hour)
+	r.save()
+
+	list := NewRememberedPeers(dir).list()
+	require.Len(t, list, 1)
+	require.Equal(t, testPeerID(t, "actual"), list[0].ID)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, rememberedPeersFile), []byte(`[{"id":`), 0644))
+	require.Empty(t, NewRememberedPeers(dir).list())
+}
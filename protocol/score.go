@@ -0,0 +1,198 @@
+package protocol
+
+import (
+	"github.com/libp2p/go-libp2p-core/peer"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	scoreBlockDelivered = 1
+	scoreFlipDelivered  = 2
+	scoreTimeout        = -20
+	scoreViolation      = -100
+	// latency in ms which costs one score point
+	scoreLatencyUnitMs = 100
+	latencyEwmaWeight  = 0.2
+	// sync errors in a row after which the peer gets batches only if there is no one else
+	maxSyncErrors = 3
+	// max number of batches assigned to a fast peer per one round
+	maxSyncSlots = 3
+)
+
+// peerScore tracks usefulness of the peer: delivered blocks and flips increase it, timeouts, violations and
+// high response latency decrease it
+type peerScore struct {
+	value     int64
+	latencyMs float64
+	// blocks per second delivered in block ranges
+	throughput float64
+	syncErrors int
+	mutex      sync.RWMutex
+}
+
+func (s *peerScore) add(delta int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.value += delta
+}
+
+func (s *peerScore) observeLatency(latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ms := float64(latency.Milliseconds())
+	if s.latencyMs == 0 {
+		s.latencyMs = ms
+		return
+	}
+	s.latencyMs = s.latencyMs*(1-latencyEwmaWeight) + ms*latencyEwmaWeight
+}
+
+func (s *peerScore) observeDelivery(blocks int, elapsed time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.syncErrors = 0
+	if elapsed <= 0 {
+		return
+	}
+	rate := float64(blocks) / elapsed.Seconds()
+	if s.throughput == 0 {
+		s.throughput = rate
+		return
+	}
+	s.throughput = s.throughput*(1-latencyEwmaWeight) + rate*latencyEwmaWeight
+}
+
+func (s *peerScore) observeSyncError() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.syncErrors++
+}
+
+func (s *peerScore) syncStats() (throughput float64, errors int) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.throughput, s.syncErrors
+}
+
+func (s *peerScore) total() int64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.value - int64(s.latencyMs/scoreLatencyUnitMs)
+}
+
+func (s *peerScore) latency() time.Duration {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return time.Duration(s.latencyMs) * time.Millisecond
+}
+
+func (h *IdenaGossipHandler) rewardPeer(id peer.ID, delta int64) {
+	if p := h.peers.Peer(id); p != nil && delta != 0 {
+		p.score.add(delta)
+	}
+}
+
+func (h *IdenaGossipHandler) peerScore(id peer.ID) int64 {
+	p := h.peers.Peer(id)
+	if p == nil {
+		return 0
+	}
+	return p.score.total()
+}
+
+// PeersByScore returns the peer ids ordered by descending score
+func (h *IdenaGossipHandler) PeersByScore(peers map[peer.ID]uint64) []peer.ID {
+	result := make([]peer.ID, 0, len(peers))
+	scores := make(map[peer.ID]int64, len(peers))
+	for id := range peers {
+		result = append(result, id)
+		scores[id] = h.peerScore(id)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return scores[result[i]] > scores[result[j]]
+	})
+	return result
+}
+
+// SyncSchedule returns the order of batch assignments for one round of the sync: peers are ordered by throughput,
+// fast peers appear several times, peers failing in a row are skipped while there are other ones
+func (h *IdenaGossipHandler) SyncSchedule(peers map[peer.ID]uint64) []peer.ID {
+	type syncPeer struct {
+		id         peer.ID
+		throughput float64
+	}
+	var reliable, failing []syncPeer
+	var slowest, fastest float64
+	for _, id := range h.PeersByScore(peers) {
+		p := h.peers.Peer(id)
+		if p == nil {
+			continue
+		}
+		throughput, errors := p.score.syncStats()
+		if errors >= maxSyncErrors {
+			failing = append(failing, syncPeer{id, throughput})
+			continue
+		}
+		reliable = append(reliable, syncPeer{id, throughput})
+		if throughput > 0 && (slowest == 0 || throughput < slowest) {
+			slowest = throughput
+		}
+		if throughput > fastest {
+			fastest = throughput
+		}
+	}
+	if len(reliable) == 0 {
+		reliable = failing
+	}
+	// unmeasured peers are treated as the fastest ones to get their throughput measured
+	for i := range reliable {
+		if reliable[i].throughput == 0 {
+			reliable[i].throughput = fastest
+		}
+	}
+	sort.SliceStable(reliable, func(i, j int) bool {
+		return reliable[i].throughput > reliable[j].throughput
+	})
+	result := make([]peer.ID, 0, len(reliable))
+	for _, p := range reliable {
+		slots := 1
+		if slowest > 0 {
+			slots = int(p.throughput/slowest + 0.5)
+		}
+		if slots < 1 {
+			slots = 1
+		}
+		if slots > maxSyncSlots {
+			slots = maxSyncSlots
+		}
+		for i := 0; i < slots; i++ {
+			result = append(result, p.id)
+		}
+	}
+	return result
+}
+
+func sortPeersByScore(peers []*protoPeer) {
+	scores := make(map[*protoPeer]int64, len(peers))
+	for _, p := range peers {
+		scores[p] = p.score.total()
+	}
+	sort.SliceStable(peers, func(i, j int) bool {
+		return scores[peers[i]] > scores[peers[j]]
+	})
+}
+
+// worstPeer returns the candidate with the lowest score
+func worstPeer(candidates []peer.ID, score func(id peer.ID) int64) peer.ID {
+	var worst peer.ID
+	var worstScore int64
+	for _, id := range candidates {
+		s := score(id)
+		if worst == "" || s < worstScore {
+			worst, worstScore = id, s
+		}
+	}
+	return worst
+}
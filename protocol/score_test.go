@@ -0,0 +1,78 @@
+package protocol
+
+import (
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPeerScore(t *testing.T) {
+	s := &peerScore{}
+	s.add(scoreBlockDelivered * 10)
+	s.add(scoreTimeout)
+	require.Equal(t, int64(-10), s.total())
+
+	s.observeLatency(time.Millisecond * 500)
+	require.Equal(t, time.Millisecond*500, s.latency())
+	require.Equal(t, int64(-15), s.total())
+
+	s.observeLatency(time.Millisecond * 1000)
+	require.Equal(t, time.Millisecond*600, s.latency())
+
+	s.observeSyncError()
+	s.observeSyncError()
+	_, errors := s.syncStats()
+	require.Equal(t, 2, errors)
+	s.observeDelivery(10, time.Second)
+	throughput, errors := s.syncStats()
+	require.Zero(t, errors)
+	require.Equal(t, float64(10), throughput)
+}
+
+func newTestScoredHandler(peers ...*protoPeer) *IdenaGossipHandler {
+	h := &IdenaGossipHandler{peers: newPeerSet()}
+	for _, p := range peers {
+		h.peers.Register(p)
+	}
+	return h
+}
+
+func TestIdenaGossipHandler_PeersByScore(t *testing.T) {
+	h := newTestScoredHandler(newTestPeer("good", 1, 10), newTestPeer("bad", 1, -10), newTestPeer("best", 1, 20))
+	ids := h.PeersByScore(map[peer.ID]uint64{"good": 1, "bad": 1, "best": 1, "unknown": 1})
+	require.Equal(t, []peer.ID{"best", "good", "unknown", "bad"}, ids)
+}
+
+func TestIdenaGossipHandler_SyncSchedule(t *testing.T) {
+	fast := newTestPeer("fast", 1, 3)
+	fast.score.throughput = 10
+	unmeasured := newTestPeer("unmeasured", 1, 2)
+	slow := newTestPeer("slow", 1, 1)
+	slow.score.throughput = 5
+	failing := newTestPeer("failing", 1, 0)
+	failing.score.throughput = 20
+	failing.score.syncErrors = maxSyncErrors
+	h := newTestScoredHandler(fast, unmeasured, slow, failing)
+
+	schedule := h.SyncSchedule(map[peer.ID]uint64{"fast": 1, "unmeasured": 1, "slow": 1, "failing": 1})
+	require.Equal(t, []peer.ID{"fast", "fast", "unmeasured", "unmeasured", "slow"}, schedule)
+
+	// failing peers are used if there is no one else
+	schedule = h.SyncSchedule(map[peer.ID]uint64{"failing": 1})
+	require.Equal(t, []peer.ID{"failing"}, schedule)
+}
+
+func TestWorstPeer(t *testing.T) {
+	scores := map[peer.ID]int64{"peer1": 5, "peer2": -3, "peer3": 0}
+	score := func(id peer.ID) int64 {
+		return scores[id]
+	}
+	require.Equal(t, peer.ID("peer2"), worstPeer([]peer.ID{"peer1", "peer2", "peer3"}, score))
+	require.Equal(t, peer.ID(""), worstPeer(nil, score))
+
+	peers := []*protoPeer{newTestPeer("peer1", 1, 1), newTestPeer("peer2", 1, 3), newTestPeer("peer3", 1, 2)}
+	sortPeersByScore(peers)
+	require.Equal(t, peer.ID("peer2"), peers[0].id)
+	require.Equal(t, peer.ID("peer1"), peers[2].id)
+}
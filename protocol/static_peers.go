@@ -0,0 +1,108 @@
+package protocol
+
+import (
+	"context"
+	"github.com/idena-network/idena-go/log"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const (
+	staticPeerMinBackoff   = time.Second * 5
+	staticPeerMaxBackoff   = time.Minute * 5
+	staticPeerDialTimeout  = time.Second * 30
+	staticPeersCheckPeriod = time.Second * 5
+)
+
+type staticPeer struct {
+	info     peer.AddrInfo
+	backoff  time.Duration
+	nextDial time.Time
+}
+
+// dialFailed postpones the next dial by the current backoff and doubles it up to staticPeerMaxBackoff
+func (sp *staticPeer) dialFailed(now time.Time) {
+	sp.nextDial = now.Add(sp.backoff)
+	sp.backoff *= 2
+	if sp.backoff > staticPeerMaxBackoff {
+		sp.backoff = staticPeerMaxBackoff
+	}
+}
+
+func (sp *staticPeer) connected() {
+	sp.backoff = staticPeerMinBackoff
+	sp.nextDial = time.Time{}
+}
+
+func parsePeerAddr(url string) (peer.AddrInfo, error) {
+	ma, err := multiaddr.NewMultiaddr(url)
+	if err != nil {
+		return peer.AddrInfo{}, err
+	}
+	transportAddr, peerId := peer.SplitAddr(ma)
+	if transportAddr == nil || peerId == "" {
+		return peer.AddrInfo{}, errors.New("invalid url")
+	}
+	return peer.AddrInfo{
+		ID:    peerId,
+		Addrs: []multiaddr.Multiaddr{transportAddr},
+	}, nil
+}
+
+func parseStaticPeers(urls []string) []*staticPeer {
+	var result []*staticPeer
+	for _, url := range urls {
+		info, err := parsePeerAddr(url)
+		if err != nil {
+			log.Error("invalid static peer", "url", url, "err", err)
+			continue
+		}
+		result = append(result, &staticPeer{
+			info:    info,
+			backoff: staticPeerMinBackoff,
+		})
+	}
+	return result
+}
+
+// maintainStaticPeers keeps connections to static peers, a disconnected peer is redialed with exponential backoff
+func (h *IdenaGossipHandler) maintainStaticPeers() {
+	if len(h.staticPeers) == 0 {
+		return
+	}
+	for {
+		now := time.Now()
+		for _, sp := range h.staticPeers {
+			if h.peers.Peer(sp.info.ID) != nil {
+				sp.connected()
+				continue
+			}
+			if now.Before(sp.nextDial) {
+				continue
+			}
+			if err := h.dialPeer(sp.info); err != nil {
+				h.log.Debug("failed to dial static peer", "id", sp.info.ID.Pretty(), "err", err, "retryIn", sp.backoff)
+				sp.dialFailed(now)
+			}
+		}
+		time.Sleep(staticPeersCheckPeriod)
+	}
+}
+
+// dialPeer connects to the peer and runs the idena protocol with it
+func (h *IdenaGossipHandler) dialPeer(info peer.AddrInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), staticPeerDialTimeout)
+	err := h.host.Connect(ctx, info)
+	cancel()
+	if err != nil {
+		return err
+	}
+	stream, err := h.connManager.newStream(info.ID)
+	if err != nil {
+		return err
+	}
+	_, err = h.runPeer(stream, false)
+	return err
+}
@@ -0,0 +1,52 @@
+package protocol
+
+import (
+	"github.com/idena-network/idena-go/config"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const testPeerUrl = "/ip4/1.2.3.4/tcp/40405/p2p/QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN"
+
+func TestParsePeerAddr(t *testing.T) {
+	info, err := parsePeerAddr(testPeerUrl)
+	require.NoError(t, err)
+	require.Equal(t, "QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN", info.ID.Pretty())
+	require.Len(t, info.Addrs, 1)
+	require.Equal(t, "/ip4/1.2.3.4/tcp/40405", info.Addrs[0].String())
+
+	_, err = parsePeerAddr("/ip4/1.2.3.4/tcp/40405")
+	require.Error(t, err)
+	_, err = parsePeerAddr("invalid")
+	require.Error(t, err)
+}
+
+func TestParseStaticPeers(t *testing.T) {
+	peers := parseStaticPeers([]string{"invalid", testPeerUrl})
+	require.Len(t, peers, 1)
+	require.Equal(t, staticPeerMinBackoff, peers[0].backoff)
+
+	m := newTestConnManager(config.P2P{StaticPeers: []string{testPeerUrl}})
+	require.True(t, m.IsStatic(peers[0].info.ID))
+	require.True(t, m.IsProtected(peers[0].info.ID))
+}
+
+func TestStaticPeer_Backoff(t *testing.T) {
+	sp := parseStaticPeers([]string{testPeerUrl})[0]
+	now := time.Now()
+
+	sp.dialFailed(now)
+	require.Equal(t, now.Add(staticPeerMinBackoff), sp.nextDial)
+	require.Equal(t, staticPeerMinBackoff*2, sp.backoff)
+
+	for i := 0; i < 10; i++ {
+		sp.dialFailed(now)
+	}
+	require.Equal(t, staticPeerMaxBackoff, sp.backoff)
+	require.Equal(t, now.Add(staticPeerMaxBackoff), sp.nextDial)
+
+	sp.connected()
+	require.Equal(t, staticPeerMinBackoff, sp.backoff)
+	require.True(t, sp.nextDial.IsZero())
+}
@@ -0,0 +1,49 @@
+package protocol
+
+import (
+	"sync"
+	"time"
+)
+
+// bandwidthLimiter caps the download rate of the sync, a nil limiter doesn't limit anything
+type bandwidthLimiter struct {
+	rate float64
+	// may become negative, the debt is paid off by waiting
+	tokens float64
+	last   time.Time
+	mutex  sync.Mutex
+}
+
+func newBandwidthLimiter(rate int) *bandwidthLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &bandwidthLimiter{
+		rate: float64(rate),
+		last: time.Now(),
+	}
+}
+
+// wait accounts downloaded bytes and blocks until the average rate fits the limit
+func (l *bandwidthLimiter) wait(size int) {
+	if l == nil || size <= 0 {
+		return
+	}
+	l.mutex.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	// allow a burst of a second to smooth small requests
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	l.tokens -= float64(size)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mutex.Unlock()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,82 @@
+package protocol
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-go/events"
+	"sync"
+	"time"
+)
+
+const (
+	syncProgressPeriod = time.Second * 10
+	syncRateEwmaWeight = 0.3
+)
+
+// syncProgress keeps the latest sync progress, the rate is smoothed to make ETA stable
+type syncProgress struct {
+	last  *events.SyncProgressEvent
+	mutex sync.RWMutex
+}
+
+func (p *syncProgress) set(e *events.SyncProgressEvent) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.last = e
+}
+
+func (p *syncProgress) get() *events.SyncProgressEvent {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.last
+}
+
+func newSyncProgressEvent(head, top uint64, rate float64) *events.SyncProgressEvent {
+	e := &events.SyncProgressEvent{
+		Head:            head,
+		Top:             top,
+		BlocksPerSecond: rate,
+	}
+	if top > head {
+		e.Remaining = top - head
+	}
+	if rate > 0 {
+		e.Eta = time.Duration(float64(e.Remaining)/rate) * time.Second
+	}
+	return e
+}
+
+// reportSyncProgress periodically publishes sync progress until the sync is stopped
+func (d *Downloader) reportSyncProgress(stop chan struct{}) {
+	ticker := time.NewTicker(syncProgressPeriod)
+	defer ticker.Stop()
+	lastHead, _ := d.SyncProgress()
+	lastTime := time.Now()
+	var rate float64
+	for {
+		select {
+		case <-stop:
+			d.progress.set(nil)
+			return
+		case now := <-ticker.C:
+			head, top := d.SyncProgress()
+			if elapsed := now.Sub(lastTime).Seconds(); elapsed > 0 && head >= lastHead {
+				current := float64(head-lastHead) / elapsed
+				if rate == 0 {
+					rate = current
+				} else {
+					rate = rate*(1-syncRateEwmaWeight) + current*syncRateEwmaWeight
+				}
+			}
+			lastHead, lastTime = head, now
+			e := newSyncProgressEvent(head, top, rate)
+			d.progress.set(e)
+			d.bus.Publish(e)
+			d.log.Info("Sync progress", "head", e.Head, "top", e.Top, "blocks/s", fmt.Sprintf("%.2f", e.BlocksPerSecond), "eta", e.Eta)
+		}
+	}
+}
+
+// Progress returns the latest sync progress or nil if the node is not syncing
+func (d *Downloader) Progress() *events.SyncProgressEvent {
+	return d.progress.get()
+}
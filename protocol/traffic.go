@@ -0,0 +1,121 @@
+package protocol
+
+import (
+	"sync"
+	"time"
+)
+
+// trafficWindow is the period of the recent traffic
+const trafficWindow = time.Minute
+
+type MsgTraffic struct {
+	MessagesIn  int64 `json:"messagesIn"`
+	BytesIn     int64 `json:"bytesIn"`
+	MessagesOut int64 `json:"messagesOut"`
+	BytesOut    int64 `json:"bytesOut"`
+}
+
+func (t *MsgTraffic) add(other MsgTraffic) {
+	t.MessagesIn += other.MessagesIn
+	t.BytesIn += other.BytesIn
+	t.MessagesOut += other.MessagesOut
+	t.BytesOut += other.BytesOut
+}
+
+// trafficStats counts messages and bytes by message type since the start, unlike metrics they are never reset.
+// Besides, it keeps the traffic of the last completed window to show the recent load
+type trafficStats struct {
+	byCode      map[uint64]*MsgTraffic
+	window      MsgTraffic
+	lastWindow  MsgTraffic
+	windowStart time.Time
+	mutex       sync.Mutex
+}
+
+func newTrafficStats() *trafficStats {
+	return &trafficStats{
+		byCode:      make(map[uint64]*MsgTraffic),
+		windowStart: time.Now(),
+	}
+}
+
+func (t *trafficStats) rollWindow(now time.Time) {
+	elapsed := now.Sub(t.windowStart)
+	if elapsed < trafficWindow {
+		return
+	}
+	if elapsed < 2*trafficWindow {
+		t.lastWindow = t.window
+	} else {
+		t.lastWindow = MsgTraffic{}
+	}
+	t.window = MsgTraffic{}
+	t.windowStart = now.Add(-elapsed % trafficWindow)
+}
+
+func (t *trafficStats) get(code uint64) *MsgTraffic {
+	traffic, ok := t.byCode[code]
+	if !ok {
+		traffic = &MsgTraffic{}
+		t.byCode[code] = traffic
+	}
+	return traffic
+}
+
+func (t *trafficStats) addIn(code uint64, size int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.rollWindow(time.Now())
+	traffic := t.get(code)
+	traffic.MessagesIn++
+	traffic.BytesIn += int64(size)
+	t.window.MessagesIn++
+	t.window.BytesIn += int64(size)
+}
+
+func (t *trafficStats) addOut(code uint64, size int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.rollWindow(time.Now())
+	traffic := t.get(code)
+	traffic.MessagesOut++
+	traffic.BytesOut += int64(size)
+	t.window.MessagesOut++
+	t.window.BytesOut += int64(size)
+}
+
+func (t *trafficStats) byMsgType() map[string]MsgTraffic {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	result := make(map[string]MsgTraffic, len(t.byCode))
+	for code, traffic := range t.byCode {
+		result[msgCodeToString(code)] = *traffic
+	}
+	return result
+}
+
+// summary returns the traffic over all message types since the start and during the last completed window
+func (t *trafficStats) summary() (total MsgTraffic, recent MsgTraffic) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.rollWindow(time.Now())
+	for _, traffic := range t.byCode {
+		total.add(*traffic)
+	}
+	return total, t.lastWindow
+}
+
+// Traffic returns messages and bytes sent to and received from the peer by message type
+func (p *protoPeer) Traffic() map[string]MsgTraffic {
+	return p.traffic.byMsgType()
+}
+
+// TrafficSummary returns messages and bytes exchanged with the peer since the connection and during the last minute
+func (p *protoPeer) TrafficSummary() (total MsgTraffic, lastMinute MsgTraffic) {
+	return p.traffic.summary()
+}
+
+// Traffic returns messages and bytes sent and received by message type over all peers
+func (h *IdenaGossipHandler) Traffic() map[string]MsgTraffic {
+	return h.metrics.traffic.byMsgType()
+}
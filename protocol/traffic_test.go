@@ -0,0 +1,46 @@
+package protocol
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTrafficStats(t *testing.T) {
+	stats := newTrafficStats()
+	stats.addIn(Handshake, 10)
+	stats.addIn(Vote, 100)
+	stats.addIn(Vote, 50)
+	stats.addOut(Vote, 70)
+
+	byType := stats.byMsgType()
+	require.Len(t, byType, 2)
+	require.Equal(t, MsgTraffic{MessagesIn: 1, BytesIn: 10}, byType["handshake"])
+	require.Equal(t, MsgTraffic{MessagesIn: 2, BytesIn: 150, MessagesOut: 1, BytesOut: 70}, byType["vote"])
+
+	total, recent := stats.summary()
+	require.Equal(t, MsgTraffic{MessagesIn: 3, BytesIn: 160, MessagesOut: 1, BytesOut: 70}, total)
+	// the current window is not completed yet
+	require.Equal(t, MsgTraffic{}, recent)
+}
+
+func TestTrafficStats_rollWindow(t *testing.T) {
+	stats := newTrafficStats()
+	stats.addIn(Vote, 100)
+	now := time.Now()
+
+	stats.windowStart = now.Add(-trafficWindow * 3 / 2)
+	stats.rollWindow(now)
+	require.Equal(t, MsgTraffic{MessagesIn: 1, BytesIn: 100}, stats.lastWindow)
+	require.Equal(t, MsgTraffic{}, stats.window)
+	require.True(t, now.Add(-trafficWindow/2).Equal(stats.windowStart))
+
+	// the window without traffic resets the recent traffic
+	stats.windowStart = now.Add(-trafficWindow * 5 / 2)
+	stats.rollWindow(now)
+	require.Equal(t, MsgTraffic{}, stats.lastWindow)
+
+	// the traffic since the start is kept
+	total, _ := stats.summary()
+	require.Equal(t, MsgTraffic{MessagesIn: 1, BytesIn: 100}, total)
+}
@@ -0,0 +1,86 @@
+package protocol
+
+import (
+	"github.com/idena-network/idena-go/log"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"sync"
+)
+
+// trustedPeers are never disconnected because of slot limits, scoring or bans, the list can be changed at runtime
+type trustedPeers struct {
+	ids   map[peer.ID]struct{}
+	mutex sync.RWMutex
+}
+
+func newTrustedPeers(ids []string) *trustedPeers {
+	t := &trustedPeers{
+		ids: make(map[peer.ID]struct{}),
+	}
+	for _, s := range ids {
+		id, err := peer.Decode(s)
+		if err != nil {
+			log.Error("invalid trusted peer", "id", s, "err", err)
+			continue
+		}
+		t.ids[id] = struct{}{}
+	}
+	return t
+}
+
+func (t *trustedPeers) add(id peer.ID) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.ids[id] = struct{}{}
+}
+
+func (t *trustedPeers) remove(id peer.ID) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.ids, id)
+}
+
+func (t *trustedPeers) contains(id peer.ID) bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	_, ok := t.ids[id]
+	return ok
+}
+
+func (t *trustedPeers) list() []peer.ID {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	result := make([]peer.ID, 0, len(t.ids))
+	for id := range t.ids {
+		result = append(result, id)
+	}
+	return result
+}
+
+func (h *IdenaGossipHandler) AddTrustedPeer(id string) error {
+	peerId, err := peer.Decode(id)
+	if err != nil {
+		return err
+	}
+	h.trustedPeers.add(peerId)
+	h.connManager.UnbanPeer(peerId)
+	h.log.Info("Trusted peer added", "id", peerId.Pretty())
+	return nil
+}
+
+func (h *IdenaGossipHandler) RemoveTrustedPeer(id string) error {
+	peerId, err := peer.Decode(id)
+	if err != nil {
+		return err
+	}
+	h.trustedPeers.remove(peerId)
+	h.log.Info("Trusted peer removed", "id", peerId.Pretty())
+	return nil
+}
+
+func (h *IdenaGossipHandler) TrustedPeers() []string {
+	var result []string
+	for _, id := range h.trustedPeers.list() {
+		result = append(result, id.Pretty())
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package protocol
+
+import (
+	"github.com/idena-network/idena-go/config"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const testTrustedPeer = "QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN"
+
+func TestTrustedPeers(t *testing.T) {
+	trusted := newTrustedPeers([]string{"invalid", testTrustedPeer})
+	require.Len(t, trusted.list(), 1)
+	id := trusted.list()[0]
+	require.Equal(t, testTrustedPeer, id.Pretty())
+	require.True(t, trusted.contains(id))
+
+	trusted.add(peer.ID("other"))
+	require.Len(t, trusted.list(), 2)
+	trusted.remove(id)
+	require.False(t, trusted.contains(id))
+	require.True(t, trusted.contains(peer.ID("other")))
+}
+
+func TestConnManager_TrustedPeers(t *testing.T) {
+	trusted := newTrustedPeers([]string{testTrustedPeer})
+	id := trusted.list()[0]
+	banList := NewBanList("", time.Hour)
+	m := NewConnManager(nil, config.P2P{MaxInboundPeers: 1}, trusted, banList, NewRememberedPeers(""))
+	m.SetShardId(1)
+
+	// trusted peers don't occupy slots
+	m.Connected(id, true, 2)
+	require.True(t, m.CanAcceptStream())
+	m.Connected(peer.ID("other"), true, 2)
+	require.False(t, m.CanAcceptStream())
+
+	// are never selected to disconnect
+	require.True(t, m.IsProtected(id))
+	require.Equal(t, peer.ID("other"), m.PeerToRenew(true))
+	require.Equal(t, peer.ID("other"), m.PeerForDisconnect(true, 1))
+
+	// and are never banned
+	m.BanPeer(id, "test")
+	require.False(t, banList.IsBanned(id.Pretty()))
+	banList.Ban("test", 0, id.Pretty())
+	require.False(t, m.isBanned(id))
+}
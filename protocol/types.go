@@ -11,6 +11,8 @@ import (
 const (
 	DecodeErr                  = 1
 	ValidationErr              = 2
+	RateLimitErr               = 3
+	ProtocolErr                = 4 // unexpected message which doesn't prove the peer is malicious
 	MaxTimestampLagSeconds     = 15
 	MaxBannedPeers             = 500000
 	IdenaProtocolWeight        = 25
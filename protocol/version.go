@@ -0,0 +1,67 @@
+package protocol
+
+import (
+	"github.com/coreos/go-semver/semver"
+	core "github.com/libp2p/go-libp2p-core"
+	"github.com/libp2p/go-libp2p-core/helpers"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// IdenaProtocols are the served protocol versions, the first one is the current version.
+// During a network upgrade the previous major version stays here until the old nodes leave the network,
+// so the upgrade doesn't require all nodes to switch at once.
+var IdenaProtocols = []core.ProtocolID{IdenaProtocol}
+
+var errNoCommonProtocol = errors.New("peer doesn't support idena protocol")
+
+func protocolVersion(p string) (*semver.Version, bool) {
+	if !strings.HasPrefix(p, IdenaProtocolPath+"/") {
+		return nil, false
+	}
+	v, err := semver.NewVersion(strings.TrimPrefix(p, IdenaProtocolPath+"/"))
+	if err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// isIdenaProtocol returns true if the protocol id can be served by one of the local protocol versions
+func isIdenaProtocol(p string) bool {
+	for _, served := range IdenaProtocols {
+		matcher, err := helpers.MultistreamSemverMatcher(served)
+		if err == nil && matcher(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateProtocol returns the highest protocol version supported by both sides,
+// the remote side serves any minor version up to the advertised one
+func negotiateProtocol(remote []string) (core.ProtocolID, error) {
+	var best *semver.Version
+	for _, p := range remote {
+		remoteVersion, ok := protocolVersion(p)
+		if !ok {
+			continue
+		}
+		for _, served := range IdenaProtocols {
+			localVersion, ok := protocolVersion(string(served))
+			if !ok || localVersion.Major != remoteVersion.Major {
+				continue
+			}
+			common := localVersion
+			if remoteVersion.LessThan(*localVersion) {
+				common = remoteVersion
+			}
+			if best == nil || best.LessThan(*common) {
+				best = common
+			}
+		}
+	}
+	if best == nil {
+		return "", errNoCommonProtocol
+	}
+	return core.ProtocolID(IdenaProtocolPath + "/" + best.String()), nil
+}
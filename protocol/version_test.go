@@ -0,0 +1,41 @@
+package protocol
+
+import (
+	core "github.com/libp2p/go-libp2p-core"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNegotiateProtocol(t *testing.T) {
+	served := IdenaProtocols
+	defer func() {
+		IdenaProtocols = served
+	}()
+	IdenaProtocols = []core.ProtocolID{IdenaProtocolPath + "/2.1.0", IdenaProtocolPath + "/1.4.0"}
+
+	negotiate := func(remote ...string) string {
+		p, err := negotiateProtocol(remote)
+		require.NoError(t, err)
+		return string(p)
+	}
+	require.Equal(t, IdenaProtocolPath+"/1.2.0", negotiate(IdenaProtocolPath+"/1.2.0"))
+	require.Equal(t, IdenaProtocolPath+"/1.4.0", negotiate(IdenaProtocolPath+"/1.6.0"))
+	require.Equal(t, IdenaProtocolPath+"/2.0.0", negotiate("/ipfs/id/1.0.0", IdenaProtocolPath+"/1.4.0", IdenaProtocolPath+"/2.0.0"))
+
+	_, err := negotiateProtocol([]string{"/ipfs/id/1.0.0", IdenaProtocolPath + "/3.0.0", IdenaProtocolPath + "/invalid"})
+	require.Equal(t, errNoCommonProtocol, err)
+}
+
+func TestIsIdenaProtocol(t *testing.T) {
+	served := IdenaProtocols
+	defer func() {
+		IdenaProtocols = served
+	}()
+	IdenaProtocols = []core.ProtocolID{IdenaProtocolPath + "/2.1.0", IdenaProtocolPath + "/1.4.0"}
+
+	require.True(t, isIdenaProtocol(IdenaProtocolPath+"/1.2.0"))
+	require.True(t, isIdenaProtocol(IdenaProtocolPath+"/2.1.0"))
+	require.False(t, isIdenaProtocol(IdenaProtocolPath+"/1.5.0"))
+	require.False(t, isIdenaProtocol(IdenaProtocolPath+"/3.0.0"))
+	require.False(t, isIdenaProtocol("/ipfs/id/1.0.0"))
+}
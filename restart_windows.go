@@ -0,0 +1,21 @@
+// +build windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// restartProcess starts a new node process with the same command line, the stopped process exits afterwards
+func restartProcess() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Start()
+}
@@ -16,7 +16,10 @@
 
 package rpc
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 // request is for an unknown service
 type methodNotFoundError struct {
@@ -71,3 +74,45 @@ type invalidApiKeyError struct{}
 func (e *invalidApiKeyError) ErrorCode() int { return -32800 }
 
 func (e *invalidApiKeyError) Error() string { return "the provided API key is invalid" }
+
+// ApplicationError is an error of the api services with a stable code, so clients don't depend on the messages.
+// The name of the code is sent as the error data
+type ApplicationError struct {
+	Code    int
+	Name    string
+	Message string
+}
+
+func (e *ApplicationError) ErrorCode() int { return e.Code }
+
+func (e *ApplicationError) Error() string { return e.Message }
+
+func (e *ApplicationError) ErrorData() interface{} { return e.Name }
+
+type registeredErrorCode struct {
+	target error
+	code   int
+	name   string
+}
+
+var errorCodes []registeredErrorCode
+
+// RegisterErrorCode makes the server respond with the code and name if a callback error is or wraps the target error.
+// It is not safe for concurrent use and should be called on initialization
+func RegisterErrorCode(target error, code int, name string) {
+	errorCodes = append(errorCodes, registeredErrorCode{target, code, name})
+}
+
+// toCallbackError attaches the application code to the error returned by the callback, the message is kept
+func toCallbackError(err error) Error {
+	var appErr *ApplicationError
+	if errors.As(err, &appErr) {
+		return &ApplicationError{Code: appErr.Code, Name: appErr.Name, Message: err.Error()}
+	}
+	for _, c := range errorCodes {
+		if errors.Is(err, c.target) {
+			return &ApplicationError{Code: c.code, Name: c.name, Message: err.Error()}
+		}
+	}
+	return &callbackError{err.Error()}
+}
@@ -0,0 +1,34 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestToCallbackError(t *testing.T) {
+	target := errors.New("insufficient funds")
+	RegisterErrorCode(target, -32010, "INSUFFICIENT_FUNDS")
+	defer func() {
+		errorCodes = nil
+	}()
+
+	err := toCallbackError(fmt.Errorf("cannot send tx: %w", target))
+	appErr, ok := err.(*ApplicationError)
+	if !ok {
+		t.Fatalf("expected application error, got %T", err)
+	}
+	if appErr.Code != -32010 || appErr.ErrorData() != "INSUFFICIENT_FUNDS" || appErr.Error() != "cannot send tx: insufficient funds" {
+		t.Errorf("unexpected application error %+v", appErr)
+	}
+
+	err = toCallbackError(fmt.Errorf("wrapped: %w", &ApplicationError{Code: -32021, Name: "NOT_SYNCED", Message: "node is not synced"}))
+	if err.ErrorCode() != -32021 || err.Error() != "wrapped: node is not synced" {
+		t.Errorf("unexpected error %v with code %v", err, err.ErrorCode())
+	}
+
+	err = toCallbackError(errors.New("unknown"))
+	if _, ok := err.(*callbackError); !ok || err.ErrorCode() != -32000 {
+		t.Errorf("expected callback error, got %T", err)
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/idena-network/idena-go/log"
@@ -196,8 +197,7 @@ func (t *httpReadWriteNopCloser) Close() error {
 // Deprecated: Server implements http.Handler
 func NewHTTPServer(cors []string, vhosts []string, timeouts HTTPTimeouts, srv *Server) *http.Server {
 	// Wrap the CORS-handler within a host-handler
-	handler := newCorsHandler(srv, cors)
-	handler = newVHostHandler(vhosts, handler)
+	handler := newVHostHandler(vhosts, newCorsHandler(srv, cors))
 
 	// Make sure timeout values are meaningful
 	if timeouts.ReadTimeout < time.Second {
@@ -271,10 +271,29 @@ func validateRequest(r *http.Request) (int, error) {
 	return 0, nil
 }
 
-func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
+// corsHandler applies the CORS policy to the requests, the allowed origins can be replaced while the server is running
+type corsHandler struct {
+	srv     *Server
+	handler atomic.Value
+}
+
+// corsPolicy keeps the concrete type stored in corsHandler.handler the same
+type corsPolicy struct {
+	handler http.Handler
+}
+
+func newCorsHandler(srv *Server, allowedOrigins []string) *corsHandler {
+	h := &corsHandler{srv: srv}
+	h.setAllowedOrigins(allowedOrigins)
+	srv.cors = h
+	return h
+}
+
+func (h *corsHandler) setAllowedOrigins(allowedOrigins []string) {
 	// disable CORS support if user has not specified a custom CORS configuration
 	if len(allowedOrigins) == 0 {
-		return srv
+		h.handler.Store(corsPolicy{h.srv})
+		return
 	}
 	c := cors.New(cors.Options{
 		AllowedOrigins:   allowedOrigins,
@@ -283,7 +302,20 @@ func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
 		AllowedHeaders:   []string{"*"},
 		AllowCredentials: true,
 	})
-	return c.Handler(srv)
+	h.handler.Store(corsPolicy{c.Handler(h.srv)})
+}
+
+func (h *corsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler.Load().(corsPolicy).handler.ServeHTTP(w, r)
+}
+
+// SetHTTPCors replaces the allowed CORS origins of the HTTP server created by NewHTTPServer
+func (srv *Server) SetHTTPCors(allowedOrigins []string) error {
+	if srv.cors == nil {
+		return errors.New("server is not served over HTTP")
+	}
+	srv.cors.setAllowedOrigins(allowedOrigins)
+	return nil
 }
 
 // virtualHostHandler is a handler which validates the Host-header of incoming requests.
@@ -313,9 +313,11 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	}
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
-			e := reply[req.callb.errPos].Interface().(error)
-			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
-			return res, nil
+			e := toCallbackError(reply[req.callb.errPos].Interface().(error))
+			if appErr, ok := e.(*ApplicationError); ok {
+				return codec.CreateErrorResponseWithInfo(&req.id, appErr, appErr.ErrorData()), nil
+			}
+			return codec.CreateErrorResponse(&req.id, e), nil
 		}
 	}
 	return codec.CreateResponse(req.id, reply[0].Interface()), nil
@@ -75,6 +75,7 @@ type Server struct {
 	run      int32
 	codecsMu sync.Mutex
 	codecs   mapset.Set
+	cors     *corsHandler
 }
 
 // rpcRequest represents a raw incoming RPC request
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/idena-network/idena-go/config"
+	"github.com/urfave/cli"
+)
+
+const (
+	serviceName        = "idena-go"
+	serviceDisplayName = "Idena node"
+	serviceDescription = "Idena blockchain node"
+	// the service output including crashes is written next to the executable
+	serviceLogFile = "service.log"
+)
+
+// newServiceCommand manages the native Windows service, the service manager runs the hidden "service run" command
+func newServiceCommand(nodeFlags []cli.Flag, buildInfo *config.BuildInfo) cli.Command {
+	return cli.Command{
+		Name:  "service",
+		Usage: "Run the node as a Windows service started with the system",
+		Subcommands: []cli.Command{
+			{
+				Name: "install",
+				Usage: "Register the auto-start service, the node flags given after install are passed to the service. " +
+					"Relative paths are resolved against the executable directory",
+				ArgsUsage:       "[node flags]",
+				SkipFlagParsing: true,
+				Action: func(context *cli.Context) error {
+					return installService(context.Args())
+				},
+			},
+			{
+				Name:  "uninstall",
+				Usage: "Remove the service, the data directory is kept",
+				Action: func(context *cli.Context) error {
+					return uninstallService()
+				},
+			},
+			{
+				Name:  "start",
+				Usage: "Start the installed service",
+				Action: func(context *cli.Context) error {
+					return startService()
+				},
+			},
+			{
+				Name:  "stop",
+				Usage: "Stop the service and wait for the node to shut down",
+				Action: func(context *cli.Context) error {
+					return stopService()
+				},
+			},
+			{
+				Name:   "run",
+				Usage:  "Run the node under the service manager",
+				Hidden: true,
+				Flags:  nodeFlags,
+				Action: func(context *cli.Context) error {
+					return runService(context, buildInfo)
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,31 @@
+// +build !windows
+
+package main
+
+import (
+	"github.com/idena-network/idena-go/config"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var errServiceNotSupported = errors.New("services are supported on Windows only, use systemd on other platforms")
+
+func installService(args []string) error {
+	return errServiceNotSupported
+}
+
+func uninstallService() error {
+	return errServiceNotSupported
+}
+
+func startService() error {
+	return errServiceNotSupported
+}
+
+func stopService() error {
+	return errServiceNotSupported
+}
+
+func runService(context *cli.Context, buildInfo *config.BuildInfo) error {
+	return errServiceNotSupported
+}
@@ -0,0 +1,184 @@
+// +build windows
+
+package main
+
+import (
+	"github.com/idena-network/idena-go/config"
+	"github.com/idena-network/idena-go/log"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	serviceStartWaitHint = 10 * time.Minute
+	serviceStopTimeout   = 3 * time.Minute
+	serviceRestartDelay  = time.Minute
+	// the exit code of admin_restart, the service manager treats it as a failure and restarts the service
+	serviceRestartExitCode = 2
+)
+
+func installService(args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	m, err := mgr.Connect()
+	if err != nil {
+		return errors.Wrap(err, "cannot connect to the service manager, run the command as administrator")
+	}
+	defer m.Disconnect()
+	if s, err := m.OpenService(serviceName); err == nil {
+		s.Close()
+		return errors.Errorf("service %v is already installed", serviceName)
+	}
+	s, err := m.CreateService(serviceName, exe, mgr.Config{
+		DisplayName:      serviceDisplayName,
+		Description:      serviceDescription,
+		StartType:        mgr.StartAutomatic,
+		DelayedAutoStart: true,
+	}, append([]string{"service", "run"}, args...)...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	// restart the node if it crashes, the failure counter is reset after a day
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{{Type: mgr.ServiceRestart, Delay: serviceRestartDelay}}, uint32((24 * time.Hour).Seconds())); err != nil {
+		return err
+	}
+	if err := s.SetRecoveryActionsOnNonCrashFailures(true); err != nil {
+		return err
+	}
+	log.Info("Service installed", "name", serviceName, "log", filepath.Join(filepath.Dir(exe), serviceLogFile))
+	return nil
+}
+
+func uninstallService() error {
+	return withService(func(s *mgr.Service) error {
+		return s.Delete()
+	})
+}
+
+func startService() error {
+	return withService(func(s *mgr.Service) error {
+		return s.Start()
+	})
+}
+
+func stopService() error {
+	return withService(func(s *mgr.Service) error {
+		status, err := s.Control(svc.Stop)
+		if err != nil {
+			return err
+		}
+		deadline := time.Now().Add(serviceStopTimeout)
+		for status.State != svc.Stopped {
+			if time.Now().After(deadline) {
+				return errors.New("timeout while waiting for the node to stop")
+			}
+			time.Sleep(time.Second)
+			if status, err = s.Query(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func withService(action func(s *mgr.Service) error) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return errors.Wrap(err, "cannot connect to the service manager, run the command as administrator")
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return errors.Wrapf(err, "service %v is not installed", serviceName)
+	}
+	defer s.Close()
+	return action(s)
+}
+
+func runService(context *cli.Context, buildInfo *config.BuildInfo) error {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return err
+	}
+	if !isService {
+		return errors.New("the command is run by the service manager, use service start")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	// services start in the system directory, relative paths of the node flags are resolved against the executable
+	if err := os.Chdir(filepath.Dir(exe)); err != nil {
+		return err
+	}
+	if err := redirectServiceOutput(serviceLogFile); err != nil {
+		return err
+	}
+	return svc.Run(serviceName, &nodeService{context: context, buildInfo: buildInfo})
+}
+
+// redirectServiceOutput writes the console logs and panics to the file, services have no console
+func redirectServiceOutput(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if err := windows.SetStdHandle(windows.STD_OUTPUT_HANDLE, windows.Handle(f.Fd())); err != nil {
+		return err
+	}
+	if err := windows.SetStdHandle(windows.STD_ERROR_HANDLE, windows.Handle(f.Fd())); err != nil {
+		return err
+	}
+	os.Stdout = f
+	os.Stderr = f
+	return nil
+}
+
+type nodeService struct {
+	context   *cli.Context
+	buildInfo *config.BuildInfo
+}
+
+func (s *nodeService) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending, WaitHint: uint32(serviceStartWaitHint.Milliseconds())}
+	n, err := startNode(s.context, s.buildInfo)
+	if err != nil {
+		log.Error("Failed to start the node", "err", err)
+		return true, 1
+	}
+	stopped := make(chan struct{})
+	go func() {
+		n.WaitForStop()
+		close(stopped)
+	}()
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case <-stopped:
+			if n.RestartRequested() {
+				// the recovery action of the service manager starts the node again
+				return true, serviceRestartExitCode
+			}
+			return false, 0
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				changes <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending, WaitHint: uint32(serviceStopTimeout.Milliseconds())}
+				go n.Stop()
+				<-stopped
+				return false, 0
+			}
+		}
+	}
+}
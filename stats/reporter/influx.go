@@ -0,0 +1,84 @@
+package reporter
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const influxTimeout = 10 * time.Second
+
+type influxSink struct {
+	url    string
+	token  string
+	host   string
+	client *http.Client
+}
+
+func newInfluxSink(url string, token string) *influxSink {
+	host, _ := os.Hostname()
+	return &influxSink{
+		url:    url,
+		token:  token,
+		host:   host,
+		client: &http.Client{Timeout: influxTimeout},
+	}
+}
+
+func (s *influxSink) send(prefix string, metrics []Metric, now time.Time) error {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(influxLine(prefix, s.host, metrics, now)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Token "+s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("influxdb responded with %v: %s", resp.Status, body)
+	}
+	return nil
+}
+
+// influxLine formats the metrics as a single point of the line protocol,
+// e.g. idena,host=node1 height=100,peers=8 1600000000000000000
+func influxLine(prefix string, host string, metrics []Metric, now time.Time) []byte {
+	measurement := prefix
+	if measurement == "" {
+		measurement = "node"
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString(influxEscape(measurement))
+	if host != "" {
+		buf.WriteString(",host=")
+		buf.WriteString(influxEscape(host))
+	}
+	for i, m := range metrics {
+		if i == 0 {
+			buf.WriteByte(' ')
+		} else {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(buf, "%v=%v", influxEscape(m.Name), formatValue(m.Value))
+	}
+	fmt.Fprintf(buf, " %d\n", now.UnixNano())
+	return buf.Bytes()
+}
+
+var influxEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+
+func influxEscape(s string) string {
+	return influxEscaper.Replace(s)
+}
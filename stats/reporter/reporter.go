@@ -0,0 +1,74 @@
+package reporter
+
+import (
+	"github.com/idena-network/idena-go/config"
+	"github.com/idena-network/idena-go/log"
+	"sort"
+	"time"
+)
+
+// Metric is a gauge value sampled by the node
+type Metric struct {
+	Name  string
+	Value float64
+}
+
+type sink interface {
+	send(prefix string, metrics []Metric, now time.Time) error
+}
+
+// Reporter periodically pushes the metrics to StatsD and InfluxDB for operators with push-based monitoring
+type Reporter struct {
+	cfg    *config.MetricsConfig
+	sample func() []Metric
+	sinks  []sink
+	log    log.Logger
+}
+
+func NewReporter(cfg *config.MetricsConfig, sample func() []Metric) (*Reporter, error) {
+	r := &Reporter{
+		cfg:    cfg,
+		sample: sample,
+		log:    log.New("component", "metrics"),
+	}
+	if cfg.StatsdAddr != "" {
+		s, err := newStatsdSink(cfg.StatsdAddr)
+		if err != nil {
+			return nil, err
+		}
+		r.sinks = append(r.sinks, s)
+	}
+	if cfg.InfluxUrl != "" {
+		r.sinks = append(r.sinks, newInfluxSink(cfg.InfluxUrl, cfg.InfluxToken))
+	}
+	return r, nil
+}
+
+// Start reports the metrics until the stop channel is closed
+func (r *Reporter) Start(stop <-chan struct{}) {
+	if len(r.sinks) == 0 {
+		return
+	}
+	go r.loop(stop)
+}
+
+func (r *Reporter) loop(stop <-chan struct{}) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			metrics := r.sample()
+			sort.Slice(metrics, func(i, j int) bool {
+				return metrics[i].Name < metrics[j].Name
+			})
+			for _, s := range r.sinks {
+				if err := s.send(r.cfg.Prefix, metrics, now); err != nil {
+					r.log.Debug("Failed to push metrics", "err", err)
+				}
+			}
+		}
+	}
+}
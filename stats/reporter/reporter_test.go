@@ -0,0 +1,33 @@
+package reporter
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsdPackets(t *testing.T) {
+	packets := statsdPackets("idena", []Metric{{"height", 100}, {"peers", 8}})
+	require.Len(t, packets, 1)
+	require.Equal(t, "idena.height:100|g\nidena.peers:8|g\n", string(packets[0]))
+
+	var metrics []Metric
+	for i := 0; i < 200; i++ {
+		metrics = append(metrics, Metric{Name: "metric", Value: float64(i)})
+	}
+	packets = statsdPackets("idena", metrics)
+	require.True(t, len(packets) > 1)
+	lines := 0
+	for _, packet := range packets {
+		require.True(t, len(packet) <= maxStatsdPacketSize)
+		lines += strings.Count(string(packet), "\n")
+	}
+	require.Equal(t, 200, lines)
+}
+
+func TestInfluxLine(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	line := influxLine("idena", "my node", []Metric{{"height", 100}, {"rss", 1.5}}, now)
+	require.Equal(t, "idena,host=my\\ node height=100,rss=1.5 1600000000000000000\n", string(line))
+}
@@ -0,0 +1,63 @@
+package reporter
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// statsd packets are kept below the common MTU, so they are not fragmented
+const maxStatsdPacketSize = 1400
+
+type statsdSink struct {
+	conn net.Conn
+}
+
+func newStatsdSink(addr string) (*statsdSink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &statsdSink{conn: conn}, nil
+}
+
+func (s *statsdSink) send(prefix string, metrics []Metric, _ time.Time) error {
+	for _, packet := range statsdPackets(prefix, metrics) {
+		if _, err := s.conn.Write(packet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statsdPackets formats the metrics as gauges, e.g. idena.height:100|g
+func statsdPackets(prefix string, metrics []Metric) [][]byte {
+	var packets [][]byte
+	buf := new(bytes.Buffer)
+	for _, m := range metrics {
+		line := fmt.Sprintf("%v:%v|g\n", metricName(prefix, m.Name), formatValue(m.Value))
+		if buf.Len() > 0 && buf.Len()+len(line) > maxStatsdPacketSize {
+			packets = append(packets, buf.Bytes())
+			buf = new(bytes.Buffer)
+		}
+		buf.WriteString(line)
+	}
+	if buf.Len() > 0 {
+		packets = append(packets, buf.Bytes())
+	}
+	return packets
+}
+
+// formatValue avoids the exponent notation which isn't supported by some StatsD servers
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func metricName(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
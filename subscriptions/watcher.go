@@ -0,0 +1,343 @@
+package subscriptions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-go/events"
+	"github.com/idena-network/idena-go/log"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	watchedAddressesFile = "watched.json"
+	watchEventLogSize    = 1000
+	// max number of the deposits waiting for confirmations, the oldest ones are dropped
+	maxPendingDeposits = 10000
+
+	WatchedTx            = "tx"
+	WatchedIdentityState = "identityState"
+)
+
+type WatchEvent struct {
+	Id        uint64         `json:"id"`
+	Type      string         `json:"type"`
+	Time      time.Time      `json:"time"`
+	Address   common.Address `json:"address"`
+	Height    uint64         `json:"height"`
+	TxHash    *common.Hash   `json:"txHash,omitempty"`
+	TxType    *types.TxType  `json:"txType,omitempty"`
+	PrevState string         `json:"prevState,omitempty"`
+	State     string         `json:"state,omitempty"`
+}
+
+// WatchOptions configures the deposit notifications of the watched address
+type WatchOptions struct {
+	// Url receives the deposit notifications of the address instead of the configured webhooks
+	Url string `json:"url,omitempty"`
+	// Confirmations is the number of blocks including the deposit block required to report the deposit confirmed,
+	// 0 waits for a final certificate
+	Confirmations uint64 `json:"confirmations,omitempty"`
+}
+
+type watchedAddress struct {
+	options WatchOptions
+	// last known identity state
+	state state.IdentityState
+}
+
+type persistedAddress struct {
+	Address common.Address `json:"address"`
+	WatchOptions
+}
+
+type pendingDeposit struct {
+	address   common.Address
+	txHash    common.Hash
+	from      common.Address
+	amount    *big.Int
+	height    uint64
+	blockHash common.Hash
+}
+
+// Watcher keeps the addresses registered by the user and notifies about the transactions touching them
+// and the changes of their identity states. The addresses are persisted, the latest events are kept in memory,
+// clients poll them by the id of the last seen event.
+// The incoming transfers are also tracked as deposits until they get the confirmations required by the address options
+type Watcher struct {
+	datadir       string
+	bus           eventbus.Bus
+	identityState func(addr common.Address) state.IdentityState
+	// confirmations returns the number of canonical blocks since the block and whether the block is final
+	confirmations func(blockHash common.Hash) (uint64, bool)
+	addresses     map[common.Address]*watchedAddress
+	events        []*WatchEvent
+	lastId        uint64
+	deposits      []*pendingDeposit
+	mutex         sync.Mutex
+	log           log.Logger
+}
+
+func NewWatcher(datadir string, bus eventbus.Bus, identityState func(addr common.Address) state.IdentityState,
+	confirmations func(blockHash common.Hash) (uint64, bool)) (*Watcher, error) {
+	w := &Watcher{
+		datadir:       datadir,
+		bus:           bus,
+		identityState: identityState,
+		confirmations: confirmations,
+		addresses:     make(map[common.Address]*watchedAddress),
+		log:           log.New("component", "watcher"),
+	}
+	data, err := ioutil.ReadFile(w.filePath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		var list []persistedAddress
+		if err := json.Unmarshal(data, &list); err != nil {
+			// the addresses were persisted as a plain list before the options were added
+			var addresses []common.Address
+			if legacyErr := json.Unmarshal(data, &addresses); legacyErr != nil {
+				w.log.Warn("cannot parse watched addresses", "err", err)
+			}
+			for _, addr := range addresses {
+				list = append(list, persistedAddress{Address: addr})
+			}
+		}
+		for _, item := range list {
+			w.addresses[item.Address] = &watchedAddress{
+				options: item.WatchOptions,
+				state:   identityState(item.Address),
+			}
+		}
+	}
+	bus.Subscribe(events.AddBlockEventID, func(e eventbus.Event) {
+		w.handleBlock(e.(*events.NewBlockEvent).Block)
+	})
+	return w, nil
+}
+
+func (w *Watcher) Watch(addr common.Address, options WatchOptions) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if _, ok := w.addresses[addr]; ok {
+		return errors.New("address is already watched")
+	}
+	w.addresses[addr] = &watchedAddress{
+		options: options,
+		state:   w.identityState(addr),
+	}
+	return w.persist()
+}
+
+func (w *Watcher) Unwatch(addr common.Address) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if _, ok := w.addresses[addr]; !ok {
+		return errors.New("address is not watched")
+	}
+	delete(w.addresses, addr)
+	return w.persist()
+}
+
+func (w *Watcher) Addresses() []common.Address {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.sortedAddresses()
+}
+
+// Options returns the options of the watched address
+func (w *Watcher) Options(addr common.Address) (WatchOptions, bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	watched, ok := w.addresses[addr]
+	if !ok {
+		return WatchOptions{}, false
+	}
+	return watched.options, true
+}
+
+// Events returns the latest events with ids greater than the passed one
+func (w *Watcher) Events(after uint64) []*WatchEvent {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	result := make([]*WatchEvent, 0)
+	for _, e := range w.events {
+		if e.Id > after {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func (w *Watcher) handleBlock(block *types.Block) {
+	w.mutex.Lock()
+	if len(w.addresses) == 0 {
+		w.mutex.Unlock()
+		return
+	}
+	var newEvents []*WatchEvent
+	var depositEvents []*events.DepositEvent
+	for _, tx := range block.Body.Transactions {
+		touched := make([]common.Address, 0, 2)
+		sender, err := types.Sender(tx)
+		if err == nil {
+			touched = append(touched, sender)
+		}
+		if tx.To != nil && (len(touched) == 0 || *tx.To != touched[0]) {
+			touched = append(touched, *tx.To)
+			if _, ok := w.addresses[*tx.To]; ok && tx.AmountOrZero().Sign() > 0 {
+				deposit := &pendingDeposit{
+					address:   *tx.To,
+					txHash:    tx.Hash(),
+					from:      sender,
+					amount:    tx.AmountOrZero(),
+					height:    block.Height(),
+					blockHash: block.Hash(),
+				}
+				w.addDeposit(deposit)
+				depositEvents = append(depositEvents, w.depositEvent(deposit, events.DepositSeen, 1, false))
+			}
+		}
+		for _, addr := range touched {
+			if _, ok := w.addresses[addr]; !ok {
+				continue
+			}
+			hash, txType := tx.Hash(), tx.Type
+			newEvents = append(newEvents, &WatchEvent{
+				Type:    WatchedTx,
+				Address: addr,
+				Height:  block.Height(),
+				TxHash:  &hash,
+				TxType:  &txType,
+			})
+		}
+	}
+	for _, addr := range w.sortedAddresses() {
+		prevState := w.addresses[addr].state
+		identityState := w.identityState(addr)
+		if identityState == prevState {
+			continue
+		}
+		w.addresses[addr].state = identityState
+		newEvents = append(newEvents, &WatchEvent{
+			Type:      WatchedIdentityState,
+			Address:   addr,
+			Height:    block.Height(),
+			PrevState: prevState.String(),
+			State:     identityState.String(),
+		})
+	}
+	for _, e := range newEvents {
+		w.lastId++
+		e.Id = w.lastId
+		e.Time = time.Now().UTC()
+		w.events = append(w.events, e)
+	}
+	if len(w.events) > watchEventLogSize {
+		w.events = w.events[len(w.events)-watchEventLogSize:]
+	}
+	depositEvents = append(depositEvents, w.checkDeposits()...)
+	w.mutex.Unlock()
+
+	for _, e := range newEvents {
+		w.bus.Publish(&events.WatchedAddressEvent{
+			Type:      e.Type,
+			Address:   e.Address,
+			Height:    e.Height,
+			TxHash:    e.TxHash,
+			TxType:    e.TxType,
+			PrevState: e.PrevState,
+			State:     e.State,
+		})
+	}
+	for _, e := range depositEvents {
+		w.bus.Publish(e)
+	}
+}
+
+func (w *Watcher) addDeposit(deposit *pendingDeposit) {
+	if len(w.deposits) == maxPendingDeposits {
+		w.log.Warn("Too many pending deposits, the oldest one is dropped", "tx", w.deposits[0].txHash.Hex())
+		w.deposits = w.deposits[1:]
+	}
+	w.deposits = append(w.deposits, deposit)
+}
+
+// checkDeposits returns the events of the deposits which are seen in the block, confirmed or reverted,
+// the confirmed and reverted deposits are not tracked anymore
+func (w *Watcher) checkDeposits() []*events.DepositEvent {
+	var result []*events.DepositEvent
+	pending := w.deposits[:0]
+	for _, deposit := range w.deposits {
+		watched, ok := w.addresses[deposit.address]
+		if !ok {
+			continue
+		}
+		confirmations, final := w.confirmations(deposit.blockHash)
+		switch {
+		case confirmations == 0:
+			result = append(result, w.depositEvent(deposit, events.DepositReverted, 0, false))
+		case watched.options.Confirmations == 0 && final || watched.options.Confirmations > 0 && confirmations >= watched.options.Confirmations:
+			result = append(result, w.depositEvent(deposit, events.DepositConfirmed, confirmations, final))
+		default:
+			pending = append(pending, deposit)
+		}
+	}
+	w.deposits = pending
+	return result
+}
+
+func (w *Watcher) depositEvent(deposit *pendingDeposit, status string, confirmations uint64, final bool) *events.DepositEvent {
+	return &events.DepositEvent{
+		Status:        status,
+		Address:       deposit.address,
+		From:          deposit.from,
+		Amount:        deposit.amount,
+		TxHash:        deposit.txHash,
+		Height:        deposit.height,
+		Confirmations: confirmations,
+		Final:         final,
+		Url:           w.addresses[deposit.address].options.Url,
+	}
+}
+
+func (w *Watcher) sortedAddresses() []common.Address {
+	result := make([]common.Address, 0, len(w.addresses))
+	for addr := range w.addresses {
+		result = append(result, addr)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i][:], result[j][:]) < 0
+	})
+	return result
+}
+
+func (w *Watcher) filePath() string {
+	return filepath.Join(w.datadir, Folder, watchedAddressesFile)
+}
+
+func (w *Watcher) persist() error {
+	if err := os.MkdirAll(filepath.Join(w.datadir, Folder), os.ModePerm); err != nil {
+		return err
+	}
+	list := make([]persistedAddress, 0, len(w.addresses))
+	for _, addr := range w.sortedAddresses() {
+		list = append(list, persistedAddress{Address: addr, WatchOptions: w.addresses[addr].options})
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(w.filePath(), data, 0666)
+}
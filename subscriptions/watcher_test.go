@@ -0,0 +1,152 @@
+package subscriptions
+
+import (
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-go/events"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func noConfirmations(common.Hash) (uint64, bool) {
+	return 0, false
+}
+
+func TestWatcher(t *testing.T) {
+	datadir := t.TempDir()
+	bus := eventbus.New()
+	identityStates := map[common.Address]state.IdentityState{}
+	identityState := func(addr common.Address) state.IdentityState {
+		return identityStates[addr]
+	}
+	var published []*events.WatchedAddressEvent
+	bus.Subscribe(events.WatchedAddressEventID, func(e eventbus.Event) {
+		published = append(published, e.(*events.WatchedAddressEvent))
+	})
+
+	key, _ := crypto.GenerateKey()
+	recipient, other := common.Address{0x1}, common.Address{0x2}
+	identityStates[recipient] = state.Candidate
+
+	watcher, err := NewWatcher(datadir, bus, identityState, noConfirmations)
+	require.NoError(t, err)
+	require.NoError(t, watcher.Watch(recipient, WatchOptions{}))
+	require.Error(t, watcher.Watch(recipient, WatchOptions{}))
+
+	newBlock := func(height uint64, to common.Address) *types.Block {
+		tx, _ := types.SignTx(&types.Transaction{Type: types.SendTx, To: &to}, key)
+		return &types.Block{
+			Header: &types.Header{ProposedHeader: &types.ProposedHeader{Height: height}},
+			Body:   &types.Body{Transactions: []*types.Transaction{tx}},
+		}
+	}
+
+	bus.Publish(&events.NewBlockEvent{Block: newBlock(1, other)})
+	require.Empty(t, watcher.Events(0))
+
+	identityStates[recipient] = state.Newbie
+	block := newBlock(2, recipient)
+	bus.Publish(&events.NewBlockEvent{Block: block})
+	watchEvents := watcher.Events(0)
+	require.Len(t, watchEvents, 2)
+	require.Equal(t, WatchedTx, watchEvents[0].Type)
+	require.Equal(t, recipient, watchEvents[0].Address)
+	require.Equal(t, block.Body.Transactions[0].Hash(), *watchEvents[0].TxHash)
+	require.Equal(t, WatchedIdentityState, watchEvents[1].Type)
+	require.Equal(t, "Candidate", watchEvents[1].PrevState)
+	require.Equal(t, "Newbie", watchEvents[1].State)
+	require.Len(t, watcher.Events(watchEvents[0].Id), 1)
+	require.Len(t, published, 2)
+	require.Equal(t, uint64(2), published[1].Height)
+
+	// the addresses are persisted
+	restored, err := NewWatcher(datadir, eventbus.New(), identityState, noConfirmations)
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{recipient}, restored.Addresses())
+
+	require.NoError(t, watcher.Unwatch(recipient))
+	require.Error(t, watcher.Unwatch(recipient))
+	bus.Publish(&events.NewBlockEvent{Block: newBlock(3, recipient)})
+	require.Len(t, watcher.Events(0), 2)
+}
+
+func TestWatcher_Deposits(t *testing.T) {
+	datadir := t.TempDir()
+	bus := eventbus.New()
+	var published []*events.DepositEvent
+	bus.Subscribe(events.DepositEventID, func(e eventbus.Event) {
+		published = append(published, e.(*events.DepositEvent))
+	})
+	canonical := map[common.Hash]uint64{}
+	var head uint64
+	final := map[common.Hash]bool{}
+	confirmations := func(hash common.Hash) (uint64, bool) {
+		height, ok := canonical[hash]
+		if !ok {
+			return 0, false
+		}
+		return head - height + 1, final[hash]
+	}
+
+	key, _ := crypto.GenerateKey()
+	byDepth, byFinality := common.Address{0x1}, common.Address{0x2}
+	watcher, err := NewWatcher(datadir, bus, func(common.Address) state.IdentityState { return state.Undefined }, confirmations)
+	require.NoError(t, err)
+	require.NoError(t, watcher.Watch(byDepth, WatchOptions{Url: "https://example.com/deposit", Confirmations: 3}))
+	require.NoError(t, watcher.Watch(byFinality, WatchOptions{}))
+
+	addBlock := func(height uint64, to ...common.Address) *types.Block {
+		var txs []*types.Transaction
+		for i, addr := range to {
+			addr := addr
+			tx, _ := types.SignTx(&types.Transaction{Type: types.SendTx, To: &addr, Amount: big.NewInt(10), AccountNonce: uint32(height*10) + uint32(i)}, key)
+			txs = append(txs, tx)
+		}
+		block := &types.Block{
+			Header: &types.Header{ProposedHeader: &types.ProposedHeader{Height: height}},
+			Body:   &types.Body{Transactions: txs},
+		}
+		head = height
+		canonical[block.Hash()] = height
+		bus.Publish(&events.NewBlockEvent{Block: block})
+		return block
+	}
+
+	first := addBlock(1, byDepth, byFinality)
+	require.Len(t, published, 2)
+	require.Equal(t, events.DepositSeen, published[0].Status)
+	require.Equal(t, byDepth, published[0].Address)
+	require.Equal(t, "https://example.com/deposit", published[0].Url)
+	require.Equal(t, big.NewInt(10), published[0].Amount)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), published[0].From)
+
+	addBlock(2)
+	require.Len(t, published, 2)
+	final[first.Hash()] = true
+	addBlock(3)
+	require.Len(t, published, 4)
+	require.Equal(t, events.DepositConfirmed, published[2].Status)
+	require.Equal(t, byDepth, published[2].Address)
+	require.Equal(t, uint64(3), published[2].Confirmations)
+	require.Equal(t, events.DepositConfirmed, published[3].Status)
+	require.Equal(t, byFinality, published[3].Address)
+	require.True(t, published[3].Final)
+
+	// the block of the deposit is dropped by a fork
+	dropped := addBlock(4, byDepth)
+	require.Len(t, published, 5)
+	delete(canonical, dropped.Hash())
+	addBlock(4)
+	require.Len(t, published, 6)
+	require.Equal(t, events.DepositReverted, published[5].Status)
+
+	restored, err := NewWatcher(datadir, eventbus.New(), func(common.Address) state.IdentityState { return state.Undefined }, confirmations)
+	require.NoError(t, err)
+	options, ok := restored.Options(byDepth)
+	require.True(t, ok)
+	require.Equal(t, uint64(3), options.Confirmations)
+}
@@ -16,6 +16,7 @@ var (
 	OracleLockContract           EmbeddedContractType
 	RefundableOracleLockContract EmbeddedContractType
 	MultisigContract             EmbeddedContractType
+	VestingContract              EmbeddedContractType
 	AvailableContracts           map[EmbeddedContractType]struct{}
 )
 
@@ -25,6 +26,7 @@ func init() {
 	OracleLockContract.SetBytes([]byte{0x3})
 	RefundableOracleLockContract.SetBytes([]byte{0x4})
 	MultisigContract.SetBytes([]byte{0x5})
+	VestingContract.SetBytes([]byte{0x6})
 
 	AvailableContracts = map[EmbeddedContractType]struct{}{
 		TimeLockContract:             {},
@@ -32,9 +34,19 @@ func init() {
 		OracleLockContract:           {},
 		RefundableOracleLockContract: {},
 		MultisigContract:             {},
+		VestingContract:              {},
 	}
 }
 
+// IsAvailable reports whether codeHash is an embedded contract enabled under the given consensus rules
+func IsAvailable(codeHash EmbeddedContractType, enableUpgrade13 bool) bool {
+	if codeHash == VestingContract && !enableUpgrade13 {
+		return false
+	}
+	_, ok := AvailableContracts[codeHash]
+	return ok
+}
+
 type Contract interface {
 	Deploy(args ...[]byte) error
 	Call(method string, args ...[]byte) error
@@ -191,6 +191,8 @@ func (c *contractTester) createContract(ctx env.CallContext, e env.Env) Contract
 		return NewRefundableOracleLock2(ctx, e, nil)
 	case MultisigContract:
 		return NewMultisig(ctx, e, nil)
+	case VestingContract:
+		return NewVesting(ctx, e, nil)
 	default:
 		return nil
 	}
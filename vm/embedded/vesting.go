@@ -0,0 +1,144 @@
+package embedded
+
+import (
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/stats/collector"
+	"github.com/idena-network/idena-go/vm/env"
+	"github.com/idena-network/idena-go/vm/helpers"
+	"github.com/pkg/errors"
+	"math/big"
+)
+
+// Vesting locks the coins sent to the contract until the start height and releases them to the beneficiary
+// linearly until the end height. Without the end height all the coins are unlocked at the start height
+type Vesting struct {
+	*BaseContract
+}
+
+func NewVesting(ctx env.CallContext, e env.Env, statsCollector collector.StatsCollector) *Vesting {
+	return &Vesting{
+		&BaseContract{
+			ctx:            ctx,
+			env:            e,
+			statsCollector: statsCollector,
+		},
+	}
+}
+
+func (v *Vesting) Deploy(args ...[]byte) error {
+	beneficiary, err := helpers.ExtractAddr(0, args...)
+	if err != nil {
+		return err
+	}
+	start, err := helpers.ExtractUInt64(1, args...)
+	if err != nil {
+		return err
+	}
+	end := start
+	if value, err := helpers.ExtractUInt64(2, args...); err == nil {
+		end = value
+	}
+	if end < start {
+		return errors.New("end height should not be less than start height")
+	}
+	v.SetArray("beneficiary", beneficiary.Bytes())
+	v.SetUint64("start", start)
+	v.SetUint64("end", end)
+	v.SetOwner(v.ctx.Caller())
+	return nil
+}
+
+func (v *Vesting) Call(method string, args ...[]byte) error {
+	switch method {
+	case "release":
+		return v.release()
+	default:
+		return errors.New("unknown method")
+	}
+}
+
+func (v *Vesting) Read(method string, args ...[]byte) ([]byte, error) {
+	switch method {
+	case "locked":
+		total := v.total()
+		return new(big.Int).Sub(total, v.vested(total)).Bytes(), nil
+	case "releasable":
+		return v.releasable().Bytes(), nil
+	case "released":
+		return v.released().Bytes(), nil
+	default:
+		return nil, errors.New("unknown method")
+	}
+}
+
+func (v *Vesting) beneficiary() common.Address {
+	var addr common.Address
+	addr.SetBytes(v.GetArray("beneficiary"))
+	return addr
+}
+
+func (v *Vesting) released() *big.Int {
+	if released := v.GetBigInt("released"); released != nil {
+		return released
+	}
+	return new(big.Int)
+}
+
+// total is the amount of coins ever sent to the contract
+func (v *Vesting) total() *big.Int {
+	return new(big.Int).Add(v.env.Balance(v.ctx.ContractAddr()), v.released())
+}
+
+func (v *Vesting) vested(total *big.Int) *big.Int {
+	height, start, end := v.env.BlockNumber(), v.GetUint64("start"), v.GetUint64("end")
+	if height < start {
+		return new(big.Int)
+	}
+	if height >= end {
+		return total
+	}
+	vested := new(big.Int).Mul(total, new(big.Int).SetUint64(height-start))
+	return vested.Div(vested, new(big.Int).SetUint64(end-start))
+}
+
+func (v *Vesting) releasable() *big.Int {
+	return new(big.Int).Sub(v.vested(v.total()), v.released())
+}
+
+func (v *Vesting) release() error {
+	beneficiary := v.beneficiary()
+	if caller := v.ctx.Caller(); caller != beneficiary && !v.IsOwner() {
+		return errors.New("sender is not a beneficiary or an owner")
+	}
+	amount := v.releasable()
+	if amount.Sign() <= 0 {
+		return errors.New("nothing to release")
+	}
+	if err := v.env.Send(v.ctx, beneficiary, amount); err != nil {
+		return err
+	}
+	v.SetBigInt("released", new(big.Int).Add(v.released(), amount))
+	return nil
+}
+
+func (v *Vesting) Terminate(args ...[]byte) (common.Address, [][]byte, error) {
+	if !v.IsOwner() {
+		return common.Address{}, nil, errors.New("sender is not an owner")
+	}
+	if v.env.BlockNumber() < v.GetUint64("end") {
+		return common.Address{}, nil, errors.New("terminate is locked")
+	}
+	balance := v.env.Balance(v.ctx.ContractAddr())
+	dust := big.NewInt(0).Mul(v.env.MinFeePerGas(), big.NewInt(100))
+	if balance.Cmp(dust) > 0 {
+		return common.Address{}, nil, errors.New("contract has dna")
+	}
+	if balance.Sign() > 0 {
+		v.env.BurnAll(v.ctx)
+	}
+	dest, err := helpers.ExtractAddr(0, args...)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return dest, nil, nil
+}
@@ -0,0 +1,71 @@
+package embedded
+
+import (
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-go/vm/env"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+type configurableVestingDeploy struct {
+	beneficiary common.Address
+	start       uint64
+	end         uint64
+}
+
+func (c *configurableVestingDeploy) Parameters() (contract EmbeddedContractType, deployStake *big.Int, params [][]byte) {
+	return VestingContract, common.DnaBase, [][]byte{c.beneficiary.Bytes(), common.ToBytes(c.start), common.ToBytes(c.end)}
+}
+
+func readVesting(tester *contractTester, method string) *big.Int {
+	gas := new(env.GasCounter)
+	gas.Reset(-1)
+	e := env.NewEnvImp(tester.appState, createHeader(tester.height, tester.timestamp), gas, nil)
+	data, err := NewVesting(&env.ReadContextImpl{Contract: tester.contractAddr, Hash: VestingContract}, e, nil).Read(method)
+	if err != nil {
+		panic(err)
+	}
+	return new(big.Int).SetBytes(data)
+}
+
+func TestVesting(t *testing.T) {
+	tester := createTestContractBuilder(&networkConfig{identityGroups: []identityGroupConfig{{count: 2, state: state.Newbie}}},
+		new(big.Int).Mul(common.DnaBase, big.NewInt(1000))).Build()
+	beneficiary := crypto.PubkeyToAddress(tester.identities[0].PublicKey)
+	stranger, _ := crypto.GenerateKey()
+
+	require.Error(t, tester.Deploy(&configurableVestingDeploy{beneficiary: beneficiary, start: 20, end: 10}))
+	require.NoError(t, tester.Deploy(&configurableVestingDeploy{beneficiary: beneficiary, start: 10, end: 20}))
+	tester.Commit()
+	total := new(big.Int).Mul(common.DnaBase, big.NewInt(100))
+	tester.AddBalance(total)
+	tester.appState.Commit(nil)
+
+	tester.setHeight(5)
+	require.Equal(t, total, readVesting(tester, "locked"))
+	require.Zero(t, readVesting(tester, "releasable").Sign())
+	require.Error(t, tester.IdentityCall(0, VestingContract, "release"))
+
+	half := new(big.Int).Div(total, big.NewInt(2))
+	tester.setHeight(15)
+	require.Equal(t, half, readVesting(tester, "releasable"))
+	require.Error(t, tester.Call(stranger, VestingContract, nil, "release"))
+	require.NoError(t, tester.IdentityCall(0, VestingContract, "release"))
+	tester.Commit()
+	require.Equal(t, half, tester.appState.State.GetBalance(beneficiary))
+	require.Equal(t, half, readVesting(tester, "released"))
+	require.Equal(t, half, readVesting(tester, "locked"))
+
+	_, err := tester.Terminate(tester.mainKey, VestingContract)
+	require.Error(t, err)
+
+	tester.setHeight(20)
+	require.Zero(t, readVesting(tester, "locked").Sign())
+	require.NoError(t, tester.OwnerCall(VestingContract, "release"))
+	tester.Commit()
+	require.Equal(t, total, tester.appState.State.GetBalance(beneficiary))
+	require.Zero(t, tester.ContractBalance().Sign())
+}
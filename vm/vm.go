@@ -63,6 +63,11 @@ func (vm *VmImpl) createContract(ctx env2.CallContext) embedded.Contract {
 		return embedded.NewRefundableOracleLock(ctx, vm.env, vm.statsCollector)
 	case embedded.MultisigContract:
 		return embedded.NewMultisig(ctx, vm.env, vm.statsCollector)
+	case embedded.VestingContract:
+		if vm.cfg.Consensus.EnableUpgrade13 {
+			return embedded.NewVesting(ctx, vm.env, vm.statsCollector)
+		}
+		return nil
 	default:
 		return nil
 	}
@@ -150,8 +155,7 @@ func (vm *VmImpl) IsWasm(tx *types.Transaction) bool {
 		if codeHash == nil {
 			return false
 		}
-		_, ok := embedded.AvailableContracts[*codeHash]
-		return !ok
+		return !embedded.IsAvailable(*codeHash, vm.cfg.Consensus.EnableUpgrade13)
 	}
 	return false
 }
@@ -0,0 +1,303 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-go/config"
+	"github.com/idena-network/idena-go/events"
+	"github.com/idena-network/idena-go/log"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	queueSize       = 100
+	signatureHeader = "X-Idena-Signature"
+)
+
+// retryDelay is the delay before the first repeated attempt, it doubles after every attempt
+var retryDelay = time.Second
+
+// Payload is the JSON body POSTed to the webhooks
+type Payload struct {
+	Event     string                 `json:"event"`
+	Message   string                 `json:"message"`
+	Node      common.Address         `json:"node"`
+	Timestamp int64                  `json:"timestamp"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+type delivery struct {
+	payload *Payload
+	urls    []string
+}
+
+// Notifier sends the critical node events to the configured webhooks, so operators get paged without parsing the logs
+type Notifier struct {
+	cfg     *config.WebhooksConfig
+	bus     eventbus.Bus
+	address common.Address
+	// events derived from blocks are sent only when the node is synced, otherwise the sync replays the chain history
+	synced func() bool
+	client *http.Client
+	queue  chan *delivery
+	log    log.Logger
+
+	peersMutex   sync.Mutex
+	peersHealthy bool
+}
+
+func NewNotifier(cfg *config.WebhooksConfig, bus eventbus.Bus, address common.Address, synced func() bool) *Notifier {
+	return &Notifier{
+		cfg:     cfg,
+		bus:     bus,
+		address: address,
+		synced:  synced,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		queue:   make(chan *delivery, queueSize),
+		log:     log.New("component", "webhooks"),
+	}
+}
+
+// Start subscribes to the node events and sends them until the stop channel is closed
+func (n *Notifier) Start(stop <-chan struct{}) {
+	subscriptions := []eventbus.Subscription{
+		n.bus.Subscribe(events.AddBlockEventID, n.handleBlock),
+		n.bus.Subscribe(events.ValidationResultEventID, n.handleValidationResult),
+		n.bus.Subscribe(events.PeerConnectedEventID, n.handlePeerConnected),
+		n.bus.Subscribe(events.PeerDisconnectedEventID, n.handlePeerDisconnected),
+		n.bus.Subscribe(events.BlockchainResetEventID, n.handleBlockchainReset),
+		n.bus.Subscribe(events.LowDiskSpaceEventID, n.handleLowDiskSpace),
+		n.bus.Subscribe(events.WatchedAddressEventID, n.handleWatchedAddress),
+		n.bus.Subscribe(events.InviteActivatedEventID, n.handleInviteActivated),
+		n.bus.Subscribe(events.DepositEventID, n.handleDeposit),
+	}
+	go func() {
+		for {
+			select {
+			case <-stop:
+				for _, s := range subscriptions {
+					n.bus.Unsubscribe(s)
+				}
+				return
+			case d := <-n.queue:
+				n.send(d, stop)
+			}
+		}
+	}()
+}
+
+func (n *Notifier) handleBlock(e eventbus.Event) {
+	block := e.(*events.NewBlockEvent).Block
+	if !n.synced() {
+		return
+	}
+	for _, phase := range types.CeremonyPhases {
+		if block.Header.Flags().HasFlag(phase.Flag) {
+			n.notify(config.WebhookCeremonyPhase, fmt.Sprintf("Ceremony phase %v started", phase.Name), map[string]interface{}{
+				"phase":  phase.Name,
+				"height": block.Height(),
+			})
+		}
+	}
+}
+
+func (n *Notifier) handleValidationResult(e eventbus.Event) {
+	result := e.(*events.ValidationResultEvent)
+	if !result.Missed || !n.synced() {
+		return
+	}
+	n.notify(config.WebhookMissedValidation, fmt.Sprintf("Identity %v missed validation", result.Address.Hex()), map[string]interface{}{
+		"epoch":     result.Epoch,
+		"validated": result.Validated,
+	})
+}
+
+func (n *Notifier) handlePeerConnected(e eventbus.Event) {
+	peers := e.(*events.PeerConnectedEvent).Peers
+	n.peersMutex.Lock()
+	defer n.peersMutex.Unlock()
+	if peers >= n.cfg.MinPeers {
+		n.peersHealthy = true
+	}
+}
+
+func (n *Notifier) handlePeerDisconnected(e eventbus.Event) {
+	peers := e.(*events.PeerDisconnectedEvent).Peers
+	n.peersMutex.Lock()
+	collapsed := n.peersHealthy && peers < n.cfg.MinPeers
+	if collapsed {
+		// reported once until the peers are back
+		n.peersHealthy = false
+	}
+	n.peersMutex.Unlock()
+	if collapsed {
+		n.notify(config.WebhookPeersCollapse, fmt.Sprintf("Peer count dropped to %v", peers), map[string]interface{}{
+			"peers": peers,
+		})
+	}
+}
+
+func (n *Notifier) handleBlockchainReset(e eventbus.Event) {
+	reset := e.(*events.BlockchainResetEvent)
+	n.notify(config.WebhookFork, fmt.Sprintf("Blockchain is reset to block %v", reset.Header.Height()), map[string]interface{}{
+		"height":      reset.Header.Height(),
+		"revertedTxs": len(reset.RevertedTxs),
+	})
+}
+
+func (n *Notifier) handleLowDiskSpace(e eventbus.Event) {
+	lowDisk := e.(*events.LowDiskSpaceEvent)
+	n.notify(config.WebhookLowDisk, fmt.Sprintf("Low disk space: %v free under %v", common.StorageSize(lowDisk.Free), lowDisk.Path), map[string]interface{}{
+		"path":     lowDisk.Path,
+		"free":     lowDisk.Free,
+		"required": lowDisk.Required,
+	})
+}
+
+func (n *Notifier) handleWatchedAddress(e eventbus.Event) {
+	watched := e.(*events.WatchedAddressEvent)
+	data := map[string]interface{}{
+		"type":    watched.Type,
+		"address": watched.Address,
+		"height":  watched.Height,
+	}
+	var message string
+	if watched.TxHash != nil {
+		data["txHash"] = watched.TxHash
+		data["txType"] = *watched.TxType
+		message = fmt.Sprintf("Transaction %v touches watched address %v", watched.TxHash.Hex(), watched.Address.Hex())
+	} else {
+		data["prevState"] = watched.PrevState
+		data["state"] = watched.State
+		message = fmt.Sprintf("Identity %v changed state from %v to %v", watched.Address.Hex(), watched.PrevState, watched.State)
+	}
+	n.notify(config.WebhookWatchedAddress, message, data)
+}
+
+func (n *Notifier) handleInviteActivated(e eventbus.Event) {
+	activated := e.(*events.InviteActivatedEvent)
+	if activated.Inviter != n.address || !n.synced() {
+		return
+	}
+	n.notify(config.WebhookInviteActivated, fmt.Sprintf("Invite %v is activated by %v", activated.Invite.Hex(), activated.Identity.Hex()), map[string]interface{}{
+		"invite":   activated.Invite,
+		"identity": activated.Identity,
+		"txHash":   activated.TxHash,
+		"height":   activated.Height,
+	})
+}
+
+func (n *Notifier) handleDeposit(e eventbus.Event) {
+	deposit := e.(*events.DepositEvent)
+	var message string
+	switch deposit.Status {
+	case events.DepositSeen:
+		message = fmt.Sprintf("Deposit %v to %v is included in block %v", deposit.TxHash.Hex(), deposit.Address.Hex(), deposit.Height)
+	case events.DepositConfirmed:
+		message = fmt.Sprintf("Deposit %v to %v is confirmed", deposit.TxHash.Hex(), deposit.Address.Hex())
+	default:
+		message = fmt.Sprintf("Deposit %v to %v is reverted", deposit.TxHash.Hex(), deposit.Address.Hex())
+	}
+	data := map[string]interface{}{
+		"status":        deposit.Status,
+		"address":       deposit.Address,
+		"from":          deposit.From,
+		"amount":        decimal.NewFromBigInt(deposit.Amount, -18),
+		"txHash":        deposit.TxHash,
+		"height":        deposit.Height,
+		"confirmations": deposit.Confirmations,
+		"final":         deposit.Final,
+	}
+	if deposit.Url != "" {
+		n.enqueue(config.WebhookDeposit, message, data, []string{deposit.Url})
+		return
+	}
+	n.notify(config.WebhookDeposit, message, data)
+}
+
+// notify enqueues the event for the configured webhooks
+func (n *Notifier) notify(event string, message string, data map[string]interface{}) {
+	if !n.cfg.Sends(event) || !n.cfg.Enabled() {
+		return
+	}
+	n.enqueue(event, message, data, n.cfg.Urls)
+}
+
+// enqueue queues the event for the urls, the bus handlers must not block on slow webhooks
+func (n *Notifier) enqueue(event string, message string, data map[string]interface{}, urls []string) {
+	d := &delivery{
+		payload: &Payload{
+			Event:     event,
+			Message:   message,
+			Node:      n.address,
+			Timestamp: time.Now().Unix(),
+			Data:      data,
+		},
+		urls: urls,
+	}
+	select {
+	case n.queue <- d:
+	default:
+		n.log.Warn("Webhook queue is full, event is dropped", "event", event)
+	}
+}
+
+func (n *Notifier) send(d *delivery, stop <-chan struct{}) {
+	body, err := json.Marshal(d.payload)
+	if err != nil {
+		n.log.Error("Failed to marshal webhook payload", "err", err)
+		return
+	}
+	for _, url := range d.urls {
+		delay := retryDelay
+		for attempt := 0; ; attempt++ {
+			err := n.post(url, body)
+			if err == nil {
+				break
+			}
+			if attempt == n.cfg.Retries {
+				n.log.Warn("Failed to send webhook", "event", d.payload.Event, "url", url, "err", err)
+				break
+			}
+			select {
+			case <-stop:
+				return
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+	}
+}
+
+func (n *Notifier) post(url string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.cfg.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.cfg.Secret))
+		mac.Write(body)
+		req.Header.Set(signatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("webhook responded with %v", resp.Status)
+	}
+	return nil
+}
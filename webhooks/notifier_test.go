@@ -0,0 +1,136 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-go/config"
+	"github.com/idena-network/idena-go/events"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNotifier(t *testing.T) {
+	received := make(chan *Payload, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := new(Payload)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(payload))
+		received <- payload
+	}))
+	defer server.Close()
+
+	cfg := config.GetDefaultWebhooksConfig()
+	cfg.Urls = []string{server.URL}
+	cfg.Events = []string{config.WebhookPeersCollapse, config.WebhookMissedValidation, config.WebhookInviteActivated}
+	bus := eventbus.New()
+	address := common.Address{0x1}
+	synced := true
+	stop := make(chan struct{})
+	defer close(stop)
+	NewNotifier(cfg, bus, address, func() bool { return synced }).Start(stop)
+
+	receive := func() *Payload {
+		select {
+		case payload := <-received:
+			return payload
+		case <-time.After(time.Second * 5):
+			require.Fail(t, "webhook is not called")
+			return nil
+		}
+	}
+
+	// the collapse is not reported before the peers are connected
+	bus.Publish(&events.PeerDisconnectedEvent{Peers: 0})
+	bus.Publish(&events.PeerConnectedEvent{Peers: cfg.MinPeers})
+	bus.Publish(&events.PeerDisconnectedEvent{Peers: cfg.MinPeers - 1})
+	bus.Publish(&events.PeerDisconnectedEvent{Peers: cfg.MinPeers - 2})
+	payload := receive()
+	require.Equal(t, config.WebhookPeersCollapse, payload.Event)
+	require.Equal(t, address, payload.Node)
+	require.Equal(t, float64(cfg.MinPeers-1), payload.Data["peers"])
+
+	// not selected event
+	bus.Publish(&events.LowDiskSpaceEvent{Path: "datadir"})
+
+	synced = false
+	bus.Publish(&events.ValidationResultEvent{Address: address, Epoch: 1, Missed: true})
+	synced = true
+	bus.Publish(&events.ValidationResultEvent{Address: address, Epoch: 2, Missed: false})
+	bus.Publish(&events.ValidationResultEvent{Address: address, Epoch: 3, Missed: true})
+	payload = receive()
+	require.Equal(t, config.WebhookMissedValidation, payload.Event)
+	require.Equal(t, float64(3), payload.Data["epoch"])
+
+	// only the invites of the node are reported
+	bus.Publish(&events.InviteActivatedEvent{Inviter: common.Address{0x2}, Invite: common.Address{0x3}, Identity: common.Address{0x4}})
+	bus.Publish(&events.InviteActivatedEvent{Inviter: address, Invite: common.Address{0x5}, Identity: common.Address{0x6}, Height: 10})
+	payload = receive()
+	require.Equal(t, config.WebhookInviteActivated, payload.Event)
+	require.Equal(t, common.Address{0x6}.Hex(), payload.Data["identity"])
+	require.Equal(t, float64(10), payload.Data["height"])
+
+	select {
+	case payload := <-received:
+		require.Failf(t, "unexpected webhook", "%v", payload.Event)
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
+func TestNotifier_Deposit(t *testing.T) {
+	retryDelay = time.Millisecond
+	received := make(chan *Payload, 10)
+	var calls int32
+	secret := "secret"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		require.Equal(t, hex.EncodeToString(mac.Sum(nil)), r.Header.Get(signatureHeader))
+		// the first attempt fails and is repeated
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		payload := new(Payload)
+		require.NoError(t, json.Unmarshal(body, payload))
+		received <- payload
+	}))
+	defer server.Close()
+
+	// the deposit is sent to the url of the watched address without the configured webhooks
+	cfg := config.GetDefaultWebhooksConfig()
+	cfg.Secret = secret
+	bus := eventbus.New()
+	stop := make(chan struct{})
+	defer close(stop)
+	NewNotifier(cfg, bus, common.Address{0x1}, func() bool { return true }).Start(stop)
+
+	bus.Publish(&events.DepositEvent{
+		Status:        events.DepositConfirmed,
+		Address:       common.Address{0x2},
+		Amount:        new(big.Int).Mul(big.NewInt(5), common.DnaBase),
+		Height:        10,
+		Confirmations: 6,
+		Url:           server.URL,
+	})
+	select {
+	case payload := <-received:
+		require.Equal(t, config.WebhookDeposit, payload.Event)
+		require.Equal(t, events.DepositConfirmed, payload.Data["status"])
+		require.Equal(t, "5", payload.Data["amount"])
+		require.Equal(t, float64(6), payload.Data["confirmations"])
+	case <-time.After(time.Second * 5):
+		require.Fail(t, "webhook is not called")
+	}
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}